@@ -38,6 +38,10 @@
 //	Parker Bros     "E0"
 //	M-Network       "E7"
 //	Tigervision     "3F"
+//	Econobanking    "0840"
+//	X07             "X07"
+//	4A50            "4A50"
+//	Chetiry         "CTY"
 //	Supercharger    "AR", "MP3, "WAV"
 //	DF              "DF"
 //	3E              "3E"
@@ -46,6 +50,7 @@
 //	DPC (Pitfall2)  "DPC"
 //	DPC+            "DP+"
 //	CDF             "CDF" (including CDFJ)
+//	BUS             "BUS"
 //	MovieCart       "MVC"
 //
 // File extensions are case insensitive.
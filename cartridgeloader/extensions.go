@@ -29,9 +29,9 @@ var autoFileExtensions = []string{
 var explicitFileExtensions = []string{
 	".2K", ".4K", ".F8", ".WF8", ".F6", ".F4",
 	".2K+", ".2KSC", ".4K+", ".4KSC", ".F8+", ".F8SC", ".WF8SC", ".F6+", ".F6SC", ".F4+", ".F4SC",
-	".CV", ".FA", ".FA2", ".FE", ".E0", ".E7", ".JANE", ".3F", ".UA", ".UASW", ".AR", ".DF",
+	".CV", ".FA", ".FA2", ".FE", ".E0", ".E7", ".JANE", ".3F", ".UA", ".UASW", ".0840", ".X07", ".4A50", ".CTY", ".AR", ".DF",
 	".3E", ".E3P", ".E3+", ".3E+", ".EF", ".EFSC", ".BF", ".BFSC", ".SB", ".WD",
-	".CDF0", ".CDF1", ".CDFJ", ".CDFJ+", ".DP+", ".DPC", ".DPCP", ".CDF", ".MVC", ".ACE", ".ELF", ".DEVC",
+	".CDF0", ".CDF1", ".CDFJ", ".CDFJ+", ".BUS", ".DP+", ".DPC", ".DPCP", ".CDF", ".MVC", ".ACE", ".ELF", ".DEVC",
 }
 
 // special file extensions. files with these extensions are treated very
@@ -26,8 +26,10 @@ import (
 	"github.com/jetsetilly/gopher2600/debugger/govern"
 	"github.com/jetsetilly/gopher2600/environment"
 	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
 	"github.com/jetsetilly/gopher2600/hardware/riot/ports"
 	"github.com/jetsetilly/gopher2600/hardware/television"
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
 	"github.com/jetsetilly/gopher2600/hardware/television/frameinfo"
 	"github.com/jetsetilly/gopher2600/hardware/television/signal"
 	"github.com/jetsetilly/gopher2600/hardware/television/specification"
@@ -51,10 +53,31 @@ type Comparison struct {
 
 	Render     chan *image.RGBA
 	DiffRender chan *image.RGBA
-	AudioDiff  chan bool
+
+	// a summary of the differences between each frame of the two emulations
+	Diff chan FrameDiff
+
+	// the most recent FrameDiff. see LastDiff()
+	lastDiff atomic.Value
+
+	// the first divergence in the state of the two emulations is sent over
+	// this channel. nothing is ever sent if lockstep is LockstepNone
+	Divergence chan Divergence
 
 	audio []uint8
 
+	// mixes the audio of the comparison emulation with the audio of the
+	// driver emulation. nil if the driver emulation has no realtime mixer
+	mixer *mixer
+
+	// the index of the driver's frame buffers that are being compared against
+	// and the number of lockstep samples compared so far in the frame
+	frameIdx      int
+	lockstepCount int
+
+	// once the emulations have diverged no more comparisons are made
+	diverged bool
+
 	// pixel renderer implementation for the "driver" emulation. ie. the
 	// emulation we'll be comparing against
 	driver driver
@@ -62,13 +85,19 @@ type Comparison struct {
 
 const comparisonLabel = environment.Label("comparison")
 
-// NewComparison is the preferred method of initialisation for the Comparison type.
-func NewComparison(driverVCS *hardware.VCS) (*Comparison, error) {
+// NewComparison is the preferred method of initialisation for the Comparison
+// type. The lockstep argument specifies how often the state of the two
+// emulations should be compared.
+//
+// If lockstep is LockstepInstruction then DriverStep() must be called after
+// every CPU instruction of the driver emulation.
+func NewComparison(driverVCS *hardware.VCS, lockstep Lockstep) (*Comparison, error) {
 	cmp := &Comparison{
 		emulationQuit: make(chan bool, 1),
 		Render:        make(chan *image.RGBA, 1),
 		DiffRender:    make(chan *image.RGBA, 1),
-		AudioDiff:     make(chan bool, 1),
+		Diff:          make(chan FrameDiff, 1),
+		Divergence:    make(chan Divergence, 1),
 	}
 
 	// set isEmulating atomic as a boolean
@@ -98,10 +127,17 @@ func NewComparison(driverVCS *hardware.VCS) (*Comparison, error) {
 	cmp.Reset()
 
 	// create driver
-	cmp.driver = newDriver(driverVCS.TV)
+	cmp.driver = newDriver(driverVCS)
+	cmp.driver.lockstep = lockstep
 	driverVCS.TV.AddPixelRenderer(&cmp.driver)
 	driverVCS.TV.AddAudioMixer(&cmp.driver)
 
+	// the mixer takes the place of the driver's realtime mixer
+	if rt := driverVCS.TV.GetRealTimeAudioMixer(); rt != nil {
+		cmp.mixer = newMixer(rt)
+		driverVCS.TV.SetRealTimeAudioMixer(cmp.mixer)
+	}
+
 	// synchronise RIOT ports
 	sync := make(chan ports.TimedInputEvent, 32)
 	err = cmp.vcs.Input.AttachPassenger(sync)
@@ -126,6 +162,13 @@ func (cmp *Comparison) String() string {
 	return s.String()
 }
 
+// DriverStep should be called after every CPU instruction of the driver
+// emulation. It should not be called for instructions executed during a rewind
+// or catch-up.
+func (cmp *Comparison) DriverStep() {
+	cmp.driver.step()
+}
+
 // IsEmulating returns true if the comparison emulator is working. Useful for
 // testing whether the cartridgeloader was an emulatable file.
 func (cmp *Comparison) IsEmulating() bool {
@@ -139,6 +182,12 @@ func (cmp *Comparison) Quit() {
 	// function. if we don't then the renderer will continue firing and get
 	// jammed waiting on a channel that has been abandone
 	cmp.driver.tv.RemovePixelRenderer(&cmp.driver)
+	cmp.driver.tv.RemoveAudioMixer(&cmp.driver)
+
+	// return the realtime mixer to the driver emulation
+	if cmp.mixer != nil {
+		cmp.driver.tv.SetRealTimeAudioMixer(cmp.mixer.realtime)
+	}
 
 	// send quit signal to the comparison emulation
 	cmp.emulationQuit <- true
@@ -231,6 +280,9 @@ func (cmp *Comparison) CreateFromLoader(cartload cartridgeloader.Loader) error {
 			return
 		}
 
+		// data for the driver's first frame is in the first buffer
+		cmp.frameIdx = 0
+
 		err = cmp.vcs.Run(func() (govern.State, error) {
 			select {
 			case <-cmp.emulationQuit:
@@ -238,6 +290,10 @@ func (cmp *Comparison) CreateFromLoader(cartload cartridgeloader.Loader) error {
 			default:
 			}
 
+			if cmp.driver.lockstep == LockstepInstruction {
+				cmp.checkLockstep(takeSample(cmp.vcs))
+			}
+
 			return govern.Running, nil
 		})
 		if err != nil {
@@ -272,6 +328,19 @@ func (cmp *Comparison) NewFrame(frameInfo frameinfo.Current) error {
 	default:
 	}
 
+	// the driver emulation may have more samples for the frame than the
+	// comparison emulation
+	if cmp.driver.lockstep != LockstepNone && !cmp.diverged {
+		if cmp.lockstepCount < len(cmp.driver.samples[cmp.frameIdx]) {
+			cmp.diverge(takeSample(cmp.vcs).coords, []string{"driver emulation has more samples in the frame than the comparison emulation"})
+		}
+	}
+
+	// audio and RAM are compared before synchronising with the driver
+	// emulation because the driver will reuse the buffers once it is running
+	// again
+	fd := cmp.compareFrame()
+
 	select {
 	case <-cmp.driver.sync:
 	case <-cmp.emulationQuit:
@@ -284,6 +353,21 @@ func (cmp *Comparison) NewFrame(frameInfo frameinfo.Current) error {
 		return nil
 	}
 
+	// the driver has started writing to the other buffers. we don't read the
+	// driver's frameIdx field because the driver emulation is running again
+	// by this point
+	cmp.frameIdx ^= 1
+	cmp.lockstepCount = 0
+
+	// send differences to the GUI even if the frames are not stable
+	defer func() {
+		cmp.lastDiff.Store(fd)
+		select {
+		case cmp.Diff <- fd:
+		default:
+		}
+	}()
+
 	if !cmp.frameInfo.Stable || !cmp.driver.frameInfo.Stable {
 		return nil
 	}
@@ -316,6 +400,7 @@ func (cmp *Comparison) NewFrame(frameInfo frameinfo.Current) error {
 			c[0] = 0xff
 			c[1] = 0xff
 			c[2] = 0xff
+			fd.Pixels++
 		} else {
 			c[0] = 0x00
 			c[1] = 0x00
@@ -323,46 +408,104 @@ func (cmp *Comparison) NewFrame(frameInfo frameinfo.Current) error {
 		}
 		c[3] = 0xff
 	}
+	fd.VideoCompared = true
 
 	// indicate visual differences
 	cmp.DiffRender <- cmp.cropDiffImg
 
-	// find differences in the two audio buffers
-	var audioIsDifferent bool
+	return nil
+}
 
-	var drvAudioIdx int
-	if cmp.driver.swapIdx {
-		drvAudioIdx = 0
-	} else {
-		drvAudioIdx = 1
+// compare the audio and RAM of the frame that has just ended with the
+// corresponding frame of the driver emulation
+func (cmp *Comparison) compareFrame() FrameDiff {
+	fd := FrameDiff{
+		FrameNum: cmp.frameInfo.FrameNum,
 	}
 
-	if len(cmp.audio) != len(cmp.driver.audio[drvAudioIdx]) {
-		audioIsDifferent = true
-	} else {
-		for i := range cmp.driver.audio[drvAudioIdx] {
-			if cmp.audio[i] != cmp.driver.audio[drvAudioIdx][i] {
-				audioIsDifferent = true
-				break
-			}
+	drvAudio := cmp.driver.audio[cmp.frameIdx]
+	fd.Audio = make([]float32, max(len(cmp.audio), len(drvAudio))/2)
+	fd.AudioDiffers = len(cmp.audio) != len(drvAudio)
+
+	// the difference signal is the sum of the two audio channels of the
+	// driver emulation minus the sum of the two channels of the comparison.
+	// where one emulation has produced more audio than the other, the missing
+	// audio is treated as silence
+	for i := range fd.Audio {
+		var d, c int
+		if i*2+1 < len(drvAudio) {
+			d = int(drvAudio[i*2]) + int(drvAudio[i*2+1])
+		}
+		if i*2+1 < len(cmp.audio) {
+			c = int(cmp.audio[i*2]) + int(cmp.audio[i*2+1])
+		}
+		if d != c {
+			fd.AudioDiffers = true
 		}
+		fd.Audio[i] = float32(d - c)
 	}
-
-	// indicate audio differences
-	cmp.AudioDiff <- audioIsDifferent
-
-	// clear audio buffers
 	cmp.audio = cmp.audio[:0]
-	cmp.driver.audio[drvAudioIdx] = cmp.driver.audio[drvAudioIdx][:0]
 
-	return nil
+	fd.DriverRAM = cmp.driver.ram[cmp.frameIdx]
+	copy(fd.ComparisonRAM[:], cmp.vcs.Mem.RAM.RAM)
+	for i := range fd.DriverRAM {
+		if fd.DriverRAM[i] != fd.ComparisonRAM[i] {
+			fd.RAM = append(fd.RAM, memorymap.OriginRAM+uint16(i))
+		}
+	}
+
+	return fd
 }
 
 // NewScanline implements the television.PixelRenderer interface.
 func (cmp *Comparison) NewScanline(scanline int) error {
+	if cmp.driver.lockstep == LockstepScanline {
+		cmp.checkLockstep(takeSample(cmp.vcs))
+	}
 	return nil
 }
 
+// compare a sample of the current state of the comparison emulation with the
+// corresponding sample from the driver emulation
+func (cmp *Comparison) checkLockstep(s sample) {
+	if cmp.diverged {
+		return
+	}
+
+	drv := cmp.driver.samples[cmp.frameIdx]
+	if cmp.lockstepCount >= len(drv) {
+		cmp.diverge(s.coords, []string{"comparison emulation has more samples in the frame than the driver emulation"})
+		return
+	}
+
+	if d := drv[cmp.lockstepCount].diff(s); len(d) > 0 {
+		cmp.diverge(s.coords, d)
+		return
+	}
+
+	cmp.lockstepCount++
+}
+
+// report divergence of the two emulations. no further comparisons of state
+// will be made
+func (cmp *Comparison) diverge(c coords.TelevisionCoords, differences []string) {
+	cmp.diverged = true
+
+	unit := "instruction"
+	if cmp.driver.lockstep == LockstepScanline {
+		unit = "scanline"
+	}
+
+	select {
+	case cmp.Divergence <- Divergence{
+		Coords:      c,
+		Position:    fmt.Sprintf("%s %d of frame", unit, cmp.lockstepCount),
+		Differences: differences,
+	}:
+	default:
+	}
+}
+
 // SetPixels implements the television.PixelRenderer interface.
 func (cmp *Comparison) SetPixels(sig []signal.SignalAttributes, last int) error {
 	var col color.RGBA
@@ -425,6 +568,9 @@ func (cmp *Comparison) SetAudio(sig []signal.AudioSignalAttributes) error {
 	for _, s := range sig {
 		cmp.audio = append(cmp.audio, s.AudioChannel0, s.AudioChannel1)
 	}
+	if cmp.mixer != nil {
+		cmp.mixer.push(sig)
+	}
 	return nil
 }
 
@@ -0,0 +1,80 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/memory"
+	"github.com/jetsetilly/gopher2600/hardware/memory/vcs"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestCompareFrame(t *testing.T) {
+	cmp := &Comparison{
+		vcs: &hardware.VCS{
+			Mem: &memory.Memory{
+				RAM: &vcs.RAM{RAM: make([]uint8, 128)},
+			},
+		},
+	}
+	cmp.frameInfo.FrameNum = 10
+
+	// the two frames of audio differ in two samples
+	cmp.driver.audio[0] = []uint8{0, 0, 4, 2, 8, 8, 15, 15}
+	cmp.audio = []uint8{0, 0, 4, 2, 6, 8, 15, 1}
+
+	// and the RAM differs in two addresses
+	cmp.driver.ram[0][0x00] = 0x01
+	cmp.driver.ram[0][0x7f] = 0xf0
+	cmp.vcs.Mem.RAM.RAM[0x00] = 0x01
+	cmp.vcs.Mem.RAM.RAM[0x10] = 0x20
+	cmp.vcs.Mem.RAM.RAM[0x7f] = 0x0f
+
+	fd := cmp.compareFrame()
+	test.ExpectEquality(t, fd.FrameNum, 10)
+
+	test.ExpectEquality(t, fd.AudioDiffers, true)
+	test.ExpectEquality(t, len(fd.Audio), 4)
+	test.ExpectEquality(t, fd.Audio[0], float32(0))
+	test.ExpectEquality(t, fd.Audio[1], float32(0))
+	test.ExpectEquality(t, fd.Audio[2], float32(2))
+	test.ExpectEquality(t, fd.Audio[3], float32(14))
+
+	test.ExpectEquality(t, len(fd.RAM), 2)
+	test.ExpectEquality(t, fd.RAM[0], uint16(0x90))
+	test.ExpectEquality(t, fd.RAM[1], uint16(0xff))
+
+	test.ExpectEquality(t, fd.Summary(), `frame 10
+video: not compared (unstable frame)
+audio: 2 of 4 samples differ
+RAM: 2 addresses differ
+  $90: 00 (driver) 20 (comparison)
+  $ff: f0 (driver) 0f (comparison)`)
+
+	// the comparison emulation has produced less audio than the driver. the
+	// missing audio is treated as silence
+	cmp.driver.audio[0] = []uint8{4, 4, 2, 2}
+	cmp.audio = append(cmp.audio, 4, 4)
+
+	fd = cmp.compareFrame()
+	test.ExpectEquality(t, fd.AudioDiffers, true)
+	test.ExpectEquality(t, len(fd.Audio), 2)
+	test.ExpectEquality(t, fd.Audio[0], float32(0))
+	test.ExpectEquality(t, fd.Audio[1], float32(4))
+	test.ExpectEquality(t, len(cmp.audio), 0)
+}
@@ -0,0 +1,186 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"os"
+
+	"github.com/jetsetilly/gopher2600/digest"
+	"github.com/jetsetilly/gopher2600/hardware/television"
+	"github.com/jetsetilly/gopher2600/hardware/television/frameinfo"
+	"github.com/jetsetilly/gopher2600/hardware/television/signal"
+)
+
+// the first line of a digests file. used to distinguish a digests file from a
+// trace file when loading a reference
+const digestsHeader = "# gopher2600 frame digests"
+
+// frameDigests creates a video and audio digest for every frame. the video
+// digest is chained, meaning that once a frame differs every subsequent frame
+// will also differ. the audio digest is for the frame only
+type frameDigests struct {
+	tv    *television.Television
+	video *digest.Video
+	audio hash.Hash
+
+	// called at the end of every frame with the digests for the frame
+	onFrame func(frameNum int, video string, audio string) error
+}
+
+func newFrameDigests(tv *television.Television, onFrame func(int, string, string) error) (*frameDigests, error) {
+	dig := &frameDigests{
+		tv:      tv,
+		audio:   sha1.New(),
+		onFrame: onFrame,
+	}
+
+	var err error
+
+	// the video digest adds itself to the television. we must add ourselves
+	// after the video digest so that the video digest is up to date by the
+	// time our NewFrame() is called
+	dig.video, err = digest.NewVideo(tv)
+	if err != nil {
+		return nil, err
+	}
+	tv.AddPixelRenderer(dig)
+	tv.AddAudioMixer(dig)
+
+	return dig, nil
+}
+
+// remove digests from television
+func (dig *frameDigests) end() {
+	dig.tv.RemovePixelRenderer(dig.video)
+	dig.tv.RemovePixelRenderer(dig)
+	dig.tv.RemoveAudioMixer(dig)
+}
+
+// Resize implements the television.PixelRenderer interface.
+func (dig *frameDigests) Resize(_ frameinfo.Current) error {
+	return nil
+}
+
+// NewFrame implements the television.PixelRenderer interface.
+func (dig *frameDigests) NewFrame(frameInfo frameinfo.Current) error {
+	audio := fmt.Sprintf("%x", dig.audio.Sum(nil))
+	dig.audio.Reset()
+	return dig.onFrame(frameInfo.FrameNum, dig.video.Hash(), audio)
+}
+
+// NewScanline implements the television.PixelRenderer interface.
+func (dig *frameDigests) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixels implements the television.PixelRenderer interface.
+func (dig *frameDigests) SetPixels(_ []signal.SignalAttributes, _ int) error {
+	return nil
+}
+
+// Reset implements the television.PixelRenderer and television.AudioMixer
+// interfaces.
+func (dig *frameDigests) Reset() {
+	dig.audio.Reset()
+}
+
+// EndRendering implements the television.PixelRenderer interface.
+func (dig *frameDigests) EndRendering() error {
+	return nil
+}
+
+// SetAudio implements the television.AudioMixer interface.
+func (dig *frameDigests) SetAudio(sig []signal.AudioSignalAttributes) error {
+	for _, s := range sig {
+		dig.audio.Write([]byte{s.AudioChannel0, s.AudioChannel1})
+	}
+	return nil
+}
+
+// EndMixing implements the television.AudioMixer interface.
+func (dig *frameDigests) EndMixing() error {
+	return nil
+}
+
+// DigestRecorder writes the video and audio digest of every frame to a file.
+// The file can later be used as a reference with NewReference().
+type DigestRecorder struct {
+	filename string
+	f        *os.File
+	w        *bufio.Writer
+	dig      *frameDigests
+	frames   int
+}
+
+// NewDigestRecorder is the preferred method of initialisation for the
+// DigestRecorder type.
+func NewDigestRecorder(tv *television.Television, filename string) (*DigestRecorder, error) {
+	rec := &DigestRecorder{
+		filename: filename,
+	}
+
+	var err error
+
+	rec.f, err = os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("comparison: %w", err)
+	}
+	rec.w = bufio.NewWriter(rec.f)
+
+	_, err = fmt.Fprintln(rec.w, digestsHeader)
+	if err != nil {
+		_ = rec.f.Close()
+		return nil, fmt.Errorf("comparison: %w", err)
+	}
+
+	rec.dig, err = newFrameDigests(tv, func(frameNum int, video string, audio string) error {
+		rec.frames++
+		_, err := fmt.Fprintf(rec.w, "%d %s %s\n", frameNum, video, audio)
+		return err
+	})
+	if err != nil {
+		_ = rec.f.Close()
+		return nil, fmt.Errorf("comparison: %w", err)
+	}
+
+	return rec, nil
+}
+
+func (rec *DigestRecorder) String() string {
+	return fmt.Sprintf("%s (%d frames)", rec.filename, rec.frames)
+}
+
+// End recording and close the digests file.
+func (rec *DigestRecorder) End() error {
+	rec.dig.end()
+
+	err := rec.w.Flush()
+	if err != nil {
+		_ = rec.f.Close()
+		return fmt.Errorf("comparison: %w", err)
+	}
+
+	err = rec.f.Close()
+	if err != nil {
+		return fmt.Errorf("comparison: %w", err)
+	}
+
+	return nil
+}
@@ -33,6 +33,30 @@
 // shows the differences (as white pixels) between corresponding frames from
 // the two emulations. Each video stream has a one frame buffer.
 //
+// In addition, a FrameDiff is produced for every frame. It contains an audio
+// difference signal and the list of VCS RAM addresses that differ at the end
+// of the frame. The most recent FrameDiff is also available with LastDiff().
+//
+// The audio of the two emulations is mixed together and played through the
+// realtime audio mixer of the driver emulation's television. Mixing happens on
+// the driver emulation's goroutine. Because the comparison emulation is one
+// frame behind the driver, its audio is heard one frame later. The realtime
+// mixer is returned to the driver emulation when the comparison ends.
+//
+// The state of the two emulations can also be compared in lockstep, either
+// at the start of every scanline or after every CPU instruction. The state
+// compared is the CPU registers, the VCS RAM, the CPU readable TIA and RIOT
+// registers, some internal TIA and RIOT state, and the cartridge bank. A
+// Divergence is sent over the Divergence channel for the first difference
+// found. Because the driver emulation is a frame ahead, it will have
+// progressed by up to a frame by the time the Divergence is received.
+//
+// As an alternative to a second emulation, the driver emulation can be checked
+// against a Reference. A reference is either an execution trace (from the
+// tracewriter package or from another emulator) or a file of per-frame video
+// and audio digests created by DigestRecorder. The first line or frame that
+// differs is sent over the Divergence channel of the Reference.
+//
 // The comparison emulation does not handle the rewind state at all. This means
 // that if the driver emulation is put into the rewinding state the constraints
 // on how the emulations are synchronised will very likely be broken. For
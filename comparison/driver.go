@@ -19,6 +19,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/jetsetilly/gopher2600/hardware"
 	"github.com/jetsetilly/gopher2600/hardware/television"
 	"github.com/jetsetilly/gopher2600/hardware/television/frameinfo"
 	"github.com/jetsetilly/gopher2600/hardware/television/signal"
@@ -26,23 +27,32 @@ import (
 )
 
 type driver struct {
-	tv *television.Television
+	vcs *hardware.VCS
+	tv  *television.Television
 
 	frameInfo frameinfo.Current
 
 	img     [2]*image.RGBA
 	cropImg [2]*image.RGBA
-	audio   [2][]uint8
 	swapIdx bool
 
+	// audio, RAM and lockstep samples for the current frame and the previous
+	// frame. the data for the current frame is always at frameIdx
+	audio    [2][]uint8
+	ram      [2][128]uint8
+	lockstep Lockstep
+	samples  [2][]sample
+	frameIdx int
+
 	sync chan bool
 	ack  chan bool
 	quit chan error
 }
 
-func newDriver(tv *television.Television) driver {
+func newDriver(vcs *hardware.VCS) driver {
 	drv := driver{
-		tv:   tv,
+		vcs:  vcs,
+		tv:   vcs.TV,
 		sync: make(chan bool),
 		ack:  make(chan bool),
 		quit: make(chan error),
@@ -77,6 +87,9 @@ func (drv *driver) NewFrame(frameInfo frameinfo.Current) error {
 	drv.frameInfo = frameInfo
 	drv.swapIdx = !drv.swapIdx
 
+	copy(drv.ram[drv.frameIdx][:], drv.vcs.Mem.RAM.RAM)
+	drv.frameIdx ^= 1
+
 	select {
 	case drv.sync <- true:
 	case err := <-drv.quit:
@@ -89,14 +102,29 @@ func (drv *driver) NewFrame(frameInfo frameinfo.Current) error {
 		return err
 	}
 
+	// the comparison emulation has finished with the data from two frames
+	// ago so the buffers can be reused
+	drv.audio[drv.frameIdx] = drv.audio[drv.frameIdx][:0]
+	drv.samples[drv.frameIdx] = drv.samples[drv.frameIdx][:0]
+
 	return nil
 }
 
 // NewScanline implements the television.PixelRenderer interface.
 func (drv *driver) NewScanline(scanline int) error {
+	if drv.lockstep == LockstepScanline {
+		drv.samples[drv.frameIdx] = append(drv.samples[drv.frameIdx], takeSample(drv.vcs))
+	}
 	return nil
 }
 
+// step should be called after every CPU instruction of the driver emulation
+func (drv *driver) step() {
+	if drv.lockstep == LockstepInstruction {
+		drv.samples[drv.frameIdx] = append(drv.samples[drv.frameIdx], takeSample(drv.vcs))
+	}
+}
+
 // SetPixels implements the television.PixelRenderer interface.
 func (drv *driver) SetPixels(sig []signal.SignalAttributes, last int) error {
 	var col color.RGBA
@@ -150,14 +178,8 @@ func (drv *driver) EndRendering() error {
 
 // SetAudio implements the television.AudioMixer interface.
 func (drv *driver) SetAudio(sig []signal.AudioSignalAttributes) error {
-	var idx int
-	if drv.swapIdx {
-		idx = 0
-	} else {
-		idx = 1
-	}
 	for _, s := range sig {
-		drv.audio[idx] = append(drv.audio[idx], s.AudioChannel0, s.AudioChannel1)
+		drv.audio[drv.frameIdx] = append(drv.audio[drv.frameIdx], s.AudioChannel0, s.AudioChannel1)
 	}
 	return nil
 }
@@ -0,0 +1,94 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FrameDiff summarises the differences between a frame of the comparison
+// emulation and the corresponding frame of the driver emulation.
+type FrameDiff struct {
+	// frame number of the comparison emulation
+	FrameNum int
+
+	// the number of pixels that differ. only valid if VideoCompared is true.
+	// video is not compared if either television is unstable
+	Pixels        int
+	VideoCompared bool
+
+	// the audio difference signal. each entry is the sum of both audio
+	// channels of the driver emulation minus the sum of both channels of the
+	// comparison emulation
+	Audio        []float32
+	AudioDiffers bool
+
+	// the addresses of the VCS RAM that differ at the end of the frame
+	RAM []uint16
+
+	// the contents of VCS RAM at the end of the frame for both emulations
+	DriverRAM     [128]uint8
+	ComparisonRAM [128]uint8
+}
+
+// Summary returns a multiline description of the differences.
+func (fd FrameDiff) Summary() string {
+	s := strings.Builder{}
+
+	fmt.Fprintf(&s, "frame %d\n", fd.FrameNum)
+
+	if fd.VideoCompared {
+		if fd.Pixels == 0 {
+			s.WriteString("video: same\n")
+		} else {
+			fmt.Fprintf(&s, "video: %d pixels differ\n", fd.Pixels)
+		}
+	} else {
+		s.WriteString("video: not compared (unstable frame)\n")
+	}
+
+	if fd.AudioDiffers {
+		var n int
+		for _, a := range fd.Audio {
+			if a != 0 {
+				n++
+			}
+		}
+		fmt.Fprintf(&s, "audio: %d of %d samples differ\n", n, len(fd.Audio))
+	} else {
+		s.WriteString("audio: same\n")
+	}
+
+	if len(fd.RAM) == 0 {
+		s.WriteString("RAM: same")
+	} else {
+		fmt.Fprintf(&s, "RAM: %d addresses differ", len(fd.RAM))
+		for _, a := range fd.RAM {
+			i := a & 0x7f
+			fmt.Fprintf(&s, "\n  $%02x: %02x (driver) %02x (comparison)", a, fd.DriverRAM[i], fd.ComparisonRAM[i])
+		}
+	}
+
+	return s.String()
+}
+
+// LastDiff returns the most recent FrameDiff. Returns false if no frames have
+// been compared yet.
+func (cmp *Comparison) LastDiff() (FrameDiff, bool) {
+	fd, ok := cmp.lastDiff.Load().(FrameDiff)
+	return fd, ok
+}
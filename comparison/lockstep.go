@@ -0,0 +1,214 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cpubus"
+	"github.com/jetsetilly/gopher2600/hardware/riot/timer"
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
+	"github.com/jetsetilly/gopher2600/hardware/tia/phaseclock"
+)
+
+// Lockstep specifies how often the state of the two emulations is compared.
+type Lockstep int
+
+// List of valid Lockstep values.
+const (
+	// state is not compared. only the video and audio output is compared
+	LockstepNone Lockstep = iota
+
+	// state is compared at the start of every scanline
+	LockstepScanline
+
+	// state is compared after every CPU instruction
+	LockstepInstruction
+)
+
+// LockstepList is the list of lockstep names in a suitable order for
+// presentation.
+var LockstepList = []string{"NONE", "SCANLINE", "INSTRUCTION"}
+
+func (l Lockstep) String() string {
+	if int(l) < len(LockstepList) {
+		return LockstepList[l]
+	}
+	return "unknown"
+}
+
+// ParseLockstep converts a string to a Lockstep value. The string is case
+// insensitive. An empty string is the same as NONE.
+func ParseLockstep(s string) (Lockstep, error) {
+	if s == "" {
+		return LockstepNone, nil
+	}
+	for i, n := range LockstepList {
+		if strings.EqualFold(s, n) {
+			return Lockstep(i), nil
+		}
+	}
+	return LockstepNone, fmt.Errorf("comparison: unknown lockstep (%s)", s)
+}
+
+// the TIA and RIOT registers that are included in a lockstep sample. these
+// are the registers that can be read by the CPU
+var lockstepTIA = [...]cpubus.Register{
+	cpubus.CXM0P, cpubus.CXM1P, cpubus.CXP0FB, cpubus.CXP1FB,
+	cpubus.CXM0FB, cpubus.CXM1FB, cpubus.CXBLPF, cpubus.CXPPMM,
+	cpubus.INPT0, cpubus.INPT1, cpubus.INPT2, cpubus.INPT3,
+	cpubus.INPT4, cpubus.INPT5,
+}
+
+var lockstepRIOT = [...]cpubus.Register{
+	cpubus.SWCHA, cpubus.SWACNT, cpubus.SWCHB, cpubus.SWBCNT,
+	cpubus.INTIM, cpubus.TIMINT,
+}
+
+// the state of an emulation at a single point in time. every field in the
+// sample is comparable
+type sample struct {
+	coords coords.TelevisionCoords
+
+	pc     uint16
+	a      uint8
+	x      uint8
+	y      uint8
+	sp     uint8
+	status uint8
+
+	bank    int
+	bankRAM bool
+
+	ram [128]uint8
+
+	tia    [len(lockstepTIA)]uint8
+	pclk   phaseclock.PhaseClock
+	hblank bool
+
+	riot    [len(lockstepRIOT)]uint8
+	ticks   int
+	divider timer.Divider
+}
+
+// take a sample of the emulation's current state
+func takeSample(vcs *hardware.VCS) sample {
+	s := sample{
+		coords:  vcs.TV.GetCoords(),
+		pc:      vcs.CPU.PC.Address(),
+		a:       vcs.CPU.A.Value(),
+		x:       vcs.CPU.X.Value(),
+		y:       vcs.CPU.Y.Value(),
+		sp:      vcs.CPU.SP.Value(),
+		status:  vcs.CPU.Status.Value(),
+		pclk:    vcs.TIA.PClk,
+		hblank:  vcs.TIA.Hblank,
+		ticks:   vcs.RIOT.Timer.PeekState("ticksRemaining").(int),
+		divider: vcs.RIOT.Timer.PeekState("divider").(timer.Divider),
+	}
+
+	bank := vcs.Mem.Cart.GetBank(s.pc)
+	s.bank = bank.Number
+	s.bankRAM = bank.IsRAM
+
+	copy(s.ram[:], vcs.Mem.RAM.RAM)
+
+	for i, r := range lockstepTIA {
+		s.tia[i], _ = vcs.Mem.TIA.Peek(cpubus.ReadAddressByRegister[r])
+	}
+	for i, r := range lockstepRIOT {
+		s.riot[i], _ = vcs.Mem.RIOT.Peek(cpubus.ReadAddressByRegister[r])
+	}
+
+	return s
+}
+
+// diff returns a description of every difference between the two samples.
+// the receiver is the sample from the driver emulation. the frame number is
+// not compared because the two emulations may have started at different
+// points
+func (drv sample) diff(cmp sample) []string {
+	var d []string
+
+	add := func(name string, drv any, cmp any) {
+		d = append(d, fmt.Sprintf("%s: %v (driver) %v (comparison)", name, drv, cmp))
+	}
+	hex8 := func(name string, drv uint8, cmp uint8) {
+		if drv != cmp {
+			add(name, fmt.Sprintf("%02x", drv), fmt.Sprintf("%02x", cmp))
+		}
+	}
+
+	if drv.coords.Scanline != cmp.coords.Scanline || drv.coords.Clock != cmp.coords.Clock {
+		add("coords", drv.coords, cmp.coords)
+	}
+	if drv.pc != cmp.pc {
+		add("PC", fmt.Sprintf("$%04x", drv.pc), fmt.Sprintf("$%04x", cmp.pc))
+	}
+	hex8("A", drv.a, cmp.a)
+	hex8("X", drv.x, cmp.x)
+	hex8("Y", drv.y, cmp.y)
+	hex8("SP", drv.sp, cmp.sp)
+	hex8("P", drv.status, cmp.status)
+	if drv.bank != cmp.bank || drv.bankRAM != cmp.bankRAM {
+		add("bank", drv.bank, cmp.bank)
+	}
+	for i := range drv.ram {
+		hex8(fmt.Sprintf("RAM $%02x", 0x80+i), drv.ram[i], cmp.ram[i])
+	}
+	for i, r := range lockstepTIA {
+		hex8(string(r), drv.tia[i], cmp.tia[i])
+	}
+	if drv.pclk != cmp.pclk {
+		add("TIA phaseclock", drv.pclk, cmp.pclk)
+	}
+	if drv.hblank != cmp.hblank {
+		add("TIA hblank", drv.hblank, cmp.hblank)
+	}
+	for i, r := range lockstepRIOT {
+		hex8(string(r), drv.riot[i], cmp.riot[i])
+	}
+	if drv.ticks != cmp.ticks || drv.divider != cmp.divider {
+		add("RIOT timer", fmt.Sprintf("%s/%d", drv.divider, drv.ticks), fmt.Sprintf("%s/%d", cmp.divider, cmp.ticks))
+	}
+
+	return d
+}
+
+// Divergence is a report of the first point at which the driver emulation
+// differed from the comparison emulation or from the reference.
+type Divergence struct {
+	// coordinates of the emulation at the point of divergence
+	Coords coords.TelevisionCoords
+
+	// description of where the divergence happened. for example, the
+	// instruction number in the frame or the line in the reference file
+	Position string
+
+	// a description of every difference found
+	Differences []string
+}
+
+func (d Divergence) String() string {
+	s := strings.Builder{}
+	fmt.Fprintf(&s, "emulation diverged at %s (%s)", d.Coords, d.Position)
+	for _, l := range d.Differences {
+		fmt.Fprintf(&s, "\n  %s", l)
+	}
+	return s.String()
+}
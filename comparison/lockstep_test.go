@@ -0,0 +1,103 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// the state of an emulation for the scanline in the frame. the accumulator
+// changes on every scanline
+func lockstepSample(frame int, scanline int) sample {
+	return sample{
+		coords: coords.TelevisionCoords{Frame: frame, Scanline: scanline},
+		pc:     0xf000 + uint16(scanline),
+		a:      uint8(scanline),
+	}
+}
+
+func TestLockstepDivergence(t *testing.T) {
+	const numScanlines = 10
+	const divergentFrame = 3
+	const divergentScanline = 5
+
+	cmp := &Comparison{
+		Divergence: make(chan Divergence, 1),
+	}
+	cmp.driver.lockstep = LockstepScanline
+
+	for frame := range 5 {
+		// samples from the driver emulation for the frame
+		cmp.driver.samples[cmp.frameIdx] = cmp.driver.samples[cmp.frameIdx][:0]
+		for sl := range numScanlines {
+			cmp.driver.samples[cmp.frameIdx] = append(cmp.driver.samples[cmp.frameIdx], lockstepSample(frame, sl))
+		}
+
+		// the comparison emulation differs from the driver emulation on one
+		// scanline. the frame numbers of the two emulations are different but
+		// that is not a divergence
+		for sl := range numScanlines {
+			s := lockstepSample(frame+100, sl)
+			if frame == divergentFrame && sl == divergentScanline {
+				s.ram[0x10] = 0xff
+			}
+			cmp.checkLockstep(s)
+		}
+
+		cmp.frameIdx ^= 1
+		cmp.lockstepCount = 0
+	}
+
+	var d Divergence
+	select {
+	case d = <-cmp.Divergence:
+	default:
+		t.Fatalf("no divergence reported")
+	}
+
+	test.ExpectEquality(t, d.Coords.Frame, divergentFrame+100)
+	test.ExpectEquality(t, d.Coords.Scanline, divergentScanline)
+	test.ExpectEquality(t, d.Position, "scanline 5 of frame")
+	test.ExpectEquality(t, len(d.Differences), 1)
+	test.ExpectSuccess(t, strings.HasPrefix(d.Differences[0], "RAM $90: 00 (driver) ff (comparison)"))
+
+	// only the first divergence is reported
+	select {
+	case <-cmp.Divergence:
+		t.Fatalf("more than one divergence reported")
+	default:
+	}
+}
+
+func TestLockstepSampleCount(t *testing.T) {
+	cmp := &Comparison{
+		Divergence: make(chan Divergence, 1),
+	}
+	cmp.driver.lockstep = LockstepInstruction
+	cmp.driver.samples[0] = []sample{lockstepSample(0, 0)}
+
+	// the comparison emulation has more samples than the driver emulation
+	cmp.checkLockstep(lockstepSample(0, 0))
+	cmp.checkLockstep(lockstepSample(0, 1))
+
+	d := <-cmp.Divergence
+	test.ExpectEquality(t, d.Coords.Scanline, 1)
+	test.ExpectEquality(t, d.Position, "instruction 1 of frame")
+}
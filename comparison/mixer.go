@@ -0,0 +1,124 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"slices"
+
+	"github.com/jetsetilly/gopher2600/hardware/television"
+	"github.com/jetsetilly/gopher2600/hardware/television/signal"
+	"github.com/jetsetilly/gopher2600/hardware/television/specification"
+	"github.com/jetsetilly/gopher2600/hardware/tia/audio"
+)
+
+// the maximum number of audio samples from the comparison emulation waiting to
+// be mixed. enough for two frames of the tallest television
+const mixerMaxPending = 2 * specification.AbsoluteMaxScanlines * audio.SamplesPerScanline
+
+// mixer takes the place of the realtime audio mixer of the driver emulation's
+// television. audio from the driver emulation is mixed with audio from the
+// comparison emulation before it is forwarded to the original realtime mixer.
+//
+// the television of the driver emulation calls the mixer from the driver
+// emulation's goroutine. audio from the comparison emulation is sent to the
+// mixer over a channel with the push() function and is drained by SetAudio().
+// because the comparison emulation runs one frame behind the driver
+// emulation, the audio of the comparison emulation will be heard one frame
+// later than the audio of the driver
+type mixer struct {
+	realtime television.RealtimeAudioMixer
+
+	// audio from the comparison emulation
+	comparison chan []signal.AudioSignalAttributes
+
+	// audio from the comparison emulation that has been received but not yet
+	// mixed
+	pending []signal.AudioSignalAttributes
+
+	// the mixed audio sent to the realtime mixer
+	mix []signal.AudioSignalAttributes
+}
+
+func newMixer(realtime television.RealtimeAudioMixer) *mixer {
+	return &mixer{
+		realtime:   realtime,
+		comparison: make(chan []signal.AudioSignalAttributes, 4),
+	}
+}
+
+// push audio from the comparison emulation to the mixer. safe to call from the
+// comparison emulation's goroutine. audio is dropped if the driver emulation
+// is not draining the channel
+func (mix *mixer) push(sig []signal.AudioSignalAttributes) {
+	select {
+	case mix.comparison <- slices.Clone(sig):
+	default:
+	}
+}
+
+// SetAudio implements the television.AudioMixer interface.
+func (mix *mixer) SetAudio(sig []signal.AudioSignalAttributes) error {
+	// drain audio sent by the comparison emulation
+	for done := false; !done; {
+		select {
+		case c := <-mix.comparison:
+			mix.pending = append(mix.pending, c...)
+		default:
+			done = true
+		}
+	}
+
+	// the driver emulation has fallen behind the comparison emulation. drop
+	// the oldest audio
+	if len(mix.pending) > mixerMaxPending {
+		mix.pending = mix.pending[len(mix.pending)-mixerMaxPending:]
+	}
+
+	// the mixed signal is the average of each channel of the two emulations.
+	// if there is no audio from the comparison emulation it is treated as
+	// silence
+	mix.mix = mix.mix[:0]
+	for i, d := range sig {
+		var c signal.AudioSignalAttributes
+		if i < len(mix.pending) {
+			c = mix.pending[i]
+		}
+		mix.mix = append(mix.mix, signal.AudioSignalAttributes{
+			AudioChannel0: uint8((int(d.AudioChannel0) + int(c.AudioChannel0)) / 2),
+			AudioChannel1: uint8((int(d.AudioChannel1) + int(c.AudioChannel1)) / 2),
+		})
+	}
+	n := min(len(sig), len(mix.pending))
+	mix.pending = append(mix.pending[:0], mix.pending[n:]...)
+
+	return mix.realtime.SetAudio(mix.mix)
+}
+
+// EndMixing implements the television.AudioMixer interface.
+func (mix *mixer) EndMixing() error {
+	return mix.realtime.EndMixing()
+}
+
+// Reset implements the television.AudioMixer interface.
+func (mix *mixer) Reset() {
+	mix.pending = mix.pending[:0]
+	mix.realtime.Reset()
+}
+
+// SetSpec implements the television.RealtimeAudioMixer interface.
+func (mix *mixer) SetSpec(spec specification.Spec) {
+	mix.realtime.SetSpec(spec)
+}
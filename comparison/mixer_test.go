@@ -0,0 +1,122 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware/television/signal"
+	"github.com/jetsetilly/gopher2600/hardware/television/specification"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// records the audio sent to the realtime mixer
+type realtimeMixer struct {
+	audio []signal.AudioSignalAttributes
+	spec  specification.Spec
+	reset bool
+}
+
+func (rt *realtimeMixer) SetAudio(sig []signal.AudioSignalAttributes) error {
+	rt.audio = append(rt.audio[:0], sig...)
+	return nil
+}
+
+func (rt *realtimeMixer) EndMixing() error {
+	return nil
+}
+
+func (rt *realtimeMixer) Reset() {
+	rt.reset = true
+}
+
+func (rt *realtimeMixer) SetSpec(spec specification.Spec) {
+	rt.spec = spec
+}
+
+// audio signal with the same value in both channels
+func mixerAudio(v ...uint8) []signal.AudioSignalAttributes {
+	sig := make([]signal.AudioSignalAttributes, len(v))
+	for i := range v {
+		sig[i] = signal.AudioSignalAttributes{AudioChannel0: v[i], AudioChannel1: v[i]}
+	}
+	return sig
+}
+
+func TestMixer(t *testing.T) {
+	rt := &realtimeMixer{}
+	mix := newMixer(rt)
+
+	mix.SetSpec(specification.SpecPAL)
+	test.ExpectEquality(t, rt.spec.ID, specification.SpecPAL.ID)
+
+	// no audio from the comparison emulation yet. it is treated as silence
+	err := mix.SetAudio(mixerAudio(8, 8))
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(rt.audio, mixerAudio(4, 4)))
+
+	// the audio sent by the comparison emulation is copied
+	sig := mixerAudio(2, 4, 6)
+	mix.push(sig)
+	sig[0].AudioChannel0 = 0xff
+
+	// the driver emulation produces less audio than has been received from
+	// the comparison emulation. the remainder is mixed with the next audio
+	// from the driver emulation
+	err = mix.SetAudio(mixerAudio(8, 8))
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(rt.audio, mixerAudio(5, 6)))
+
+	mix.push(mixerAudio(10))
+	err = mix.SetAudio(mixerAudio(8, 8, 8))
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(rt.audio, mixerAudio(7, 9, 4)))
+
+	// audio from the comparison emulation is discarded on reset
+	mix.push(mixerAudio(10))
+	err = mix.SetAudio(nil)
+	test.ExpectSuccess(t, err)
+	mix.Reset()
+	test.ExpectSuccess(t, rt.reset)
+	err = mix.SetAudio(mixerAudio(8))
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(rt.audio, mixerAudio(4)))
+}
+
+func TestMixerPending(t *testing.T) {
+	rt := &realtimeMixer{}
+	mix := newMixer(rt)
+
+	// audio is dropped if the channel from the comparison emulation is full
+	for i := range cap(mix.comparison) + 1 {
+		mix.push(mixerAudio(uint8(i)))
+	}
+	err := mix.SetAudio(mixerAudio(0, 0, 0, 0, 0, 0))
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(rt.audio, mixerAudio(0, 0, 1, 1, 0, 0)))
+
+	// the oldest audio is dropped if too much audio from the comparison
+	// emulation is waiting to be mixed
+	for range mixerMaxPending + 10 {
+		mix.pending = append(mix.pending, mixerAudio(2)...)
+	}
+	mix.pending[len(mix.pending)-mixerMaxPending] = mixerAudio(4)[0]
+	err = mix.SetAudio(mixerAudio(0))
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(rt.audio, mixerAudio(2)))
+	test.ExpectEquality(t, len(mix.pending), mixerMaxPending-1)
+}
@@ -0,0 +1,256 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/tracewriter"
+)
+
+// Reference checks the driver emulation against a file recorded earlier. The
+// file can be either an execution trace or a digests file created by
+// DigestRecorder.
+//
+// An execution trace can come from the tracewriter package or from another
+// emulator, so long as the format of each line can be described with a
+// tracewriter format string.
+type Reference struct {
+	vcs *hardware.VCS
+
+	filename string
+	f        *os.File
+	scanner  *bufio.Scanner
+
+	// the line number of the most recent line read from the reference file
+	line int
+
+	// only one of these will be non-nil depending on the type of reference
+	trace   *tracewriter.TraceWriter
+	digests *frameDigests
+
+	// partial line of trace output. see Write() function
+	pending []byte
+
+	// the most recent line read from a digests reference that has not yet
+	// been compared with a frame of the driver. see checkFrame() function
+	frame struct {
+		valid bool
+		num   int
+		video string
+		audio string
+	}
+
+	// the reference has been exhausted or the driver has diverged from it.
+	// no further comparisons will be made
+	done     bool
+	diverged bool
+
+	// the first divergence from the reference is sent over this channel
+	Divergence chan Divergence
+}
+
+// NewReference is the preferred method of initialisation for the Reference
+// type. The traceFormat argument is only used if the reference file is an
+// execution trace. It can be the name of a tracewriter preset or a format
+// string.
+//
+// If the reference is an execution trace then Step() must be called after
+// every CPU instruction of the driver emulation.
+func NewReference(vcs *hardware.VCS, filename string, traceFormat string) (*Reference, error) {
+	ref := &Reference{
+		vcs:        vcs,
+		filename:   filename,
+		Divergence: make(chan Divergence, 1),
+	}
+
+	var err error
+
+	ref.f, err = os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("comparison: %w", err)
+	}
+	ref.scanner = bufio.NewScanner(ref.f)
+
+	// the first line of the file decides what type of reference it is. if
+	// the file is a trace then the line will be needed again when the first
+	// instruction is executed
+	first, ok := ref.next()
+	if ok && first == digestsHeader {
+		ref.digests, err = newFrameDigests(vcs.TV, ref.checkFrame)
+	} else {
+		_, _ = ref.f.Seek(0, 0)
+		ref.scanner = bufio.NewScanner(ref.f)
+		ref.line = 0
+		ref.trace, err = tracewriter.NewTraceWriterFromWriter(vcs, ref, filename, traceFormat,
+			tracewriter.Condition{}, tracewriter.Condition{})
+	}
+	if err != nil {
+		_ = ref.f.Close()
+		return nil, fmt.Errorf("comparison: %w", err)
+	}
+
+	return ref, nil
+}
+
+func (ref *Reference) String() string {
+	s := strings.Builder{}
+	if ref.digests != nil {
+		fmt.Fprintf(&s, "digests reference: %s", ref.filename)
+	} else {
+		fmt.Fprintf(&s, "trace reference: %s", ref.filename)
+	}
+	if ref.diverged {
+		fmt.Fprintf(&s, " (diverged at line %d)", ref.line)
+	} else if ref.done {
+		fmt.Fprintf(&s, " (matched %d lines)", ref.line)
+	} else {
+		fmt.Fprintf(&s, " (line %d)", ref.line)
+	}
+	return s.String()
+}
+
+// read the next line from the reference file. trailing whitespace is removed
+func (ref *Reference) next() (string, bool) {
+	if !ref.scanner.Scan() {
+		return "", false
+	}
+	ref.line++
+	return strings.TrimRight(ref.scanner.Text(), " \t\r"), true
+}
+
+// report divergence from the reference. no further comparisons will be made
+func (ref *Reference) diverge(position string, differences []string) {
+	ref.done = true
+	ref.diverged = true
+	select {
+	case ref.Divergence <- Divergence{
+		Coords:      ref.vcs.TV.GetCoords(),
+		Position:    position,
+		Differences: differences,
+	}:
+	default:
+	}
+}
+
+// Step should be called after every CPU instruction of the driver emulation.
+// It should not be called for instructions executed during a rewind or
+// catch-up.
+func (ref *Reference) Step() {
+	if ref.trace == nil || ref.done {
+		return
+	}
+	_ = ref.trace.Step()
+}
+
+// Write implements the io.Writer interface. The tracewriter writes each line
+// of the driver's trace to the Reference, where it is compared with the next
+// line of the reference file.
+func (ref *Reference) Write(p []byte) (int, error) {
+	ref.pending = append(ref.pending, p...)
+
+	for !ref.done {
+		i := bytes.IndexByte(ref.pending, '\n')
+		if i == -1 {
+			break // for loop
+		}
+
+		drv := strings.TrimRight(string(ref.pending[:i]), " \t\r")
+		ref.pending = ref.pending[i+1:]
+
+		l, ok := ref.next()
+		if !ok {
+			ref.done = true
+			break // for loop
+		}
+
+		if l != drv {
+			ref.diverge(fmt.Sprintf("line %d of reference trace", ref.line), []string{
+				fmt.Sprintf("reference: %s", l),
+				fmt.Sprintf("driver:    %s", drv),
+			})
+		}
+	}
+
+	if ref.done {
+		ref.pending = ref.pending[:0]
+	}
+
+	return len(p), nil
+}
+
+// compare the digests of a frame with the line in the reference file for the
+// same frame number. reference lines for earlier frames are skipped and if the
+// reference is ahead of the driver the line is kept until the driver catches up
+func (ref *Reference) checkFrame(frameNum int, video string, audio string) error {
+	if ref.done {
+		return nil
+	}
+
+	for !ref.frame.valid || ref.frame.num < frameNum {
+		l, ok := ref.next()
+		if !ok {
+			ref.done = true
+			return nil
+		}
+
+		_, err := fmt.Sscanf(l, "%d %s %s", &ref.frame.num, &ref.frame.video, &ref.frame.audio)
+		if err != nil {
+			ref.done = true
+			return fmt.Errorf("comparison: malformed line %d in digests reference", ref.line)
+		}
+		ref.frame.valid = true
+	}
+
+	// reference is ahead of the driver
+	if ref.frame.num > frameNum {
+		return nil
+	}
+	ref.frame.valid = false
+
+	var d []string
+	if ref.frame.video != video {
+		d = append(d, fmt.Sprintf("video digest: %s (reference) %s (driver)", ref.frame.video, video))
+	}
+	if ref.frame.audio != audio {
+		d = append(d, fmt.Sprintf("audio digest: %s (reference) %s (driver)", ref.frame.audio, audio))
+	}
+	if len(d) > 0 {
+		ref.diverge(fmt.Sprintf("frame %d of reference", frameNum), d)
+	}
+
+	return nil
+}
+
+// End comparison with the reference and close the reference file.
+func (ref *Reference) End() error {
+	if ref.digests != nil {
+		ref.digests.end()
+	}
+	if ref.trace != nil {
+		_ = ref.trace.End()
+	}
+	err := ref.f.Close()
+	if err != nil {
+		return fmt.Errorf("comparison: %w", err)
+	}
+	return nil
+}
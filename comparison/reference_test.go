@@ -0,0 +1,209 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package comparison
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/television"
+	"github.com/jetsetilly/gopher2600/hardware/television/frameinfo"
+	"github.com/jetsetilly/gopher2600/hardware/television/signal"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// a VCS with only a television. this is enough for the reference to report
+// the coordinates of a divergence
+func referenceVCS(t *testing.T) *hardware.VCS {
+	t.Helper()
+	tv, err := television.NewTelevision("NTSC")
+	test.ExpectSuccess(t, err)
+	return &hardware.VCS{TV: tv}
+}
+
+// a trace reference that reads from the lines rather than from a file
+func traceReference(t *testing.T, lines ...string) *Reference {
+	t.Helper()
+	return &Reference{
+		vcs:        referenceVCS(t),
+		filename:   "test",
+		scanner:    bufio.NewScanner(strings.NewReader(strings.Join(lines, "\n"))),
+		Divergence: make(chan Divergence, 1),
+	}
+}
+
+func TestReferenceTrace(t *testing.T) {
+	ref := traceReference(t, "f000 LDA #$00", "f002 STA $80", "f004 JMP $f000")
+
+	// lines are compared as they are completed. trailing whitespace is
+	// ignored
+	_, _ = ref.Write([]byte("f000 LDA #$00  \nf002 "))
+	test.ExpectEquality(t, ref.String(), "trace reference: test (line 1)")
+	_, _ = ref.Write([]byte("STA $80\n"))
+	_, _ = ref.Write([]byte("f004 JMP $f000\n"))
+	test.ExpectEquality(t, ref.String(), "trace reference: test (line 3)")
+	test.ExpectEquality(t, ref.done, false)
+
+	// the driver continues after the end of the reference
+	_, _ = ref.Write([]byte("f000 LDA #$00\nf002 STA $80\n"))
+	test.ExpectEquality(t, ref.done, true)
+	test.ExpectEquality(t, ref.String(), "trace reference: test (matched 3 lines)")
+	test.ExpectEquality(t, len(ref.pending), 0)
+
+	select {
+	case <-ref.Divergence:
+		t.Fatalf("divergence reported for matching trace")
+	default:
+	}
+}
+
+func TestReferenceTraceDivergence(t *testing.T) {
+	ref := traceReference(t, "f000 LDA #$00", "f002 STA $80", "f004 JMP $f000")
+
+	_, _ = ref.Write([]byte("f000 LDA #$00\nf002 STA $81\nf004 JMP $f000\n"))
+	test.ExpectEquality(t, ref.String(), "trace reference: test (diverged at line 2)")
+
+	d := <-ref.Divergence
+	test.ExpectEquality(t, d.Position, "line 2 of reference trace")
+	test.ExpectEquality(t, len(d.Differences), 2)
+	test.ExpectEquality(t, d.Differences[0], "reference: f002 STA $80")
+	test.ExpectEquality(t, d.Differences[1], "driver:    f002 STA $81")
+
+	// no further comparisons are made after a divergence
+	_, _ = ref.Write([]byte("ffff BRK\n"))
+	test.ExpectEquality(t, ref.line, 2)
+}
+
+// the digests for a sequence of frames. the audio for each frame is decided by
+// the audio function
+func recordDigests(t *testing.T, frames []int, audio func(frameNum int) uint8) string {
+	t.Helper()
+
+	var s strings.Builder
+	fmt.Fprintln(&s, digestsHeader)
+
+	dig, err := newFrameDigests(referenceVCS(t).TV, func(frameNum int, video string, audio string) error {
+		fmt.Fprintf(&s, "%d %s %s\n", frameNum, video, audio)
+		return nil
+	})
+	test.ExpectSuccess(t, err)
+	defer dig.end()
+
+	for _, fn := range frames {
+		playDigests(t, dig, fn, audio(fn))
+	}
+
+	return s.String()
+}
+
+// add audio to the digests and end the frame
+func playDigests(t *testing.T, dig *frameDigests, frameNum int, audio uint8) {
+	t.Helper()
+	err := dig.SetAudio([]signal.AudioSignalAttributes{{AudioChannel0: audio, AudioChannel1: audio}})
+	test.ExpectSuccess(t, err)
+	err = dig.NewFrame(frameinfo.Current{FrameNum: frameNum})
+	test.ExpectSuccess(t, err)
+}
+
+// a digests reference loaded from a file with the contents
+func digestsReference(t *testing.T, contents string) *Reference {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "digests")
+	err := os.WriteFile(filename, []byte(contents), 0o600)
+	test.ExpectSuccess(t, err)
+
+	ref, err := NewReference(referenceVCS(t), filename, "")
+	test.ExpectSuccess(t, err)
+	test.ExpectInequality(t, ref.digests, nil)
+	t.Cleanup(func() { _ = ref.End() })
+
+	return ref
+}
+
+func TestReferenceDigests(t *testing.T) {
+	audio := func(frameNum int) uint8 { return uint8(frameNum) }
+	ref := digestsReference(t, recordDigests(t, []int{1, 2, 3, 4, 5}, audio))
+
+	// the driver starts later than the reference and skips a frame. the
+	// reference lines for the frames are skipped
+	for _, fn := range []int{2, 3, 5} {
+		playDigests(t, ref.digests, fn, audio(fn))
+	}
+	test.ExpectEquality(t, ref.done, false)
+	test.ExpectEquality(t, ref.String(), fmt.Sprintf("digests reference: %s (line 6)", ref.filename))
+
+	// the driver continues after the end of the reference
+	playDigests(t, ref.digests, 6, audio(6))
+	test.ExpectEquality(t, ref.done, true)
+	test.ExpectEquality(t, ref.String(), fmt.Sprintf("digests reference: %s (matched 6 lines)", ref.filename))
+
+	select {
+	case <-ref.Divergence:
+		t.Fatalf("divergence reported for matching digests")
+	default:
+	}
+}
+
+func TestReferenceDigestsAhead(t *testing.T) {
+	audio := func(frameNum int) uint8 { return uint8(frameNum) }
+	ref := digestsReference(t, recordDigests(t, []int{3, 4}, audio))
+
+	// the reference is ahead of the driver. the line for frame 3 is kept until
+	// the driver reaches frame 3
+	playDigests(t, ref.digests, 1, 0xff)
+	playDigests(t, ref.digests, 2, 0xff)
+	test.ExpectEquality(t, ref.line, 2)
+	playDigests(t, ref.digests, 3, audio(3))
+	playDigests(t, ref.digests, 4, audio(4))
+	test.ExpectEquality(t, ref.line, 3)
+
+	select {
+	case <-ref.Divergence:
+		t.Fatalf("divergence reported for matching digests")
+	default:
+	}
+}
+
+func TestReferenceDigestsDivergence(t *testing.T) {
+	audio := func(frameNum int) uint8 { return uint8(frameNum) }
+	ref := digestsReference(t, recordDigests(t, []int{1, 2, 3}, audio))
+
+	playDigests(t, ref.digests, 1, audio(1))
+	playDigests(t, ref.digests, 2, 0xff)
+	test.ExpectEquality(t, ref.String(), fmt.Sprintf("digests reference: %s (diverged at line 3)", ref.filename))
+
+	d := <-ref.Divergence
+	test.ExpectEquality(t, d.Position, "frame 2 of reference")
+	test.ExpectEquality(t, len(d.Differences), 1)
+	test.ExpectSuccess(t, strings.HasPrefix(d.Differences[0], "audio digest: "))
+
+	// no further comparisons are made after a divergence
+	playDigests(t, ref.digests, 3, audio(3))
+	test.ExpectEquality(t, ref.line, 3)
+}
+
+func TestReferenceDigestsMalformed(t *testing.T) {
+	ref := digestsReference(t, digestsHeader+"\n1 abc\n")
+	err := ref.checkFrame(1, "abc", "def")
+	test.ExpectFailure(t, err)
+	test.ExpectEquality(t, ref.done, true)
+}
@@ -268,6 +268,18 @@ type CartCoProcDeveloper interface {
 	// checks if address has a breakpoint assigned to it
 	CheckBreakpoint(addr uint32) bool
 
+	// checks if a memory access triggers a watchpoint. the instruction address
+	// is the address of the instruction making the access. for write accesses
+	// the function is called after the memory has been written. returns true
+	// and a description of the watchpoint if it has been triggered
+	CheckWatchpoint(instructionAddr uint32, accessAddr uint32, size int, write bool) (bool, string)
+
+	// a memory access has read memory that has never been written to. the
+	// fault is recorded in the same way as for MemoryFault() but returns a
+	// description of the read, including the source line and variable if
+	// available
+	UninitialisedRead(event string, instructionAddr uint32, accessAddr uint32, size int) string
+
 	// update strobed variables
 	UpdateStrobe(addr uint32)
 
@@ -287,6 +299,54 @@ type CartCoProcDeveloper interface {
 	OnYield(addr uint32, reason CoProcYield)
 }
 
+// CartCoProcTraceWrite is a single memory write recorded in a coprocessor trace
+type CartCoProcTraceWrite struct {
+	Addr uint32
+	Size int
+
+	// the value in memory before and after the write
+	Prev  uint32
+	Value uint32
+}
+
+// CartCoProcTraceEntry is a single instruction recorded in a coprocessor trace
+type CartCoProcTraceEntry struct {
+	// address of the instruction
+	Addr uint32
+
+	// the core registers and status register before the instruction was
+	// executed. the format of the status register is defined by the coprocessor
+	Registers [16]uint32
+	Status    uint32
+
+	// memory writes made by the instruction in the order they were made
+	Writes []CartCoProcTraceWrite
+}
+
+// CartCoProcTracer is implemented by coprocessors that can record the
+// instructions executed during a coprocessor program and step backwards through
+// them
+type CartCoProcTracer interface {
+	// enable or disable tracing. the limit is the maximum number of
+	// instructions that are kept in the trace. older instructions are discarded
+	// as new instructions are recorded. a limit of zero or less indicates that
+	// a default value should be used
+	SetTracing(enable bool, limit int)
+
+	// whether tracing is enabled and the current limit
+	Tracing() (bool, int)
+
+	// the supplied function is called with the current trace. the oldest
+	// instruction is first in the list. the trace should not be retained
+	// beyond the end of the function
+	BorrowTrace(func([]CartCoProcTraceEntry))
+
+	// undo the most recent instructions in the trace, restoring the registers
+	// and memory to the state before the earliest of those instructions was
+	// executed. returns the number of instructions that were undone
+	StepBack(n int) (int, error)
+}
+
 // CoProcYield describes a coprocessor yield state
 type CoProcYield struct {
 	Type  CoProcYieldType
@@ -318,6 +378,10 @@ const (
 	// a user supplied breakpoint has been encountered
 	YieldBreakpoint CoProcYieldType = "Breakpoint"
 
+	// a user supplied watchpoint has been triggered by a memory access. the
+	// yield happens after the instruction making the access has completed
+	YieldWatchpoint CoProcYieldType = "Watchpoint"
+
 	// the program has triggered undefined behaviour in the coprocessor
 	YieldUndefinedBehaviour CoProcYieldType = "Undefined Behaviour"
 
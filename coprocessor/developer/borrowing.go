@@ -17,6 +17,7 @@ package developer
 
 import (
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/breakpoints"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/budget"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/callstack"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/yield"
@@ -49,6 +50,15 @@ func (dev *Developer) BorrowBreakpoints(f func(*breakpoints.Breakpoints)) {
 	f(&dev.breakpoints)
 }
 
+// BorrowBudget will lock the budget for the duration of the supplied function.
+//
+// May be nil.
+func (dev *Developer) BorrowBudget(f func(*budget.Budget)) {
+	dev.budgetLock.Lock()
+	defer dev.budgetLock.Unlock()
+	f(dev.budget)
+}
+
 // BorrowYieldState will lock the yield state for the duration of the supplied function.
 //
 // If the LocalVariables field is accessed then the access should occur within BorrowSource()
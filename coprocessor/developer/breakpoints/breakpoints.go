@@ -18,25 +18,77 @@ package breakpoints
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
 )
 
+// Breakpoint is a single breakpoint on a line of source code. All break
+// addresses in the line share the same Breakpoint instance.
+type Breakpoint struct {
+	Line *dwarf.SourceLine
+
+	// the condition that must be true for the breakpoint to halt execution.
+	// can be nil in which case the breakpoint is unconditional
+	Condition *Condition
+
+	// the number of times the breakpoint has been reached and the condition
+	// was true
+	Hits int
+
+	// the breakpoint will not halt execution until the number of hits has
+	// reached this value. a value of zero means that the breakpoint will halt
+	// execution on every hit
+	HitTarget int
+}
+
+func (b *Breakpoint) String() string {
+	s := b.Line.String()
+	if b.Condition != nil {
+		s = fmt.Sprintf("%s if %s", s, b.Condition)
+	}
+	if b.HitTarget > 0 {
+		s = fmt.Sprintf("%s (hits %d of %d)", s, b.Hits, b.HitTarget)
+	} else {
+		s = fmt.Sprintf("%s (hits %d)", s, b.Hits)
+	}
+	return s
+}
+
 type Breakpoints struct {
-	breakpoints map[uint32]bool
+	breakpoints map[uint32]*Breakpoint
 }
 
 // NewBreakpoints is the preferred method of initialiasation for the Breakpoints type
 func NewBreakpoints() Breakpoints {
 	return Breakpoints{
-		breakpoints: make(map[uint32]bool),
+		breakpoints: make(map[uint32]*Breakpoint),
 	}
 }
 
-// Write writes out the current callstack
+// List returns the breakpoints sorted by filename and line number
+func (bp *Breakpoints) List() []*Breakpoint {
+	var l []*Breakpoint
+	seen := make(map[*Breakpoint]bool)
+	for _, b := range bp.breakpoints {
+		if !seen[b] {
+			seen[b] = true
+			l = append(l, b)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Line.File == l[j].Line.File {
+			return l[i].Line.LineNumber < l[j].Line.LineNumber
+		}
+		return l[i].Line.File.Filename < l[j].Line.File.Filename
+	})
+	return l
+}
+
+// Write writes out the list of breakpoints
 func (bp *Breakpoints) Write(w io.Writer) {
-	for b := range bp.breakpoints {
-		fmt.Fprintf(w, "%08x\n", b)
+	for _, b := range bp.List() {
+		fmt.Fprintln(w, b.String())
 	}
 }
 
@@ -45,15 +97,42 @@ func (bp *Breakpoints) Count() int {
 	return len(bp.breakpoints)
 }
 
-// HasBreakpoint returns true if there is a breakpoint on the specified line
-func (bp *Breakpoints) Check(addr uint32) bool {
-	return bp.breakpoints[addr]
+// Clear removes all breakpoints
+func (bp *Breakpoints) Clear() {
+	clear(bp.breakpoints)
+}
+
+// Check returns true if there is a breakpoint at the address and whether
+// execution should halt. The condition of the breakpoint, if any, is evaluated
+// using the Environment provided by the env function. The function will only
+// be called if the breakpoint has a condition.
+//
+// If the condition cannot be evaluated then the breakpoint will halt
+// execution and the error is returned.
+func (bp *Breakpoints) Check(addr uint32, env func() Environment) (bool, error) {
+	b, ok := bp.breakpoints[addr]
+	if !ok {
+		return false, nil
+	}
+
+	if b.Condition != nil {
+		ok, err := b.Condition.Evaluate(env())
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	b.Hits++
+	return b.Hits >= b.HitTarget, nil
 }
 
-// addBreakpoint adds an address to the list of addresses that will be checked
-// each PC iteration
-func (bp *Breakpoints) addBreakpoint(addr uint32) {
-	bp.breakpoints[addr] = true
+// addBreakpoint adds a breakpoint to the list of addresses that will be
+// checked each PC iteration
+func (bp *Breakpoints) addBreakpoint(addr uint32, b *Breakpoint) {
+	bp.breakpoints[addr] = b
 }
 
 // removeBreakpoint removes an address from the list of breakpoint addresses
@@ -69,26 +148,87 @@ func (bp *Breakpoints) ToggleBreakpoint(ln *dwarf.SourceLine) {
 	// this means that if there is any address in the line with a breakpoint,
 	// all the addresses in the line are removed. otherwise all the break
 	// addresses are added
-	has := bp.HasBreakpoint(ln)
+	if bp.HasBreakpoint(ln) {
+		for _, i := range ln.Instruction {
+			bp.removeBreakpoint(i.Addr)
+		}
+		return
+	}
 
+	b := &Breakpoint{Line: ln}
 	for _, addr := range ln.BreakAddresses {
-		if has {
-			bp.removeBreakpoint(addr)
-		} else {
-			bp.addBreakpoint(addr)
-		}
+		bp.addBreakpoint(addr, b)
 	}
 }
 
-// HasBreakpoint returns true if there is a breakpoint on the specified line
-func (bp *Breakpoints) HasBreakpoint(ln *dwarf.SourceLine) bool {
-	// look for any address in the line and not just the break addresses
+// Breakpoint returns the breakpoint for the specified line. Returns nil if
+// there is no breakpoint on the line
+func (bp *Breakpoints) Breakpoint(ln *dwarf.SourceLine) *Breakpoint {
 	for _, i := range ln.Instruction {
-		if bp.breakpoints[i.Addr] {
-			return true
+		if b, ok := bp.breakpoints[i.Addr]; ok {
+			return b
 		}
 	}
-	return false
+	return nil
+}
+
+// breakpoint returns the breakpoint for the line, adding a new breakpoint if
+// necessary
+func (bp *Breakpoints) breakpoint(ln *dwarf.SourceLine) (*Breakpoint, error) {
+	if b := bp.Breakpoint(ln); b != nil {
+		return b, nil
+	}
+	if !bp.CanBreakpoint(ln) {
+		return nil, fmt.Errorf("breakpoints: line cannot have a breakpoint (%s)", ln)
+	}
+	b := &Breakpoint{Line: ln}
+	for _, addr := range ln.BreakAddresses {
+		bp.addBreakpoint(addr, b)
+	}
+	return b, nil
+}
+
+// SetCondition sets the condition for the breakpoint on the line. A
+// breakpoint will be added to the line if it does not already exist. An empty
+// expression removes the condition from an existing breakpoint.
+func (bp *Breakpoints) SetCondition(ln *dwarf.SourceLine, expression string) error {
+	var cond *Condition
+	if expression != "" {
+		var err error
+		cond, err = ParseCondition(expression)
+		if err != nil {
+			return fmt.Errorf("breakpoints: %w", err)
+		}
+	}
+
+	b, err := bp.breakpoint(ln)
+	if err != nil {
+		return err
+	}
+	b.Condition = cond
+	return nil
+}
+
+// SetHitTarget sets the number of hits required before the breakpoint on the
+// line halts execution. A breakpoint will be added to the line if it does not
+// already exist. The hit count for the breakpoint is reset.
+func (bp *Breakpoints) SetHitTarget(ln *dwarf.SourceLine, target int) error {
+	if target < 0 {
+		return fmt.Errorf("breakpoints: hit target cannot be negative")
+	}
+
+	b, err := bp.breakpoint(ln)
+	if err != nil {
+		return err
+	}
+	b.HitTarget = target
+	b.Hits = 0
+	return nil
+}
+
+// HasBreakpoint returns true if there is a breakpoint on the specified line
+func (bp *Breakpoints) HasBreakpoint(ln *dwarf.SourceLine) bool {
+	return bp.Breakpoint(ln) != nil
 }
 
 // CanBreakpoint returns true if the specified line can have a breakpoint applied to it
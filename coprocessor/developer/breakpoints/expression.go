@@ -0,0 +1,804 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package breakpoints
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+)
+
+// Environment is used to resolve the names and memory addresses used in a
+// Condition expression.
+type Environment interface {
+	// returns the named variable. the variable should have been updated and
+	// local variables should take precedence over global variables
+	Variable(name string) (Variable, bool)
+
+	// returns the value of the numbered register
+	Register(reg int) (uint32, bool)
+
+	// returns the value in memory. the size argument is the number of bytes
+	// to read and will be 1, 2 or 4
+	Peek(addr uint32, size int) (uint32, bool)
+}
+
+// Variable is a source variable referred to in a Condition expression.
+type Variable interface {
+	// the name and type of the variable
+	Name() string
+	Type() *dwarf.SourceType
+
+	// the location and current value of the variable. the value is only
+	// meaningful if the variable is valid and Err() returns nil
+	Address() (uint64, bool)
+	Value() uint32
+	IsValid() bool
+	Err() error
+
+	// the array elements, composite members or dereferenced variable. Child()
+	// and Member() return nil if there is no such child
+	NumChildren() int
+	Child(i int) Variable
+	Member(name string) Variable
+}
+
+// Condition is a C-like expression that is evaluated whenever a breakpoint is
+// reached. The breakpoint will only halt execution if the expression evaluates
+// to a non-zero value.
+//
+// The expression can refer to local and global variables by name. Struct
+// members, array elements and pointer dereferencing use the usual C syntax.
+// For example:
+//
+//	frame > 100 && p->x == 3
+//
+// Registers are referred to with a dollar prefix ($r0 to $r15, $sp, $lr and
+// $pc) and memory can be read with the mem8(), mem16() and mem32() functions.
+type Condition struct {
+	expression string
+	root       node
+}
+
+// ParseCondition parses the expression and returns a new Condition instance.
+func ParseCondition(expression string) (*Condition, error) {
+	toks, err := tokenise(expression)
+	if err != nil {
+		return nil, fmt.Errorf("condition: %w", err)
+	}
+
+	p := parser{toks: toks}
+	root, err := p.parse(0)
+	if err != nil {
+		return nil, fmt.Errorf("condition: %w", err)
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("condition: unexpected %s", p.toks[p.pos].s)
+	}
+
+	return &Condition{
+		expression: strings.TrimSpace(expression),
+		root:       root,
+	}, nil
+}
+
+func (c *Condition) String() string {
+	return c.expression
+}
+
+// Evaluate the condition using the supplied Environment. Returns true if the
+// expression evaluates to a non-zero value.
+func (c *Condition) Evaluate(env Environment) (bool, error) {
+	v, err := c.root.eval(env)
+	if err != nil {
+		return false, fmt.Errorf("condition: %w", err)
+	}
+	n, err := v.number()
+	if err != nil {
+		return false, fmt.Errorf("condition: %w", err)
+	}
+	return n != 0, nil
+}
+
+// the type of a token in the expression
+type tokenType int
+
+const (
+	tokenNumber tokenType = iota
+	tokenIdentifier
+	tokenRegister
+	tokenOperator
+)
+
+type token struct {
+	typ tokenType
+	s   string
+	n   int64
+}
+
+// operators are listed longest first so that, for example, "<=" is matched
+// before "<"
+var operators = []string{
+	"||", "&&", "==", "!=", "<=", ">=", "<<", ">>", "->",
+	"+", "-", "*", "/", "%", "<", ">", "!", "~", "&", "|", "^",
+	"(", ")", "[", "]", ".",
+}
+
+func isIdentifierChar(c byte, first bool) bool {
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+func tokenise(s string) ([]token, error) {
+	var toks []token
+
+	i := 0
+	for i < len(s) {
+		c := s[i]
+
+		if c == ' ' || c == '\t' {
+			i++
+			continue // for loop
+		}
+
+		// numbers in any of the notations accepted by strconv.ParseInt()
+		if c >= '0' && c <= '9' {
+			j := i
+			for j < len(s) && isIdentifierChar(s[j], false) {
+				j++
+			}
+			n, err := strconv.ParseInt(s[i:j], 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a valid number", s[i:j])
+			}
+			toks = append(toks, token{typ: tokenNumber, s: s[i:j], n: n})
+			i = j
+			continue // for loop
+		}
+
+		// variable names and registers. variable names can be qualified with
+		// C++ namespaces and class names (eg. "game::Player::count")
+		if c == '$' || isIdentifierChar(c, true) {
+			j := i + 1
+			for j < len(s) {
+				if isIdentifierChar(s[j], false) {
+					j++
+				} else if c != '$' && strings.HasPrefix(s[j:], "::") && j+2 < len(s) && isIdentifierChar(s[j+2], true) {
+					j += 2
+				} else {
+					break // for loop
+				}
+			}
+			if c == '$' {
+				if j == i+1 {
+					return nil, fmt.Errorf("missing register name")
+				}
+				toks = append(toks, token{typ: tokenRegister, s: s[i:j]})
+			} else {
+				toks = append(toks, token{typ: tokenIdentifier, s: s[i:j]})
+			}
+			i = j
+			continue // for loop
+		}
+
+		var matched bool
+		for _, op := range operators {
+			if strings.HasPrefix(s[i:], op) {
+				toks = append(toks, token{typ: tokenOperator, s: op})
+				i += len(op)
+				matched = true
+				break // for loop
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("unexpected character (%c)", c)
+		}
+	}
+
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	return toks, nil
+}
+
+// the precedence of binary operators. higher values bind more tightly. the
+// order follows the C language
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+func (p *parser) isOperator(op string) bool {
+	t, ok := p.peek()
+	return ok && t.typ == tokenOperator && t.s == op
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOperator(op) {
+		if t, ok := p.peek(); ok {
+			return fmt.Errorf("expected %s but found %s", op, t.s)
+		}
+		return fmt.Errorf("expected %s at end of expression", op)
+	}
+	p.pos++
+	return nil
+}
+
+// parse binary operators with a precedence higher than minPrec
+func (p *parser) parse(minPrec int) (node, error) {
+	lhs, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.peek()
+		if !ok || t.typ != tokenOperator {
+			return lhs, nil
+		}
+		prec, ok := precedence[t.s]
+		if !ok || prec <= minPrec {
+			return lhs, nil
+		}
+		p.pos++
+
+		rhs, err := p.parse(prec)
+		if err != nil {
+			return nil, err
+		}
+		lhs = &binaryNode{op: t.s, lhs: lhs, rhs: rhs}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	if t.typ == tokenOperator {
+		switch t.s {
+		case "!", "~", "-", "*", "&":
+			p.pos++
+			x, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{op: t.s, x: x}, nil
+		}
+	}
+
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isOperator("."), p.isOperator("->"):
+			arrow := p.isOperator("->")
+			p.pos++
+			t, ok := p.peek()
+			if !ok || t.typ != tokenIdentifier {
+				return nil, fmt.Errorf("expected member name")
+			}
+			p.pos++
+			x = &memberNode{x: x, name: t.s, arrow: arrow}
+
+		case p.isOperator("["):
+			p.pos++
+			idx, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexNode{x: x, idx: idx}
+
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	switch t.typ {
+	case tokenNumber:
+		return &numberNode{n: t.n}, nil
+
+	case tokenRegister:
+		reg, ok := registerNames[strings.ToLower(t.s[1:])]
+		if !ok {
+			return nil, fmt.Errorf("unknown register (%s)", t.s)
+		}
+		return &registerNode{name: t.s, reg: reg}, nil
+
+	case tokenIdentifier:
+		// memory access functions
+		if size, ok := memoryFunctions[t.s]; ok && p.isOperator("(") {
+			p.pos++
+			x, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return &memoryNode{size: size, x: x}, nil
+		}
+		return &variableNode{name: t.s}, nil
+
+	case tokenOperator:
+		if t.s == "(" {
+			x, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %s", t.s)
+}
+
+// the names of registers that can be used in an expression (without the
+// dollar prefix)
+var registerNames = map[string]int{
+	"r0": 0, "r1": 1, "r2": 2, "r3": 3, "r4": 4, "r5": 5, "r6": 6, "r7": 7,
+	"r8": 8, "r9": 9, "r10": 10, "r11": 11, "r12": 12, "r13": 13, "r14": 14, "r15": 15,
+	"sp": 13, "lr": 14, "pc": 15,
+}
+
+// the functions that can be used to read memory and the number of bytes read
+// by each function
+var memoryFunctions = map[string]int{
+	"mem8":  1,
+	"mem16": 2,
+	"mem32": 4,
+}
+
+// the result of evaluating a node. if varb is not nil then the value is the
+// variable and the n field is unused
+type value struct {
+	n    int64
+	varb Variable
+}
+
+// number returns the numeric value of the value
+func (v value) number() (int64, error) {
+	if v.varb == nil {
+		return v.n, nil
+	}
+
+	varb := v.varb
+
+	// C++ references are dereferenced automatically
+	if varb.Type().IsReference() {
+		d, err := deref(varb)
+		if err != nil {
+			return 0, err
+		}
+		varb = d
+	}
+
+	if !varb.IsValid() {
+		return 0, fmt.Errorf("%s is not locatable", varb.Name())
+	}
+	if varb.Err() != nil {
+		return 0, fmt.Errorf("%s: %w", varb.Name(), varb.Err())
+	}
+
+	// arrays decay to the address of the first element
+	if varb.Type().IsArray() {
+		a, ok := varb.Address()
+		if !ok {
+			return 0, fmt.Errorf("%s does not have an address", varb.Name())
+		}
+		return int64(a), nil
+	}
+
+	if varb.Type().IsComposite() {
+		return 0, fmt.Errorf("%s is a struct and cannot be used as a value", varb.Name())
+	}
+
+	n := varb.Value()
+	if isSigned(varb.Type()) {
+		switch varb.Type().Size {
+		case 1:
+			return int64(int8(n)), nil
+		case 2:
+			return int64(int16(n)), nil
+		case 4:
+			return int64(int32(n)), nil
+		}
+	}
+	return int64(n), nil
+}
+
+// isSigned returns true if the type is a signed integer type. the DWARF
+// information we keep for a type doesn't include the encoding so we must rely
+// on the name of the type. plain char types are unsigned on ARM
+func isSigned(typ *dwarf.SourceType) bool {
+	if typ.IsPointer() {
+		return false
+	}
+	n := strings.TrimPrefix(typ.Name, "const ")
+	if strings.Contains(n, "unsigned") {
+		return false
+	}
+	switch n {
+	case "int", "short", "long", "long long", "signed char", "signed",
+		"short int", "long int", "long long int", "signed int":
+		return true
+	}
+	return strings.HasPrefix(n, "int") && strings.HasSuffix(n, "_t")
+}
+
+type node interface {
+	eval(env Environment) (value, error)
+}
+
+type numberNode struct {
+	n int64
+}
+
+func (nd *numberNode) eval(_ Environment) (value, error) {
+	return value{n: nd.n}, nil
+}
+
+type registerNode struct {
+	name string
+	reg  int
+}
+
+func (nd *registerNode) eval(env Environment) (value, error) {
+	v, ok := env.Register(nd.reg)
+	if !ok {
+		return value{}, fmt.Errorf("cannot read register %s", nd.name)
+	}
+	return value{n: int64(v)}, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (nd *variableNode) eval(env Environment) (value, error) {
+	varb, ok := env.Variable(nd.name)
+	if !ok {
+		return value{}, fmt.Errorf("no variable named %s", nd.name)
+	}
+	return value{varb: varb}, nil
+}
+
+type memoryNode struct {
+	size int
+	x    node
+}
+
+func (nd *memoryNode) eval(env Environment) (value, error) {
+	v, err := nd.x.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	addr, err := v.number()
+	if err != nil {
+		return value{}, err
+	}
+	return peek(env, uint32(addr), nd.size)
+}
+
+func peek(env Environment, addr uint32, size int) (value, error) {
+	m, ok := env.Peek(addr, size)
+	if !ok {
+		return value{}, fmt.Errorf("cannot read memory at %08x", addr)
+	}
+	return value{n: int64(m)}, nil
+}
+
+// deref returns the variable pointed to by the pointer variable
+func deref(varb Variable) (Variable, error) {
+	if !varb.Type().IsPointer() {
+		return nil, fmt.Errorf("%s is not a pointer", varb.Name())
+	}
+
+	// the only child of a pointer variable is the dereferenced variable
+	d := varb.Child(0)
+	if d == nil {
+		return nil, fmt.Errorf("cannot dereference %s", varb.Name())
+	}
+	return d, nil
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (nd *unaryNode) eval(env Environment) (value, error) {
+	v, err := nd.x.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+
+	switch nd.op {
+	case "*":
+		if v.varb != nil {
+			if v.varb.Type().IsPointer() {
+				d, err := deref(v.varb)
+				if err != nil {
+					return value{}, err
+				}
+				return value{varb: d}, nil
+			}
+			if v.varb.Type().IsArray() {
+				return value{varb: v.varb.Child(0)}, nil
+			}
+		}
+		addr, err := v.number()
+		if err != nil {
+			return value{}, err
+		}
+		return peek(env, uint32(addr), 4)
+
+	case "&":
+		if v.varb == nil {
+			return value{}, fmt.Errorf("cannot take the address of a value")
+		}
+		a, ok := v.varb.Address()
+		if !ok {
+			return value{}, fmt.Errorf("%s does not have an address", v.varb.Name())
+		}
+		return value{n: int64(a)}, nil
+	}
+
+	n, err := v.number()
+	if err != nil {
+		return value{}, err
+	}
+
+	switch nd.op {
+	case "!":
+		if n == 0 {
+			return value{n: 1}, nil
+		}
+		return value{n: 0}, nil
+	case "~":
+		return value{n: int64(^uint32(n))}, nil
+	case "-":
+		return value{n: -n}, nil
+	}
+
+	return value{}, fmt.Errorf("unknown operator %s", nd.op)
+}
+
+type memberNode struct {
+	x     node
+	name  string
+	arrow bool
+}
+
+func (nd *memberNode) eval(env Environment) (value, error) {
+	v, err := nd.x.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	if v.varb == nil {
+		return value{}, fmt.Errorf("cannot select member %s from a value", nd.name)
+	}
+
+	varb := v.varb
+	if nd.arrow {
+		varb, err = deref(varb)
+		if err != nil {
+			return value{}, err
+		}
+	}
+
+	if !varb.Type().IsComposite() && !varb.Type().IsReference() {
+		return value{}, fmt.Errorf("%s is not a struct", varb.Name())
+	}
+
+	if c := varb.Member(nd.name); c != nil {
+		return value{varb: c}, nil
+	}
+
+	return value{}, fmt.Errorf("%s has no member named %s", varb.Name(), nd.name)
+}
+
+type indexNode struct {
+	x   node
+	idx node
+}
+
+func (nd *indexNode) eval(env Environment) (value, error) {
+	v, err := nd.x.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	if v.varb == nil {
+		return value{}, fmt.Errorf("cannot index a value")
+	}
+
+	iv, err := nd.idx.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	idx, err := iv.number()
+	if err != nil {
+		return value{}, err
+	}
+
+	varb := v.varb
+
+	if varb.Type().IsArray() {
+		if idx < 0 || idx >= int64(varb.NumChildren()) {
+			return value{}, fmt.Errorf("array index (%d) is out of range for %s", idx, varb.Name())
+		}
+		return value{varb: varb.Child(int(idx))}, nil
+	}
+
+	if varb.Type().IsPointer() {
+		if idx == 0 {
+			d, err := deref(varb)
+			if err != nil {
+				return value{}, err
+			}
+			return value{varb: d}, nil
+		}
+
+		// elements other than the first element can only be read if they are
+		// a simple type that can be read with a single memory access
+		size := varb.Type().PointerType.Size
+		if size != 1 && size != 2 && size != 4 {
+			return value{}, fmt.Errorf("cannot index %s with a non-zero index", varb.Name())
+		}
+		addr := uint32(varb.Value()) + uint32(idx*int64(size))
+		return peek(env, addr, size)
+	}
+
+	return value{}, fmt.Errorf("%s is not an array or a pointer", varb.Name())
+}
+
+type binaryNode struct {
+	op  string
+	lhs node
+	rhs node
+}
+
+func (nd *binaryNode) eval(env Environment) (value, error) {
+	l, err := nd.lhs.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	a, err := l.number()
+	if err != nil {
+		return value{}, err
+	}
+
+	// logical operators are short-circuited so that expressions like "p != 0
+	// && p->x == 1" are safe
+	switch nd.op {
+	case "&&":
+		if a == 0 {
+			return value{n: 0}, nil
+		}
+	case "||":
+		if a != 0 {
+			return value{n: 1}, nil
+		}
+	}
+
+	r, err := nd.rhs.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	b, err := r.number()
+	if err != nil {
+		return value{}, err
+	}
+
+	truth := func(t bool) (value, error) {
+		if t {
+			return value{n: 1}, nil
+		}
+		return value{n: 0}, nil
+	}
+
+	switch nd.op {
+	case "&&", "||":
+		return truth(b != 0)
+	case "==":
+		return truth(a == b)
+	case "!=":
+		return truth(a != b)
+	case "<":
+		return truth(a < b)
+	case "<=":
+		return truth(a <= b)
+	case ">":
+		return truth(a > b)
+	case ">=":
+		return truth(a >= b)
+	case "|":
+		return value{n: a | b}, nil
+	case "^":
+		return value{n: a ^ b}, nil
+	case "&":
+		return value{n: a & b}, nil
+	case "<<":
+		return value{n: int64(uint32(a) << (uint32(b) & 31))}, nil
+	case ">>":
+		return value{n: a >> (uint32(b) & 31)}, nil
+	case "+":
+		return value{n: a + b}, nil
+	case "-":
+		return value{n: a - b}, nil
+	case "*":
+		return value{n: a * b}, nil
+	case "/":
+		if b == 0 {
+			return value{}, fmt.Errorf("division by zero")
+		}
+		return value{n: a / b}, nil
+	case "%":
+		if b == 0 {
+			return value{}, fmt.Errorf("division by zero")
+		}
+		return value{n: a % b}, nil
+	}
+
+	return value{}, fmt.Errorf("unknown operator %s", nd.op)
+}
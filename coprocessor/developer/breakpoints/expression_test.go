@@ -0,0 +1,447 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package breakpoints_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/breakpoints"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// variable with a fixed address and value
+type variable struct {
+	name      string
+	typ       *dwarf.SourceType
+	address   uint64
+	value     uint32
+	baseClass bool
+	children  []*variable
+}
+
+func newVariable(name string, typ *dwarf.SourceType, address uint64, value uint32, children ...*variable) *variable {
+	return &variable{
+		name:     name,
+		typ:      typ,
+		address:  address,
+		value:    value,
+		children: children,
+	}
+}
+
+func (varb *variable) Name() string {
+	return varb.name
+}
+
+func (varb *variable) Type() *dwarf.SourceType {
+	return varb.typ
+}
+
+func (varb *variable) Address() (uint64, bool) {
+	return varb.address, true
+}
+
+func (varb *variable) Value() uint32 {
+	return varb.value
+}
+
+func (varb *variable) IsValid() bool {
+	return true
+}
+
+func (varb *variable) Err() error {
+	return nil
+}
+
+func (varb *variable) NumChildren() int {
+	return len(varb.children)
+}
+
+func (varb *variable) Child(i int) breakpoints.Variable {
+	if i >= len(varb.children) {
+		return nil
+	}
+	return varb.children[i]
+}
+
+// members of references and base classes are found in the same way as the
+// Member() function of dwarf.SourceVariable
+func (varb *variable) Member(name string) breakpoints.Variable {
+	if varb.typ.IsReference() {
+		if len(varb.children) == 0 {
+			return nil
+		}
+		varb = varb.children[0]
+	}
+	if !varb.typ.IsComposite() {
+		return nil
+	}
+	for _, c := range varb.children {
+		if c.name == name {
+			return c
+		}
+	}
+	for _, c := range varb.children {
+		if c.baseClass {
+			if m := c.Member(name); m != nil {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// environment with registers, memory and variables
+type environment struct {
+	registers [16]uint32
+	memory    map[uint32]uint32
+	variables map[string]breakpoints.Variable
+}
+
+func (env *environment) Variable(name string) (breakpoints.Variable, bool) {
+	v, ok := env.variables[name]
+	return v, ok
+}
+
+func (env *environment) Register(reg int) (uint32, bool) {
+	if reg < 0 || reg >= len(env.registers) {
+		return 0, false
+	}
+	return env.registers[reg], true
+}
+
+func (env *environment) Peek(addr uint32, size int) (uint32, bool) {
+	v, ok := env.memory[addr]
+	if !ok {
+		return 0, false
+	}
+	switch size {
+	case 1:
+		v &= 0xff
+	case 2:
+		v &= 0xffff
+	}
+	return v, true
+}
+
+func TestCondition(t *testing.T) {
+	env := &environment{
+		memory: map[uint32]uint32{
+			0x20000000: 0x12345678,
+		},
+	}
+	env.registers[0] = 10
+	env.registers[13] = 0x20000000
+
+	evaluate := func(expression string) bool {
+		t.Helper()
+		c, err := breakpoints.ParseCondition(expression)
+		test.ExpectSuccess(t, err)
+		v, err := c.Evaluate(env)
+		test.ExpectSuccess(t, err)
+		return v
+	}
+
+	test.ExpectEquality(t, evaluate("1"), true)
+	test.ExpectEquality(t, evaluate("0"), false)
+	test.ExpectEquality(t, evaluate("1 + 2 * 3 == 7"), true)
+	test.ExpectEquality(t, evaluate("(1 + 2) * 3 == 9"), true)
+	test.ExpectEquality(t, evaluate("-1 < 0"), true)
+	test.ExpectEquality(t, evaluate("~0 == 0xffffffff"), true)
+	test.ExpectEquality(t, evaluate("1 << 4 == 16 && 0x10 >> 4 == 1"), true)
+	test.ExpectEquality(t, evaluate("!0 && !!5"), true)
+	test.ExpectEquality(t, evaluate("$r0 == 10"), true)
+	test.ExpectEquality(t, evaluate("$R0 > 5 || $r1"), true)
+	test.ExpectEquality(t, evaluate("*$sp == 0x12345678"), true)
+	test.ExpectEquality(t, evaluate("mem8($sp) == 0x78"), true)
+	test.ExpectEquality(t, evaluate("mem16(0x20000000) == 0x5678"), true)
+	test.ExpectEquality(t, evaluate("7 % 4 == 3 && 7 / 2 == 3"), true)
+
+	// short-circuiting prevents the error in the right hand side of the
+	// expression from being seen
+	test.ExpectEquality(t, evaluate("0 && mem32(0)"), false)
+	test.ExpectEquality(t, evaluate("1 || mem32(0)"), true)
+
+	// parsing errors
+	for _, s := range []string{"", "1 +", "(1", "1 2", "$foo", "1 @ 2", "p.", "a[1", "a::", "::a"} {
+		_, err := breakpoints.ParseCondition(s)
+		test.ExpectFailure(t, err)
+	}
+
+	// evaluation errors
+	for _, s := range []string{"x == 1", "mem32(0)", "1 / 0", "&1"} {
+		c, err := breakpoints.ParseCondition(s)
+		test.ExpectSuccess(t, err)
+		_, err = c.Evaluate(env)
+		test.ExpectFailure(t, err)
+	}
+
+	// C++ qualified names are a single variable name
+	c, err := breakpoints.ParseCondition("game::Player::count == 1")
+	test.ExpectSuccess(t, err)
+	_, err = c.Evaluate(env)
+	test.ExpectEquality(t, err.Error(), "condition: no variable named game::Player::count")
+}
+
+// environment with variables of different types. memory contains the values of
+// the variables that are accessed through pointers
+func variablesEnvironment() *environment {
+	intType := &dwarf.SourceType{Name: "int", Size: 4}
+	uintType := &dwarf.SourceType{Name: "unsigned int", Size: 4}
+	scharType := &dwarf.SourceType{Name: "signed char", Size: 1}
+	ucharType := &dwarf.SourceType{Name: "unsigned char", Size: 1}
+
+	// struct pos { int x; int y; }
+	posType := &dwarf.SourceType{Name: "pos", Size: 8, Members: []*dwarf.SourceVariable{
+		{Name: "x", Type: intType},
+		{Name: "y", Type: intType},
+	}}
+	pos := func(name string) *variable {
+		return newVariable(name, posType, 0x20000010, 0,
+			newVariable("x", intType, 0x20000010, 3),
+			newVariable("y", intType, 0x20000014, 0xfffffffe),
+		)
+	}
+	posPtrType := &dwarf.SourceType{Name: "pos *", Size: 4, PointerType: posType}
+	posRefType := &dwarf.SourceType{Name: "pos &", Size: 4, PointerType: posType, Reference: true}
+
+	// int arr[3]
+	arrType := &dwarf.SourceType{Name: "int[3]", Size: 12, ElementType: intType, ElementCount: 3}
+	arr := newVariable("arr", arrType, 0x20000020, 0,
+		newVariable("arr[0]", intType, 0x20000020, 10),
+		newVariable("arr[1]", intType, 0x20000024, 20),
+		newVariable("arr[2]", intType, 0x20000028, 30),
+	)
+
+	// int *ip
+	intPtrType := &dwarf.SourceType{Name: "int *", Size: 4, PointerType: intType}
+	ip := newVariable("ip", intPtrType, 0x20000040, 0x20000030,
+		newVariable("*ip", intType, 0x20000030, 100),
+	)
+
+	// C++ class derived : base { int id; } where base { int health; }
+	baseType := &dwarf.SourceType{Name: "base", Size: 4, Members: []*dwarf.SourceVariable{
+		{Name: "health", Type: intType},
+	}}
+	derivedType := &dwarf.SourceType{Name: "derived", Size: 8, Members: []*dwarf.SourceVariable{
+		{Name: "base", Type: baseType, BaseClass: true},
+		{Name: "id", Type: uintType},
+	}}
+	base := newVariable("base", baseType, 0x20000050, 0,
+		newVariable("health", intType, 0x20000050, 50),
+	)
+	base.baseClass = true
+	derived := newVariable("derived", derivedType, 0x20000050, 0,
+		base,
+		newVariable("id", uintType, 0x20000054, 7),
+	)
+
+	return &environment{
+		memory: map[uint32]uint32{
+			0x20000030: 100,
+			0x20000034: 200,
+		},
+		variables: map[string]breakpoints.Variable{
+			"pos":                 pos("pos"),
+			"p":                   newVariable("p", posPtrType, 0x20000060, 0x20000010, pos("*p")),
+			"ref":                 newVariable("ref", posRefType, 0x20000064, 0x20000010, pos("ref")),
+			"arr":                 arr,
+			"ip":                  ip,
+			"derived":             derived,
+			"sc":                  newVariable("sc", scharType, 0x20000070, 0xff),
+			"uc":                  newVariable("uc", ucharType, 0x20000071, 0xff),
+			"ui":                  newVariable("ui", uintType, 0x20000074, 0xffffffff),
+			"game::Player::count": newVariable("game::Player::count", intType, 0x20000078, 5),
+		},
+	}
+}
+
+func TestConditionVariables(t *testing.T) {
+	env := variablesEnvironment()
+
+	evaluate := func(expression string) bool {
+		t.Helper()
+		c, err := breakpoints.ParseCondition(expression)
+		test.ExpectSuccess(t, err)
+		v, err := c.Evaluate(env)
+		test.ExpectSuccess(t, err)
+		return v
+	}
+
+	// struct members with the . and -> operators
+	test.ExpectEquality(t, evaluate("pos.x == 3"), true)
+	test.ExpectEquality(t, evaluate("pos.y == -2"), true)
+	test.ExpectEquality(t, evaluate("pos.x + pos.y == 1"), true)
+	test.ExpectEquality(t, evaluate("p->x == 3 && p->y < 0"), true)
+	test.ExpectEquality(t, evaluate("(*p).y == -2"), true)
+	test.ExpectEquality(t, evaluate("p[0].x == 3"), true)
+	test.ExpectEquality(t, evaluate("p == 0x20000010"), true)
+	test.ExpectEquality(t, evaluate("&pos == 0x20000010 && &pos.y == 0x20000014"), true)
+
+	// C++ references are dereferenced automatically
+	test.ExpectEquality(t, evaluate("ref.x == 3"), true)
+	test.ExpectEquality(t, evaluate("ref.y == -2"), true)
+
+	// members of base classes
+	test.ExpectEquality(t, evaluate("derived.health == 50"), true)
+	test.ExpectEquality(t, evaluate("derived.id == 7"), true)
+
+	// array elements and array decay
+	test.ExpectEquality(t, evaluate("arr[0] == 10 && arr[2] == 30"), true)
+	test.ExpectEquality(t, evaluate("arr[pos.x - 2] == 20"), true)
+	test.ExpectEquality(t, evaluate("*arr == 10"), true)
+	test.ExpectEquality(t, evaluate("arr == 0x20000020"), true)
+	test.ExpectEquality(t, evaluate("&arr[2] == 0x20000028"), true)
+
+	// pointer indexing. elements other than the first are read from memory
+	test.ExpectEquality(t, evaluate("*ip == 100"), true)
+	test.ExpectEquality(t, evaluate("ip[0] == 100"), true)
+	test.ExpectEquality(t, evaluate("ip[1] == 200"), true)
+
+	// signed and unsigned comparison
+	test.ExpectEquality(t, evaluate("sc == -1"), true)
+	test.ExpectEquality(t, evaluate("sc < 0"), true)
+	test.ExpectEquality(t, evaluate("uc == 255"), true)
+	test.ExpectEquality(t, evaluate("uc > 0"), true)
+	test.ExpectEquality(t, evaluate("ui > 0"), true)
+	test.ExpectEquality(t, evaluate("ui == 0xffffffff"), true)
+	test.ExpectEquality(t, evaluate("sc < uc"), true)
+
+	// C++ qualified names
+	test.ExpectEquality(t, evaluate("game::Player::count == 5"), true)
+
+	// evaluation errors
+	for _, s := range []string{
+		"pos",       // a struct is not a value
+		"pos.z",     // no such member
+		"pos->x",    // not a pointer
+		"uc.x",      // not a struct
+		"arr[3]",    // out of range
+		"arr[-1]",   // out of range
+		"uc[0]",     // not an array or pointer
+		"p[1].x",    // non-zero index of a pointer to a struct
+		"(1).x",     // member of a value
+		"&(1 + 2)",  // address of a value
+		"*pos == 1", // dereference of a struct
+	} {
+		c, err := breakpoints.ParseCondition(s)
+		test.ExpectSuccess(t, err)
+		_, err = c.Evaluate(env)
+		if err == nil {
+			t.Errorf("expected evaluation error for %s", s)
+		}
+	}
+}
+
+func TestBreakpointHits(t *testing.T) {
+	env := variablesEnvironment()
+	envFunc := func() breakpoints.Environment { return env }
+
+	file := &dwarf.SourceFile{Filename: "main.c"}
+	fn := &dwarf.SourceFunction{Name: "main"}
+	fn.DeclLine = &dwarf.SourceLine{File: file, LineNumber: 9, Function: fn}
+	ln := &dwarf.SourceLine{
+		File:           file,
+		LineNumber:     10,
+		Function:       fn,
+		Instruction:    []*dwarf.SourceInstruction{{Addr: 0x100}, {Addr: 0x102}},
+		BreakAddresses: []uint32{0x100},
+	}
+	noBreak := &dwarf.SourceLine{File: file, LineNumber: 11}
+
+	bp := breakpoints.NewBreakpoints()
+
+	// a line without break addresses cannot have a breakpoint
+	test.ExpectFailure(t, bp.SetHitTarget(noBreak, 1))
+	test.ExpectFailure(t, bp.SetCondition(noBreak, "1"))
+
+	// unconditional breakpoint
+	bp.ToggleBreakpoint(ln)
+	test.ExpectSuccess(t, bp.HasBreakpoint(ln))
+
+	hit, err := bp.Check(0x100, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, hit)
+	test.ExpectEquality(t, bp.Breakpoint(ln).Hits, 1)
+
+	// addresses without a breakpoint
+	hit, err = bp.Check(0x102, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectFailure(t, hit)
+
+	// the breakpoint only halts on the third hit. setting the hit target resets
+	// the hit count
+	test.ExpectSuccess(t, bp.SetHitTarget(ln, 3))
+	test.ExpectEquality(t, bp.Breakpoint(ln).Hits, 0)
+	for i := range 3 {
+		hit, err = bp.Check(0x100, envFunc)
+		test.ExpectSuccess(t, err)
+		test.ExpectEquality(t, hit, i == 2)
+	}
+	test.ExpectSuccess(t, strings.HasSuffix(bp.Breakpoint(ln).String(), "(hits 3 of 3)"))
+
+	// hits are only counted when the condition is true
+	test.ExpectSuccess(t, bp.SetHitTarget(ln, 2))
+	test.ExpectSuccess(t, bp.SetCondition(ln, "pos.x == 3"))
+	hit, err = bp.Check(0x100, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectFailure(t, hit)
+	test.ExpectEquality(t, bp.Breakpoint(ln).Hits, 1)
+
+	test.ExpectSuccess(t, bp.SetCondition(ln, "pos.x == 4"))
+	hit, err = bp.Check(0x100, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectFailure(t, hit)
+	test.ExpectEquality(t, bp.Breakpoint(ln).Hits, 1)
+
+	test.ExpectSuccess(t, bp.SetCondition(ln, "pos.x == 3"))
+	hit, err = bp.Check(0x100, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, hit)
+	test.ExpectEquality(t, bp.Breakpoint(ln).Hits, 2)
+
+	// a condition that cannot be evaluated halts execution and returns the
+	// error. the hit is not counted
+	test.ExpectSuccess(t, bp.SetCondition(ln, "pos.z == 3"))
+	hit, err = bp.Check(0x100, envFunc)
+	test.ExpectFailure(t, err)
+	test.ExpectSuccess(t, hit)
+	test.ExpectEquality(t, bp.Breakpoint(ln).Hits, 2)
+
+	// an empty expression removes the condition
+	test.ExpectSuccess(t, bp.SetCondition(ln, ""))
+	test.ExpectEquality(t, bp.Breakpoint(ln).Condition, (*breakpoints.Condition)(nil))
+
+	// invalid expressions are not set
+	test.ExpectFailure(t, bp.SetCondition(ln, "1 +"))
+	test.ExpectFailure(t, bp.SetHitTarget(ln, -1))
+
+	// removing the breakpoint
+	bp.ToggleBreakpoint(ln)
+	test.ExpectFailure(t, bp.HasBreakpoint(ln))
+	hit, err = bp.Check(0x100, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectFailure(t, hit)
+
+	// setting a condition on a line without a breakpoint adds a breakpoint
+	test.ExpectSuccess(t, bp.SetCondition(ln, "sc < 0"))
+	test.ExpectSuccess(t, bp.HasBreakpoint(ln))
+	hit, err = bp.Check(0x100, envFunc)
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, hit)
+}
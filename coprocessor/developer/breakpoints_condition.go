@@ -0,0 +1,147 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/breakpoints"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+)
+
+// conditionVariable implements the breakpoints.Variable interface for a
+// variable found in the DWARF data
+type conditionVariable struct {
+	varb *dwarf.SourceVariable
+}
+
+// wrap the variable in the breakpoints.Variable interface. a nil variable
+// results in a nil interface
+func newConditionVariable(varb *dwarf.SourceVariable) breakpoints.Variable {
+	if varb == nil {
+		return nil
+	}
+	return conditionVariable{varb: varb}
+}
+
+// Name implements the breakpoints.Variable interface
+func (v conditionVariable) Name() string {
+	return v.varb.Name
+}
+
+// Type implements the breakpoints.Variable interface
+func (v conditionVariable) Type() *dwarf.SourceType {
+	return v.varb.Type
+}
+
+// Address implements the breakpoints.Variable interface
+func (v conditionVariable) Address() (uint64, bool) {
+	return v.varb.Address()
+}
+
+// Value implements the breakpoints.Variable interface
+func (v conditionVariable) Value() uint32 {
+	return v.varb.Value()
+}
+
+// IsValid implements the breakpoints.Variable interface
+func (v conditionVariable) IsValid() bool {
+	return v.varb.IsValid()
+}
+
+// Err implements the breakpoints.Variable interface
+func (v conditionVariable) Err() error {
+	return v.varb.Error
+}
+
+// NumChildren implements the breakpoints.Variable interface
+func (v conditionVariable) NumChildren() int {
+	return v.varb.NumChildren()
+}
+
+// Child implements the breakpoints.Variable interface
+func (v conditionVariable) Child(i int) breakpoints.Variable {
+	return newConditionVariable(v.varb.Child(i))
+}
+
+// Member implements the breakpoints.Variable interface
+func (v conditionVariable) Member(name string) breakpoints.Variable {
+	return newConditionVariable(v.varb.Member(name))
+}
+
+// conditionEnvironment implements the breakpoints.Environment interface for
+// the evaluation of breakpoint conditions. it assumes that the sourceLock has
+// been acquired
+type conditionEnvironment struct {
+	dev  *Developer
+	ln   *dwarf.SourceLine
+	addr uint32
+
+	// local variables are only found when they are first required
+	locals         []*dwarf.SourceVariableLocal
+	localsResolved bool
+}
+
+// Variable implements the breakpoints.Environment interface
+func (env *conditionEnvironment) Variable(name string) (breakpoints.Variable, bool) {
+	if !env.localsResolved {
+		env.localsResolved = true
+		if env.ln != nil {
+			env.locals = env.dev.source.GetLocalVariables(env.ln, env.addr)
+		}
+	}
+
+	for _, local := range env.locals {
+		if local.Name == name {
+			env.dev.base.address = env.addr
+			local.Update()
+			return newConditionVariable(local.SourceVariable), true
+		}
+	}
+
+	// inside a C++ member function the members of the class can be referred to
+	// without "this->"
+	for _, local := range env.locals {
+		if local.Name == "this" && local.Type.IsPointer() {
+			env.dev.base.address = env.addr
+			local.Update()
+			if d := local.Child(0); d != nil {
+				if m := d.Member(name); m != nil {
+					return newConditionVariable(m), true
+				}
+			}
+		}
+	}
+
+	if varb, ok := env.dev.source.GlobalsByName[name]; ok {
+		varb.Update()
+		return newConditionVariable(varb), true
+	}
+
+	return nil, false
+}
+
+// Register implements the breakpoints.Environment interface
+func (env *conditionEnvironment) Register(reg int) (uint32, bool) {
+	bus := env.dev.cart.GetCoProcBus()
+	if bus == nil {
+		return 0, false
+	}
+	return bus.GetCoProc().Register(reg)
+}
+
+// Peek implements the breakpoints.Environment interface
+func (env *conditionEnvironment) Peek(addr uint32, size int) (uint32, bool) {
+	return env.dev.peekWatch(addr, size)
+}
@@ -0,0 +1,131 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/budget"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/profiling"
+	"github.com/jetsetilly/gopher2600/logger"
+)
+
+// LoadBudget loads the cycle budget from the named file. Any previously loaded
+// budget is replaced but the Halt setting of the previous budget is kept.
+func (dev *Developer) LoadBudget(filename string) error {
+	if dev.source == nil {
+		return fmt.Errorf("developer: cycle budget requires DWARF information")
+	}
+
+	b, err := budget.Load(filename)
+	if err != nil {
+		return fmt.Errorf("developer: %w", err)
+	}
+
+	dev.budgetLock.Lock()
+	defer dev.budgetLock.Unlock()
+
+	if dev.budget != nil {
+		b.Halt = dev.budget.Halt
+	}
+	dev.budget = b
+	dev.budgetHalt = ""
+	dev.budgetHaltPending.Store(false)
+
+	logger.Logf(logger.Allow, "developer", "cycle budget loaded from %s (%d entries)", filename, b.Count())
+
+	return nil
+}
+
+// ClearBudget removes the cycle budget.
+func (dev *Developer) ClearBudget() {
+	dev.budgetLock.Lock()
+	defer dev.budgetLock.Unlock()
+	dev.budget = nil
+	dev.budgetHalt = ""
+	dev.budgetHaltPending.Store(false)
+}
+
+// BudgetHalt returns a description of the budget violation that should cause
+// the emulation to halt. Returns the empty string if the emulation should not
+// halt. The description is cleared by the call.
+func (dev *Developer) BudgetHalt() string {
+	if !dev.budgetHaltPending.Load() {
+		return ""
+	}
+
+	dev.budgetLock.Lock()
+	defer dev.budgetLock.Unlock()
+	s := dev.budgetHalt
+	dev.budgetHalt = ""
+	dev.budgetHaltPending.Store(false)
+	return s
+}
+
+// check budget for the most recent frame. should be called with the source
+// lock held and after the source profiling has been updated for the frame
+func (dev *Developer) checkBudget(frame int) {
+	dev.budgetLock.Lock()
+	defer dev.budgetLock.Unlock()
+
+	if dev.budget == nil {
+		return
+	}
+
+	violations := dev.budget.Check(frame, func(e budget.Entry) (*profiling.Cycles, bool) {
+		if e.IsLine() {
+			ln, err := dev.source.FindSourceLineByFileLine(e.Target)
+			if err != nil {
+				return nil, false
+			}
+			return &ln.Cycles, true
+		}
+
+		// the budget for a function includes the cycles of the functions
+		// it calls
+		fn, ok := dev.source.Functions[e.Target]
+		if !ok {
+			return nil, false
+		}
+		return &fn.CumulativeCycles, true
+	})
+
+	if len(violations) == 0 {
+		return
+	}
+
+	// an entry is likely to be exceeded in many consecutive frames so only
+	// the first violation of each entry is logged. the budget report
+	// summarises all violations
+	for _, v := range violations {
+		if v.First {
+			logger.Logf(logger.Allow, "developer", "cycle budget exceeded: %s (further violations are not logged)", v)
+		}
+	}
+
+	if dev.budget.Halt {
+		var s strings.Builder
+		for i, v := range violations {
+			if i > 0 {
+				s.WriteString("; ")
+			}
+			s.WriteString(v.String())
+		}
+		dev.budgetHalt = s.String()
+		dev.budgetHaltPending.Store(true)
+	}
+}
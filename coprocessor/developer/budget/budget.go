@@ -0,0 +1,309 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package budget
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/profiling"
+)
+
+// Extension is the file extension of a budget sidecar file
+const Extension = ".budget"
+
+// Entry is a single budget declaration.
+type Entry struct {
+	// the name of a function or a source line in the form file:line
+	Target string
+
+	// the number of cycles allowed for the target each frame
+	Cycles float32
+
+	// the part of the frame the budget applies to. FocusAll means the entire
+	// frame
+	Scope profiling.Focus
+}
+
+// IsLine returns true if the target of the budget is a source line rather
+// than a function.
+func (e Entry) IsLine() bool {
+	return strings.Contains(e.Target, ":")
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %.0f cycles (%s)", e.Target, e.Cycles, e.Scope)
+}
+
+// Violation records a single instance of a budget being exceeded.
+type Violation struct {
+	Frame  int
+	Entry  Entry
+	Cycles float32
+
+	// the violation is the first for the entry since the budget was loaded or
+	// since the violations were last cleared
+	First bool
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("frame %d: %s used %.0f of %.0f cycles (%s)",
+		v.Frame, v.Entry.Target, v.Cycles, v.Entry.Cycles, v.Entry.Scope)
+}
+
+// the maximum number of violations kept by the Budget type. the total number
+// of violations is still counted
+const maxViolations = 1000
+
+// Budget is a list of budget entries and the violations of those entries.
+type Budget struct {
+	// the file the budget was loaded from. will be empty if the budget was
+	// not loaded from a file
+	Filename string
+
+	entries []Entry
+
+	// budget targets that could not be found when checking
+	unresolved map[string]bool
+
+	violations []Violation
+
+	// whether each entry has been exceeded. indexed in the same way as the
+	// entries field
+	exceeded []bool
+
+	// total number of violations including those not kept
+	numViolations int
+
+	// frames in which at least one budget was exceeded
+	numFrames int
+
+	// the emulation should halt when a budget is exceeded
+	Halt bool
+}
+
+// Lookup is used by the Check() function to find the profiling information for
+// a budget entry.
+type Lookup func(e Entry) (*profiling.Cycles, bool)
+
+// Sidecar returns the first budget file that exists for the list of files. The
+// budget file for a file has the same name but with the budget Extension. An
+// empty string is returned if no budget file exists.
+func Sidecar(files ...string) string {
+	for _, f := range files {
+		if f == "" {
+			continue // for loop
+		}
+		s := strings.TrimSuffix(f, filepath.Ext(f)) + Extension
+		if _, err := os.Stat(s); err == nil {
+			return s
+		}
+	}
+	return ""
+}
+
+// Load budget from the named file.
+func Load(filename string) (*Budget, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("budget: %w", err)
+	}
+	defer f.Close()
+
+	b, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, filename)
+	}
+	b.Filename = filename
+
+	return b, nil
+}
+
+// Parse the budget declarations in r. See the package documentation for the
+// format.
+func Parse(r io.Reader) (*Budget, error) {
+	b := &Budget{
+		unresolved: make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(r)
+	var n int
+	for scanner.Scan() {
+		n++
+
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue // for loop
+		}
+
+		f := strings.Fields(s)
+		if len(f) < 2 || len(f) > 3 {
+			return nil, fmt.Errorf("budget: line %d: expected target, cycles and optional scope", n)
+		}
+
+		e := Entry{
+			Target: f[0],
+		}
+
+		c, err := strconv.ParseFloat(f[1], 32)
+		if err != nil || c < 0 {
+			return nil, fmt.Errorf("budget: line %d: invalid number of cycles (%s)", n, f[1])
+		}
+		e.Cycles = float32(c)
+
+		if len(f) == 3 {
+			e.Scope, err = parseScope(f[2])
+			if err != nil {
+				return nil, fmt.Errorf("budget: line %d: %w", n, err)
+			}
+		}
+
+		b.entries = append(b.entries, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("budget: %w", err)
+	}
+
+	b.exceeded = make([]bool, len(b.entries))
+
+	return b, nil
+}
+
+func parseScope(s string) (profiling.Focus, error) {
+	switch strings.ToUpper(s) {
+	case "ALL":
+		return profiling.FocusAll, nil
+	case "VBLANK":
+		return profiling.FocusVBLANK, nil
+	case "SCREEN":
+		return profiling.FocusScreen, nil
+	case "OVERSCAN":
+		return profiling.FocusOverscan, nil
+	}
+	return profiling.FocusAll, fmt.Errorf("unknown scope (%s)", s)
+}
+
+// Count returns the number of budget entries.
+func (b *Budget) Count() int {
+	return len(b.entries)
+}
+
+// Check the most recent frame against the budget. Returns the violations for
+// the frame, if any.
+func (b *Budget) Check(frame int, lookup Lookup) []Violation {
+	var frameViolations []Violation
+
+	for i, e := range b.entries {
+		cy, ok := lookup(e)
+		if !ok {
+			b.unresolved[e.Target] = true
+			continue // for loop
+		}
+
+		c := cy.Scope(e.Scope).FrameCycles()
+		if c > e.Cycles {
+			frameViolations = append(frameViolations, Violation{
+				Frame:  frame,
+				Entry:  e,
+				Cycles: c,
+				First:  !b.exceeded[i],
+			})
+			b.exceeded[i] = true
+		}
+	}
+
+	if len(frameViolations) > 0 {
+		b.numFrames++
+		b.numViolations += len(frameViolations)
+		if len(b.violations) < maxViolations {
+			b.violations = append(b.violations, frameViolations...)
+		}
+	}
+
+	return frameViolations
+}
+
+// Exceeded returns true if the budget has been exceeded in any frame.
+func (b *Budget) Exceeded() bool {
+	return b.numViolations > 0
+}
+
+// ClearViolations forgets all violations seen so far.
+func (b *Budget) ClearViolations() {
+	b.violations = b.violations[:0]
+	clear(b.exceeded)
+	b.numViolations = 0
+	b.numFrames = 0
+}
+
+// Write the list of budget entries to w.
+func (b *Budget) Write(w io.Writer) {
+	if b.Filename != "" {
+		fmt.Fprintf(w, "budget from %s\n", b.Filename)
+	}
+	for _, e := range b.entries {
+		fmt.Fprintf(w, "  %s", e)
+		if b.unresolved[e.Target] {
+			fmt.Fprintf(w, " [not found]")
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Report writes a summary of the budget violations to w.
+func (b *Budget) Report(w io.Writer) {
+	if b.numViolations == 0 {
+		fmt.Fprintf(w, "no budget violations\n")
+		return
+	}
+
+	fmt.Fprintf(w, "%d budget violations in %d frames\n", b.numViolations, b.numFrames)
+
+	// the worst violation for each entry
+	type worst struct {
+		count int
+		v     Violation
+	}
+	byEntry := make(map[Entry]*worst)
+	for _, v := range b.violations {
+		if x, ok := byEntry[v.Entry]; ok {
+			x.count++
+			if v.Cycles > x.v.Cycles {
+				x.v = v
+			}
+		} else {
+			byEntry[v.Entry] = &worst{count: 1, v: v}
+		}
+	}
+
+	// output in the same order as the budget entries
+	for _, e := range b.entries {
+		if x, ok := byEntry[e]; ok {
+			fmt.Fprintf(w, "  %s: exceeded %d times. worst %.0f cycles in frame %d\n",
+				e, x.count, x.v.Cycles, x.v.Frame)
+		}
+	}
+
+	if b.numViolations > len(b.violations) {
+		fmt.Fprintf(w, "  (only the first %d violations are included in the summary)\n", len(b.violations))
+	}
+}
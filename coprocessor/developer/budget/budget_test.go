@@ -0,0 +1,102 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package budget_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/budget"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/profiling"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestParse(t *testing.T) {
+	_, err := budget.Parse(strings.NewReader("# comment\n\nkernel 1000\nmain.c:10 200 Overscan\n"))
+	test.ExpectSuccess(t, err)
+
+	_, err = budget.Parse(strings.NewReader("kernel\n"))
+	test.ExpectFailure(t, err)
+
+	_, err = budget.Parse(strings.NewReader("kernel many\n"))
+	test.ExpectFailure(t, err)
+
+	_, err = budget.Parse(strings.NewReader("kernel 1000 Sometimes\n"))
+	test.ExpectFailure(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	b, err := budget.Parse(strings.NewReader("kernel 1000\noverscan 100 Overscan\nmissing 10\n"))
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, b.Count(), 3)
+
+	var kernel profiling.Cycles
+	var overscan profiling.Cycles
+
+	lookup := func(e budget.Entry) (*profiling.Cycles, bool) {
+		switch e.Target {
+		case "kernel":
+			return &kernel, true
+		case "overscan":
+			return &overscan, true
+		}
+		return nil, false
+	}
+
+	// within budget
+	kernel.Cycle(500, profiling.FocusScreen)
+	overscan.Cycle(100, profiling.FocusOverscan)
+	kernel.NewFrame(nil, nil, false)
+	overscan.NewFrame(nil, nil, false)
+	test.ExpectEquality(t, len(b.Check(1, lookup)), 0)
+	test.ExpectEquality(t, b.Exceeded(), false)
+
+	// both entries over budget. the overscan entry is only concerned with the
+	// overscan cycles
+	kernel.Cycle(1001, profiling.FocusScreen)
+	overscan.Cycle(50, profiling.FocusScreen)
+	overscan.Cycle(101, profiling.FocusOverscan)
+	kernel.NewFrame(nil, nil, false)
+	overscan.NewFrame(nil, nil, false)
+	v := b.Check(2, lookup)
+	test.ExpectEquality(t, len(v), 2)
+	test.ExpectEquality(t, v[0].Entry.Target, "kernel")
+	test.ExpectEquality(t, v[0].Cycles, float32(1001))
+	test.ExpectEquality(t, v[1].Entry.Target, "overscan")
+	test.ExpectEquality(t, v[1].Cycles, float32(101))
+	test.ExpectEquality(t, v[0].First, true)
+	test.ExpectEquality(t, v[1].First, true)
+	test.ExpectEquality(t, b.Exceeded(), true)
+
+	// the kernel entry is exceeded for a second time
+	kernel.Cycle(1001, profiling.FocusScreen)
+	kernel.NewFrame(nil, nil, false)
+	overscan.NewFrame(nil, nil, false)
+	v = b.Check(3, lookup)
+	test.ExpectEquality(t, len(v), 1)
+	test.ExpectEquality(t, v[0].Entry.Target, "kernel")
+	test.ExpectEquality(t, v[0].First, false)
+
+	// clearing the violations means the next violation of an entry is the
+	// first again
+	b.ClearViolations()
+	test.ExpectEquality(t, b.Exceeded(), false)
+	kernel.Cycle(1001, profiling.FocusScreen)
+	kernel.NewFrame(nil, nil, false)
+	v = b.Check(4, lookup)
+	test.ExpectEquality(t, len(v), 1)
+	test.ExpectEquality(t, v[0].First, true)
+}
@@ -0,0 +1,33 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package budget checks the number of cycles consumed by coprocessor functions
+// and source lines each frame against a declared budget.
+//
+// A budget is usually declared in a sidecar file with the same name as the ROM
+// file (or the DWARF file) but with the .budget extension. Each line of the
+// file declares a budget for a function or for a source line, in the form of
+// file:line, followed by the number of cycles allowed each frame. Optionally,
+// the budget can be restricted to a part of the VCS frame by naming one of
+// VBLANK, Screen or Overscan. Blank lines and lines beginning with # are
+// ignored.
+//
+//	# budget for the overscan kernel
+//	overscan 4000 Overscan
+//	main.c:120 1500
+//
+// The budget for a function includes the cycles of every function it calls.
+// The budget for a source line is for that line only.
+package budget
@@ -19,12 +19,15 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jetsetilly/gopher2600/coprocessor"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/breakpoints"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/budget"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/callstack"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/watchpoints"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/yield"
 	"github.com/jetsetilly/gopher2600/coprocessor/faults"
 	"github.com/jetsetilly/gopher2600/debugger/govern"
@@ -60,6 +63,9 @@ type Developer struct {
 
 	cart Cartridge
 
+	// the filename of the ROM. used when exporting profiling data
+	romFile string
+
 	// information about the source code to the program. can be nil.
 	// note that source is checked for nil outside the sourceLock. this is
 	// performance reasons (not need to acquire the lock if source is nil).
@@ -87,6 +93,34 @@ type Developer struct {
 	breakNextInstruction bool
 	breakAddress         uint32
 
+	watchpoints     watchpoints.Watchpoints
+	watchpointsLock sync.Mutex
+
+	// the number of watchpoints. the count means CheckWatchpoint() can return
+	// early without acquiring the watchpoints lock when there are no
+	// watchpoints
+	watchpointsCount atomic.Int32
+
+	// the emulation is rewinding. the recorded value of change watchpoints
+	// is refreshed when the rewind ends
+	rewinding bool
+
+	// cycle budget for functions and source lines. can be nil
+	budget     *budget.Budget
+	budgetLock sync.Mutex
+
+	// description of the budget violation that should cause the emulation to
+	// halt. see BudgetHalt()
+	//
+	// the budgetHaltPending flag means BudgetHalt() can be called frequently
+	// without acquiring the budget lock
+	budgetHalt        string
+	budgetHaltPending atomic.Bool
+
+	// source level step in progress. can be nil
+	step     *sourceStep
+	stepLock sync.Mutex
+
 	strobe       yield.Strobe
 	strobeLock   sync.Mutex
 	strobeTicker *time.Ticker
@@ -102,6 +136,10 @@ type Developer struct {
 
 	// keeps track of the previous line in profiling scan. see processProfiling()
 	prevProfileLine *dwarf.SourceLine
+
+	// profiling data being collected for export. can be nil
+	export     *profileExport
+	exportLock sync.Mutex
 }
 
 const (
@@ -122,7 +160,16 @@ func NewDeveloper(state Emulation, tv TV) Developer {
 }
 
 func (dev *Developer) AttachCartridge(cart Cartridge, romFile string, dwarfFile string) error {
+	// profile export for the previous cartridge is written with whatever data
+	// has been collected
+	if s, err := dev.EndProfileExport(); err != nil {
+		logger.Log(logger.Allow, "developer", err)
+	} else if s != "" {
+		logger.Logf(logger.Allow, "developer", "ended %s", s)
+	}
+
 	dev.cart = nil
+	dev.romFile = romFile
 
 	dev.sourceLock.Lock()
 	dev.source = nil
@@ -140,6 +187,19 @@ func (dev *Developer) AttachCartridge(cart Cartridge, romFile string, dwarfFile
 	dev.breakpoints = breakpoints.NewBreakpoints()
 	dev.breakpointsLock.Unlock()
 
+	dev.watchpointsLock.Lock()
+	dev.watchpoints = watchpoints.NewWatchpoints()
+	dev.watchpointsCount.Store(0)
+	dev.watchpointsLock.Unlock()
+
+	dev.budgetLock.Lock()
+	dev.budget = nil
+	dev.budgetHalt = ""
+	dev.budgetHaltPending.Store(false)
+	dev.budgetLock.Unlock()
+
+	dev.EndStep()
+
 	dev.framesSinceLastUpdate = 0
 
 	dev.profiler = coprocessor.CartCoProcProfiler{
@@ -186,6 +246,15 @@ func (dev *Developer) AttachCartridge(cart Cartridge, romFile string, dwarfFile
 		c.CoProcSourceDebugging()
 	}
 
+	// load budget sidecar file if one exists
+	if dev.source != nil {
+		if f := budget.Sidecar(romFile, dwarfFile); f != "" {
+			if err := dev.LoadBudget(f); err != nil {
+				logger.Log(logger.Allow, "developer", err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -256,6 +325,11 @@ func (dev *Developer) CheckBreakpoint(addr uint32) bool {
 		return true
 	}
 
+	if dev.checkStep(addr) {
+		dev.breakAddress = addr
+		return true
+	}
+
 	dev.breakpointsLock.Lock()
 	defer dev.breakpointsLock.Unlock()
 
@@ -272,7 +346,13 @@ func (dev *Developer) CheckBreakpoint(addr uint32) bool {
 	}
 	dev.prevBreakpointCheck = ln
 
-	if dev.breakpoints.Check(addr) {
+	hit, err := dev.breakpoints.Check(addr, func() breakpoints.Environment {
+		return &conditionEnvironment{dev: dev, ln: ln, addr: addr}
+	})
+	if err != nil {
+		logger.Log(logger.Allow, "developer", err)
+	}
+	if hit {
 		dev.breakAddress = addr
 		return true
 	}
@@ -288,6 +368,17 @@ const maxWaitUpdateTime = 60 // in frames
 
 // NewFrame implements the television.FrameTrigger interface.
 func (dev *Developer) NewFrame(frameInfo frameinfo.Current) error {
+	// write profile export once the last frame in the range has completed
+	dev.exportLock.Lock()
+	if dev.export != nil && frameInfo.FrameNum > dev.export.to {
+		if s, err := dev.endProfileExport(); err != nil {
+			logger.Log(logger.Allow, "developer", err)
+		} else {
+			logger.Logf(logger.Allow, "developer", "completed %s", s)
+		}
+	}
+	dev.exportLock.Unlock()
+
 	// only update FrameCycles if new frame was caused by a VSYNC or we've
 	// waited long enough since the last update
 	dev.framesSinceLastUpdate++
@@ -305,6 +396,12 @@ func (dev *Developer) NewFrame(frameInfo frameinfo.Current) error {
 	defer dev.sourceLock.Unlock()
 	dev.source.NewFrame(dev.emulation.State() == govern.Rewinding)
 
+	// budget is not checked when rewinding because the frame will have been
+	// checked already
+	if dev.emulation.State() != govern.Rewinding {
+		dev.checkBudget(frameInfo.FrameNum)
+	}
+
 	return nil
 }
 
@@ -370,17 +467,52 @@ func (dev *Developer) MemoryFault(event string, fault faults.Category, instructi
 	dev.faults.NewEntry(event, fault, instructionAddr, accessAddr)
 }
 
+// UninitialisedRead implements the coprocessor.CartCoProcDeveloper interface.
+func (dev *Developer) UninitialisedRead(event string, instructionAddr uint32, accessAddr uint32, size int) string {
+	detail := fmt.Sprintf("%s of %d bytes at %08x", event, size, accessAddr)
+
+	if dev.source != nil {
+		dev.sourceLock.Lock()
+		ln := dev.source.FindSourceLine(instructionAddr)
+		if ln != nil && !ln.IsStub() {
+			dev.base.address = instructionAddr
+			if varb := dev.source.FindVariableByAddress(ln, instructionAddr, accessAddr); varb != nil {
+				detail = fmt.Sprintf("%s (%s)", detail, varb.Name)
+			}
+			detail = fmt.Sprintf("%s at %s", detail, ln)
+		}
+		dev.sourceLock.Unlock()
+	}
+
+	dev.MemoryFault(detail, faults.UninitialisedRead, instructionAddr, accessAddr)
+
+	return detail
+}
+
 // SetEmulationState is called by the emulation whenever state changes
 func (dev *Developer) SetEmulationState(state govern.State) {
 	dev.BorrowSource(func(src *dwarf.Source) {
 		dev.yieldStateLock.Lock()
 		defer dev.yieldStateLock.Unlock()
 
+		// memory will have changed during the rewind without any watchpoint
+		// being checked
+		if dev.rewinding && state != govern.Rewinding {
+			dev.watchpointsLock.Lock()
+			dev.watchpoints.Refresh(dev.peekWatch)
+			dev.watchpointsLock.Unlock()
+		}
+		dev.rewinding = state == govern.Rewinding
+
 		switch state {
 		case govern.Rewinding:
 			dev.breakpointsInhibit = true
 
 		case govern.Paused:
+			// any source level step is cancelled when the emulation halts,
+			// whether or not the step completed
+			dev.EndStep()
+
 			if src == nil {
 				return
 			}
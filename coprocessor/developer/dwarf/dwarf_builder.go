@@ -71,6 +71,12 @@ type build struct {
 	// an index of just the compile units. indexed by the offset of the compile unit
 	units map[dwarf.Offset]*compileUnit
 
+	// the C++ scope of every dwarf entry. the scope is the list of enclosing
+	// namespaces and classes, separated and terminated by "::". entries in
+	// compilation units that are not C++, and entries inside functions, will
+	// have an empty scope
+	scope map[dwarf.Offset]string
+
 	// the type, globals and local variables discovered during the build process
 	types   map[dwarf.Offset]*SourceType
 	globals map[string]*SourceVariable
@@ -83,10 +89,18 @@ func newBuild(dwrf *dwarf.Data) (*build, error) {
 		idx:     make(map[dwarf.Offset]*dwarf.Entry),
 		parent:  make(map[dwarf.Offset]*compileUnit),
 		units:   make(map[dwarf.Offset]*compileUnit),
+		scope:   make(map[dwarf.Offset]string),
 		types:   make(map[dwarf.Offset]*SourceType),
 		globals: make(map[string]*SourceVariable),
 	}
 
+	// the scope for the children of each entry in the tree
+	var scope []string
+
+	// whether the current compile unit is a C++ compile unit. scopes are
+	// only applied to entries in C++ compile units
+	var cplusplus bool
+
 	r := bld.dwrf.Reader()
 	for {
 		entry, err := r.Next()
@@ -102,11 +116,138 @@ func newBuild(dwrf *dwarf.Data) (*build, error) {
 
 		bld.order = append(bld.order, entry)
 		bld.idx[entry.Offset] = entry
+
+		// a null entry is the end of a list of children
+		if entry.Tag == 0 {
+			if len(scope) > 0 {
+				scope = scope[:len(scope)-1]
+			}
+			continue // for loop
+		}
+
+		var prefix string
+		if len(scope) > 0 {
+			prefix = scope[len(scope)-1]
+		}
+		bld.scope[entry.Offset] = prefix
+
+		if entry.Tag == dwarf.TagCompileUnit {
+			cplusplus = isCPlusPlus(entry)
+		}
+
+		if entry.Children {
+			switch entry.Tag {
+			case dwarf.TagNamespace:
+				fld := entry.AttrField(dwarf.AttrName)
+				if fld == nil {
+					prefix = fmt.Sprintf("%s(anonymous namespace)::", prefix)
+				} else {
+					prefix = fmt.Sprintf("%s%s::", prefix, fld.Val.(string))
+				}
+			case dwarf.TagClassType, dwarf.TagStructType, dwarf.TagUnionType:
+				fld := entry.AttrField(dwarf.AttrName)
+				if fld != nil && cplusplus {
+					prefix = fmt.Sprintf("%s%s::", prefix, fld.Val.(string))
+				}
+			case dwarf.TagCompileUnit, dwarf.TagSubprogram, dwarf.TagLexDwarfBlock, dwarf.TagInlinedSubroutine:
+				// variables and types inside functions are not qualified
+				prefix = ""
+			}
+			scope = append(scope, prefix)
+		}
 	}
 
 	return bld, nil
 }
 
+// DWARF language codes for C++. from "7.12 Source Languages" of the DWARF5
+// specification
+const (
+	langCPlusPlus   = 0x04
+	langCPlusPlus03 = 0x19
+	langCPlusPlus11 = 0x1a
+	langCPlusPlus14 = 0x21
+)
+
+// isCPlusPlus returns true if the compile unit entry is for a C++ compile unit
+func isCPlusPlus(e *dwarf.Entry) bool {
+	fld := e.AttrField(dwarf.AttrLanguage)
+	if fld == nil {
+		return false
+	}
+	lang, ok := fld.Val.(int64)
+	if !ok {
+		return false
+	}
+	switch lang {
+	case langCPlusPlus, langCPlusPlus03, langCPlusPlus11, langCPlusPlus14:
+		return true
+	}
+	return false
+}
+
+// qualifiedName returns the name of the entry with the names of any enclosing
+// namespaces and classes. if the entry has no name then the name of the entry
+// it is a specification of is used. returns false if no name can be found
+func (bld *build) qualifiedName(e *dwarf.Entry) (string, bool) {
+	for e != nil {
+		fld := e.AttrField(dwarf.AttrName)
+		if fld != nil {
+			return bld.scope[e.Offset] + fld.Val.(string), true
+		}
+		fld = e.AttrField(dwarf.AttrSpecification)
+		if fld == nil {
+			break // for loop
+		}
+		e = bld.idx[fld.Val.(dwarf.Offset)]
+	}
+	return "", false
+}
+
+// decode the data member location of a member or of an inherited class. the
+// member address will be kept at zero if the field is not present. returns
+// false if the location cannot be decoded
+func (bld *build) memberLocation(e *dwarf.Entry, memb *SourceVariable, src *Source) (bool, error) {
+	// look for data member location field. if it's not present then it
+	// doesn't matter, the member address will be kept at zero and will still
+	// be considered an offset address. absence of the data member location
+	// field is the case with union types
+	fld := e.AttrField(dwarf.AttrDataMemberLoc)
+	if fld == nil {
+		return true, nil
+	}
+
+	switch fld.Class {
+	case dwarf.ClassConstant:
+		memb.loclist = src.debugLoc.newLoclistJustFramebase(memb)
+		address := fld.Val.(int64)
+		memb.loclist.addOperator(loclistOperator{
+			resolve: func(loc *loclist, _ io.Writer) (loclistStack, error) {
+				return loclistStack{
+					class: stackClassIsValue,
+					value: uint32(address),
+				}, nil
+			},
+			operator: "member offset",
+		})
+	case dwarf.ClassExprLoc:
+		memb.loclist = src.debugLoc.newLoclistJustFramebase(memb)
+		expr := fld.Val.([]uint8)
+		r, n, err := src.debugLoc.decodeLoclistOperation(expr)
+		if err != nil {
+			return false, err
+		}
+		if n == 0 {
+			return false, fmt.Errorf("unhandled expression operator %02x", expr[0])
+		}
+		memb.loclist.addOperator(r)
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
 // build list of compilation units in the DWARF data
 func (bld *build) buildCompilationUnits() error {
 	var unit *compileUnit
@@ -225,11 +366,11 @@ func (bld *build) buildTypes(src *Source) error {
 				}(baseType)
 
 				// override the name field
-				fld := e.AttrField(dwarf.AttrName)
-				if fld == nil {
+				name, ok := bld.qualifiedName(e)
+				if !ok {
 					continue
 				}
-				typ.Name = fld.Val.(string)
+				typ.Name = name
 
 				bld.types[e.Offset] = typ
 			}
@@ -244,13 +385,18 @@ func (bld *build) buildTypes(src *Source) error {
 		case dwarf.TagBaseType, dwarf.TagEnumerationType:
 			var typ SourceType
 
-			fld := e.AttrField(dwarf.AttrName)
-			if fld == nil {
+			name, ok := bld.qualifiedName(e)
+			if !ok {
 				continue
 			}
-			typ.Name = fld.Val.(string)
+			typ.Name = name
 
-			fld = e.AttrField(dwarf.AttrByteSize)
+			// scoped enumerations are annotated in the same way as composite types
+			if fld := e.AttrField(dwarf.AttrEnumClass); fld != nil && fld.Val.(bool) {
+				typ.Name = fmt.Sprintf("enum class %s", name)
+			}
+
+			fld := e.AttrField(dwarf.AttrByteSize)
 			if fld == nil {
 				continue
 			}
@@ -260,6 +406,55 @@ func (bld *build) buildTypes(src *Source) error {
 		}
 	}
 
+	// the values of enumerated types are shown with the name of the enumerator
+	var enumerators map[uint32]string
+	var enumMask uint32
+	for _, e := range bld.order {
+		switch e.Tag {
+		case dwarf.TagEnumerationType:
+			enumerators = nil
+
+			typ, ok := bld.types[e.Offset]
+			if !ok {
+				continue
+			}
+
+			names := make(map[uint32]string)
+			mask := typ.Mask()
+			typ.Conversion = func(v uint32) (string, any) {
+				if n, ok := names[v&mask]; ok {
+					return "%s", n
+				}
+				return "%d", v
+			}
+			enumerators = names
+			enumMask = mask
+
+		case dwarf.TagEnumerator:
+			if enumerators == nil {
+				continue
+			}
+
+			fld := e.AttrField(dwarf.AttrName)
+			if fld == nil {
+				continue
+			}
+			name := fld.Val.(string)
+
+			fld = e.AttrField(dwarf.AttrConstValue)
+			if fld == nil {
+				continue
+			}
+			switch v := fld.Val.(type) {
+			case int64:
+				enumerators[uint32(v)&enumMask] = name
+			}
+
+		default:
+			enumerators = nil
+		}
+	}
+
 	// three passes over more complex types
 	for range 3 {
 		err := resolveTypeDefs()
@@ -267,10 +462,11 @@ func (bld *build) buildTypes(src *Source) error {
 			return err
 		}
 
-		// pointer types
+		// pointer types. C++ reference types are treated as pointers that are
+		// dereferenced automatically
 		for _, e := range bld.order {
 			switch e.Tag {
-			case dwarf.TagPointerType:
+			case dwarf.TagPointerType, dwarf.TagReferenceType, dwarf.TagRvalueReferenceType:
 				var typ SourceType
 
 				typ.PointerType, err = bld.resolveType(e)
@@ -281,7 +477,16 @@ func (bld *build) buildTypes(src *Source) error {
 					continue
 				}
 
-				typ.Name = fmt.Sprintf("%s *", typ.PointerType.Name)
+				switch e.Tag {
+				case dwarf.TagReferenceType:
+					typ.Name = fmt.Sprintf("%s &", typ.PointerType.Name)
+					typ.Reference = true
+				case dwarf.TagRvalueReferenceType:
+					typ.Name = fmt.Sprintf("%s &&", typ.PointerType.Name)
+					typ.Reference = true
+				default:
+					typ.Name = fmt.Sprintf("%s *", typ.PointerType.Name)
+				}
 
 				fld := e.AttrField(dwarf.AttrByteSize)
 				if fld == nil {
@@ -294,52 +499,101 @@ func (bld *build) buildTypes(src *Source) error {
 		}
 
 		// resolve composite types
+		definitions := make(map[string]*SourceType)
 		for _, e := range bld.order {
 			switch e.Tag {
-			case dwarf.TagUnionType:
-				fallthrough
-			case dwarf.TagStructType:
+			case dwarf.TagUnionType, dwarf.TagStructType, dwarf.TagClassType:
 				var typ SourceType
-				var name string
 
-				fld := e.AttrField(dwarf.AttrName)
-				if fld == nil {
+				name, ok := bld.qualifiedName(e)
+				if !ok {
 					// allow anonymous structures. we sometimes see this when
 					// structs are defined with typedef
 					name = fmt.Sprintf("%x", e.Offset)
-				} else {
-					name = fld.Val.(string)
 				}
 
-				fld = e.AttrField(dwarf.AttrByteSize)
+				// the name we store in the type is annotated with the composite category
+				switch e.Tag {
+				case dwarf.TagUnionType:
+					typ.Name = fmt.Sprintf("union %s", name)
+				case dwarf.TagStructType:
+					typ.Name = fmt.Sprintf("struct %s", name)
+				case dwarf.TagClassType:
+					typ.Name = fmt.Sprintf("class %s", name)
+				}
+
+				fld := e.AttrField(dwarf.AttrByteSize)
 				if fld == nil {
 					continue
 				}
 				typ.Size = int(fld.Val.(int64))
 
 				bld.types[e.Offset] = &typ
+				definitions[typ.Name] = &typ
+			}
+		}
 
-				// the name we store in the type is annotated with the composite category
+		// C++ compilation units will often contain only a declaration of a
+		// class that is defined in another compilation unit. the declaration
+		// is given the type of the definition
+		for _, e := range bld.order {
+			switch e.Tag {
+			case dwarf.TagUnionType, dwarf.TagStructType, dwarf.TagClassType:
+				if e.AttrField(dwarf.AttrDeclaration) == nil {
+					continue
+				}
+
+				name, ok := bld.qualifiedName(e)
+				if !ok {
+					continue
+				}
+
+				var typ *SourceType
 				switch e.Tag {
 				case dwarf.TagUnionType:
-					typ.Name = fmt.Sprintf("union %s", name)
+					typ = definitions[fmt.Sprintf("union %s", name)]
 				case dwarf.TagStructType:
-					typ.Name = fmt.Sprintf("struct %s", name)
-				default:
-					typ.Name = fmt.Sprintf("%s", name)
+					typ = definitions[fmt.Sprintf("struct %s", name)]
+				case dwarf.TagClassType:
+					typ = definitions[fmt.Sprintf("class %s", name)]
+				}
+				if typ != nil {
+					bld.types[e.Offset] = typ
 				}
 			}
 		}
 
-		// allocate members to composite types
-		var composite *SourceType
+		// allocate members to composite types. C++ composite types can contain
+		// other entries, including member functions and template parameters,
+		// so we keep track of the composite type that each entry belongs to
+		var composites []*SourceType
 		for _, e := range bld.order {
-			switch e.Tag {
-			case dwarf.TagUnionType:
-				fallthrough
-			case dwarf.TagStructType:
-				composite = bld.types[e.Offset]
+			if e.Tag == 0 {
+				if len(composites) > 0 {
+					composites = composites[:len(composites)-1]
+				}
+				continue
+			}
+
+			var composite *SourceType
+			if len(composites) > 0 {
+				composite = composites[len(composites)-1]
+			}
+
+			if e.Children {
+				switch e.Tag {
+				case dwarf.TagUnionType, dwarf.TagStructType, dwarf.TagClassType:
+					if e.AttrField(dwarf.AttrDeclaration) == nil {
+						composites = append(composites, bld.types[e.Offset])
+					} else {
+						composites = append(composites, nil)
+					}
+				default:
+					composites = append(composites, nil)
+				}
+			}
 
+			switch e.Tag {
 			case dwarf.TagMember:
 				if composite == nil {
 					// found a member without first finding a composite type. this
@@ -347,6 +601,11 @@ func (bld *build) buildTypes(src *Source) error {
 					continue
 				}
 
+				// static members are declarations of a variable defined elsewhere
+				if e.AttrField(dwarf.AttrDeclaration) != nil {
+					continue
+				}
+
 				// members are basically like variables but with special address
 				// handling
 				memb, err := bld.resolveVariableDeclaration(e, src)
@@ -357,55 +616,64 @@ func (bld *build) buildTypes(src *Source) error {
 					continue
 				}
 
-				// look for data member location field. if it's not present
-				// then it doesn't matter, the member address will be kept at
-				// zero and will still be considered an offset address. absence
-				// of the data member location field is the case with union
-				// types
-				fld := e.AttrField(dwarf.AttrDataMemberLoc)
-				if fld != nil {
-					switch fld.Class {
-					case dwarf.ClassConstant:
-						memb.loclist = src.debugLoc.newLoclistJustFramebase(memb)
-						address := fld.Val.(int64)
-						memb.loclist.addOperator(loclistOperator{
-							resolve: func(loc *loclist, _ io.Writer) (loclistStack, error) {
-								return loclistStack{
-									class: stackClassIsValue,
-									value: uint32(address),
-								}, nil
-							},
-							operator: "member offset",
-						})
-					case dwarf.ClassExprLoc:
-						memb.loclist = src.debugLoc.newLoclistJustFramebase(memb)
-						expr := fld.Val.([]uint8)
-						r, n, err := src.debugLoc.decodeLoclistOperation(expr)
-						if err != nil {
-							return err
-						}
-						if n == 0 {
-							return fmt.Errorf("unhandled expression operator %02x", expr[0])
-						}
-						memb.loclist.addOperator(r)
-					default:
-						continue
-					}
+				// the name of a member is not qualified by the name of the composite
+				if fld := e.AttrField(dwarf.AttrName); fld != nil {
+					memb.Name = fld.Val.(string)
+				}
+
+				ok, err := bld.memberLocation(e, memb, src)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
 				}
 
 				composite.Members = append(composite.Members, memb)
 
-			default:
-				composite = nil
+			case dwarf.TagInheritance:
+				if composite == nil {
+					continue
+				}
+
+				// an inherited class is treated as a member with the name of the
+				// inherited class
+				base, err := bld.resolveType(e)
+				if err != nil {
+					return err
+				}
+				if base == nil {
+					continue
+				}
+
+				memb := &SourceVariable{
+					Type:      base,
+					BaseClass: true,
+				}
+
+				if fld := e.AttrField(dwarf.AttrType); fld != nil {
+					memb.Name, _ = bld.qualifiedName(bld.idx[fld.Val.(dwarf.Offset)])
+				}
+				if memb.Name == "" {
+					memb.Name = base.Name
+				}
+
+				ok, err := bld.memberLocation(e, memb, src)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+
+				composite.Members = append(composite.Members, memb)
 			}
 		}
 
 		// remove any composites that have no members
 		for _, e := range bld.order {
 			switch e.Tag {
-			case dwarf.TagUnionType:
-				fallthrough
-			case dwarf.TagStructType:
+			case dwarf.TagUnionType, dwarf.TagStructType, dwarf.TagClassType:
 				if bld.types[e.Offset] != nil && len(bld.types[e.Offset].Members) == 0 {
 					delete(bld.types, e.Offset)
 				}
@@ -555,9 +823,8 @@ func (bld *build) resolveVariableDeclaration(e *dwarf.Entry, src *Source) (*Sour
 		var varb SourceVariable
 
 		for _, v := range es {
-			fld := v.AttrField(dwarf.AttrName)
-			if fld != nil {
-				varb.Name = fld.Val.(string)
+			if name, ok := bld.qualifiedName(v); ok {
+				varb.Name = name
 			}
 		}
 
@@ -632,16 +899,24 @@ func (bld *build) buildVariables(src *Source) error {
 	// index zero
 	var stack []*dwarf.Entry
 
-	// if the length of the stack is equal to this value then that means any
-	// variable is in global scope
-	const globalDepth = 1
+	// a variable is in global scope if it is not inside a function. variables
+	// in C++ namespaces and static class members are global
+	isGlobalScope := func() bool {
+		for _, e := range stack {
+			switch e.Tag {
+			case dwarf.TagCompileUnit, dwarf.TagNamespace:
+			case dwarf.TagClassType, dwarf.TagStructType, dwarf.TagUnionType:
+			default:
+				return false
+			}
+		}
+		return true
+	}
 
 	// walk through the entire DWARF sequence in order. we'll only deal with
 	// the entries that are of interest to us
 	for _, e := range bld.order {
-		// increase depth if the entry has children. the moment when we choose
-		// to increase the depth value impacts what is meant by globalDepth (see
-		// constant value of same name)
+		// increase depth if the entry has children
 		if e.Children {
 			stack = append([]*dwarf.Entry{e}, stack...)
 		}
@@ -702,7 +977,7 @@ func (bld *build) buildVariables(src *Source) error {
 		// add variable to list of globals if aprropriate. returns true if the
 		// variable has been added and false if it is not a global
 		addGlobal := func(varb *SourceVariable) bool {
-			if len(stack) != globalDepth {
+			if !isGlobalScope() {
 				return false
 			}
 
@@ -943,6 +1218,13 @@ func (bld *build) buildFunctions(src *Source) error {
 			linkageName = fld.Val.(string)
 		}
 
+		// C++ functions are named with the demangled linkage name. the
+		// demangled name includes the namespace and class of the function and
+		// the parameter types, which distinguishes overloaded functions
+		if linkageName != name {
+			name = demangle(linkageName)
+		}
+
 		// declaration file
 		fld = e.AttrField(dwarf.AttrDeclFile)
 		if fld == nil {
@@ -0,0 +1,182 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package dwarf
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// abbreviation codes used by the test DWARF data
+const (
+	abbrevCompileUnit = iota + 1
+	abbrevNamespace
+	abbrevAnonNamespace
+	abbrevStruct
+	abbrevMember
+	abbrevSubprogram
+	abbrevVariable
+)
+
+// the abbreviation table for the test DWARF data. all names are inline strings
+var testAbbrev = []byte{
+	abbrevCompileUnit, byte(dwarf.TagCompileUnit), 1,
+	byte(dwarf.AttrName), 0x08, // DW_FORM_string
+	byte(dwarf.AttrLanguage), 0x0b, // DW_FORM_data1
+	0, 0,
+
+	abbrevNamespace, byte(dwarf.TagNamespace), 1,
+	byte(dwarf.AttrName), 0x08,
+	0, 0,
+
+	abbrevAnonNamespace, byte(dwarf.TagNamespace), 1,
+	0, 0,
+
+	abbrevStruct, byte(dwarf.TagStructType), 1,
+	byte(dwarf.AttrName), 0x08,
+	0, 0,
+
+	abbrevMember, byte(dwarf.TagMember), 0,
+	byte(dwarf.AttrName), 0x08,
+	0, 0,
+
+	abbrevSubprogram, byte(dwarf.TagSubprogram), 1,
+	byte(dwarf.AttrName), 0x08,
+	0, 0,
+
+	abbrevVariable, byte(dwarf.TagVariable), 0,
+	byte(dwarf.AttrName), 0x08,
+	0, 0,
+
+	0,
+}
+
+// testDIE is a debugging information entry in the test DWARF data
+type testDIE struct {
+	abbrev   byte
+	name     string
+	lang     byte
+	children []testDIE
+}
+
+func (die testDIE) encode(b []byte) []byte {
+	b = append(b, die.abbrev)
+	if die.abbrev != abbrevAnonNamespace {
+		b = append(b, die.name...)
+		b = append(b, 0)
+	}
+	if die.abbrev == abbrevCompileUnit {
+		b = append(b, die.lang)
+	}
+	switch die.abbrev {
+	case abbrevMember, abbrevVariable:
+	default:
+		for _, c := range die.children {
+			b = c.encode(b)
+		}
+		b = append(b, 0)
+	}
+	return b
+}
+
+// create a DWARF4 .debug_info section for the list of compile units
+func testInfo(units ...testDIE) []byte {
+	var info []byte
+	for _, u := range units {
+		// version 4, abbreviation offset 0 and 4 byte addresses
+		body := []byte{4, 0, 0, 0, 0, 0, 4}
+		body = u.encode(body)
+		info = binary.LittleEndian.AppendUint32(info, uint32(len(body)))
+		info = append(info, body...)
+	}
+	return info
+}
+
+func TestBuildScope(t *testing.T) {
+	// a struct with a nested struct, a function and a variable in the same
+	// form for each compile unit
+	content := func() []testDIE {
+		return []testDIE{
+			{abbrev: abbrevStruct, name: "outer", children: []testDIE{
+				{abbrev: abbrevMember, name: "a"},
+				{abbrev: abbrevStruct, name: "inner", children: []testDIE{
+					{abbrev: abbrevMember, name: "b"},
+				}},
+			}},
+			{abbrev: abbrevSubprogram, name: "fn", children: []testDIE{
+				{abbrev: abbrevVariable, name: "local"},
+			}},
+			{abbrev: abbrevVariable, name: "global"},
+		}
+	}
+
+	c := testDIE{abbrev: abbrevCompileUnit, name: "c.c", lang: 0x0c, children: content()}
+
+	cpp := testDIE{abbrev: abbrevCompileUnit, name: "cpp.cpp", lang: langCPlusPlus14, children: []testDIE{
+		{abbrev: abbrevNamespace, name: "ns", children: content()},
+		{abbrev: abbrevAnonNamespace, children: []testDIE{
+			{abbrev: abbrevVariable, name: "hidden"},
+		}},
+	}}
+
+	dwrf, err := dwarf.New(testAbbrev, nil, nil, testInfo(c, cpp), nil, nil, nil, nil)
+	test.ExpectSuccess(t, err)
+
+	bld, err := newBuild(dwrf)
+	test.ExpectSuccess(t, err)
+
+	// the qualified names of all named entries in each compile unit, in order
+	var names [][]string
+	for _, e := range bld.order {
+		if e.Tag == dwarf.TagCompileUnit {
+			names = append(names, []string{})
+			continue // for loop
+		}
+		if n, ok := bld.qualifiedName(e); ok {
+			names[len(names)-1] = append(names[len(names)-1], n)
+		}
+	}
+
+	test.ExpectEquality(t, len(names), 2)
+
+	// nothing is qualified in a C compile unit. not even nested structs
+	test.ExpectEquality(t, len(names[0]), 7)
+	for i, n := range []string{"outer", "a", "inner", "b", "fn", "local", "global"} {
+		test.ExpectEquality(t, names[0][i], n)
+	}
+
+	// entries in C++ compile units are qualified by namespaces and classes
+	// but entries inside functions are not
+	test.ExpectEquality(t, len(names[1]), 9)
+	for i, n := range []string{"ns", "ns::outer", "ns::outer::a", "ns::outer::inner",
+		"ns::outer::inner::b", "ns::fn", "local", "ns::global",
+		"(anonymous namespace)::hidden"} {
+		test.ExpectEquality(t, names[1][i], n)
+	}
+}
+
+func TestDemangle(t *testing.T) {
+	test.ExpectEquality(t, demangle("main"), "main")
+	test.ExpectEquality(t, demangle("_Z3fooi"), "foo(int)")
+	test.ExpectEquality(t, demangle("_ZN2ns5Klass6methodEv"), "ns::Klass::method()")
+
+	// suffixes added to cloned functions are removed
+	test.ExpectEquality(t, demangle("_Z3fooi.constprop.0"), "foo(int)")
+	test.ExpectEquality(t, demangle("_Z3fooi.isra.0"), "foo(int)")
+}
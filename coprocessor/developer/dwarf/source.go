@@ -27,6 +27,7 @@ import (
 	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jetsetilly/gopher2600/coprocessor"
@@ -432,16 +433,18 @@ func findHighAddress(src *Source) {
 	}
 }
 
-// regular expressions used by resolveSymbols() to test whether the stubs
-// should be added or whether they are compiler artefacts
+// regular expressions used by resolveSymbols() to identify mangled names and to
+// test whether the stubs should be added or whether they are compiler artefacts
 var (
 	mangledCppNames        *regexp.Regexp
+	mangledMSVCNames       *regexp.Regexp
 	compilerGeneratedNames *regexp.Regexp
 )
 
 // initialisation of regular expressions used by resolveSymbols()
 func init() {
-	mangledCppNames = regexp.MustCompile(`^(?:_Z[\w\d_]+|\?[^\s@]+\@.*)$`)
+	mangledCppNames = regexp.MustCompile(`^_Z[\w\d_.]+$`)
+	mangledMSVCNames = regexp.MustCompile(`^\?[^\s@]+\@.*$`)
 	compilerGeneratedNames = regexp.MustCompile(`^_GLOBAL__(sub_I|D|I)_[\w\d_]+$`)
 }
 
@@ -454,15 +457,16 @@ type CartridgeFunctionSymbol interface {
 // add function stubs for any functions without DWARF data
 func resolveSymbols(src *Source, syms []elf.Symbol) error {
 	type fn struct {
-		name string
-		rng  SourceRange
+		name    string
+		rng     SourceRange
+		mangled bool
 	}
 
 	var symbolTableFunctions []fn
 
 	// the functions from the symbol table
 	for _, s := range syms {
-		if mangledCppNames.MatchString(s.Name) {
+		if mangledMSVCNames.MatchString(s.Name) {
 			continue // for loop
 		}
 
@@ -481,16 +485,25 @@ func resolveSymbols(src *Source, syms []elf.Symbol) error {
 					Start: a,
 					End:   a + s.Size - 1,
 				},
+				mangled: mangledCppNames.MatchString(s.Name),
 			})
 		}
 	}
 
 	for _, fn := range symbolTableFunctions {
+		// C++ functions are named with the demangled name in the same way as
+		// functions found in the DWARF data
+		if fn.mangled {
+			fn.name = demangle(fn.name)
+		}
+
 		if _, ok := src.Functions[fn.name]; !ok {
 			// chop off suffix from symbol table name if there is one. not sure
 			// about this but it neatens things up for the cases I've seen so
 			// far
-			fn.name = strings.Split(fn.name, ".")[0]
+			if !fn.mangled {
+				fn.name = strings.Split(fn.name, ".")[0]
+			}
 
 			stubFn := &SourceFunction{
 				Name: fn.name,
@@ -584,6 +597,109 @@ func (src *Source) FindSourceLine(addr uint32) *SourceLine {
 	return src.LinesByAddress[uint64(addr)]
 }
 
+// FindSourceLineByFileLine returns the source line described by a string of
+// the form file:line. The file can be the short filename or the full filename.
+func (src *Source) FindSourceLineByFileLine(spec string) (*SourceLine, error) {
+	// option is divided by a maximum of one colon, meaning the split
+	// array should be a length of two
+	s := strings.Split(spec, ":")
+	if len(s) != 2 {
+		return nil, fmt.Errorf("command requires argument file:line")
+	}
+
+	// filename and line number
+	fn := s[0]
+	n, err := strconv.ParseInt(s[1], 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a number", s[1])
+	}
+	ln := int(n)
+
+	// check file by shortname and then by full name
+	f, ok := src.FilesByShortname[fn]
+	if !ok {
+		f, ok = src.Files[fn]
+		if !ok {
+			return nil, fmt.Errorf("no file named %s", fn)
+		}
+	}
+
+	// line numbers are counted from one
+	if ln < 1 {
+		ln = 1
+	}
+	if ln > len(f.Content.Lines) {
+		return nil, fmt.Errorf("%s only has %d lines", fn, len(f.Content.Lines))
+	}
+
+	return f.Content.Lines[ln-1], nil
+}
+
+// FindGlobalVariable returns the global (or static) variable described by the
+// path. The path is the name of the variable followed by any number of member
+// selectors and array indices. For example, "player.pos[2].x"
+//
+// The returned variable will not have been updated.
+func (src *Source) FindGlobalVariable(path string) (*SourceVariable, error) {
+	// the name of the variable is everything up to the first selector
+	n := strings.IndexAny(path, ".[")
+	if n == -1 {
+		n = len(path)
+	}
+
+	varb, ok := src.GlobalsByName[path[:n]]
+	if !ok {
+		return nil, fmt.Errorf("no global variable named %s", path[:n])
+	}
+	path = path[n:]
+
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			n := strings.IndexAny(path, ".[")
+			if n == -1 {
+				n = len(path)
+			}
+			if !varb.Type.IsComposite() && !varb.Type.IsReference() {
+				return nil, fmt.Errorf("%s is not a struct", varb.Name)
+			}
+
+			memb := varb.Member(path[:n])
+			if memb == nil {
+				return nil, fmt.Errorf("%s has no member named %s", varb.Name, path[:n])
+			}
+
+			varb = memb
+			path = path[n:]
+
+		case '[':
+			n := strings.IndexRune(path, ']')
+			if n == -1 {
+				return nil, fmt.Errorf("missing ] in variable path")
+			}
+			idx, err := strconv.Atoi(path[1:n])
+			if err != nil {
+				return nil, fmt.Errorf("array index (%s) is not a number", path[1:n])
+			}
+			if !varb.Type.IsArray() {
+				return nil, fmt.Errorf("%s is not an array", varb.Name)
+			}
+			if idx < 0 || idx >= varb.NumChildren() {
+				return nil, fmt.Errorf("array index (%d) is out of range for %s", idx, varb.Name)
+			}
+
+			varb = varb.Child(idx)
+			path = path[n+1:]
+
+		default:
+			return nil, fmt.Errorf("unexpected character (%c) in variable path", path[0])
+		}
+	}
+
+	return varb, nil
+}
+
 // UpdateGlobalVariables using the current state of the emulated coprocessor.
 // Local variables are updated when coprocessor yields (see OnYield() function)
 //
@@ -644,6 +760,42 @@ func (src *Source) GetLocalVariables(ln *SourceLine, addr uint32) []*SourceVaria
 	return locals
 }
 
+// FindVariableByAddress returns the variable that occupies the memory at the
+// access address. Local variables in scope at the instruction address are
+// checked first and then the global variables. Returns nil if no variable
+// occupies the memory.
+//
+// Local variables will be updated so this function should only be called
+// from the emulation goroutine.
+func (src *Source) FindVariableByAddress(ln *SourceLine, instructionAddr uint32, accessAddr uint32) *SourceVariable {
+	contains := func(varb *SourceVariable) bool {
+		a, ok := varb.Address()
+		if !ok {
+			return false
+		}
+		return uint64(accessAddr) >= a && uint64(accessAddr) < a+uint64(varb.Type.Size)
+	}
+
+	if ln != nil {
+		for _, local := range src.GetLocalVariables(ln, instructionAddr) {
+			local.Update()
+			if contains(local.SourceVariable) {
+				return local.SourceVariable
+			}
+		}
+	}
+
+	// the address of a global variable doesn't change so there's no need to
+	// update them
+	for _, varb := range src.SortedGlobals.Variables {
+		if contains(varb) {
+			return varb
+		}
+	}
+
+	return nil
+}
+
 // FramebaseCurrent returns the current framebase value
 func (src *Source) FramebaseCurrent(derivation io.Writer) (uint64, error) {
 	return src.debugFrame.resolveFramebase(derivation)
@@ -0,0 +1,106 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package dwarf
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// add children to the variable and set the parent of each child
+func adopt(parent *SourceVariable, children ...*SourceVariable) *SourceVariable {
+	for _, c := range children {
+		c.parent = parent
+	}
+	parent.children = children
+	return parent
+}
+
+func TestFindGlobalVariable(t *testing.T) {
+	intType := &SourceType{Name: "int", Size: 4}
+
+	// struct pos { int x; int y; }
+	x := &SourceVariable{Name: "x", Type: intType}
+	y := &SourceVariable{Name: "y", Type: intType}
+	posType := &SourceType{Name: "pos", Size: 8, Members: []*SourceVariable{x, y}}
+
+	// struct player { struct pos pos[2]; int lives; }
+	posArrayType := &SourceType{Name: "pos[2]", Size: 16, ElementType: posType, ElementCount: 2}
+	playerType := &SourceType{Name: "player", Size: 20, Members: []*SourceVariable{
+		{Name: "pos", Type: posArrayType},
+		{Name: "lives", Type: intType},
+	}}
+
+	pos0 := adopt(&SourceVariable{Name: "pos[0]", Type: posType},
+		&SourceVariable{Name: "x", Type: intType},
+		&SourceVariable{Name: "y", Type: intType})
+	pos1 := adopt(&SourceVariable{Name: "pos[1]", Type: posType},
+		&SourceVariable{Name: "x", Type: intType},
+		&SourceVariable{Name: "y", Type: intType})
+	player := adopt(&SourceVariable{Name: "player", Type: playerType},
+		adopt(&SourceVariable{Name: "pos", Type: posArrayType}, pos0, pos1),
+		&SourceVariable{Name: "lives", Type: intType})
+
+	// a C++ reference to the player
+	refType := &SourceType{Name: "player&", Size: 4, PointerType: playerType, Reference: true}
+	ref := adopt(&SourceVariable{Name: "ref", Type: refType}, player)
+
+	src := &Source{
+		GlobalsByName: map[string]*SourceVariable{
+			"player": player,
+			"ref":    ref,
+			"count":  {Name: "count", Type: intType},
+		},
+	}
+
+	varb, err := src.FindGlobalVariable("count")
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, varb.Name, "count")
+
+	varb, err = src.FindGlobalVariable("player.lives")
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, varb.Name, "lives")
+
+	varb, err = src.FindGlobalVariable("player.pos[1].y")
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, varb, pos1.Child(1))
+
+	// members of a reference are the members of the referenced variable
+	varb, err = src.FindGlobalVariable("ref.pos[0].x")
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, varb, pos0.Child(0))
+
+	// errors in the path
+	_, err = src.FindGlobalVariable("missing")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("count.x")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.score")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.lives[0]")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.pos[2]")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.pos[-1]")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.pos[a]")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.pos[0")
+	test.ExpectFailure(t, err)
+	_, err = src.FindGlobalVariable("player.pos[0]x")
+	test.ExpectFailure(t, err)
+}
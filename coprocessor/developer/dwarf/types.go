@@ -302,6 +302,9 @@ type SourceType struct {
 	// the base type of pointer types. will be nil if type is not a pointer type
 	PointerType *SourceType
 
+	// a C++ reference type is a pointer type that is dereferenced automatically
+	Reference bool
+
 	// size of values of this type (in bytes)
 	Size int
 
@@ -332,7 +335,9 @@ func (typ *SourceType) String() string {
 	if typ.IsArray() {
 		s.WriteString(" is array")
 	}
-	if typ.IsPointer() {
+	if typ.IsReference() {
+		s.WriteString(" is reference")
+	} else if typ.IsPointer() {
 		s.WriteString(" is pointer")
 	}
 	return s.String()
@@ -348,11 +353,17 @@ func (typ *SourceType) IsArray() bool {
 	return typ.ElementType != nil && typ.ElementCount > 0
 }
 
-// IsPointer returns true if SourceType is a pointer type.
+// IsPointer returns true if SourceType is a pointer type. C++ reference types
+// are also pointer types.
 func (typ *SourceType) IsPointer() bool {
 	return typ.PointerType != nil
 }
 
+// IsReference returns true if SourceType is a C++ reference type.
+func (typ *SourceType) IsReference() bool {
+	return typ.PointerType != nil && typ.Reference
+}
+
 // Hex returns a format string to represent a value as a correctly padded
 // hexadecinal number.
 func (typ *SourceType) Hex() string {
@@ -52,6 +52,10 @@ type SourceVariable struct {
 	// first source line for each instance of the function
 	DeclLine *SourceLine
 
+	// the variable is the part of a C++ class that is inherited from a base
+	// class. the name of the variable is the name of the base class
+	BaseClass bool
+
 	hasConstantValue bool
 	constantValue    uint32
 
@@ -93,8 +97,8 @@ func (varb *SourceVariable) String() string {
 	} else {
 		s.WriteString(" = ")
 		if varb.Type.Conversion != nil {
-			c, _ := varb.Type.Conversion(varb.Value())
-			s.WriteString(c)
+			f, v := varb.Type.Conversion(varb.Value())
+			fmt.Fprintf(&s, f, v)
 		} else {
 			fmt.Fprintf(&s, varb.Type.Hex(), varb.Value())
 		}
@@ -176,6 +180,42 @@ func (varb *SourceVariable) Child(i int) *SourceVariable {
 	return varb.children[i]
 }
 
+// Member returns the member of a composite variable with the specified name.
+// Members of inherited C++ classes are also found. If the variable is a C++
+// reference then the member of the referenced variable is returned
+//
+// Returns nil if no such member exists
+func (varb *SourceVariable) Member(name string) *SourceVariable {
+	if varb.Type.IsReference() {
+		varb = varb.Child(0)
+		if varb == nil {
+			return nil
+		}
+	}
+
+	if !varb.Type.IsComposite() {
+		return nil
+	}
+
+	for _, c := range varb.children {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	// search base classes only once the members of the derived class have been
+	// checked. this mirrors the name hiding rules of C++
+	for _, c := range varb.children {
+		if c.BaseClass {
+			if m := c.Member(name); m != nil {
+				return m
+			}
+		}
+	}
+
+	return nil
+}
+
 // Parent returns the parent variable and whether the parent is valid. A variable can have a parent
 // if it is an array element, part of a composite, or the parent is a pointer
 func (varb *SourceVariable) Parent() (*SourceVariable, bool) {
@@ -286,9 +326,10 @@ func (varb *SourceVariable) addVariableChildren(debug_loc *loclistDecoder) {
 	if varb.Type.IsComposite() {
 		for i, m := range varb.Type.Members {
 			memb := &SourceVariable{
-				Name:     m.Name,
-				Type:     m.Type,
-				DeclLine: varb.DeclLine,
+				Name:      m.Name,
+				Type:      m.Type,
+				DeclLine:  varb.DeclLine,
+				BaseClass: m.BaseClass,
 			}
 			memb.loclist = debug_loc.newLoclistJustFramebase(varb)
 
@@ -20,6 +20,7 @@ import (
 
 	"github.com/jetsetilly/gopher2600/coprocessor"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/profiling"
+	"github.com/jetsetilly/gopher2600/debugger/govern"
 )
 
 // Profiling implements the coprocessor.CartCoProcDeveloper interface.
@@ -74,6 +75,17 @@ func (dev *Developer) ProcessProfiling() {
 		dev.callstackLock.Lock()
 		defer dev.callstackLock.Unlock()
 
+		dev.exportLock.Lock()
+		defer dev.exportLock.Unlock()
+
+		// profile export is only interested in the frames in its range. data
+		// from a rewind or catch-up has already been seen
+		var export *profileExport
+		frame := dev.tv.GetCoords().Frame
+		if dev.export != nil && dev.export.inRange(frame) && dev.emulation.State() != govern.Rewinding {
+			export = dev.export
+		}
+
 		for _, p := range dev.profiler.Entries {
 			// line of executed instruction. every instruction should have an
 			// associated line/function. if it does not then we assume it is in
@@ -144,6 +156,10 @@ func (dev *Developer) ProcessProfiling() {
 				}
 			}
 
+			if export != nil {
+				export.entry(frame, dev.callstack.Stack, ln, p.Cycles)
+			}
+
 			// check that this function looks like it has been called at least once
 			ln.Function.NumCalls.Check(focus)
 			ln.Function.CyclesPerCall.Check(focus)
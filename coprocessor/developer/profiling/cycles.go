@@ -48,6 +48,19 @@ func (cy *Cycles) Cycle(n float32, focus Focus) {
 	}
 }
 
+// Scope returns the CyclesScope for the specified focus
+func (cy *Cycles) Scope(focus Focus) *CyclesScope {
+	switch focus {
+	case FocusVBLANK:
+		return &cy.VBLANK
+	case FocusScreen:
+		return &cy.Screen
+	case FocusOverscan:
+		return &cy.Overscan
+	}
+	return &cy.Overall
+}
+
 // NewFrame commits accumulated cycles for the frame. The rewinding flag
 // indicates that the emulation is in the rewinding state and that some data
 // should not be updated
@@ -125,6 +138,12 @@ func (cy *CyclesScope) Cycle(n float32) {
 	cy.cycles += n
 }
 
+// FrameCycles returns the number of cycles counted in the most recent frame.
+// Unlike the FrameCount field in CycleFigures, the value is always valid
+func (cy *CyclesScope) FrameCycles() float32 {
+	return cy.frameCount
+}
+
 // HasExecuted returns true if the entity (program, function or line) has ever
 // been executed
 func (cy *CyclesScope) HasExecuted() bool {
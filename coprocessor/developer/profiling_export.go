@@ -0,0 +1,402 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+)
+
+// ProfileExportFormat specifies the file format of a profile export.
+type ProfileExportFormat int
+
+// List of valid ProfileExportFormat values.
+const (
+	// callgrind format as used by valgrind. suitable for kcachegrind and
+	// similar tools
+	ProfileExportCallgrind ProfileExportFormat = iota
+
+	// collapsed stack format. one line for each unique call stack with the
+	// number of cycles consumed by the stack. suitable for flame graph tools
+	ProfileExportFolded
+)
+
+func (f ProfileExportFormat) String() string {
+	switch f {
+	case ProfileExportCallgrind:
+		return "callgrind"
+	case ProfileExportFolded:
+		return "folded"
+	}
+	return "unknown"
+}
+
+// a call from a line in the calling function to the called function
+type profileCall struct {
+	site   *dwarf.SourceLine
+	callee *dwarf.SourceFunction
+}
+
+// the cost of a profileCall. the cycles are inclusive, meaning that they
+// include the cycles of every function called by the callee
+type profileCallCost struct {
+	calls  int
+	cycles float32
+
+	// the first line executed in the callee
+	entry *dwarf.SourceLine
+}
+
+// profileExport accumulates profiling data for a range of frames and writes
+// it to a file in one of the ProfileExportFormat formats
+type profileExport struct {
+	format   ProfileExportFormat
+	filename string
+	romFile  string
+
+	// the range of frames to profile (inclusive)
+	from int
+	to   int
+
+	// the number of frames seen in the range
+	frames     int
+	lastFrame  int
+	hasStarted bool
+
+	// cycles for each unique call stack. the key is the list of function
+	// names separated by a semicolon
+	folded map[string]float32
+
+	// self cycles for each line and the cost of each call
+	lines map[*dwarf.SourceLine]float32
+	calls map[profileCall]*profileCallCost
+
+	// the call stack as it was for the previous entry and the site from which
+	// each function in the call stack was called
+	prevStack []*dwarf.SourceFunction
+	sites     []*dwarf.SourceLine
+	prevLine  *dwarf.SourceLine
+
+	// scratch space for building folded stack keys
+	key strings.Builder
+}
+
+func newProfileExport(format ProfileExportFormat, filename string, romFile string, from int, to int) *profileExport {
+	return &profileExport{
+		format:   format,
+		filename: filename,
+		romFile:  romFile,
+		from:     from,
+		to:       to,
+		folded:   make(map[string]float32),
+		lines:    make(map[*dwarf.SourceLine]float32),
+		calls:    make(map[profileCall]*profileCallCost),
+	}
+}
+
+func (exp *profileExport) String() string {
+	s := fmt.Sprintf("%s profile of frames %d to %d to %s", exp.format, exp.from, exp.to, exp.filename)
+	if exp.hasStarted {
+		s = fmt.Sprintf("%s (%d frames profiled)", s, exp.frames)
+	}
+	return s
+}
+
+// inRange returns true if the frame number is in the range of the export
+func (exp *profileExport) inRange(frame int) bool {
+	return frame >= exp.from && frame <= exp.to
+}
+
+// entry adds the cycles for the line to the export. the line has been
+// executed with the specified call stack
+func (exp *profileExport) entry(frame int, stack []*dwarf.SourceLine, ln *dwarf.SourceLine, cycles float32) {
+	if !exp.hasStarted || frame != exp.lastFrame {
+		exp.hasStarted = true
+		exp.lastFrame = frame
+		exp.frames++
+	}
+
+	// note any functions that have been called since the previous entry. this
+	// is done by comparing the call stack with the call stack of the previous
+	// entry
+	if len(stack) > 0 {
+		if len(exp.prevStack) == 0 || exp.prevStack[0] != stack[0].Function {
+			exp.prevStack = append(exp.prevStack[:0], stack[0].Function)
+			exp.sites = append(exp.sites[:0], nil)
+		}
+
+		for i := 1; i < len(stack); i++ {
+			if i < len(exp.prevStack) && exp.prevStack[i] == stack[i].Function {
+				continue // for loop
+			}
+
+			// the call site is the line that was executed before the call. if
+			// that line isn't in the calling function then the best we can do
+			// is use the line in the call stack
+			site := stack[i-1]
+			if exp.prevLine != nil && exp.prevLine.Function == site.Function {
+				site = exp.prevLine
+			}
+
+			exp.sites = append(exp.sites[:i], site)
+			exp.prevStack = append(exp.prevStack[:i], stack[i].Function)
+
+			c := profileCall{site: site, callee: stack[i].Function}
+			cost, ok := exp.calls[c]
+			if !ok {
+				cost = &profileCallCost{entry: stack[i]}
+				exp.calls[c] = cost
+			}
+			cost.calls++
+		}
+
+		exp.prevStack = exp.prevStack[:len(stack)]
+		exp.sites = exp.sites[:len(stack)]
+	}
+
+	// inclusive cycles for every call in the call stack. a function that
+	// appears more than once in the call stack is a recursive function and
+	// only the lowest call to it includes the cycles. otherwise the cycles
+	// will be counted more than once in the inclusive cost of the function
+	for i := 1; i < len(stack); i++ {
+		if !exp.recursive(stack, i) {
+			exp.calls[profileCall{site: exp.sites[i], callee: stack[i].Function}].cycles += cycles
+		}
+	}
+
+	// self cycles for the line
+	exp.lines[ln] += cycles
+
+	// collapsed stack. the function of the line is added to the end of the
+	// stack if it isn't already there (for example, if the line is in a stub
+	// function)
+	exp.key.Reset()
+	for i, s := range stack {
+		if i > 0 {
+			exp.key.WriteRune(';')
+		}
+		exp.key.WriteString(s.Function.Name)
+	}
+	if len(stack) == 0 || stack[len(stack)-1].Function != ln.Function {
+		if len(stack) > 0 {
+			exp.key.WriteRune(';')
+		}
+		exp.key.WriteString(ln.Function.Name)
+	}
+	exp.folded[exp.key.String()] += cycles
+
+	exp.prevLine = ln
+}
+
+// recursive returns true if the function at index i of the call stack also
+// appears lower in the call stack
+func (exp *profileExport) recursive(stack []*dwarf.SourceLine, i int) bool {
+	for j := range i {
+		if stack[j].Function == stack[i].Function {
+			return true
+		}
+	}
+	return false
+}
+
+// write the accumulated profile to the export file
+func (exp *profileExport) write() error {
+	f, err := os.Create(exp.filename)
+	if err != nil {
+		return fmt.Errorf("profile export: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+
+	switch exp.format {
+	case ProfileExportCallgrind:
+		exp.writeCallgrind(w)
+	case ProfileExportFolded:
+		exp.writeFolded(w)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("profile export: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("profile export: %w", err)
+	}
+
+	return nil
+}
+
+// the cycle counts are floating point values because of the cycle regulator
+// in the ARM emulation. both output formats expect integer values
+func profileCycles(cycles float32) int64 {
+	return int64(math.Round(float64(cycles)))
+}
+
+func (exp *profileExport) writeFolded(w io.Writer) {
+	keys := make([]string, 0, len(exp.folded))
+	for k := range exp.folded {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if n := profileCycles(exp.folded[k]); n > 0 {
+			fmt.Fprintf(w, "%s %d\n", k, n)
+		}
+	}
+}
+
+func (exp *profileExport) writeCallgrind(w io.Writer) {
+	fmt.Fprintln(w, "# callgrind format")
+	fmt.Fprintln(w, "version: 1")
+	fmt.Fprintln(w, "creator: gopher2600")
+	fmt.Fprintf(w, "cmd: %s\n", exp.romFile)
+	fmt.Fprintf(w, "desc: Frames: %d to %d (%d profiled)\n", exp.from, exp.to, exp.frames)
+	fmt.Fprintln(w, "positions: line")
+	fmt.Fprintln(w, "events: Cycles")
+
+	// collate lines and calls by function
+	type function struct {
+		fn    *dwarf.SourceFunction
+		lines []*dwarf.SourceLine
+		calls []profileCall
+	}
+	functions := make(map[*dwarf.SourceFunction]*function)
+	get := func(fn *dwarf.SourceFunction) *function {
+		f, ok := functions[fn]
+		if !ok {
+			f = &function{fn: fn}
+			functions[fn] = f
+		}
+		return f
+	}
+	for ln := range exp.lines {
+		f := get(ln.Function)
+		f.lines = append(f.lines, ln)
+	}
+	for c := range exp.calls {
+		f := get(c.site.Function)
+		f.calls = append(f.calls, c)
+	}
+
+	sorted := make([]*function, 0, len(functions))
+	for _, f := range functions {
+		sort.Slice(f.lines, func(i, j int) bool {
+			return f.lines[i].LineNumber < f.lines[j].LineNumber
+		})
+		sort.Slice(f.calls, func(i, j int) bool {
+			if f.calls[i].site.LineNumber == f.calls[j].site.LineNumber {
+				return f.calls[i].callee.Name < f.calls[j].callee.Name
+			}
+			return f.calls[i].site.LineNumber < f.calls[j].site.LineNumber
+		})
+		sorted = append(sorted, f)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].fn.Name < sorted[j].fn.Name
+	})
+
+	filename := func(fn *dwarf.SourceFunction) string {
+		if fn.DeclLine == nil || fn.DeclLine.File == nil {
+			return "???"
+		}
+		return fn.DeclLine.File.Filename
+	}
+
+	for _, f := range sorted {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "fl=%s\n", filename(f.fn))
+		fmt.Fprintf(w, "fn=%s\n", f.fn.Name)
+		for _, ln := range f.lines {
+			fmt.Fprintf(w, "%d %d\n", ln.LineNumber, profileCycles(exp.lines[ln]))
+		}
+		for _, c := range f.calls {
+			cost := exp.calls[c]
+			fmt.Fprintf(w, "cfl=%s\n", filename(c.callee))
+			fmt.Fprintf(w, "cfn=%s\n", c.callee.Name)
+			fmt.Fprintf(w, "calls=%d %d\n", cost.calls, cost.entry.LineNumber)
+			fmt.Fprintf(w, "%d %d\n", c.site.LineNumber, profileCycles(cost.cycles))
+		}
+	}
+}
+
+// StartProfileExport begins the collection of profiling data for the range of
+// frames. The data is written to the named file in the specified format once
+// the last frame in the range has been completed, or when EndProfileExport()
+// is called.
+//
+// Any existing profile export will be ended.
+func (dev *Developer) StartProfileExport(format ProfileExportFormat, filename string, from int, to int) error {
+	if dev.source == nil {
+		return fmt.Errorf("profile export: no source available for profiling")
+	}
+	if from > to {
+		return fmt.Errorf("profile export: first frame (%d) is after the last frame (%d)", from, to)
+	}
+
+	_, err := dev.EndProfileExport()
+	if err != nil {
+		return err
+	}
+
+	dev.exportLock.Lock()
+	defer dev.exportLock.Unlock()
+
+	dev.export = newProfileExport(format, filename, dev.romFile, from, to)
+
+	return nil
+}
+
+// EndProfileExport writes the data collected so far and ends the profile
+// export. Returns a description of the export that has ended or the empty
+// string if there was no profile export.
+func (dev *Developer) EndProfileExport() (string, error) {
+	dev.exportLock.Lock()
+	defer dev.exportLock.Unlock()
+	return dev.endProfileExport()
+}
+
+// endProfileExport is the same as EndProfileExport but assumes that the
+// exportLock has been acquired
+func (dev *Developer) endProfileExport() (string, error) {
+	if dev.export == nil {
+		return "", nil
+	}
+	exp := dev.export
+	dev.export = nil
+	return exp.String(), exp.write()
+}
+
+// ProfileExport returns a description of the current profile export. Returns
+// false if there is no profile export in progress.
+func (dev *Developer) ProfileExport() (string, bool) {
+	dev.exportLock.Lock()
+	defer dev.exportLock.Unlock()
+	if dev.export == nil {
+		return "", false
+	}
+	return dev.export.String(), true
+}
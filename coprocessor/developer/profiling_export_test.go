@@ -0,0 +1,156 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// a small synthetic profile of two frames. main() calls foo() from line 11
+func testProfileExport(format ProfileExportFormat) *profileExport {
+	file := &dwarf.SourceFile{Filename: "main.c"}
+
+	main := &dwarf.SourceFunction{Name: "main"}
+	main.DeclLine = &dwarf.SourceLine{File: file, LineNumber: 10, Function: main}
+	foo := &dwarf.SourceFunction{Name: "foo"}
+	foo.DeclLine = &dwarf.SourceLine{File: file, LineNumber: 20, Function: foo}
+
+	line := func(fn *dwarf.SourceFunction, n int) *dwarf.SourceLine {
+		return &dwarf.SourceLine{File: file, LineNumber: n, Function: fn}
+	}
+	main11 := line(main, 11)
+	main12 := line(main, 12)
+	foo21 := line(foo, 21)
+	foo22 := line(foo, 22)
+
+	exp := newProfileExport(format, "", "test.bin", 1, 2)
+	exp.entry(1, []*dwarf.SourceLine{main11}, main11, 10)
+	exp.entry(1, []*dwarf.SourceLine{main12, foo21}, foo21, 5)
+	exp.entry(1, []*dwarf.SourceLine{main12, foo22}, foo22, 7)
+	exp.entry(2, []*dwarf.SourceLine{main12}, main12, 3)
+
+	return exp
+}
+
+func TestProfileExportFolded(t *testing.T) {
+	exp := testProfileExport(ProfileExportFolded)
+
+	var s strings.Builder
+	exp.writeFolded(&s)
+
+	test.ExpectEquality(t, s.String(), "main 13\nmain;foo 12\n")
+}
+
+func TestProfileExportCallgrind(t *testing.T) {
+	exp := testProfileExport(ProfileExportCallgrind)
+	test.ExpectEquality(t, exp.frames, 2)
+
+	var s strings.Builder
+	exp.writeCallgrind(&s)
+
+	const golden = `# callgrind format
+version: 1
+creator: gopher2600
+cmd: test.bin
+desc: Frames: 1 to 2 (2 profiled)
+positions: line
+events: Cycles
+
+fl=main.c
+fn=foo
+21 5
+22 7
+
+fl=main.c
+fn=main
+11 10
+12 3
+cfl=main.c
+cfn=foo
+calls=1 21
+11 12
+`
+	test.ExpectEquality(t, s.String(), golden)
+}
+
+// a recursive call chain. main() calls fact() from line 11 and fact() calls
+// itself from line 32
+func TestProfileExportRecursive(t *testing.T) {
+	file := &dwarf.SourceFile{Filename: "main.c"}
+
+	main := &dwarf.SourceFunction{Name: "main"}
+	main.DeclLine = &dwarf.SourceLine{File: file, LineNumber: 10, Function: main}
+	fact := &dwarf.SourceFunction{Name: "fact"}
+	fact.DeclLine = &dwarf.SourceLine{File: file, LineNumber: 30, Function: fact}
+
+	line := func(fn *dwarf.SourceFunction, n int) *dwarf.SourceLine {
+		return &dwarf.SourceLine{File: file, LineNumber: n, Function: fn}
+	}
+	main11 := line(main, 11)
+	main12 := line(main, 12)
+	fact31 := line(fact, 31)
+	fact32 := line(fact, 32)
+
+	exp := newProfileExport(ProfileExportCallgrind, "", "test.bin", 1, 1)
+	exp.entry(1, []*dwarf.SourceLine{main11}, main11, 10)
+	exp.entry(1, []*dwarf.SourceLine{main11, fact31}, fact31, 5)
+	exp.entry(1, []*dwarf.SourceLine{main11, fact32}, fact32, 2)
+	exp.entry(1, []*dwarf.SourceLine{main11, fact32, fact31}, fact31, 5)
+	exp.entry(1, []*dwarf.SourceLine{main11, fact32, fact32}, fact32, 2)
+	exp.entry(1, []*dwarf.SourceLine{main11, fact32, fact32, fact31}, fact31, 5)
+	exp.entry(1, []*dwarf.SourceLine{main12}, main12, 3)
+
+	var s strings.Builder
+	exp.writeCallgrind(&s)
+
+	// the inclusive cost of the call from main() is the total of the self
+	// cycles of fact(). the recursive calls do not add to the inclusive cost
+	const golden = `# callgrind format
+version: 1
+creator: gopher2600
+cmd: test.bin
+desc: Frames: 1 to 1 (1 profiled)
+positions: line
+events: Cycles
+
+fl=main.c
+fn=fact
+31 15
+32 4
+cfl=main.c
+cfn=fact
+calls=2 31
+32 0
+
+fl=main.c
+fn=main
+11 10
+12 3
+cfl=main.c
+cfn=fact
+calls=1 31
+11 19
+`
+	test.ExpectEquality(t, s.String(), golden)
+
+	s.Reset()
+	exp.writeFolded(&s)
+	test.ExpectEquality(t, s.String(), "main 13\nmain;fact 7\nmain;fact;fact 7\nmain;fact;fact;fact 5\n")
+}
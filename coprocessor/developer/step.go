@@ -0,0 +1,211 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/callstack"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+)
+
+// StepMode specifies how a source level step proceeds.
+type StepMode int
+
+// List of valid StepMode values.
+const (
+	// continue until a different line in the same function is reached.
+	// functions called by the current line are stepped over
+	StepOver StepMode = iota
+
+	// continue until any other line is reached, including lines in functions
+	// called by the current line
+	StepInto
+
+	// continue until the current function returns to its caller
+	StepOut
+)
+
+func (m StepMode) String() string {
+	switch m {
+	case StepOver:
+		return "over"
+	case StepInto:
+		return "into"
+	case StepOut:
+		return "out"
+	}
+	return "unknown"
+}
+
+// the register number of the stack pointer. this is the DWARF register number
+// for the ARM architecture
+const stepRegisterSP = 13
+
+// sourceStep records the position of the program when a source level step was
+// requested
+type sourceStep struct {
+	mode StepMode
+
+	line *dwarf.SourceLine
+	fn   *dwarf.SourceFunction
+
+	// the function that called fn according to the call stack. can be nil
+	caller *dwarf.SourceFunction
+
+	// value of the stack pointer at the start of the step. the stack pointer
+	// is used to decide whether execution has returned from a function and
+	// whether a line in fn belongs to a recursive call
+	sp uint32
+
+	// the step started on the first line of the function, before the stack
+	// frame has been created. the stack pointer is therefore the same as the
+	// stack pointer of the caller
+	prologue bool
+}
+
+// Step begins a source level step from the most recent coprocessor break.
+// Execution should be resumed after calling this function. The step will
+// complete when the coprocessor reaches the line described by the StepMode
+// and the coprocessor will break as though a breakpoint had been hit.
+//
+// If the coprocessor yields to the 6507 before the step has completed then
+// the step will continue the next time the coprocessor program runs.
+func (dev *Developer) Step(mode StepMode) error {
+	if dev.source == nil {
+		return fmt.Errorf("step: no source available")
+	}
+
+	step := &sourceStep{mode: mode}
+
+	dev.BorrowSource(func(src *dwarf.Source) {
+		step.line = src.FindSourceLine(dev.breakAddress)
+	})
+	if step.line == nil || step.line.IsStub() {
+		return fmt.Errorf("step: coprocessor is not stopped on a line of source code")
+	}
+	step.fn = step.line.Function
+	step.prologue = step.line == step.fn.DeclLine
+
+	// the call stack is used to help identify when the function has returned
+	dev.BorrowCallStack(func(cs callstack.CallStack) {
+		for i := len(cs.Stack) - 1; i > 0; i-- {
+			if cs.Stack[i].Function == step.fn {
+				step.caller = cs.Stack[i-1].Function
+				break // for loop
+			}
+		}
+	})
+
+	var ok bool
+	step.sp, ok = dev.stepRegister(stepRegisterSP)
+	if !ok {
+		return fmt.Errorf("step: cannot read stack pointer")
+	}
+
+	dev.stepLock.Lock()
+	defer dev.stepLock.Unlock()
+	dev.step = step
+
+	return nil
+}
+
+// EndStep cancels any source level step that is in progress.
+func (dev *Developer) EndStep() {
+	dev.stepLock.Lock()
+	defer dev.stepLock.Unlock()
+	dev.step = nil
+}
+
+// read coprocessor register
+func (dev *Developer) stepRegister(reg int) (uint32, bool) {
+	if dev.cart == nil {
+		return 0, false
+	}
+	bus := dev.cart.GetCoProcBus()
+	if bus == nil {
+		return 0, false
+	}
+	return bus.GetCoProc().Register(reg)
+}
+
+// checkStep returns true if the address is where the current source level
+// step should complete. the step is ended if the function returns true
+func (dev *Developer) checkStep(addr uint32) bool {
+	dev.stepLock.Lock()
+	step := dev.step
+	dev.stepLock.Unlock()
+
+	if step == nil {
+		return false
+	}
+
+	dev.sourceLock.Lock()
+	ln := dev.source.LinesByAddress[uint64(addr)]
+	dev.sourceLock.Unlock()
+
+	// never stop on an instruction that doesn't have a real line of source
+	if ln == nil || ln.IsStub() || ln.Function.IsStub() {
+		return false
+	}
+
+	sp, spOk := dev.stepRegister(stepRegisterSP)
+
+	// a function has returned if the stack pointer is higher than it was at
+	// the start of the step or if execution has reached the caller. the caller
+	// check is necessary for functions that don't use the stack
+	returned := func() bool {
+		return (spOk && sp > step.sp) || (step.caller != nil && ln.Function == step.caller)
+	}
+
+	// the function has returned to a recursive caller of itself. if the step
+	// started in the prologue then the stack pointer will be the same as it
+	// was at the start of the step
+	returnedToSelf := func() bool {
+		if !spOk {
+			return false
+		}
+		return sp > step.sp || (step.prologue && sp == step.sp && ln != step.line)
+	}
+
+	var stop bool
+
+	switch step.mode {
+	case StepInto:
+		stop = ln != step.line
+	case StepOver:
+		if ln.Function == step.fn {
+			// reaching the first line of the function or a lower stack
+			// pointer means that the line is in a recursive call of the
+			// function
+			stop = ln != step.line && ln != step.fn.DeclLine && (step.prologue || !spOk || sp >= step.sp)
+		} else {
+			stop = returned()
+		}
+	case StepOut:
+		if ln.Function == step.fn {
+			stop = returnedToSelf()
+		} else {
+			stop = returned()
+		}
+	}
+
+	if stop {
+		dev.EndStep()
+	}
+
+	return stop
+}
@@ -0,0 +1,282 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/callstack"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// coprocessor stub that only supports reading the stack pointer
+type stepCoProc struct {
+	coprocessor.CartCoProc
+	sp uint32
+}
+
+func (cpu *stepCoProc) Register(reg int) (uint32, bool) {
+	if reg == stepRegisterSP {
+		return cpu.sp, true
+	}
+	return 0, false
+}
+
+type stepCoProcBus struct {
+	coprocessor.CartCoProcBus
+	cpu *stepCoProc
+}
+
+func (bus *stepCoProcBus) GetCoProc() coprocessor.CartCoProc {
+	return bus.cpu
+}
+
+type stepCartridge struct {
+	Cartridge
+	bus *stepCoProcBus
+}
+
+func (cart *stepCartridge) GetCoProcBus() coprocessor.CartCoProcBus {
+	return cart.bus
+}
+
+// a synthetic program of two functions. main() calls fact() on line 2 and
+// fact() calls itself recursively on line 12. the first line of each function
+// is the prologue
+//
+//	main: $100 line 1, $104 line 2, $108 line 3
+//	fact: $200 line 10, $204 line 11, $208 line 12, $20c line 13
+//	foo:  $300 line 20, $304 line 21
+func newStepDeveloper() (*Developer, *stepCoProc) {
+	file := &dwarf.SourceFile{Filename: "main.c"}
+	src := &dwarf.Source{LinesByAddress: make(map[uint64]*dwarf.SourceLine)}
+
+	function := func(name string, addr uint64, lines ...int) *dwarf.SourceFunction {
+		fn := &dwarf.SourceFunction{Name: name}
+		for i, n := range lines {
+			ln := &dwarf.SourceLine{File: file, LineNumber: n, Function: fn, PlainContent: fmt.Sprintf("line %d", n)}
+			if i == 0 {
+				fn.DeclLine = ln
+			}
+			src.LinesByAddress[addr+uint64(i*4)] = ln
+		}
+		return fn
+	}
+	function("main", 0x100, 1, 2, 3)
+	function("fact", 0x200, 10, 11, 12, 13)
+	function("foo", 0x300, 20, 21)
+
+	cpu := &stepCoProc{}
+	dev := &Developer{
+		source:    src,
+		cart:      &stepCartridge{bus: &stepCoProcBus{cpu: cpu}},
+		callstack: callstack.NewCallStack(),
+	}
+
+	return dev, cpu
+}
+
+// a single instruction executed during a step
+type stepInstruction struct {
+	addr uint32
+	sp   uint32
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  StepMode
+		start stepInstruction
+
+		// the functions in the call stack at the start of the step. the
+		// first entry is the outermost function
+		stack []uint64
+
+		instructions []stepInstruction
+
+		// the index of the instruction where the step completes. -1 if the
+		// step never completes
+		stop int
+	}{
+		{
+			name:         "into next line",
+			mode:         StepInto,
+			start:        stepInstruction{0x204, 0x1000},
+			instructions: []stepInstruction{{0x204, 0x1000}, {0x208, 0x1000}},
+			stop:         1,
+		},
+		{
+			name:         "into called function",
+			mode:         StepInto,
+			start:        stepInstruction{0x104, 0x1100},
+			instructions: []stepInstruction{{0x200, 0x1100}},
+			stop:         0,
+		},
+		{
+			name:         "over next line",
+			mode:         StepOver,
+			start:        stepInstruction{0x204, 0x1000},
+			instructions: []stepInstruction{{0x204, 0x1000}, {0x208, 0x1000}},
+			stop:         1,
+		},
+		{
+			name:  "over call to another function",
+			mode:  StepOver,
+			start: stepInstruction{0x104, 0x1100},
+			instructions: []stepInstruction{
+				{0x200, 0x1100}, {0x204, 0x1000}, {0x20c, 0x1000}, {0x108, 0x1100},
+			},
+			stop: 3,
+		},
+		{
+			name:  "over recursive call",
+			mode:  StepOver,
+			start: stepInstruction{0x208, 0x1000},
+			instructions: []stepInstruction{
+				{0x200, 0x1000}, {0x204, 0x0f00}, {0x208, 0x0f00}, {0x20c, 0x0f00}, {0x20c, 0x1000},
+			},
+			stop: 4,
+		},
+		{
+			name:         "over from prologue",
+			mode:         StepOver,
+			start:        stepInstruction{0x200, 0x1000},
+			instructions: []stepInstruction{{0x200, 0x0ff8}, {0x204, 0x0f00}},
+			stop:         1,
+		},
+		{
+			name:  "over last line returns to caller",
+			mode:  StepOver,
+			start: stepInstruction{0x20c, 0x0f00},
+			stack: []uint64{0x100, 0x200},
+			instructions: []stepInstruction{
+				{0x20c, 0x0f00}, {0x108, 0x1000},
+			},
+			stop: 1,
+		},
+		{
+			name:  "out to caller",
+			mode:  StepOut,
+			start: stepInstruction{0x204, 0x0f00},
+			stack: []uint64{0x100, 0x200},
+			instructions: []stepInstruction{
+				{0x208, 0x0f00}, {0x300, 0x0f00}, {0x304, 0x0e00}, {0x20c, 0x0f00}, {0x108, 0x1000},
+			},
+			stop: 4,
+		},
+		{
+			name:  "out to recursive caller",
+			mode:  StepOut,
+			start: stepInstruction{0x204, 0x0f00},
+			stack: []uint64{0x100, 0x200, 0x200},
+			instructions: []stepInstruction{
+				{0x208, 0x0f00}, {0x200, 0x0f00}, {0x204, 0x0e00}, {0x20c, 0x0e00}, {0x20c, 0x0f00}, {0x20c, 0x1000},
+			},
+			stop: 5,
+		},
+		{
+			name:  "out from prologue to recursive caller",
+			mode:  StepOut,
+			start: stepInstruction{0x200, 0x1000},
+			stack: []uint64{0x100, 0x200, 0x200},
+			instructions: []stepInstruction{
+				{0x200, 0x0ff8}, {0x204, 0x0f00}, {0x20c, 0x0f00}, {0x20c, 0x1000},
+			},
+			stop: 3,
+		},
+		{
+			name:  "out never returns",
+			mode:  StepOut,
+			start: stepInstruction{0x204, 0x0f00},
+			instructions: []stepInstruction{
+				{0x208, 0x0f00}, {0x300, 0x0f00}, {0x304, 0x0e00}, {0x20c, 0x0f00},
+			},
+			stop: -1,
+		},
+		{
+			name:  "stub lines are ignored",
+			mode:  StepInto,
+			start: stepInstruction{0x204, 0x1000},
+			instructions: []stepInstruction{
+				{0x400, 0x1000}, {0x208, 0x1000},
+			},
+			stop: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, cpu := newStepDeveloper()
+
+			for _, addr := range tt.stack {
+				dev.callstack.Stack = append(dev.callstack.Stack, dev.source.LinesByAddress[addr])
+			}
+
+			dev.breakAddress = tt.start.addr
+			cpu.sp = tt.start.sp
+			test.ExpectSuccess(t, dev.Step(tt.mode))
+
+			stop := -1
+			for i, ins := range tt.instructions {
+				cpu.sp = ins.sp
+				if dev.checkStep(ins.addr) {
+					stop = i
+					break // for loop
+				}
+			}
+			test.ExpectEquality(t, stop, tt.stop)
+
+			// the step is ended when it completes
+			if stop >= 0 {
+				test.ExpectSuccess(t, dev.step == nil)
+			} else {
+				test.ExpectSuccess(t, dev.step != nil)
+			}
+		})
+	}
+}
+
+func TestStepErrors(t *testing.T) {
+	dev, _ := newStepDeveloper()
+
+	// there is no line of source code at the break address
+	dev.breakAddress = 0x400
+	test.ExpectFailure(t, dev.Step(StepOver))
+
+	// the stack pointer cannot be read
+	dev.breakAddress = 0x204
+	dev.cart = nil
+	test.ExpectFailure(t, dev.Step(StepOver))
+
+	// no source
+	dev.source = nil
+	test.ExpectFailure(t, dev.Step(StepOver))
+}
+
+func TestEndStep(t *testing.T) {
+	dev, cpu := newStepDeveloper()
+
+	dev.breakAddress = 0x204
+	cpu.sp = 0x1000
+	test.ExpectSuccess(t, dev.Step(StepInto))
+
+	// the step does not complete after it has been ended
+	dev.EndStep()
+	test.ExpectFailure(t, dev.checkStep(0x208))
+}
@@ -0,0 +1,198 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+)
+
+// returns the tracer for the attached coprocessor
+func (dev *Developer) tracer() (coprocessor.CartCoProcTracer, error) {
+	if dev.cart == nil {
+		return nil, fmt.Errorf("trace: no coprocessor")
+	}
+	bus := dev.cart.GetCoProcBus()
+	if bus == nil {
+		return nil, fmt.Errorf("trace: no coprocessor")
+	}
+	tr, ok := bus.GetCoProc().(coprocessor.CartCoProcTracer)
+	if !ok {
+		return nil, fmt.Errorf("trace: coprocessor does not support tracing")
+	}
+	return tr, nil
+}
+
+// SetTracing enables or disables the recording of coprocessor instructions.
+// The limit is the number of instructions to keep. A limit of zero indicates
+// that the coprocessor's default limit should be used
+func (dev *Developer) SetTracing(enable bool, limit int) error {
+	tr, err := dev.tracer()
+	if err != nil {
+		return err
+	}
+	tr.SetTracing(enable, limit)
+	return nil
+}
+
+// Tracing returns whether tracing is enabled, the limit and the number of
+// instructions currently in the trace
+func (dev *Developer) Tracing() (bool, int, int) {
+	tr, err := dev.tracer()
+	if err != nil {
+		return false, 0, 0
+	}
+	enabled, limit := tr.Tracing()
+	var n int
+	tr.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+		n = len(trace)
+	})
+	return enabled, limit, n
+}
+
+// StepBack undoes the most recent n instructions in the coprocessor trace. The
+// coprocessor break address is moved to the new position so that source level
+// stepping and the source view work from there
+func (dev *Developer) StepBack(n int) (int, error) {
+	tr, err := dev.tracer()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = tr.StepBack(n)
+	if err != nil {
+		return 0, fmt.Errorf("trace: %w", err)
+	}
+
+	addr, ok := dev.cart.GetCoProcBus().GetCoProc().Register(15)
+	if !ok {
+		return n, nil
+	}
+
+	// the program counter is one instruction ahead of the instruction that
+	// will be executed next
+	addr -= 2
+
+	// any step that was in progress no longer makes sense
+	dev.EndStep()
+
+	dev.breakAddress = addr
+
+	// prevent the breakpoint on the new line from being triggered immediately
+	// when execution resumes
+	if dev.source != nil {
+		dev.breakpointsLock.Lock()
+		dev.sourceLock.Lock()
+		dev.prevBreakpointCheck = dev.source.LinesByAddress[uint64(addr)]
+		dev.sourceLock.Unlock()
+		dev.breakpointsLock.Unlock()
+	}
+
+	dev.OnYield(addr, coprocessor.CoProcYield{Type: coprocessor.YieldBreakpoint})
+
+	return n, nil
+}
+
+// WriteTrace writes the most recent n instructions in the trace to the
+// io.Writer. The most recent instruction is written last
+func (dev *Developer) WriteTrace(w io.Writer, n int) error {
+	tr, err := dev.tracer()
+	if err != nil {
+		return err
+	}
+
+	tr.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+		if len(trace) == 0 {
+			fmt.Fprintln(w, "trace is empty")
+			return
+		}
+
+		if n > 0 && n < len(trace) {
+			trace = trace[len(trace)-n:]
+		}
+
+		if dev.source != nil {
+			dev.sourceLock.Lock()
+			defer dev.sourceLock.Unlock()
+		}
+
+		for _, e := range trace {
+			fmt.Fprintf(w, "%08x", e.Addr)
+			if dev.source != nil {
+				if ln := dev.source.FindSourceLine(e.Addr); ln != nil && !ln.IsStub() {
+					fmt.Fprintf(w, " %s", ln)
+				}
+			}
+			for _, wr := range e.Writes {
+				fmt.Fprintf(w, " (%08x: %0*x -> %0*x)", wr.Addr, wr.Size*2, wr.Prev, wr.Size*2, wr.Value)
+			}
+			fmt.Fprintln(w)
+		}
+	})
+
+	return nil
+}
+
+// WriteTraceHistory writes every change to the target recorded in the trace.
+// The target can be an address or the name of a global variable, as described
+// for AddWatchpoint()
+func (dev *Developer) WriteTraceHistory(w io.Writer, target string) error {
+	tr, err := dev.tracer()
+	if err != nil {
+		return err
+	}
+
+	addr, size, label, err := dev.resolveTarget(target)
+	if err != nil {
+		return fmt.Errorf("trace: %w", err)
+	}
+
+	var found bool
+
+	tr.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+		if dev.source != nil {
+			dev.sourceLock.Lock()
+			defer dev.sourceLock.Unlock()
+		}
+
+		for _, e := range trace {
+			for _, wr := range e.Writes {
+				// ignore writes that don't overlap the target
+				if wr.Addr+uint32(wr.Size) <= addr || wr.Addr >= addr+uint32(size) {
+					continue
+				}
+				found = true
+
+				fmt.Fprintf(w, "%08x: %0*x -> %0*x", wr.Addr, wr.Size*2, wr.Prev, wr.Size*2, wr.Value)
+				fmt.Fprintf(w, " by %08x", e.Addr)
+				if dev.source != nil {
+					if ln := dev.source.FindSourceLine(e.Addr); ln != nil && !ln.IsStub() {
+						fmt.Fprintf(w, " %s", ln)
+					}
+				}
+				fmt.Fprintln(w)
+			}
+		}
+	})
+
+	if !found {
+		fmt.Fprintf(w, "no changes to %s in trace\n", label)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package developer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/watchpoints"
+)
+
+// peek value from coprocessor memory. suitable for use as a watchpoints.Peek
+// function
+func (dev *Developer) peekWatch(addr uint32, size int) (uint32, bool) {
+	if dev.cart == nil {
+		return 0, false
+	}
+	bus := dev.cart.GetCoProcBus()
+	if bus == nil {
+		return 0, false
+	}
+
+	// coprocessor peek is always 32bits so we need to mask off the bytes that
+	// aren't being watched. this assumes a little endian architecture
+	v, ok := bus.GetCoProc().Peek(addr)
+	if !ok {
+		return 0, false
+	}
+	switch size {
+	case 1:
+		v &= 0xff
+	case 2:
+		v &= 0xffff
+	}
+	return v, true
+}
+
+// AddWatchpoint adds a watchpoint for the target. The target can be an
+// address or the name of a global variable. Members of a struct and elements
+// of an array can be specified with the usual C syntax. For example,
+// "player.pos[2].x"
+//
+// An address watches four bytes unless the number of bytes is given after a
+// colon. For example, "0x20000010:2"
+func (dev *Developer) AddWatchpoint(target string, trigger watchpoints.Trigger) error {
+	addr, size, label, err := dev.resolveTarget(target)
+	if err != nil {
+		return fmt.Errorf("watchpoint: %w", err)
+	}
+
+	wp := watchpoints.Watchpoint{
+		Trigger: trigger,
+		Label:   label,
+		Addr:    addr,
+		Size:    size,
+	}
+
+	dev.watchpointsLock.Lock()
+	defer dev.watchpointsLock.Unlock()
+
+	err = dev.watchpoints.Add(wp, dev.peekWatch)
+	dev.watchpointsCount.Store(int32(dev.watchpoints.Count()))
+	return err
+}
+
+// resolve the target to an address and size in coprocessor memory. the target
+// can be an address, with an optional size, or the name of a global variable.
+// the returned label is the form of the target that should be used when
+// displaying it
+func (dev *Developer) resolveTarget(target string) (uint32, int, string, error) {
+	// a colon only separates the address from the size if the first part of
+	// the target is a number. C++ names can also contain colons
+	a, s, hasSize := strings.Cut(target, ":")
+	if addr, err := strconv.ParseUint(a, 0, 32); err == nil {
+		size := 4
+		if hasSize {
+			size, err = strconv.Atoi(s)
+			if err != nil || size <= 0 {
+				return 0, 0, "", fmt.Errorf("invalid size for %s (%s)", a, s)
+			}
+		}
+		return uint32(addr), size, fmt.Sprintf("%08x", addr), nil
+	}
+
+	if dev.source == nil {
+		return 0, 0, "", fmt.Errorf("no source available for variable lookup")
+	}
+
+	dev.sourceLock.Lock()
+	defer dev.sourceLock.Unlock()
+
+	varb, err := dev.source.FindGlobalVariable(target)
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	varb.Update()
+	addr, ok := varb.Address()
+	if !ok {
+		return 0, 0, "", fmt.Errorf("%s does not have an address in memory", target)
+	}
+
+	return uint32(addr), varb.Type.Size, target, nil
+}
+
+// DropWatchpoint removes the watchpoint with the index number shown by the
+// WriteWatchpoints() function
+func (dev *Developer) DropWatchpoint(idx int) error {
+	dev.watchpointsLock.Lock()
+	defer dev.watchpointsLock.Unlock()
+	err := dev.watchpoints.Drop(idx)
+	dev.watchpointsCount.Store(int32(dev.watchpoints.Count()))
+	return err
+}
+
+// ClearWatchpoints removes all watchpoints
+func (dev *Developer) ClearWatchpoints() {
+	dev.watchpointsLock.Lock()
+	defer dev.watchpointsLock.Unlock()
+	dev.watchpoints.Clear()
+	dev.watchpointsCount.Store(0)
+}
+
+// WriteWatchpoints writes a numbered list of all watchpoints to the io.Writer.
+// Returns false if there are no watchpoints
+func (dev *Developer) WriteWatchpoints(w io.Writer) bool {
+	dev.watchpointsLock.Lock()
+	defer dev.watchpointsLock.Unlock()
+	if dev.watchpoints.Count() == 0 {
+		return false
+	}
+	dev.watchpoints.Write(w)
+	return true
+}
+
+// CheckWatchpoint implements the coprocessor.CartCoProcDeveloper interface.
+func (dev *Developer) CheckWatchpoint(instructionAddr uint32, accessAddr uint32, size int, write bool) (bool, string) {
+	if dev.breakpointsInhibit || dev.watchpointsCount.Load() == 0 {
+		return false, ""
+	}
+
+	dev.watchpointsLock.Lock()
+	defer dev.watchpointsLock.Unlock()
+
+	wp, ok := dev.watchpoints.Check(accessAddr, size, write, dev.peekWatch)
+	if !ok {
+		return false, ""
+	}
+
+	access := "read"
+	if write {
+		access = "write"
+	}
+	detail := fmt.Sprintf("%s by %s of %08x", wp, access, accessAddr)
+
+	if dev.source != nil {
+		dev.sourceLock.Lock()
+		defer dev.sourceLock.Unlock()
+		if ln := dev.source.FindSourceLine(instructionAddr); ln != nil && !ln.IsStub() {
+			detail = fmt.Sprintf("%s at %s", detail, ln)
+		}
+	}
+
+	return true, detail
+}
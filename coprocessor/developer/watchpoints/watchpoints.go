@@ -0,0 +1,203 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package watchpoints
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Trigger specifies the type of memory access that will trigger a watchpoint.
+type Trigger int
+
+// List of valid Trigger values.
+const (
+	TriggerRead Trigger = iota
+	TriggerWrite
+	TriggerChange
+)
+
+func (t Trigger) String() string {
+	switch t {
+	case TriggerRead:
+		return "read"
+	case TriggerWrite:
+		return "write"
+	case TriggerChange:
+		return "change"
+	}
+	return "unknown"
+}
+
+// ParseTrigger converts a string to a Trigger value. The string is case
+// insensitive.
+func ParseTrigger(s string) (Trigger, error) {
+	switch strings.ToUpper(s) {
+	case "READ":
+		return TriggerRead, nil
+	case "WRITE":
+		return TriggerWrite, nil
+	case "CHANGE":
+		return TriggerChange, nil
+	}
+	return TriggerRead, fmt.Errorf("watchpoints: unknown trigger (%s)", s)
+}
+
+// Peek is used by the Check() function to retrieve the value in memory. The
+// size argument is the number of bytes to read and will be 1, 2 or 4.
+type Peek func(addr uint32, size int) (uint32, bool)
+
+// Watchpoint is a single range of memory being watched.
+type Watchpoint struct {
+	// the range of memory being watched
+	Addr uint32
+	Size int
+
+	Trigger Trigger
+
+	// a description of what is being watched. either the name of a variable
+	// or the address
+	Label string
+
+	// the most recent value in the watched memory. only used for TriggerChange
+	value uint32
+}
+
+func (p Watchpoint) String() string {
+	return fmt.Sprintf("%s on %s (%08x, %d bytes)", p.Trigger, p.Label, p.Addr, p.Size)
+}
+
+// overlaps returns true if the range of the memory access overlaps with the
+// watchpoint
+func (p Watchpoint) overlaps(addr uint32, size int) bool {
+	return addr < p.Addr+uint32(p.Size) && p.Addr < addr+uint32(size)
+}
+
+// Watchpoints is the list of all watchpoints.
+type Watchpoints struct {
+	watchpoints []Watchpoint
+}
+
+// NewWatchpoints is the preferred method of initialiasation for the
+// Watchpoints type.
+func NewWatchpoints() Watchpoints {
+	return Watchpoints{}
+}
+
+// Write writes out the list of watchpoints. Each watchpoint is numbered for
+// use with the Drop() function.
+func (wp *Watchpoints) Write(w io.Writer) {
+	for i, p := range wp.watchpoints {
+		fmt.Fprintf(w, "% 2d: %s\n", i, p)
+	}
+}
+
+// Count returns the number of watchpoints
+func (wp *Watchpoints) Count() int {
+	return len(wp.watchpoints)
+}
+
+// Add a new watchpoint. The peek function is used to record the current value
+// for watchpoints with a TriggerChange trigger.
+func (wp *Watchpoints) Add(p Watchpoint, peek Peek) error {
+	if p.Size <= 0 {
+		return fmt.Errorf("watchpoints: cannot watch %d bytes", p.Size)
+	}
+	if p.Trigger == TriggerChange && p.Size != 1 && p.Size != 2 && p.Size != 4 {
+		return fmt.Errorf("watchpoints: change trigger can only watch 1, 2 or 4 bytes")
+	}
+
+	for _, q := range wp.watchpoints {
+		if q.Addr == p.Addr && q.Size == p.Size && q.Trigger == p.Trigger {
+			return fmt.Errorf("watchpoints: already exists (%s)", q)
+		}
+	}
+
+	if p.Trigger == TriggerChange {
+		var ok bool
+		p.value, ok = peek(p.Addr, p.Size)
+		if !ok {
+			return fmt.Errorf("watchpoints: cannot read value at %08x", p.Addr)
+		}
+	}
+
+	wp.watchpoints = append(wp.watchpoints, p)
+	return nil
+}
+
+// Drop the watchpoint with the index number shown by the Write() function.
+func (wp *Watchpoints) Drop(idx int) error {
+	if idx < 0 || idx >= len(wp.watchpoints) {
+		return fmt.Errorf("watchpoints: no watchpoint numbered %d", idx)
+	}
+	wp.watchpoints = append(wp.watchpoints[:idx], wp.watchpoints[idx+1:]...)
+	return nil
+}
+
+// Clear all watchpoints.
+func (wp *Watchpoints) Clear() {
+	wp.watchpoints = wp.watchpoints[:0]
+}
+
+// Refresh the recorded value of every watchpoint with a TriggerChange trigger.
+// Should be called when memory has changed without Check() being called. For
+// example, after the emulation has been rewound.
+func (wp *Watchpoints) Refresh(peek Peek) {
+	for i := range wp.watchpoints {
+		p := &wp.watchpoints[i]
+		if p.Trigger == TriggerChange {
+			if v, ok := peek(p.Addr, p.Size); ok {
+				p.value = v
+			}
+		}
+	}
+}
+
+// Check whether a memory access triggers a watchpoint. For write accesses,
+// the Check() function should be called after the memory has been written so
+// that the peek function returns the new value.
+//
+// Returns the watchpoint that has been triggered and true; or an empty
+// Watchpoint instance and false if no watchpoint has been triggered.
+func (wp *Watchpoints) Check(addr uint32, size int, write bool, peek Peek) (Watchpoint, bool) {
+	for i := range wp.watchpoints {
+		p := &wp.watchpoints[i]
+		if !p.overlaps(addr, size) {
+			continue // for loop
+		}
+
+		switch p.Trigger {
+		case TriggerRead:
+			if !write {
+				return *p, true
+			}
+		case TriggerWrite:
+			if write {
+				return *p, true
+			}
+		case TriggerChange:
+			if write {
+				if v, ok := peek(p.Addr, p.Size); ok && v != p.value {
+					p.value = v
+					return *p, true
+				}
+			}
+		}
+	}
+
+	return Watchpoint{}, false
+}
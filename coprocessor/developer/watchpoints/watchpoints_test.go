@@ -0,0 +1,148 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package watchpoints_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/watchpoints"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// little endian memory suitable for use as a watchpoints.Peek function
+type memory []uint8
+
+func (mem memory) peek(addr uint32, size int) (uint32, bool) {
+	if int(addr)+size > len(mem) {
+		return 0, false
+	}
+	var v uint32
+	for i := size - 1; i >= 0; i-- {
+		v = (v << 8) | uint32(mem[int(addr)+i])
+	}
+	return v, true
+}
+
+func TestAdd(t *testing.T) {
+	mem := make(memory, 16)
+	wp := watchpoints.NewWatchpoints()
+
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 0, Size: 4, Trigger: watchpoints.TriggerRead}, mem.peek))
+	test.ExpectFailure(t, wp.Add(watchpoints.Watchpoint{Addr: 0, Size: 4, Trigger: watchpoints.TriggerRead}, mem.peek))
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 0, Size: 4, Trigger: watchpoints.TriggerWrite}, mem.peek))
+	test.ExpectFailure(t, wp.Add(watchpoints.Watchpoint{Addr: 4, Size: 0, Trigger: watchpoints.TriggerRead}, mem.peek))
+
+	// change watchpoints must be a size that can be peeked and must be
+	// readable when added
+	test.ExpectFailure(t, wp.Add(watchpoints.Watchpoint{Addr: 4, Size: 3, Trigger: watchpoints.TriggerChange}, mem.peek))
+	test.ExpectFailure(t, wp.Add(watchpoints.Watchpoint{Addr: 16, Size: 4, Trigger: watchpoints.TriggerChange}, mem.peek))
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 4, Size: 2, Trigger: watchpoints.TriggerChange}, mem.peek))
+
+	test.ExpectEquality(t, wp.Count(), 3)
+	test.ExpectFailure(t, wp.Drop(3))
+	test.ExpectSuccess(t, wp.Drop(0))
+	test.ExpectEquality(t, wp.Count(), 2)
+	wp.Clear()
+	test.ExpectEquality(t, wp.Count(), 0)
+}
+
+func TestCheckOverlap(t *testing.T) {
+	mem := make(memory, 16)
+	wp := watchpoints.NewWatchpoints()
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 4, Size: 4, Trigger: watchpoints.TriggerRead}, mem.peek))
+
+	// accesses that touch any byte of the watched range
+	for _, a := range []struct {
+		addr uint32
+		size int
+	}{
+		{addr: 4, size: 1},
+		{addr: 7, size: 1},
+		{addr: 2, size: 4},
+		{addr: 6, size: 4},
+		{addr: 0, size: 16},
+	} {
+		_, ok := wp.Check(a.addr, a.size, false, mem.peek)
+		test.ExpectSuccess(t, ok)
+	}
+
+	// accesses that are adjacent to but do not touch the watched range
+	for _, a := range []struct {
+		addr uint32
+		size int
+	}{
+		{addr: 3, size: 1},
+		{addr: 8, size: 1},
+		{addr: 0, size: 4},
+		{addr: 8, size: 4},
+	} {
+		_, ok := wp.Check(a.addr, a.size, false, mem.peek)
+		test.ExpectFailure(t, ok)
+	}
+}
+
+func TestCheckTrigger(t *testing.T) {
+	mem := make(memory, 16)
+	wp := watchpoints.NewWatchpoints()
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 0, Size: 1, Trigger: watchpoints.TriggerRead, Label: "r"}, mem.peek))
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 4, Size: 1, Trigger: watchpoints.TriggerWrite, Label: "w"}, mem.peek))
+
+	p, ok := wp.Check(0, 1, false, mem.peek)
+	test.ExpectSuccess(t, ok)
+	test.ExpectEquality(t, p.Label, "r")
+	_, ok = wp.Check(0, 1, true, mem.peek)
+	test.ExpectFailure(t, ok)
+
+	p, ok = wp.Check(4, 1, true, mem.peek)
+	test.ExpectSuccess(t, ok)
+	test.ExpectEquality(t, p.Label, "w")
+	_, ok = wp.Check(4, 1, false, mem.peek)
+	test.ExpectFailure(t, ok)
+}
+
+func TestCheckChange(t *testing.T) {
+	mem := make(memory, 16)
+	mem[8] = 0x12
+	mem[9] = 0x34
+
+	wp := watchpoints.NewWatchpoints()
+	test.ExpectSuccess(t, wp.Add(watchpoints.Watchpoint{Addr: 8, Size: 2, Trigger: watchpoints.TriggerChange}, mem.peek))
+
+	// reads never trigger a change watchpoint
+	mem[8] = 0x00
+	_, ok := wp.Check(8, 1, false, mem.peek)
+	test.ExpectFailure(t, ok)
+
+	// the write changed the value
+	_, ok = wp.Check(8, 1, true, mem.peek)
+	test.ExpectSuccess(t, ok)
+
+	// writing the same value is not a change
+	_, ok = wp.Check(8, 1, true, mem.peek)
+	test.ExpectFailure(t, ok)
+
+	// a change in the second byte is detected by a wider write
+	mem[9] = 0x00
+	_, ok = wp.Check(6, 4, true, mem.peek)
+	test.ExpectSuccess(t, ok)
+
+	// memory changed without the watchpoint being checked. after a refresh the
+	// next write of the same value is not a change
+	mem[8] = 0xff
+	wp.Refresh(mem.peek)
+	_, ok = wp.Check(8, 1, true, mem.peek)
+	test.ExpectFailure(t, ok)
+}
@@ -0,0 +1,75 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+package developer
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/watchpoints"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestResolveTarget(t *testing.T) {
+	dev := &Developer{}
+
+	addr, size, label, err := dev.resolveTarget("0x20000010")
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, addr, uint32(0x20000010))
+	test.ExpectEquality(t, size, 4)
+	test.ExpectEquality(t, label, "20000010")
+
+	addr, size, _, err = dev.resolveTarget("0x20000010:2")
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, addr, uint32(0x20000010))
+	test.ExpectEquality(t, size, 2)
+
+	for _, s := range []string{"0x20000010:", "0x20000010:0", "0x20000010:x"} {
+		_, _, _, err = dev.resolveTarget(s)
+		test.ExpectFailure(t, err)
+	}
+
+	// names containing colons are variables and there is no source to look
+	// them up in
+	_, _, _, err = dev.resolveTarget("game::count")
+	test.ExpectFailure(t, err)
+}
+
+func TestCheckWatchpoint(t *testing.T) {
+	dev := &Developer{watchpoints: watchpoints.NewWatchpoints()}
+
+	hit, _ := dev.CheckWatchpoint(0x100, 0x20000010, 4, true)
+	test.ExpectFailure(t, hit)
+
+	// the watchpoint covers two bytes only
+	test.ExpectSuccess(t, dev.AddWatchpoint("0x20000010:2", watchpoints.TriggerWrite))
+	test.ExpectEquality(t, dev.watchpointsCount.Load(), int32(1))
+
+	hit, _ = dev.CheckWatchpoint(0x100, 0x20000011, 1, true)
+	test.ExpectSuccess(t, hit)
+	hit, _ = dev.CheckWatchpoint(0x100, 0x20000012, 1, true)
+	test.ExpectFailure(t, hit)
+	hit, _ = dev.CheckWatchpoint(0x100, 0x20000010, 1, false)
+	test.ExpectFailure(t, hit)
+
+	test.ExpectSuccess(t, dev.AddWatchpoint("0x20000020", watchpoints.TriggerRead))
+	test.ExpectEquality(t, dev.watchpointsCount.Load(), int32(2))
+	test.ExpectSuccess(t, dev.DropWatchpoint(0))
+	test.ExpectEquality(t, dev.watchpointsCount.Load(), int32(1))
+
+	dev.ClearWatchpoints()
+	test.ExpectEquality(t, dev.watchpointsCount.Load(), int32(0))
+	hit, _ = dev.CheckWatchpoint(0x100, 0x20000020, 4, false)
+	test.ExpectFailure(t, hit)
+}
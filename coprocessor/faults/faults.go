@@ -40,6 +40,11 @@ const (
 	// an undefined symbol is encountered when a symbol has not been resolved at load time. the
 	// fault is raised whenever the symbol is encountered
 	UndefinedSymbol Category = "undefined symbol"
+
+	// a read of memory that has never been written to by the program. the
+	// value read will be whatever the memory happened to contain, which may
+	// differ between cartridges
+	UninitialisedRead Category = "uninitialised read"
 )
 
 // Entry is a single entry in the fault log
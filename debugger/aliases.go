@@ -0,0 +1,400 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
+	"github.com/jetsetilly/gopher2600/logger"
+	"github.com/jetsetilly/gopher2600/prefs"
+	"github.com/jetsetilly/gopher2600/resources"
+	"github.com/jetsetilly/gopher2600/resources/fs"
+)
+
+// the file in the resources directory that aliases are saved to
+const aliasesFile = "debuggerAliases"
+
+// the number of fields in each line of the aliases file
+const aliasesNumFields = 2
+
+// the separator between commands in an alias definition
+const aliasCommandSep = ";"
+
+// the maximum depth of nested alias calls. prevents an alias that calls
+// itself, directly or indirectly, from running forever
+const maxAliasDepth = 8
+
+// aliases are user defined commands. each alias is made up of one or more
+// commands separated by a semi-colon. commands can refer to the arguments
+// given to the alias with the %1 to %9 placeholders. the %* placeholder refers
+// to all the arguments
+type aliases struct {
+	// alias definitions indexed by the normalised alias name
+	definitions map[string]string
+
+	// the current depth of nested alias calls
+	depth int
+}
+
+func newAliases() *aliases {
+	return &aliases{
+		definitions: make(map[string]string),
+	}
+}
+
+// list of alias names in alphabetical order
+func (als *aliases) names() []string {
+	n := make([]string, 0, len(als.definitions))
+	for k := range als.definitions {
+		n = append(n, k)
+	}
+	slices.Sort(n)
+	return n
+}
+
+// split definition into the individual commands, expanding the positional
+// placeholders with the supplied arguments. arguments that contain spaces are
+// quoted so that they remain a single argument
+func expandAlias(definition string, args []string) ([]string, error) {
+	var cmds []string
+
+	args = slices.Clone(args)
+	for i := range args {
+		if strings.Contains(args[i], " ") {
+			args[i] = fmt.Sprintf("\"%s\"", args[i])
+		}
+	}
+
+	for c := range strings.SplitSeq(definition, aliasCommandSep) {
+		c = strings.TrimSpace(c)
+		if len(c) == 0 {
+			continue
+		}
+
+		s := strings.Builder{}
+		for i := 0; i < len(c); i++ {
+			if c[i] != '%' || i+1 >= len(c) {
+				s.WriteByte(c[i])
+				continue
+			}
+
+			switch p := c[i+1]; {
+			case p == '*':
+				s.WriteString(strings.Join(args, " "))
+				i++
+			case p >= '1' && p <= '9':
+				n := int(p - '1')
+				if n >= len(args) {
+					return nil, fmt.Errorf("argument %%%c is required", p)
+				}
+				s.WriteString(args[n])
+				i++
+			default:
+				s.WriteByte(c[i])
+			}
+		}
+
+		cmds = append(cmds, s.String())
+	}
+
+	return cmds, nil
+}
+
+// checks that each command in the definition is a valid command. commands
+// that refer to arguments are validated fully only when the alias is run
+func checkAliasDefinition(name string, definition string) error {
+	var ct int
+
+	for c := range strings.SplitSeq(definition, aliasCommandSep) {
+		c = strings.TrimSpace(c)
+		if len(c) == 0 {
+			continue
+		}
+		ct++
+
+		c, _, err := splitRedirect(c)
+		if err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+
+		tokens := commandline.TokeniseInput(c)
+		cmd, _ := tokens.Peek()
+		cmd = strings.ToUpper(cmd)
+
+		if cmd == name {
+			return fmt.Errorf("alias %s cannot refer to itself", name)
+		}
+
+		if !debuggerCommands.HasCommand(cmd) {
+			return fmt.Errorf("unrecognised command (%s) in alias %s", cmd, name)
+		}
+
+		if !strings.Contains(c, "%") {
+			err := debuggerCommands.ValidateTokens(tokens)
+			if err != nil {
+				return fmt.Errorf("alias %s: %w", name, err)
+			}
+		}
+	}
+
+	if ct == 0 {
+		return fmt.Errorf("alias %s has no commands", name)
+	}
+
+	return nil
+}
+
+// define a new alias or redefine an existing alias
+func (dbg *Debugger) defineAlias(name string, definition string) error {
+	name = strings.ToUpper(name)
+	definition = strings.TrimSpace(definition)
+
+	if !debuggerCommands.IsAlias(name) && debuggerCommands.HasCommand(name) {
+		return fmt.Errorf("%s is an existing command and cannot be an alias", name)
+	}
+
+	err := checkAliasDefinition(name, definition)
+	if err != nil {
+		return err
+	}
+
+	err = debuggerCommands.AddAlias(name)
+	if err != nil {
+		return err
+	}
+
+	dbg.aliases.definitions[name] = definition
+
+	return dbg.saveAliases()
+}
+
+// remove alias
+func (dbg *Debugger) dropAlias(name string) error {
+	name = strings.ToUpper(name)
+
+	if _, ok := dbg.aliases.definitions[name]; !ok {
+		return fmt.Errorf("%s is not an alias", name)
+	}
+
+	err := debuggerCommands.RemoveAlias(name)
+	if err != nil {
+		return err
+	}
+
+	delete(dbg.aliases.definitions, name)
+
+	return dbg.saveAliases()
+}
+
+// remove all aliases
+func (dbg *Debugger) clearAliases() error {
+	for _, n := range dbg.aliases.names() {
+		err := debuggerCommands.RemoveAlias(n)
+		if err != nil {
+			return err
+		}
+		delete(dbg.aliases.definitions, n)
+	}
+
+	return dbg.saveAliases()
+}
+
+// print the definition of the alias to the terminal. if name is empty then all
+// aliases are printed
+func (dbg *Debugger) listAliases(name string) {
+	if name != "" {
+		name = strings.ToUpper(name)
+		if d, ok := dbg.aliases.definitions[name]; ok {
+			dbg.printLine(terminal.StyleFeedback, "%s = %s", name, d)
+		} else {
+			dbg.printLine(terminal.StyleError, "%s is not an alias", name)
+		}
+		return
+	}
+
+	if len(dbg.aliases.definitions) == 0 {
+		dbg.printLine(terminal.StyleFeedback, "no aliases defined")
+		return
+	}
+
+	for _, n := range dbg.aliases.names() {
+		dbg.printLine(terminal.StyleFeedback, "%s = %s", n, dbg.aliases.definitions[n])
+	}
+}
+
+// run the alias with the remaining tokens as arguments
+func (dbg *Debugger) runAlias(name string, tokens *commandline.Tokens) error {
+	name = strings.ToUpper(name)
+
+	definition, ok := dbg.aliases.definitions[name]
+	if !ok {
+		return fmt.Errorf("%s is not an alias", name)
+	}
+
+	if dbg.aliases.depth >= maxAliasDepth {
+		return fmt.Errorf("alias %s: too many nested aliases", name)
+	}
+	dbg.aliases.depth++
+	defer func() {
+		dbg.aliases.depth--
+	}()
+
+	var args []string
+	for arg, ok := tokens.Get(); ok; arg, ok = tokens.Get() {
+		args = append(args, arg)
+	}
+
+	cmds, err := expandAlias(definition, args)
+	if err != nil {
+		return fmt.Errorf("alias %s: %w", name, err)
+	}
+
+	for _, c := range cmds {
+		c, rdr, err := splitRedirect(c)
+		if err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+		toks := commandline.TokeniseInput(c)
+		err = debuggerCommands.ValidateTokens(toks)
+		if err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+		err = dbg.processRedirect(toks, rdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// save aliases to disk
+//
+// uses a similar method to the prefs package and in fact references the prefs
+// package for consistency
+func (dbg *Debugger) saveAliases() (rerr error) {
+	pth, err := resources.JoinPath(aliasesFile)
+	if err != nil {
+		return fmt.Errorf("aliases: %w", err)
+	}
+
+	f, err := fs.Create(pth)
+	if err != nil {
+		return fmt.Errorf("aliases: %w", err)
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			rerr = fmt.Errorf("aliases: %w", err)
+		}
+	}()
+
+	// write boiler plate warning to aliases file
+	s := fmt.Sprintf("%s\n", prefs.WarningBoilerPlate)
+	n, err := fmt.Fprint(f, s)
+	if err != nil {
+		return fmt.Errorf("aliases: %w", err)
+	}
+	if n != len(s) {
+		return fmt.Errorf("aliases: incorrect number of characters written to file")
+	}
+
+	for _, a := range dbg.aliases.names() {
+		s := fmt.Sprintf("%s%s%s\n", a, prefs.KeySep, dbg.aliases.definitions[a])
+		n, err := fmt.Fprint(f, s)
+		if err != nil {
+			return fmt.Errorf("aliases: %w", err)
+		}
+		if n != len(s) {
+			return fmt.Errorf("aliases: incorrect number of characters written to file")
+		}
+	}
+
+	return nil
+}
+
+// load aliases from disk. aliases that are no longer valid are dropped and
+// logged
+//
+// called once on startup
+func (dbg *Debugger) loadAliases() (rerr error) {
+	pth, err := resources.JoinPath(aliasesFile)
+	if err != nil {
+		return fmt.Errorf("aliases: %w", err)
+	}
+
+	f, err := fs.Open(pth)
+	if err != nil {
+		var pathError *os.PathError
+		if errors.As(err, &pathError) {
+			return nil
+		}
+		return fmt.Errorf("aliases: %w", err)
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			rerr = fmt.Errorf("aliases: %w", err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(f)
+
+	// check validity of file by checking the first line for the boiler plate warning
+	scanner.Scan()
+	if len(scanner.Text()) > 0 && scanner.Text() != prefs.WarningBoilerPlate {
+		return fmt.Errorf("aliases: not a valid aliases file (%s)", pth)
+	}
+
+	for scanner.Scan() {
+		spt := strings.SplitN(scanner.Text(), prefs.KeySep, aliasesNumFields)
+		if len(spt) != aliasesNumFields {
+			continue
+		}
+
+		name := strings.ToUpper(spt[0])
+		definition := spt[1]
+
+		// adding the alias to the list of commands before checking the
+		// definition means that aliases can refer to other aliases regardless
+		// of the order in which they appear in the file
+		err := debuggerCommands.AddAlias(name)
+		if err != nil {
+			logger.Logf(logger.Allow, "aliases", "dropped %s: %v", name, err)
+			continue
+		}
+		dbg.aliases.definitions[name] = definition
+	}
+
+	// check definitions now that all aliases have been added
+	for _, n := range dbg.aliases.names() {
+		err := checkAliasDefinition(n, dbg.aliases.definitions[n])
+		if err != nil {
+			logger.Logf(logger.Allow, "aliases", "dropped %s: %v", n, err)
+			_ = debuggerCommands.RemoveAlias(n)
+			delete(dbg.aliases.definitions, n)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/prefs"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// the aliases file in the resources directory. the working directory is
+// changed to a temporary directory by TestDebugger()
+var aliasesFile = filepath.Join(".gopher2600", "debuggerAliases")
+
+// write an aliases file to be loaded when the debugger is created. the BAD
+// alias is not a valid alias and will be dropped
+func writeAliases(t *testing.T) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(aliasesFile), 0700)
+	test.ExpectSuccess(t, err)
+
+	s := strings.Builder{}
+	fmt.Fprintln(&s, prefs.WarningBoilerPlate)
+	fmt.Fprintf(&s, "LOADED%sLIST TRAPS\n", prefs.KeySep)
+	fmt.Fprintf(&s, "BAD%sNOT A COMMAND\n", prefs.KeySep)
+	err = os.WriteFile(aliasesFile, []byte(s.String()), 0600)
+	test.ExpectSuccess(t, err)
+}
+
+func testAliases(t *testing.T, trm *mockTerm) {
+	// aliases loaded from disk
+	trm.command("ALIAS LOADED")
+	test.ExpectEquality(t, trm.lastLine(), "LOADED = LIST TRAPS")
+	trm.command("LOADED")
+	test.ExpectEquality(t, trm.lastLine(), " 0: A")
+	trm.command("ALIAS BAD")
+	test.ExpectEquality(t, trm.lastLine(), "BAD is not an alias")
+
+	// quoting in the definition is preserved
+	trm.command(`ALIAS SYM = SYMBOL "A B"`)
+	test.ExpectEquality(t, trm.lastLine(), `SYM = SYMBOL "A B"`)
+	trm.command("SYM")
+	test.ExpectEquality(t, trm.lastLine(), "A B not found in any symbol table")
+
+	// arguments to the alias
+	trm.command("ALIAS ARG = SYMBOL %1")
+	test.ExpectEquality(t, trm.lastLine(), "ARG = SYMBOL %1")
+	trm.command("ARG C")
+	test.ExpectEquality(t, trm.lastLine(), "C not found in any symbol table")
+	trm.command(`ARG "C D"`)
+	test.ExpectEquality(t, trm.lastLine(), "C D not found in any symbol table")
+	trm.command("ARG")
+	test.ExpectEquality(t, trm.lastLine(), "alias ARG: argument %1 is required")
+
+	// more than one command in the alias
+	trm.command("ALIAS ALL = SYMBOL E; SYMBOL %*")
+	test.ExpectEquality(t, trm.lastLine(), "ALL = SYMBOL E; SYMBOL %*")
+	trm.command("ALL F")
+	trm.response()
+	test.ExpectEquality(t, len(trm.output), 2)
+	test.ExpectEquality(t, trm.output[0], "E not found in any symbol table")
+	test.ExpectEquality(t, trm.output[1], "F not found in any symbol table")
+
+	// an alias can not be an existing command or refer to itself
+	trm.command("ALIAS TRAP = LIST TRAPS")
+	test.ExpectEquality(t, trm.lastLine(), "TRAP is an existing command and cannot be an alias")
+	trm.command("ALIAS SELF = SELF")
+	test.ExpectEquality(t, trm.lastLine(), "alias SELF cannot refer to itself")
+
+	// aliases that refer to each other do not run forever
+	trm.command("ALIAS R1 = LIST TRAPS")
+	trm.command("ALIAS R2 = R1")
+	trm.command("ALIAS R1 = R2")
+	test.ExpectEquality(t, trm.lastLine(), "R1 = R2")
+	trm.command("R1")
+	test.ExpectSuccess(t, strings.HasSuffix(trm.lastLine(), "too many nested aliases"))
+
+	// aliases are saved to disk as they are defined
+	b, err := os.ReadFile(aliasesFile)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, string(b), fmt.Sprintf(`%s
+ALL%sSYMBOL E; SYMBOL %%*
+ARG%sSYMBOL %%1
+LOADED%sLIST TRAPS
+R1%sR2
+R2%sR1
+SYM%sSYMBOL "A B"
+`, prefs.WarningBoilerPlate, prefs.KeySep, prefs.KeySep, prefs.KeySep, prefs.KeySep, prefs.KeySep, prefs.KeySep))
+
+	trm.command("ALIAS DROP SYM")
+	test.ExpectEquality(t, trm.lastLine(), "alias SYM dropped")
+	trm.command("ALIAS SYM")
+	test.ExpectEquality(t, trm.lastLine(), "SYM is not an alias")
+
+	trm.command("ALIAS CLEAR")
+	test.ExpectEquality(t, trm.lastLine(), "aliases cleared")
+	b, err = os.ReadFile(aliasesFile)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, string(b), prefs.WarningBoilerPlate+"\n")
+}
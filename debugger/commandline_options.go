@@ -36,19 +36,31 @@ type CommandLineOptions struct {
 	Keyportari string
 	Profile    string
 	DWARF      string
+	Trace      string
+
+	// export coprocessor profiling. same arguments as COPROC PROFILE
+	CoProcProfile string
+
+	// coprocessor cycle budget file. if empty then the budget sidecar file is
+	// used if it exists
+	CoProcBudget string
 
 	// playmode only
-	ComparisonROM        string
-	ComparisonPrefs      string
-	Record               bool
-	RecordFilename       string
-	PlaybackCheckROM     bool
-	PlaybackIgnoreDigest bool
-	PatchFile            string
-	Wav                  bool
-	Video                bool
-	NoEject              bool
-	Macro                string
+	ComparisonROM         string
+	ComparisonPrefs       string
+	ComparisonLockstep    string
+	ComparisonReference   string
+	ComparisonTraceFormat string
+	ComparisonDigests     string
+	Record                bool
+	RecordFilename        string
+	PlaybackCheckROM      bool
+	PlaybackIgnoreDigest  bool
+	PatchFile             string
+	Wav                   bool
+	Video                 bool
+	NoEject               bool
+	Macro                 string
 
 	// debugger only
 	Script string
@@ -67,8 +79,15 @@ func (opts *CommandLineOptions) Default() {
 	opts.Keyportari = "NONE"
 	opts.Profile = "none"
 	opts.DWARF = ""
+	opts.Trace = ""
+	opts.CoProcProfile = ""
+	opts.CoProcBudget = ""
 	opts.ComparisonROM = ""
 	opts.ComparisonPrefs = ""
+	opts.ComparisonLockstep = "NONE"
+	opts.ComparisonReference = ""
+	opts.ComparisonTraceFormat = "GOPHER"
+	opts.ComparisonDigests = ""
 	opts.Record = false
 	opts.RecordFilename = ""
 	opts.PlaybackCheckROM = true
@@ -25,9 +25,11 @@ import (
 	"strings"
 
 	"github.com/jetsetilly/gopher2600/coprocessor"
+	coproc_dev "github.com/jetsetilly/gopher2600/coprocessor/developer"
 	coproc_breakpoints "github.com/jetsetilly/gopher2600/coprocessor/developer/breakpoints"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/callstack"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/watchpoints"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/yield"
 	"github.com/jetsetilly/gopher2600/coprocessor/faults"
 	"github.com/jetsetilly/gopher2600/debugger/dbgmem"
@@ -79,8 +81,19 @@ func init() {
 	}
 }
 
-// parseCommand tokenises the input and processes the tokens.
+// parseCommand tokenises the input and processes the tokens. Output from the
+// command can be redirected to a file or filtered with GREP. See the
+// splitRedirect() function for details.
 func (dbg *Debugger) parseCommand(cmd string, batch bool, echo bool) error {
+	cmd, rdr, err := splitRedirect(cmd)
+	if err != nil {
+		return err
+	}
+
+	if rdr != nil && strings.TrimSpace(cmd) == "" {
+		return fmt.Errorf("redirect: no command to redirect")
+	}
+
 	tokens, err := dbg.tokeniseCommand(cmd, batch, echo)
 	if err != nil {
 		return err
@@ -88,7 +101,47 @@ func (dbg *Debugger) parseCommand(cmd string, batch bool, echo bool) error {
 	if tokens == nil {
 		return nil
 	}
-	return dbg.processTokens(tokens)
+
+	// the redirection should be included in the script that is being
+	// recorded. replace the input line written by tokeniseCommand() with one
+	// that includes the redirection
+	if rdr != nil && dbg.scriptWrite.IsActive() {
+		dbg.scriptWrite.Rollback()
+		dbg.scriptWrite.WriteInput(fmt.Sprintf("%s %s", tokens.String(), rdr.String()))
+	}
+
+	return dbg.processRedirect(tokens, rdr)
+}
+
+// process tokens with output sent to the redirection. the redirection can be
+// nil, in which case output is not redirected. redirections nest, so that the
+// output of a command in an alias can be filtered before being sent to the
+// redirection of the alias
+func (dbg *Debugger) processRedirect(tokens *commandline.Tokens, rdr *redirect) error {
+	if rdr == nil {
+		return dbg.processTokens(tokens)
+	}
+
+	rdr.term = dbg.term
+	if dbg.redirect != nil {
+		rdr.term = dbg.redirect
+	}
+
+	err := rdr.open()
+	if err != nil {
+		return err
+	}
+
+	prev := dbg.redirect
+	dbg.redirect = rdr
+	err = dbg.processTokens(tokens)
+	dbg.redirect = prev
+
+	if cerr := rdr.close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	return err
 }
 
 // return tokenised command.
@@ -158,6 +211,10 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 	tokens.Reset()
 	command, _ := tokens.Get()
 
+	if debuggerCommands.IsAlias(command) {
+		return dbg.runAlias(command, tokens)
+	}
+
 	switch command {
 	default:
 		return fmt.Errorf("%s is not yet implemented", command)
@@ -165,6 +222,14 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 	case commandline.HelpCommand:
 		if topic, ok := tokens.Get(); ok {
 			topic = strings.ToUpper(topic)
+
+			// help for an alias is the alias definition
+			if debuggerCommands.IsAlias(topic) {
+				dbg.printLine(terminal.StyleHelp, "%s is an alias for: %s", topic, dbg.aliases.definitions[topic])
+				dbg.scriptWrite.Rollback()
+				return nil
+			}
+
 			dbg.printLine(terminal.StyleHelp, helps[topic])
 
 			// also print usage command if the command has arguments
@@ -462,6 +527,20 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 				if dbg.State() == govern.Running {
 					dbg.Rewind.UpdateComparison()
 				}
+			case "EMULATION":
+				if dbg.comparison == nil {
+					dbg.printLine(terminal.StyleFeedback, "no comparison emulation")
+					return nil
+				}
+				fd, ok := dbg.comparison.LastDiff()
+				if !ok {
+					dbg.printLine(terminal.StyleFeedback, "comparison emulation has not completed a frame")
+					return nil
+				}
+				dbg.printLine(terminal.StyleFeedback, "comparison with %s", dbg.comparison)
+				for _, l := range strings.Split(fd.Summary(), "\n") {
+					dbg.printLine(terminal.StyleFeedback, "%s", l)
+				}
 			default:
 				frame, _ := strconv.Atoi(arg)
 				dbg.Rewind.SetComparison(frame)
@@ -663,6 +742,30 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 
 		s, _ := tokens.Get()
 		switch strings.ToUpper(s) {
+		case "BYTES":
+			var fields []string
+			for f, ok := tokens.Get(); ok; f, ok = tokens.Get() {
+				fields = append(fields, f)
+			}
+
+			pattern, err := disassembly.ParseBytePattern(fields)
+			if err != nil {
+				dbg.printLine(terminal.StyleError, err.Error())
+				return nil
+			}
+
+			matches, err := dbg.Disasm.GrepBytes(pattern)
+			if err != nil {
+				return err
+			}
+			if len(matches) == 0 {
+				dbg.printLine(terminal.StyleError, "%s not found in cartridge", pattern)
+			} else {
+				disassembly.WriteByteMatches(output, matches)
+				dbg.printLine(terminal.StyleFeedback, strings.TrimSuffix(output.String(), "\n"))
+			}
+			return nil
+
 		case "COPROC":
 			search, _ := tokens.Get()
 
@@ -712,6 +815,17 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 			dbg.printLine(terminal.StyleFeedback, output.String())
 		}
 
+	case cmdCycles:
+		from, _ := tokens.Get()
+		to, _ := tokens.Get()
+
+		ca, err := dbg.Disasm.Cycles(from, to)
+		if err != nil {
+			dbg.printLine(terminal.StyleError, err.Error())
+			return nil
+		}
+		ca.Write(dbg.writerInStyle(terminal.StyleFeedback))
+
 	case cmdSymbol:
 		tok, _ := tokens.Get()
 		switch strings.ToUpper(tok) {
@@ -1657,11 +1771,163 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 				dbg.printLine(terminal.StyleError, fmt.Sprintf("cannot set coproc register %d to %08x\n", reg, value))
 			}
 
+		case "PROFILE":
+			return dbg.parseCoProcProfileCommand(tokens)
+
+		case "BUDGET":
+			return dbg.parseCoProcBudgetCommand(tokens)
+
+		case "CONSOLE":
+			if arg, ok := tokens.Get(); ok && arg == "CLEAR" {
+				dbg.coprocConsole = dbg.coprocConsole[:0]
+				return nil
+			}
+			if len(dbg.coprocConsole) == 0 {
+				dbg.printLine(terminal.StyleFeedback, "no coprocessor console output")
+				return nil
+			}
+			for _, l := range dbg.coprocConsole {
+				dbg.printLine(terminal.StyleCoProcConsole, l)
+			}
+
+		case "WATCH":
+			arg, ok := tokens.Get()
+			if !ok {
+				if !dbg.CoProcDev.WriteWatchpoints(dbg.writerInStyle(terminal.StyleFeedback)) {
+					dbg.printLine(terminal.StyleFeedback, "no coprocessor watchpoints")
+				}
+				return nil
+			}
+
+			switch arg {
+			case "DROP":
+				arg, ok := tokens.Get()
+				if !ok {
+					return fmt.Errorf("watchpoint number required")
+				}
+				n, err := strconv.Atoi(arg)
+				if err != nil {
+					return fmt.Errorf("%s is not a number", arg)
+				}
+				err = dbg.CoProcDev.DropWatchpoint(n)
+				if err != nil {
+					return err
+				}
+				dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("coprocessor watchpoint #%d dropped", n))
+
+			case "CLEAR":
+				dbg.CoProcDev.ClearWatchpoints()
+				dbg.printLine(terminal.StyleFeedback, "coprocessor watchpoints cleared")
+
+			default:
+				trigger, err := watchpoints.ParseTrigger(arg)
+				if err != nil {
+					return err
+				}
+				target, ok := tokens.Get()
+				if !ok {
+					return fmt.Errorf("address or variable required for watchpoint")
+				}
+				err = dbg.CoProcDev.AddWatchpoint(target, trigger)
+				if err != nil {
+					return err
+				}
+			}
+
 		case "STEP":
-			dbg.CoProcDev.BreakNextInstruction()
+			if arg, ok := tokens.Get(); ok {
+				if strings.ToUpper(arg) == "BACK" {
+					n := 1
+					if arg, ok := tokens.Get(); ok {
+						var err error
+						n, err = strconv.Atoi(arg)
+						if err != nil {
+							return fmt.Errorf("%s is not a number", arg)
+						}
+					}
+					n, err := dbg.CoProcDev.StepBack(n)
+					if err != nil {
+						return err
+					}
+					dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("stepped back %d coprocessor instructions", n))
+					return nil
+				}
+
+				var mode coproc_dev.StepMode
+				switch strings.ToUpper(arg) {
+				case "OVER":
+					mode = coproc_dev.StepOver
+				case "INTO":
+					mode = coproc_dev.StepInto
+				case "OUT":
+					mode = coproc_dev.StepOut
+				default:
+					return fmt.Errorf("unknown step mode (%s)", arg)
+				}
+				err := dbg.CoProcDev.Step(mode)
+				if err != nil {
+					return err
+				}
+			} else {
+				dbg.CoProcDev.BreakNextInstruction()
+			}
 			dbg.runUntilHalt = true
 			dbg.continueEmulation = true
 
+		case "TRACE":
+			arg, ok := tokens.Get()
+			if !ok {
+				enabled, limit, n := dbg.CoProcDev.Tracing()
+				if enabled {
+					dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("coprocessor tracing is on: %d of %d instructions recorded", n, limit))
+				} else {
+					dbg.printLine(terminal.StyleFeedback, "coprocessor tracing is off")
+				}
+				return nil
+			}
+
+			switch arg {
+			case "ON":
+				var limit int
+				if arg, ok := tokens.Get(); ok {
+					var err error
+					limit, err = strconv.Atoi(arg)
+					if err != nil {
+						return fmt.Errorf("%s is not a number", arg)
+					}
+				}
+				err := dbg.CoProcDev.SetTracing(true, limit)
+				if err != nil {
+					return err
+				}
+				dbg.printLine(terminal.StyleFeedback, "coprocessor tracing is on")
+
+			case "OFF":
+				err := dbg.CoProcDev.SetTracing(false, 0)
+				if err != nil {
+					return err
+				}
+				dbg.printLine(terminal.StyleFeedback, "coprocessor tracing is off")
+
+			case "LIST":
+				n := 20
+				if arg, ok := tokens.Get(); ok {
+					var err error
+					n, err = strconv.Atoi(arg)
+					if err != nil {
+						return fmt.Errorf("%s is not a number", arg)
+					}
+				}
+				return dbg.CoProcDev.WriteTrace(dbg.writerInStyle(terminal.StyleFeedback), n)
+
+			case "HISTORY":
+				target, ok := tokens.Get()
+				if !ok {
+					return fmt.Errorf("address or variable required for trace history")
+				}
+				return dbg.CoProcDev.WriteTraceHistory(dbg.writerInStyle(terminal.StyleFeedback), target)
+			}
+
 		case "ID":
 			fallthrough
 		default:
@@ -1792,52 +2058,20 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 				return nil
 			}
 
-			// option is divided by a maximum of one colon, meaning the split
-			// array should be a length of two
-			s := strings.Split(arg, ":")
-			if len(s) != 2 {
-				dbg.printLine(terminal.StyleError, "command requires argument file:line")
-				return nil
-			}
-
-			// filename and line number
-			fn := s[0]
-			n, err := strconv.ParseInt(s[1], 0, 32)
-			if err != nil {
-				dbg.printLine(terminal.StyleError, fmt.Sprintf("%s is not a number", s[1]))
-				return nil
-			}
-			ln := int(n)
-
 			dbg.CoProcDev.BorrowSource(func(src *dwarf.Source) {
-				if src == nil {
-					dbg.printLine(terminal.StyleError, "no source available")
-				}
-
-				// check file by shortname and then by full name
-				f, ok := src.FilesByShortname[fn]
-				if !ok {
-					f, ok = src.Files[fn]
-					if !ok {
-						dbg.printLine(terminal.StyleError, fmt.Sprintf("no file named %s", fn))
-						return
-					}
-				}
-
-				// line numbers are counted from one
-				if ln < 1 {
-					ln = 1
-				}
-				if ln > len(f.Content.Lines) {
-					dbg.printLine(terminal.StyleError, fmt.Sprintf("%s only has %d lines", fn, len(f.Content.Lines)))
+				ln, err := findSourceLine(src, arg)
+				if err != nil {
+					dbg.printLine(terminal.StyleError, err.Error())
 					return
 				}
-				l := f.Content.Lines[ln-1]
 
 				// display what we know about line
-				dbg.printLine(terminal.StyleFeedback, l.String())
+				dbg.printLine(terminal.StyleFeedback, ln.String())
 			})
 
+		case "BREAK":
+			return dbg.parseDWARFBreakCommand(tokens)
+
 		case "CALLSTACK":
 			dbg.CoProcDev.BorrowCallStack(func(callstack callstack.CallStack) {
 				w := dbg.writerInStyle(terminal.StyleFeedback)
@@ -1857,6 +2091,49 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 					return
 				}
 			})
+
+		case "MEMBERS":
+			arg, ok := tokens.Get()
+			if !ok {
+				dbg.printLine(terminal.StyleError, "variable name is required")
+				return nil
+			}
+			dbg.CoProcDev.BorrowSource(func(src *dwarf.Source) {
+				if src == nil {
+					dbg.printLine(terminal.StyleError, "no source available")
+					return
+				}
+
+				varb, err := src.FindGlobalVariable(arg)
+				if err != nil {
+					dbg.printLine(terminal.StyleError, err.Error())
+					return
+				}
+				varb.Update()
+
+				// pointers are not followed because the structure being pointed
+				// to may be very large or may point back to the variable.
+				// references are followed but a reference can also point back
+				// to the variable so the depth of the output is limited
+				const maxDepth = 8
+
+				var members func(varb *dwarf.SourceVariable, depth int)
+				members = func(varb *dwarf.SourceVariable, depth int) {
+					indent := strings.Repeat("\t", depth)
+					dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("%s%s", indent, varb.String()))
+					if varb.Type.IsPointer() && !varb.Type.IsReference() {
+						return
+					}
+					if varb.NumChildren() > 0 && depth >= maxDepth {
+						dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("%s\t...", indent))
+						return
+					}
+					for i := 0; i < varb.NumChildren(); i++ {
+						members(varb.Child(i), depth+1)
+					}
+				}
+				members(varb, 0)
+			})
 		}
 
 	case cmdScreenshot:
@@ -2155,6 +2432,11 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 		}
 
 	case cmdTrace:
+		if arg, _ := tokens.Peek(); strings.ToUpper(arg) == "FILE" {
+			tokens.Get()
+			return dbg.parseTraceFileCommand(tokens)
+		}
+
 		err := dbg.traces.parseCommand(tokens)
 		if err != nil {
 			return err
@@ -2250,6 +2532,46 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) error {
 			// already caught by command line ValidateTokens()
 		}
 
+	case cmdAlias:
+		option, ok := tokens.Get()
+		if !ok {
+			dbg.listAliases("")
+			return nil
+		}
+
+		switch strings.ToUpper(option) {
+		case "LIST":
+			dbg.listAliases("")
+		case "DROP":
+			name, _ := tokens.Get()
+			err := dbg.dropAlias(name)
+			if err != nil {
+				return err
+			}
+			dbg.printLine(terminal.StyleFeedback, "alias %s dropped", strings.ToUpper(name))
+		case "CLEAR":
+			err := dbg.clearAliases()
+			if err != nil {
+				return err
+			}
+			dbg.printLine(terminal.StyleFeedback, "aliases cleared")
+		default:
+			name := option
+			if _, ok := tokens.Get(); !ok {
+				dbg.listAliases(name)
+				return nil
+			}
+
+			// the definition is taken from the raw input so that quoting in
+			// the definition is preserved
+			_, definition, _ := strings.Cut(tokens.Input(), "=")
+			err := dbg.defineAlias(name, definition)
+			if err != nil {
+				return err
+			}
+			dbg.listAliases(name)
+		}
+
 	case cmdLog:
 		option, ok := tokens.Get()
 		if ok {
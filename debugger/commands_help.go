@@ -18,7 +18,18 @@ package debugger
 import "github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
 
 var helps = map[string]string{
-	commandline.HelpCommand: "Lists commands and provides help for individual commands.",
+	commandline.HelpCommand: `Lists commands and provides help for individual commands.
+
+The output of any command can be filtered or redirected to a file:
+
+  LIST BREAKS | GREP pattern    prints only lines that contain the pattern
+  LIST BREAKS > filename        writes the output to the file
+  LIST BREAKS >> filename       appends the output to the file
+
+GREP can be repeated to further filter the output. Redirection to a file must
+come at the end of the command. Errors are always printed to the terminal. A DWARF
+BREAK command with an IF condition can not be redirected because the condition
+may contain the > and | characters.`,
 
 	cmdReload: `Reset the emulated machine (including television) to its initial state by reloading the cartridge.
 The disassembly will also be recreated including any new symbols loaded. Breakpoints etc. are not reset.`,
@@ -83,7 +94,16 @@ be 'current' execution state. If numbered frame is not in rewind history,
 emulation will move to the nearest frame that is.`,
 
 	cmdComparison: `Alter the comparison state. The comparison state is used to highlight
-differences in RAM displays, for example.`,
+differences in RAM displays, for example.
+
+The EMULATION argument does not alter the comparison state. Instead it prints a
+summary of the differences between the most recent frame of the comparison
+emulation (see the -comparisonROM option) and the corresponding frame of the
+main emulation. The summary includes the number of pixels and audio samples
+that differ, and the RAM addresses that differ at the end of the frame.
+
+The audio of the comparison emulation is mixed with the audio of the main
+emulation. The difference signal can be seen in the comparison window.`,
 
 	cmdGoto: `Run emulation to the specified clock, scanline, frame. Note that the values
 are specified in what might be considered the "reverse" order. This means the scanline and
@@ -111,7 +131,39 @@ of the specific cartridge address.`,
 in the disassembly to the termain.
 
 The scope of the GREP can be restricted to the OPERATOR and OPERAND columns. By
-default GREP will consider the entire line.`,
+default GREP will consider the entire line.
+
+GREP BYTES searches the cartridge ROM, RAM and coprocessor static areas for a
+sequence of bytes. Bytes are given in hexadecimal. A ? can be used in place of
+either digit to match any value in that nibble, or ?? to match any byte. An
+explicit mask can be given after a slash. For example:
+
+  GREP BYTES a9 ?? 85 0?
+  GREP BYTES 80/f0 ea
+
+The bank, offset and mapped address of every match is printed.
+
+GREP can also be used to filter the output of another command. For example,
+LIST TRAPS | GREP pattern. See HELP for more information.`,
+
+	cmdCycles: `Static analysis of the number of CPU cycles between two labels or addresses in the
+disassembly. Every path between the two points is followed, including both directions of every
+branch, and the number of cycles for each path is listed. The cost of a taken branch, including any
+page-crossing penalty, is included in the count.
+
+	CYCLES kernel overscan
+
+Instructions that might incur a page-crossing penalty, such as indexed addressing instructions, are
+counted as a range of cycles. The cycles for the instruction at the end point are not included.
+
+If the two points are the same then the paths that return to that point are counted. This is useful
+for measuring the cycles taken by a loop.
+
+Loops that do not pass through the end point cannot be bounded and will be reported as a warning.
+Paths that return from a subroutine, that jump indirectly or that leave the cartridge cannot be
+followed and will also be reported as a warning.
+
+Both points must be in the same cartridge bank.`,
 
 	cmdSymbol: `The SYMBOL command displays symbolic information about a memory address. Addresses can be
 specified by symbol.
@@ -378,6 +430,51 @@ will have a "FPU" group.
 
 The SET argument will set a register value. The 'register' number must be the 'extended register'
 number rather than the display number.
+
+The PROFILE argument will export profiling information for the coprocessor program to a file. The
+CALLGRIND format is suitable for kcachegrind and similar tools. The FOLDED format is the collapsed
+stack format used by flame graph tools. The profile is collected between the 'from' and 'to' frame
+numbers (inclusive) and the file is written when the 'to' frame has completed. PROFILE END will
+write the file early. Profiling requires DWARF information for the coprocessor program.
+
+The BUDGET argument checks the number of cycles used each frame by coprocessor functions and source
+lines against a declared budget. A budget file with the same name as the ROM (or DWARF file) and
+the .budget extension is loaded automatically. Each line of the file is a function name or a
+file:line, the number of cycles allowed each frame and optionally one of VBLANK, Screen or Overscan.
+BUDGET LOAD will load a different budget file. BUDGET HALT ON will halt the emulation at the end of
+any frame in which the budget is exceeded. BUDGET REPORT summarises the violations seen so far and
+BUDGET CLEAR removes the budget. Budgets require DWARF information for the coprocessor program.
+
+The STEP argument will execute a single coprocessor instruction. STEP OVER, STEP INTO and STEP OUT
+step through the source code of the coprocessor program and require DWARF information. STEP OVER
+continues until the next line in the current function, STEP INTO continues until any other line,
+including lines in called functions, and STEP OUT continues until the current function returns. If
+the coprocessor yields to the 6507 during a step, the step continues the next time the coprocessor
+program runs.
+
+STEP BACK undoes the most recent coprocessor instructions. The number of instructions to undo can
+be given and defaults to one. Stepping back requires tracing to be on and is limited to the
+instructions executed since the coprocessor last yielded to the 6507. Writes to coprocessor memory
+are undone but writes to peripherals are not.
+
+The CONSOLE argument will display recent output from the coprocessor program. Output is created
+with semihosting requests (SYS_WRITE0, SYS_WRITEC and SYS_WRITE) or by writing bytes to the debug
+output register of the coprocessor. CONSOLE CLEAR will forget all output seen so far.
+
+The WATCH argument will halt emulation when coprocessor memory is accessed. The 'target' can be an
+address or the name of a global variable from the DWARF information. Struct members and array
+elements can be specified with the usual C syntax, for example "player.pos[2].x". An address watches
+four bytes unless the number of bytes is given after a colon, for example 0x20000010:2. A READ or
+WRITE watchpoint halts on any read or write of the target. A CHANGE watchpoint halts only when a
+write changes the value of the target. Without arguments, WATCH will list all watchpoints. WATCH
+DROP will remove the numbered watchpoint and WATCH CLEAR will remove all watchpoints.
+
+The TRACE argument records every coprocessor instruction along with the register values and the
+writes to memory made by the instruction. TRACE ON starts recording and TRACE OFF stops recording
+and forgets the trace. TRACE ON can be given the number of instructions to keep. Without arguments
+TRACE will show whether tracing is on. TRACE LIST shows the most recent instructions in the trace
+and TRACE HISTORY shows every change to the 'target' in the trace. The 'target' is specified in the
+same way as for WATCH. The trace is cleared every time the coprocessor program starts.
 	`,
 
 	cmdDWARF: `Debugging information for cartridge types that support DWARF debugging.
@@ -388,6 +485,24 @@ if execution has not broken inside the coprocessor program.
 
 The DUMP switch to the GLOBAL option will save the list of global variables to a CSV file.
 
+BREAK toggles a breakpoint on a line of source code, specified as file:line. Without arguments BREAK
+lists all source breakpoints along with their condition and hit count. BREAK CLEAR removes all source
+breakpoints.
+
+The IF switch to the BREAK option sets a condition for the breakpoint. The condition is a C-like
+expression that can refer to local and global variables, including struct members, array elements
+and dereferenced pointers. For example, "frame > 100 && p->x == 3". Registers are referred to as $r0
+to $r15, $sp, $lr and $pc. Memory can be read with mem8(), mem16() and mem32(). The breakpoint
+halts only when the condition is true. IF without an expression removes the condition.
+
+The HITS switch to the BREAK option prevents the breakpoint from halting until it has been hit the
+specified number of times. The hit count is reset when HITS is used.
+
+MEMBERS prints the value of a global variable and the values of every member of the variable. The
+variable is specified in the same way as the target of the COPROC WATCH command. The members of
+inherited C++ classes are listed under the name of the inherited class. Names in C++ namespaces and
+static class members are specified with the qualified name, for example "game::Player::count".
+
 The optional DERIVATION switch to the GLOBALS, LOCAL and FRAMEBASE options prints out the location list
 derivations. Normal Atari 2600 developers do not need to worry about location lists.`,
 
@@ -539,13 +654,52 @@ There is no way to isolate one activity from the other. Use WATCH for that.
 Generally, WATCH is a more flexible instrument but TRACE can be useful to quickly gather information
 about an address.
 
-The ONTRACE command can be used to supplement the TRACE output with contextual information.`,
+The ONTRACE command can be used to supplement the TRACE output with contextual information.
+
+TRACE FILE writes every CPU instruction to the named file. This is useful for comparing the
+execution of a ROM with other emulators. TRACE FILE END closes the file and TRACE FILE on its
+own shows the state of the current trace file.
+
+The format of each line can be chosen with one of the PRESET options or with a FORMAT string.
+The STELLA and MAME presets follow the trace output of those emulators. A FORMAT string
+contains fields in braces, for example:
+
+  TRACE FILE out.log FORMAT "{pc}: {instruction} A={a} X={x} Y={y}"
+
+Available fields are: frame, scanline, clock, cycles, total, bank, pc, bytes, mnemonic,
+operand, instruction, a, x, y, sp, p and flags. Fields named in upper case are written in
+upper case.
+
+Tracing can be started and stopped automatically with the START and STOP options. For
+example, START FRAME 10 STOP COUNT 1000 will write 1000 instructions from the beginning of
+frame 10. The -trace command line option takes the same arguments as TRACE FILE.`,
 
 	cmdList:  "List currently defined BREAKS, TRAPS, WATCHES and TRACES.",
 	cmdDrop:  "Drop a specific BREAK, TRAP, WATCH or TRACE condition, using the number of the condition reported by LIST.",
 	cmdClear: "Clear all BREAKS, TRAPS, WATCHES and TRACES.",
 
 	// meta
+	cmdAlias: `Define a new command made up of one or more existing commands. Commands are separated
+by a semi-colon:
+
+	ALIAS regs = CPU; TIA; RIOT
+
+The new command can then be used like any other command and will appear in HELP and tab completion.
+Arguments given to an alias can be referred to with the %1 to %9 placeholders. The %* placeholder
+refers to all arguments:
+
+	ALIAS pk = PEEK %1; POKE %1 %2
+
+Output redirection in the definition is part of the alias and is applied when the alias is run:
+
+	ALIAS tia = TIA | GREP %1
+
+Without arguments, or with the LIST argument, the command lists all aliases. With only the name of an
+alias the definition of that alias is shown. An alias can be removed with DROP and all aliases can be
+removed with CLEAR.
+
+Aliases are saved and will be available the next time the debugger is started.`,
+
 	cmdLog: `Print log to terminal. The LAST argument will cause the most recent log entry to be printed.
 
 Note that while "ONSTEP LOG LAST" is a valid construct it may not print what you expect - it will always print the last
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/jetsetilly/gopher2600/hardware/television/specification"
+	"github.com/jetsetilly/gopher2600/tracewriter"
 )
 
 // debugger keywords
@@ -42,6 +43,7 @@ const (
 	cmdPatch     = "PATCH"
 	cmdDisasm    = "DISASM"
 	cmdGrep      = "GREP"
+	cmdCycles    = "CYCLES"
 	cmdSymbol    = "SYMBOL"
 	cmdOnHalt    = "ONHALT"
 	cmdOnStep    = "ONSTEP"
@@ -85,6 +87,9 @@ const (
 	cmdDrop  = "DROP"
 	cmdClear = "CLEAR"
 
+	// aliases
+	cmdAlias = "ALIAS"
+
 	// meta
 	cmdLog      = "LOG"
 	cmdMemUsage = "MEMUSAGE"
@@ -102,14 +107,15 @@ var commandTemplate = []string{
 	cmdQuantum + " (INSTRUCTION|CYCLE|CLOCK)",
 	cmdScript + " [RECORD %<new file>F|END|%<file>F]",
 	cmdRewind + " [%<frame>N|(+|-)%<relative_frame>N|LAST|SUMMARY|PEEPHOLE]",
-	cmdComparison + " [%<frame>N|LOCK|UNLOCK]",
+	cmdComparison + " [%<frame>N|LOCK|UNLOCK|EMULATION]",
 	cmdGoto + " [%<clock>N] (%<scanline>N) (%<frame>N)",
 
 	cmdInsert + " %<cartridge>F",
 	cmdCartridge + " (PATH|NAME|MAPPER|CONTAINER|MAPPED|HASH|STATIC|REGISTERS|RAM|DUMP|SETBANK %<bank>S|{%<mapper specific>X})",
 	cmdPatch + " %<patch file>S",
 	cmdDisasm + " (BYTECODE|REDUX|SEQUENTIAL)",
-	cmdGrep + " (OPERATOR|OPERAND|COPROC) %<search>S",
+	cmdGrep + " [BYTES %<pattern>S {%<pattern>S}|OPERATOR %<search>S|OPERAND %<search>S|COPROC %<address>S|%<search>S]",
+	cmdCycles + " %<from>S %<to>S",
 	cmdSymbol + " [LIST (LABELS|READ|WRITE)|%<symbol>X]",
 	cmdOnHalt + " (OFF|ON|%<command>S {%<commands>S})",
 	cmdOnStep + " (OFF|ON|%<command>S {%<commands>S})",
@@ -132,8 +138,8 @@ var commandTemplate = []string{
 	cmdPlayfield,
 
 	cmdPlusROM + " (NICK [%<name>S]|ID [%<id>S]|HOST [%<host>S]|PATH [%<path>S])",
-	cmdCoProc + " (ID|LIST [FAULTS|SOURCEFILES|FUNCTIONS]|TOP (%<top>N)|MEM [DUMP {%<area>S}|SEARCH {%<value>N} {%<bitwidth>N}]|REGS %<group>S|SET %<register>N %<value>N|STEP)",
-	cmdDWARF + " [FUNCTIONS|GLOBALS (DERIVATION|DUMP)|LOCALS (DERIVATION|RANGES)|FRAMEBASE (DERIVATION)|LINE %<file:line>S|CALLSTACK|CALLERS %<function>S]",
+	cmdCoProc + " (ID|LIST [FAULTS|SOURCEFILES|FUNCTIONS]|TOP (%<top>N)|MEM [DUMP {%<area>S}|SEARCH {%<value>N} {%<bitwidth>N}]|REGS %<group>S|SET %<register>N %<value>N|PROFILE (CALLGRIND %<filename>S %<from>N %<to>N|FOLDED %<filename>S %<from>N %<to>N|END)|BUDGET (LOAD %<filename>S|HALT (ON|OFF)|REPORT|CLEAR)|CONSOLE (CLEAR)|WATCH (READ %<target>S|WRITE %<target>S|CHANGE %<target>S|DROP %<n>N|CLEAR)|STEP (OVER|INTO|OUT|BACK (%<n>N))|TRACE (ON (%<limit>N)|OFF|LIST (%<n>N)|HISTORY %<target>S))",
+	cmdDWARF + " [FUNCTIONS|GLOBALS (DERIVATION|DUMP)|LOCALS (DERIVATION|RANGES)|FRAMEBASE (DERIVATION)|LINE %<file:line>S|BREAK (CLEAR|%<file:line>S (IF {%<expression>S}|HITS %<n>N))|CALLSTACK|CALLERS %<function>S|MEMBERS %<variable>S]",
 
 	cmdScreenshot + "(%<filename>S)",
 
@@ -148,11 +154,15 @@ var commandTemplate = []string{
 	cmdBreak + " (TOGGLE|DROP) [%<address>S|%<target>S %<value>N] {& %<address>S|%<target>S %<value>S}",
 	cmdTrap + " [%<address>S] {%<address>S}",
 	cmdWatch + " (READ|WRITE (CHANGED)) (STRICT) (PHANTOM|GHOST) [%<address>S] (%<value>S)",
-	cmdTrace + " (STRICT) (%<address>S)",
+	cmdTrace + fmt.Sprintf(" [FILE (END|%%<filename>S (PRESET [%s]|FORMAT %%<format>S) (START [FRAME %%<frame>N|PC %%<address>S]) (STOP [FRAME %%<frame>N|PC %%<address>S|COUNT %%<count>N]))|STRICT %%<address>S|%%<address>S]",
+		strings.Join(tracewriter.PresetList, "|")),
 	cmdList + " [BREAKS|TRAPS|WATCHES|TRACES|ALL]",
 	cmdDrop + " [BREAK|TRAP|WATCH|TRACE] %<number in list>N",
 	cmdClear + " [BREAKS|TRAPS|WATCHES|TRACES|ALL]",
 
+	// aliases
+	cmdAlias + " (LIST|DROP %<alias>S|CLEAR|%<name>S (= %<commands>S {%<commands>S}))",
+
 	// emulation
 	cmdLog + " (LAST|RECENT|CLEAR)",
 	cmdMemUsage + " (PROFILE)",
@@ -0,0 +1,110 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	coproc_breakpoints "github.com/jetsetilly/gopher2600/coprocessor/developer/breakpoints"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
+)
+
+// findSourceLine returns the source line described by a string of the form
+// file:line. the file can be the short filename or the full filename
+func findSourceLine(src *dwarf.Source, spec string) (*dwarf.SourceLine, error) {
+	if src == nil {
+		return nil, fmt.Errorf("no source available")
+	}
+
+	return src.FindSourceLineByFileLine(spec)
+}
+
+// parse the arguments to the DWARF BREAK command. the DWARF and BREAK keywords
+// should have been consumed already
+func (dbg *Debugger) parseDWARFBreakCommand(tokens *commandline.Tokens) error {
+	arg, ok := tokens.Get()
+	if !ok {
+		dbg.CoProcDev.BorrowBreakpoints(func(bp *coproc_breakpoints.Breakpoints) {
+			if bp.Count() == 0 {
+				dbg.printLine(terminal.StyleFeedback, "no source breakpoints")
+				return
+			}
+			bp.Write(dbg.writerInStyle(terminal.StyleFeedback))
+		})
+		return nil
+	}
+
+	if strings.ToUpper(arg) == "CLEAR" {
+		dbg.CoProcDev.BorrowBreakpoints(func(bp *coproc_breakpoints.Breakpoints) {
+			bp.Clear()
+		})
+		dbg.printLine(terminal.StyleFeedback, "source breakpoints cleared")
+		return nil
+	}
+
+	var ln *dwarf.SourceLine
+	var err error
+	dbg.CoProcDev.BorrowSource(func(src *dwarf.Source) {
+		ln, err = findSourceLine(src, arg)
+	})
+	if err != nil {
+		return err
+	}
+
+	option, _ := tokens.Get()
+	option = strings.ToUpper(option)
+
+	dbg.CoProcDev.BorrowBreakpoints(func(bp *coproc_breakpoints.Breakpoints) {
+		switch option {
+		case "IF":
+			err = bp.SetCondition(ln, strings.TrimSpace(tokens.Remainder()))
+			tokens.End()
+
+		case "HITS":
+			v, _ := tokens.Get()
+			var n int
+			n, err = strconv.Atoi(v)
+			if err != nil {
+				err = fmt.Errorf("hit target must be a number")
+				return
+			}
+			err = bp.SetHitTarget(ln, n)
+
+		default:
+			if !bp.CanBreakpoint(ln) {
+				err = fmt.Errorf("cannot add a breakpoint to %s", ln)
+				return
+			}
+			bp.ToggleBreakpoint(ln)
+			if !bp.HasBreakpoint(ln) {
+				dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("breakpoint removed from %s", ln))
+				return
+			}
+		}
+
+		if err == nil {
+			if b := bp.Breakpoint(ln); b != nil {
+				dbg.printLine(terminal.StyleFeedback, fmt.Sprintf("breakpoint on %s", b))
+			}
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,171 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	coproc_dev "github.com/jetsetilly/gopher2600/coprocessor/developer"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/budget"
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
+	"github.com/jetsetilly/gopher2600/logger"
+)
+
+// parse the arguments to the COPROC PROFILE command. the COPROC and PROFILE
+// keywords should have been consumed already
+func (dbg *Debugger) parseCoProcProfileCommand(tokens *commandline.Tokens) error {
+	arg, ok := tokens.Get()
+	if !ok {
+		if s, ok := dbg.CoProcDev.ProfileExport(); ok {
+			dbg.printLine(terminal.StyleFeedback, s)
+		} else {
+			dbg.printLine(terminal.StyleFeedback, "no coprocessor profile export")
+		}
+		return nil
+	}
+
+	var format coproc_dev.ProfileExportFormat
+
+	switch strings.ToUpper(arg) {
+	case "END":
+		s, err := dbg.CoProcDev.EndProfileExport()
+		if err != nil {
+			return err
+		}
+		if s == "" {
+			return fmt.Errorf("no coprocessor profile export to end")
+		}
+		logger.Logf(logger.Allow, "debugger", "ended %s", s)
+		return nil
+	case "CALLGRIND":
+		format = coproc_dev.ProfileExportCallgrind
+	case "FOLDED":
+		format = coproc_dev.ProfileExportFolded
+	default:
+		return fmt.Errorf("unknown profile format (%s)", arg)
+	}
+
+	filename, _ := tokens.Get()
+
+	frame := func() (int, error) {
+		v, _ := tokens.Get()
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid frame number (%s)", v)
+		}
+		return n, nil
+	}
+
+	from, err := frame()
+	if err != nil {
+		return err
+	}
+	to, err := frame()
+	if err != nil {
+		return err
+	}
+
+	err = dbg.CoProcDev.StartProfileExport(format, filename, from, to)
+	if err != nil {
+		return err
+	}
+
+	s, _ := dbg.CoProcDev.ProfileExport()
+	logger.Logf(logger.Allow, "debugger", "started %s", s)
+
+	return nil
+}
+
+// start a coprocessor profile export using the arguments in the command line
+// options
+func (dbg *Debugger) startCoProcProfileFromOptions() error {
+	if dbg.opts.CoProcProfile == "" {
+		return nil
+	}
+
+	tokens := commandline.TokeniseInput(fmt.Sprintf("%s PROFILE %s", cmdCoProc, dbg.opts.CoProcProfile))
+	err := debuggerCommands.ValidateTokens(tokens)
+	if err != nil {
+		return err
+	}
+
+	// skip the COPROC and PROFILE keywords
+	tokens.Get()
+	tokens.Get()
+
+	return dbg.parseCoProcProfileCommand(tokens)
+}
+
+// parse the arguments to the COPROC BUDGET command. the COPROC and BUDGET
+// keywords should have been consumed already
+func (dbg *Debugger) parseCoProcBudgetCommand(tokens *commandline.Tokens) error {
+	arg, ok := tokens.Get()
+	if !ok {
+		dbg.CoProcDev.BorrowBudget(func(b *budget.Budget) {
+			if b == nil {
+				dbg.printLine(terminal.StyleFeedback, "no coprocessor cycle budget")
+				return
+			}
+			b.Write(dbg.writerInStyle(terminal.StyleFeedback))
+			if b.Halt {
+				dbg.printLine(terminal.StyleFeedback, "emulation will halt when budget is exceeded")
+			}
+		})
+		return nil
+	}
+
+	switch strings.ToUpper(arg) {
+	case "LOAD":
+		filename, ok := tokens.Get()
+		if !ok {
+			return fmt.Errorf("budget filename required")
+		}
+		return dbg.CoProcDev.LoadBudget(filename)
+
+	case "CLEAR":
+		dbg.CoProcDev.ClearBudget()
+		dbg.printLine(terminal.StyleFeedback, "coprocessor cycle budget cleared")
+		return nil
+	}
+
+	var err error
+	dbg.CoProcDev.BorrowBudget(func(b *budget.Budget) {
+		if b == nil {
+			err = fmt.Errorf("no coprocessor cycle budget")
+			return
+		}
+
+		switch strings.ToUpper(arg) {
+		case "HALT":
+			arg, ok := tokens.Get()
+			if ok {
+				b.Halt = strings.ToUpper(arg) == "ON"
+			}
+			if b.Halt {
+				dbg.printLine(terminal.StyleFeedback, "emulation will halt when budget is exceeded")
+			} else {
+				dbg.printLine(terminal.StyleFeedback, "emulation will not halt when budget is exceeded")
+			}
+		case "REPORT":
+			b.Report(dbg.writerInStyle(terminal.StyleFeedback))
+		}
+	})
+
+	return err
+}
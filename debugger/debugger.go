@@ -64,12 +64,16 @@ import (
 	"github.com/jetsetilly/gopher2600/resources/unique"
 	"github.com/jetsetilly/gopher2600/rewind"
 	"github.com/jetsetilly/gopher2600/setup"
+	"github.com/jetsetilly/gopher2600/tracewriter"
 	"github.com/jetsetilly/gopher2600/tracker"
 	"github.com/jetsetilly/gopher2600/userinput"
 	"github.com/jetsetilly/gopher2600/video"
 	"github.com/jetsetilly/gopher2600/wavwriter"
 )
 
+// the maximum number of lines of coprocessor console output that are kept
+const maxCoProcConsole = 1000
+
 // Debugger is the basic debugging frontend for the emulation. In order to be
 // kind to code that accesses the debugger from a different goroutine (ie. a
 // GUI), we try not to reinitialise anything once it has been initialised. For
@@ -109,6 +113,15 @@ type Debugger struct {
 	// comparison emulator
 	comparison *comparison.Comparison
 
+	// reference file to compare the emulation against and the recorder for
+	// creating digest reference files
+	reference      *comparison.Reference
+	digestRecorder *comparison.DigestRecorder
+
+	// lines of output from the coprocessor console. oldest lines are removed
+	// once the number of lines reaches maxCoProcConsole
+	coprocConsole []string
+
 	// GUI, terminal and controllers
 	gui         gui.GUI
 	term        terminal.Terminal
@@ -181,6 +194,9 @@ type Debugger struct {
 	// trace memory access
 	traces *traces
 
+	// writes every CPU instruction to a file. nil if no trace file is active
+	traceWriter *tracewriter.TraceWriter
+
 	// commandOnHalt is the sequence of commands that runs when emulation
 	// halts
 	commandOnHalt       []*commandline.Tokens
@@ -194,6 +210,13 @@ type Debugger struct {
 	commandOnTrace       []*commandline.Tokens
 	commandOnTraceStored []*commandline.Tokens
 
+	// user defined commands
+	aliases *aliases
+
+	// redirection of output for the command currently being processed. nil
+	// if output is not being redirected
+	redirect *redirect
+
 	// Quantum to use when stepping/running
 	quantum atomic.Value // govern.Quantum
 
@@ -487,6 +510,15 @@ func NewDebugger(opts CommandLineOptions, create CreateUserInterface) (*Debugger
 	debuggerCommands.AddExtension("write symbol", &dbg.Disasm.Sym)
 	debuggerCommands.AddExtension("label", &dbg.Disasm.Sym)
 
+	// load user defined commands. semi-colons in an alias definition separate
+	// the commands in the alias and not the commands in the script queue
+	dbg.scriptQueue.Unsplittable = []string{cmdAlias}
+	dbg.aliases = newAliases()
+	err = dbg.loadAliases()
+	if err != nil {
+		logger.Log(logger.Allow, "debugger", err)
+	}
+
 	return dbg, nil
 }
 
@@ -545,6 +577,12 @@ func (dbg *Debugger) setState(state govern.State, subState govern.SubState) {
 		))
 	}
 
+	// the emulation has moved to a different point in time and the trace file
+	// must be resynchronised with it
+	if dbg.State() == govern.Rewinding && state != govern.Rewinding {
+		dbg.resyncTraceFile()
+	}
+
 	if state == govern.Rewinding {
 		dbg.endPlayback()
 		dbg.endRecording()
@@ -656,6 +694,14 @@ func (dbg *Debugger) setMode(mode govern.Mode) error {
 
 // End cleans up any resources that may be dangling.
 func (dbg *Debugger) end() {
+	if err := dbg.endTraceFile(); err != nil {
+		logger.Log(logger.Allow, "debugger", err)
+	}
+	if s, err := dbg.CoProcDev.EndProfileExport(); err != nil {
+		logger.Log(logger.Allow, "debugger", err)
+	} else if s != "" {
+		logger.Logf(logger.Allow, "debugger", "ended %s", s)
+	}
 	dbg.endPlayback()
 	dbg.endRecording()
 	dbg.endComparison()
@@ -705,6 +751,16 @@ func (dbg *Debugger) StartInDebugMode(filename string) error {
 		return fmt.Errorf("debugger: %w", err)
 	}
 
+	err = dbg.startTraceFileFromOptions()
+	if err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+
+	err = dbg.startCoProcProfileFromOptions()
+	if err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+
 	// intialisation script because we're in debugger mode
 	if dbg.opts.Script != "" {
 		err := dbg.scriptQueue.Load(dbg.opts.Script)
@@ -845,7 +901,22 @@ func (dbg *Debugger) StartInPlayMode(filename string) error {
 		}
 	}
 
-	err = dbg.startComparison(dbg.opts.ComparisonROM, dbg.opts.ComparisonPrefs)
+	err = dbg.startComparison(dbg.opts.ComparisonROM, dbg.opts.ComparisonPrefs, dbg.opts.ComparisonLockstep)
+	if err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+
+	err = dbg.startReference(dbg.opts.ComparisonReference, dbg.opts.ComparisonTraceFormat, dbg.opts.ComparisonDigests)
+	if err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+
+	err = dbg.startTraceFileFromOptions()
+	if err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+
+	err = dbg.startCoProcProfileFromOptions()
 	if err != nil {
 		return fmt.Errorf("debugger: %w", err)
 	}
@@ -1142,6 +1213,13 @@ func (dbg *Debugger) Notify(notice notifications.Notice, data ...string) error {
 		if err != nil {
 			return err
 		}
+	case notifications.NotifyCoProcConsole:
+		logger.Log(logger.Allow, "coproc", data[0])
+		dbg.coprocConsole = append(dbg.coprocConsole, data[0])
+		if len(dbg.coprocConsole) > maxCoProcConsole {
+			dbg.coprocConsole = dbg.coprocConsole[1:]
+		}
+		dbg.printLine(terminal.StyleCoProcConsole, data[0])
 	default:
 		logger.Logf(logger.Allow, "debugger", "unhandled notification for plusrom (%v)", notice)
 	}
@@ -1215,6 +1293,13 @@ func (dbg *Debugger) attachCartridge(cartload cartridgeloader.Loader) (e error)
 					logger.Log(logger.Allow, "debugger", err)
 				}
 			}
+		} else if dbg.opts.CoProcBudget != "" {
+			// a budget that has been specified explicitly is always loaded so
+			// that the failure is logged if there is no source for the cartridge
+			err = dbg.CoProcDev.LoadBudget(dbg.opts.CoProcBudget)
+			if err != nil {
+				logger.Log(logger.Allow, "debugger", err)
+			}
 		}
 
 		// attach current debugger as the yield hook for cartridge
@@ -1358,21 +1443,24 @@ func (dbg *Debugger) endPlayback() {
 	dbg.vcs.Input.AttachPlayback(nil)
 }
 
-func (dbg *Debugger) startComparison(comparisonROM string, comparisonPrefs string) error {
+func (dbg *Debugger) startComparison(comparisonROM string, comparisonPrefs string, comparisonLockstep string) error {
 	if comparisonROM == "" {
 		return nil
 	}
 
+	lockstep, err := comparison.ParseLockstep(comparisonLockstep)
+	if err != nil {
+		return err
+	}
+
 	// add any bespoke comparision prefs
 	prefs.PushCommandLineStack(comparisonPrefs)
 
-	var err error
-
-	dbg.comparison, err = comparison.NewComparison(dbg.vcs)
+	dbg.comparison, err = comparison.NewComparison(dbg.vcs, lockstep)
 	if err != nil {
 		return err
 	}
-	err = dbg.gui.SetFeature(gui.ReqComparison, dbg.comparison.Render, dbg.comparison.DiffRender, dbg.comparison.AudioDiff)
+	err = dbg.gui.SetFeature(gui.ReqComparison, dbg.comparison.Render, dbg.comparison.DiffRender, dbg.comparison.Diff)
 	if err != nil {
 		return err
 	}
@@ -1396,7 +1484,85 @@ func (dbg *Debugger) startComparison(comparisonROM string, comparisonPrefs strin
 	return nil
 }
 
+// start comparison against a reference file and/or the recording of a digests
+// file. either filename can be empty
+func (dbg *Debugger) startReference(referenceFile string, traceFormat string, digestsFile string) error {
+	var err error
+
+	if referenceFile != "" {
+		dbg.reference, err = comparison.NewReference(dbg.vcs, referenceFile, traceFormat)
+		if err != nil {
+			return err
+		}
+		logger.Logf(logger.Allow, "debugger", "comparing against %s", dbg.reference)
+	}
+
+	if digestsFile != "" {
+		dbg.digestRecorder, err = comparison.NewDigestRecorder(dbg.vcs.TV, digestsFile)
+		if err != nil {
+			dbg.digestRecorder = nil
+
+			// the reference will not be used if the digests recorder
+			// cannot be created
+			if dbg.reference != nil {
+				_ = dbg.reference.End()
+				dbg.reference = nil
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stepComparison should be called after every CPU instruction. it should not
+// be called during rewind or catch-up. returns true if the emulation has
+// diverged from the comparison emulation or from the reference, in which case
+// a report will have been printed
+func (dbg *Debugger) stepComparison() bool {
+	if dbg.reference != nil {
+		dbg.reference.Step()
+
+		select {
+		case d := <-dbg.reference.Divergence:
+			dbg.printLine(terminal.StyleError, "%s", d)
+			return true
+		default:
+		}
+	}
+
+	if dbg.comparison != nil {
+		dbg.comparison.DriverStep()
+
+		select {
+		case d := <-dbg.comparison.Divergence:
+			dbg.printLine(terminal.StyleError, "%s", d)
+			return true
+		default:
+		}
+	}
+
+	return false
+}
+
 func (dbg *Debugger) endComparison() {
+	if dbg.reference != nil {
+		logger.Logf(logger.Allow, "debugger", "comparison ended: %s", dbg.reference)
+		if err := dbg.reference.End(); err != nil {
+			logger.Log(logger.Allow, "debugger", err)
+		}
+		dbg.reference = nil
+	}
+
+	if dbg.digestRecorder != nil {
+		logger.Logf(logger.Allow, "debugger", "digests recorded: %s", dbg.digestRecorder)
+		if err := dbg.digestRecorder.End(); err != nil {
+			logger.Log(logger.Allow, "debugger", err)
+		}
+		dbg.digestRecorder = nil
+	}
+
 	if dbg.comparison == nil {
 		return
 	}
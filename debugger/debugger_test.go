@@ -128,10 +128,19 @@ func testSequence(t *testing.T, trm *mockTerm) {
 	testBreakpoints(t, trm)
 	testBreakpoints_drop(t, trm)
 	testTraps(t, trm)
+	testAliases(t, trm)
+	testRedirect(t, trm)
+	testTraceFile(t, trm)
+	testTraceFileRewind(t, trm)
 	testWatches(t, trm)
 }
 
 func TestDebugger(t *testing.T) {
+	// the debugger saves some files, such as the aliases file, to the
+	// resources directory, which is relative to the working directory
+	t.Chdir(t.TempDir())
+	writeAliases(t)
+
 	var trm *mockTerm
 
 	create := func(dbg *debugger.Debugger) (gui.GUI, terminal.Terminal, error) {
@@ -119,6 +119,13 @@ func (h *haltCoordination) check() bool {
 			Reason: string(h.cartridgeYield.Type),
 			Coords: h.dbg.vcs.TV.GetCoords(),
 		}
+
+		// the watchpoint that has been triggered is described by the error
+		// field of the yield
+		if h.cartridgeYield.Type == coprocessor.YieldWatchpoint && h.cartridgeYield.Error != nil {
+			h.haltReason.Detail = h.cartridgeYield.Error.Error()
+		}
+
 		h.halt = true
 		return false
 	}
@@ -133,6 +140,17 @@ func (h *haltCoordination) check() bool {
 		return false
 	}
 
+	if budgetMessage := h.dbg.CoProcDev.BudgetHalt(); budgetMessage != "" {
+		h.dbg.printLine(terminal.StyleFeedback, budgetMessage)
+		h.haltReason = HaltReason{
+			Reason: "Cycle Budget",
+			Detail: budgetMessage,
+			Coords: h.dbg.vcs.TV.GetCoords(),
+		}
+		h.halt = true
+		return false
+	}
+
 	// we don't check for regular break/trap/wathes if there are volatileTraps in place
 	if h.volatileTraps.isEmpty() && h.volatileBreakpoints.isEmpty() {
 		breakMessage := h.breakpoints.check()
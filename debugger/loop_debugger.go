@@ -494,6 +494,15 @@ func (dbg *Debugger) step(inpt terminal.Input, catchup bool) error {
 	}
 	dbg.counter.Step(1, dbg.liveBankInfo)
 
+	// write instruction to trace file. instructions executed during catchup
+	// have already been written to the trace file
+	if !catchup {
+		dbg.stepTraceFile()
+		if dbg.stepComparison() {
+			dbg.continueEmulation = false
+		}
+	}
+
 	if dbg.unwindLoopRestart != nil {
 		return nil
 	}
@@ -49,6 +49,15 @@ func (dbg *Debugger) playLoop() error {
 		// we must keep lastBank updated during the play loop
 		dbg.liveBankInfo = dbg.vcs.Mem.Cart.GetBank(dbg.vcs.CPU.PC.Address())
 
+		// write instruction to trace file
+		dbg.stepTraceFile()
+
+		// halt emulation if the comparison emulation has diverged
+		if dbg.stepComparison() {
+			dbg.setMode(govern.ModeDebugger)
+			return govern.Ending, nil
+		}
+
 		// record state. we do this before any of the conditions below that may
 		// result in an early return from the function
 		if dbg.state.Load().(govern.State) == govern.Running {
@@ -41,10 +41,16 @@ func (dbg *Debugger) printLine(sty terminal.Style, s string, a ...any) {
 		return
 	}
 
+	// output is sent to the redirection if there is one
+	var out terminal.Output = dbg.term
+	if dbg.redirect != nil {
+		out = dbg.redirect
+	}
+
 	// split string if necessary
 	t := strings.SplitSeq(s, "\n")
 	for s := range t {
-		dbg.term.TermPrintLine(sty, s)
+		out.TermPrintLine(sty, s)
 	}
 }
 
@@ -0,0 +1,246 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+)
+
+// redirection operators that can appear at the end of a command
+const (
+	redirectPipe     = "|"
+	redirectTruncate = ">"
+	redirectAppend   = ">>"
+)
+
+// commands that store the remainder of the input as text. redirection
+// operators in the input to these commands are part of the text and are not
+// applied to the output of the command
+var redirectExempt = []string{cmdAlias}
+
+// redirect implements the terminal.Output interface. It sits between the
+// debugger and the terminal and can send output to a file, filter the output
+// with one or more GREP patterns, or both.
+//
+// Error messages are never redirected or filtered and are always sent to the
+// terminal.
+type redirect struct {
+	term terminal.Output
+
+	// grep patterns. a line of output must contain every pattern to be
+	// included in the output. patterns are normalised to upper case because
+	// matching is case insensitive
+	grep []string
+
+	// the file to write output to. if file is nil then output is sent to the
+	// terminal after filtering
+	file     *os.File
+	filename string
+	append   bool
+}
+
+// String returns the redirection as it would be written on the command line.
+func (r *redirect) String() string {
+	s := strings.Builder{}
+	for _, g := range r.grep {
+		fmt.Fprintf(&s, " %s %s %s", redirectPipe, cmdGrep, g)
+	}
+	if r.filename != "" {
+		if r.append {
+			fmt.Fprintf(&s, " %s %s", redirectAppend, r.filename)
+		} else {
+			fmt.Fprintf(&s, " %s %s", redirectTruncate, r.filename)
+		}
+	}
+	return strings.TrimSpace(s.String())
+}
+
+// open the redirection file. does nothing if the redirection is not to a file
+func (r *redirect) open() error {
+	if r.filename == "" {
+		return nil
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if r.append {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	var err error
+	r.file, err = os.OpenFile(r.filename, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("redirect: %w", err)
+	}
+
+	return nil
+}
+
+// close the redirection file. does nothing if the redirection is not to a file
+func (r *redirect) close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return fmt.Errorf("redirect: %w", err)
+	}
+	return nil
+}
+
+// TermPrintLine implements the terminal.Output interface.
+func (r *redirect) TermPrintLine(sty terminal.Style, s string) {
+	if sty == terminal.StyleError {
+		r.term.TermPrintLine(sty, s)
+		return
+	}
+
+	if len(r.grep) > 0 {
+		m := strings.ToUpper(s)
+		for _, g := range r.grep {
+			if !strings.Contains(m, g) {
+				return
+			}
+		}
+	}
+
+	if r.file != nil {
+		_, err := fmt.Fprintln(r.file, s)
+		if err != nil {
+			r.term.TermPrintLine(terminal.StyleError, err.Error())
+		}
+		return
+	}
+
+	r.term.TermPrintLine(sty, s)
+}
+
+// splitRedirect divides the input into the command and any redirection at
+// the end of the command. the redirection will be nil if there is no
+// redirection.
+//
+// the grammar of the redirection is:
+//
+//	command { | GREP pattern } ( > filename | >> filename )
+//
+// redirection operators inside double quotes are not considered to be
+// redirection operators. nor are operators that appear after the IF keyword of
+// the DWARF BREAK command. everything after IF is a conditional expression,
+// which can legitimately contain the > and | characters. for example:
+//
+//	DWARF BREAK main.c:10 IF frame > 100 && p->x == 3
+//
+// the output of a DWARF BREAK command with an IF condition therefore can not be
+// redirected
+//
+// the input to the commands in the redirectExempt list is never split
+func splitRedirect(input string) (string, *redirect, error) {
+	f := strings.Fields(input)
+	if len(f) > 0 {
+		for _, c := range redirectExempt {
+			if strings.EqualFold(f[0], c) {
+				return input, nil, nil
+			}
+		}
+	}
+	condition := len(f) > 1 && strings.EqualFold(f[0], cmdDWARF) && strings.EqualFold(f[1], "BREAK")
+
+	// divide input into sections at each unquoted redirection operator
+	type section struct {
+		op  string
+		arg string
+	}
+	var sections []section
+
+	var quoted bool
+	var op string
+	mark := 0
+
+	for i := 0; i < len(input) && !(condition && isCondition(input, i, quoted)); i++ {
+		switch input[i] {
+		case '"':
+			quoted = !quoted
+		case '|', '>':
+			if quoted {
+				continue
+			}
+
+			sections = append(sections, section{op: op, arg: strings.TrimSpace(input[mark:i])})
+
+			op = input[i : i+1]
+			if input[i] == '>' && i+1 < len(input) && input[i+1] == '>' {
+				op = redirectAppend
+				i++
+			}
+			mark = i + 1
+		}
+	}
+
+	// no redirection operators found
+	if len(sections) == 0 {
+		return input, nil, nil
+	}
+
+	sections = append(sections, section{op: op, arg: strings.TrimSpace(input[mark:])})
+
+	r := &redirect{}
+
+	for i, s := range sections[1:] {
+		if s.arg == "" {
+			return "", nil, fmt.Errorf("redirect: missing argument for %s", s.op)
+		}
+
+		switch s.op {
+		case redirectPipe:
+			// GREP is the only command that can be piped to
+			f := strings.Fields(s.arg)
+			if len(f) < 2 || !strings.EqualFold(f[0], cmdGrep) {
+				return "", nil, fmt.Errorf("redirect: only %s can be piped to", cmdGrep)
+			}
+			pattern := strings.TrimSpace(s.arg[len(f[0]):])
+			pattern = strings.Trim(pattern, "\"")
+			r.grep = append(r.grep, strings.ToUpper(pattern))
+
+		case redirectTruncate, redirectAppend:
+			if i < len(sections)-2 {
+				return "", nil, fmt.Errorf("redirect: %s must be at the end of the command", s.op)
+			}
+			r.filename = strings.Trim(s.arg, "\"")
+			r.append = s.op == redirectAppend
+		}
+	}
+
+	return sections[0].arg, r, nil
+}
+
+// isCondition returns true if the input at index i is the unquoted IF keyword.
+// the keyword must be a word on its own
+func isCondition(input string, i int, quoted bool) bool {
+	if quoted || i == 0 || input[i-1] != ' ' {
+		return false
+	}
+	if len(input) < i+2 || !strings.EqualFold(input[i:i+2], "IF") {
+		return false
+	}
+	return len(input) == i+2 || input[i+2] == ' '
+}
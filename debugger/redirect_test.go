@@ -0,0 +1,127 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func testRedirect(t *testing.T, trm *mockTerm) {
+	trm.command("TRAP X")
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	// filter output with GREP
+	trm.command("LIST TRAPS | GREP x")
+	test.ExpectEquality(t, trm.lastLine(), " 1: X")
+
+	// filter output so that nothing is printed
+	trm.command("LIST TRAPS | GREP Y")
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	// multiple filters
+	trm.command("LIST TRAPS | GREP 1 | GREP X")
+	test.ExpectEquality(t, trm.lastLine(), " 1: X")
+	trm.command("LIST TRAPS | GREP 0 | GREP X")
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	// only GREP can be piped to
+	trm.command("LIST TRAPS | LIST BREAKS")
+	test.ExpectEquality(t, trm.lastLine(), "redirect: only GREP can be piped to")
+
+	// redirect output to a file. nothing is printed to the terminal
+	pth := filepath.Join(t.TempDir(), "redirect")
+	trm.command(fmt.Sprintf("LIST TRAPS > %s", pth))
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	b, err := os.ReadFile(pth)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, string(b), "traps:\n 0: A\n 1: X\n")
+
+	// append filtered output to the file
+	trm.command(fmt.Sprintf("LIST TRAPS | GREP X >> %s", pth))
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	b, err = os.ReadFile(pth)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, string(b), "traps:\n 0: A\n 1: X\n 1: X\n")
+
+	// truncate file
+	trm.command(fmt.Sprintf("LIST TRAPS | GREP 0: > %s", pth))
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	b, err = os.ReadFile(pth)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, string(b), " 0: A\n")
+
+	// file redirection must be at the end of the command
+	trm.command(fmt.Sprintf("LIST TRAPS > %s | GREP A", pth))
+	test.ExpectEquality(t, trm.lastLine(), "redirect: > must be at the end of the command")
+
+	// operators in the condition of a DWARF breakpoint are not redirections.
+	// there is no coprocessor in the test cartridge so the command reaching
+	// the DWARF handler is enough to show that the condition was accepted
+	for _, cond := range []string{
+		"frame > 100 && p->x == 3",
+		"x > 3",
+		"a || b",
+	} {
+		trm.command(fmt.Sprintf("DWARF BREAK main.c:10 IF %s", cond))
+		test.ExpectEquality(t, trm.lastLine(), "cartridge does not have a coprocessor")
+	}
+
+	// the IF keyword must be a word on its own
+	trm.command(fmt.Sprintf("LIST TRAPS | GREP DIFF > %s", pth))
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	// the IF keyword only prevents redirection in the DWARF BREAK command
+	trm.command("SYMBOL IF")
+	test.ExpectEquality(t, trm.lastLine(), "IF not found in any symbol table")
+	trm.command("SYMBOL IF | GREP X")
+	test.ExpectEquality(t, trm.lastLine(), "")
+	trm.command("SYMBOL IF | GREP FOUND")
+	test.ExpectEquality(t, trm.lastLine(), "IF not found in any symbol table")
+
+	// redirection operators in an alias definition are part of the alias and
+	// are not applied to the output of the ALIAS command
+	pth = filepath.Join(t.TempDir(), "alias")
+	trm.command(fmt.Sprintf("ALIAS LT = LIST TRAPS | GREP %%1 > %s", pth))
+	test.ExpectEquality(t, trm.lastLine(), fmt.Sprintf("LT = LIST TRAPS | GREP %%1 > %s", pth))
+	_, err = os.Stat(pth)
+	test.ExpectFailure(t, err)
+
+	trm.command("LT X")
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	b, err = os.ReadFile(pth)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, string(b), " 1: X\n")
+
+	// the output of an alias can itself be redirected
+	trm.command("ALIAS LA = LIST TRAPS | GREP :")
+	test.ExpectEquality(t, trm.lastLine(), "LA = LIST TRAPS | GREP :")
+	trm.command("LA | GREP A")
+	test.ExpectEquality(t, trm.lastLine(), " 0: A")
+	trm.command("LA | GREP Y")
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	trm.command("ALIAS CLEAR")
+	test.ExpectEquality(t, trm.lastLine(), "aliases cleared")
+}
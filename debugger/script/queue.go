@@ -31,6 +31,11 @@ type Line struct {
 // interactive terminals and scripts.
 type Queue struct {
 	lines []Line
+
+	// lines that begin with any of these commands will not be divided by
+	// semi-colons. this is for commands that use the semi-colon as part of
+	// their own syntax
+	Unsplittable []string
 }
 
 // More returns true if there are more commands in the queue
@@ -63,17 +68,37 @@ func (q *Queue) push(input string, batch bool) {
 	input = strings.ReplaceAll(input, "\r\n", "\n")
 	input = strings.ReplaceAll(input, "\r", "\n")
 
-	// commands can be separated by semi-colons as well as newlines.
-	// normalise semi-colons with newlines
-	input = strings.ReplaceAll(input, ";", "\n")
-
 	// loop through lines
 	for s := range strings.SplitSeq(input, "\n") {
-		if !strings.HasPrefix(s, "#") {
-			ln := Line{Entry: s, Batch: batch}
-			q.lines = append(q.lines, ln)
+		if strings.HasPrefix(s, "#") {
+			continue
+		}
+
+		// commands can be separated by semi-colons as well as newlines
+		if q.unsplittable(s) {
+			q.lines = append(q.lines, Line{Entry: s, Batch: batch})
+		} else {
+			for c := range strings.SplitSeq(s, ";") {
+				if !strings.HasPrefix(c, "#") {
+					q.lines = append(q.lines, Line{Entry: c, Batch: batch})
+				}
+			}
+		}
+	}
+}
+
+// returns true if the line begins with one of the unsplittable commands
+func (q *Queue) unsplittable(s string) bool {
+	f := strings.Fields(s)
+	if len(f) == 0 {
+		return false
+	}
+	for _, c := range q.Unsplittable {
+		if strings.EqualFold(f[0], c) {
+			return true
 		}
 	}
+	return false
 }
 
 // Load script into queue
@@ -59,6 +59,9 @@ func (ct *ColorTerminal) TermPrintLine(style terminal.Style, s string) {
 
 	case terminal.StyleLog:
 		ct.EasyTerm.TermPrint(ansi.Pens["magenta"])
+
+	case terminal.StyleCoProcConsole:
+		ct.EasyTerm.TermPrint(ansi.Pens["green"])
 	}
 
 	ct.EasyTerm.TermPrint(s)
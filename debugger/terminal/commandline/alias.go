@@ -0,0 +1,114 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package commandline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AddAlias adds a user defined command to the list of commands. The alias
+// will accept any number of string arguments. It is up to the user of the
+// commandline package to decide what to do with the alias and its arguments.
+//
+// The name of the alias is normalised to upper case. An alias cannot have the
+// same name as a command that is not an alias. Adding an alias that already
+// exists is not an error.
+//
+// The HELP command will be updated to include the new alias if AddHelp() has
+// already been called.
+func (cmds *Commands) AddAlias(name string) error {
+	name = strings.ToUpper(name)
+
+	if err := validAliasName(name); err != nil {
+		return err
+	}
+
+	if _, ok := cmds.index[name]; ok {
+		if cmds.aliases[name] {
+			return nil
+		}
+		return fmt.Errorf("alias: %s is an existing command", name)
+	}
+
+	p, _, err := parseDefinition(fmt.Sprintf("%s {%%<argument>S}", name), "")
+	if err != nil {
+		return fmt.Errorf("alias: %w", err)
+	}
+
+	if cmds.aliases == nil {
+		cmds.aliases = make(map[string]bool)
+	}
+	cmds.aliases[name] = true
+	cmds.index[name] = p
+	cmds.list = append(cmds.list, p)
+	sort.Stable(cmds)
+
+	return refreshHelp(cmds)
+}
+
+// RemoveAlias removes a command previously added with AddAlias().
+func (cmds *Commands) RemoveAlias(name string) error {
+	name = strings.ToUpper(name)
+
+	if !cmds.aliases[name] {
+		return fmt.Errorf("alias: %s is not an alias", name)
+	}
+
+	n := cmds.index[name]
+	delete(cmds.aliases, name)
+	delete(cmds.index, name)
+	for i := range cmds.list {
+		if cmds.list[i] == n {
+			cmds.list = append(cmds.list[:i], cmds.list[i+1:]...)
+			break // for loop
+		}
+	}
+
+	return refreshHelp(cmds)
+}
+
+// IsAlias returns true if the command was added with AddAlias().
+func (cmds *Commands) IsAlias(name string) bool {
+	return cmds.aliases[strings.ToUpper(name)]
+}
+
+// HasCommand returns true if the command is in the list of commands. The
+// command can be an alias.
+func (cmds *Commands) HasCommand(name string) bool {
+	_, ok := cmds.index[strings.ToUpper(name)]
+	return ok
+}
+
+// alias names must begin with a letter and consist only of letters, digits
+// and the underscore character
+func validAliasName(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("alias: name is empty")
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '_') {
+			continue
+		}
+		return fmt.Errorf("alias: %s is not a valid name", name)
+	}
+	return nil
+}
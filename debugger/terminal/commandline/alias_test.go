@@ -0,0 +1,80 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package commandline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestAlias(t *testing.T) {
+	cmds, err := commandline.ParseCommandTemplate([]string{
+		"TEST [arg]",
+		"FOO [bar|baz] wibble",
+	})
+	test.DemandSuccess(t, err)
+
+	err = commandline.AddHelp(cmds)
+	test.DemandSuccess(t, err)
+
+	// alias cannot replace an existing command
+	err = cmds.AddAlias("test")
+	test.ExpectFailure(t, err)
+
+	// alias name must be valid
+	err = cmds.AddAlias("1test")
+	test.ExpectFailure(t, err)
+
+	err = cmds.AddAlias("tst")
+	test.DemandSuccess(t, err)
+	test.ExpectSuccess(t, cmds.IsAlias("TST"))
+	test.ExpectSuccess(t, cmds.HasCommand("TST"))
+
+	// adding the same alias a second time is not an error
+	err = cmds.AddAlias("tst")
+	test.ExpectSuccess(t, err)
+
+	// alias accepts any number of arguments
+	err = cmds.Validate("TST")
+	test.ExpectSuccess(t, err)
+	err = cmds.Validate("TST a b c")
+	test.ExpectSuccess(t, err)
+
+	// alias is included in the HELP command
+	err = cmds.Validate("HELP TST")
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, strings.Contains(commandline.HelpSummary(cmds), "TST"))
+
+	// alias takes part in tab completion
+	tc := commandline.NewTabCompletion(cmds)
+	test.ExpectEquality(t, tc.Complete("TS"), "TST ")
+
+	err = cmds.RemoveAlias("tst")
+	test.DemandSuccess(t, err)
+	test.ExpectFailure(t, cmds.IsAlias("TST"))
+
+	err = cmds.Validate("TST")
+	test.ExpectFailure(t, err)
+	err = cmds.Validate("HELP TST")
+	test.ExpectFailure(t, err)
+
+	// only aliases can be removed
+	err = cmds.RemoveAlias("test")
+	test.ExpectFailure(t, err)
+}
@@ -36,6 +36,9 @@ type Commands struct {
 	// extension handlers. indexed by a name given to the extension in the
 	// commands template
 	extensions map[string]Extension
+
+	// commands that have been added with AddAlias()
+	aliases map[string]bool
 }
 
 // Len implements Sort package interface.
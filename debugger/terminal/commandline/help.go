@@ -35,7 +35,30 @@ func AddHelp(cmds *Commands) error {
 	if _, ok := cmds.index[HelpCommand]; ok {
 		return nil
 	}
+	return addHelp(cmds)
+}
+
+// rebuild the HELP command if it has already been added. the HELP command must
+// be rebuilt if the list of commands has changed. for example, because of the
+// addition of an alias
+func refreshHelp(cmds *Commands) error {
+	h, ok := cmds.index[HelpCommand]
+	if !ok {
+		return nil
+	}
+
+	delete(cmds.index, HelpCommand)
+	for i, n := range cmds.list {
+		if n == h {
+			cmds.list = append(cmds.list[:i], cmds.list[i+1:]...)
+			break // for loop
+		}
+	}
+
+	return addHelp(cmds)
+}
 
+func addHelp(cmds *Commands) error {
 	// create definition string using existing command list
 	var def string
 	def = fmt.Sprintf("%s (", HelpCommand)
@@ -58,6 +58,12 @@ func (tk Tokens) Remainder() string {
 	return strings.Join(tk.tokens[tk.curr:], " ")
 }
 
+// Input returns the input that was tokenised. Unlike String() the quoting
+// and spacing of the original input is preserved.
+func (tk Tokens) Input() string {
+	return tk.input
+}
+
 // Remaining returns the count of reminaing tokens in the token list.
 func (tk Tokens) Remaining() int {
 	return len(tk.tokens) - tk.curr
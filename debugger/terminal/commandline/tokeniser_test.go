@@ -103,3 +103,9 @@ func TestTokeniser_singleCharArgs(t *testing.T) {
 	toks := commandline.TokeniseInput("FOO & BAR")
 	test.ExpectEquality(t, toks.Len(), 3)
 }
+
+func TestTokeniser_input(t *testing.T) {
+	toks := commandline.TokeniseInput(`  ALIAS X = PRINT "a  b"   `)
+	test.ExpectEquality(t, toks.String(), "ALIAS X = PRINT a  b")
+	test.ExpectEquality(t, toks.Input(), `ALIAS X = PRINT "a  b"`)
+}
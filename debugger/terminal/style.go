@@ -51,4 +51,7 @@ const (
 
 	// information from the internal logging system
 	StyleLog
+
+	// output from a coprocessor program
+	StyleCoProcConsole
 )
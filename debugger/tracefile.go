@@ -0,0 +1,192 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/debugger/govern"
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
+	"github.com/jetsetilly/gopher2600/logger"
+	"github.com/jetsetilly/gopher2600/tracewriter"
+)
+
+// parse the arguments of the TRACE FILE command. the FILE keyword should have
+// already been consumed
+func (dbg *Debugger) parseTraceFileCommand(tokens *commandline.Tokens) error {
+	arg, ok := tokens.Get()
+	if !ok {
+		if dbg.traceWriter == nil {
+			dbg.printLine(terminal.StyleFeedback, "no trace file")
+		} else {
+			dbg.printLine(terminal.StyleFeedback, "trace file: %s", dbg.traceWriter)
+		}
+		return nil
+	}
+
+	if strings.ToUpper(arg) == "END" {
+		if dbg.traceWriter == nil {
+			return fmt.Errorf("no trace file to end")
+		}
+		return dbg.endTraceFile()
+	}
+
+	filename := arg
+	format := tracewriter.PresetGopher
+	var start, stop tracewriter.Condition
+
+	parseCondition := func() (tracewriter.Condition, error) {
+		var c tracewriter.Condition
+
+		typ, _ := tokens.Get()
+		val, _ := tokens.Get()
+
+		switch strings.ToUpper(typ) {
+		case "FRAME":
+			c.Type = tracewriter.ConditionFrame
+		case "PC":
+			c.Type = tracewriter.ConditionPC
+			ai := dbg.dbgmem.GetAddressInfo(val, true)
+			if ai == nil {
+				return c, fmt.Errorf("invalid trace address (%s) expecting 16-bit address or symbol", val)
+			}
+			c.Value = int(ai.Address)
+			return c, nil
+		case "COUNT":
+			c.Type = tracewriter.ConditionCount
+		}
+
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return c, fmt.Errorf("invalid value for %s (%s)", typ, val)
+		}
+		c.Value = n
+
+		return c, nil
+	}
+
+	for arg, ok := tokens.Get(); ok; arg, ok = tokens.Get() {
+		var err error
+
+		switch strings.ToUpper(arg) {
+		case "PRESET":
+			format, _ = tokens.Get()
+		case "FORMAT":
+			format, _ = tokens.Get()
+		case "START":
+			start, err = parseCondition()
+		case "STOP":
+			stop, err = parseCondition()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbg.startTraceFile(filename, format, start, stop)
+}
+
+// start trace file if the Trace field in the command line options has been
+// set. the option is a string in the same format as the arguments to the TRACE
+// FILE command
+func (dbg *Debugger) startTraceFileFromOptions() error {
+	if dbg.opts.Trace == "" {
+		return nil
+	}
+
+	tokens := commandline.TokeniseInput(fmt.Sprintf("%s FILE %s", cmdTrace, dbg.opts.Trace))
+	err := debuggerCommands.ValidateTokens(tokens)
+	if err != nil {
+		return err
+	}
+
+	// skip the TRACE and FILE keywords
+	tokens.Get()
+	tokens.Get()
+
+	return dbg.parseTraceFileCommand(tokens)
+}
+
+// start a new trace file. any existing trace file will be ended
+func (dbg *Debugger) startTraceFile(filename string, format string, start tracewriter.Condition, stop tracewriter.Condition) error {
+	if dbg.traceWriter != nil {
+		err := dbg.endTraceFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	tw, err := tracewriter.NewTraceWriter(dbg.vcs, filename, format, start, stop)
+	if err != nil {
+		return err
+	}
+	dbg.traceWriter = tw
+
+	logger.Logf(logger.Allow, "debugger", "trace file started: %s", dbg.traceWriter)
+
+	return nil
+}
+
+// end the current trace file
+func (dbg *Debugger) endTraceFile() error {
+	if dbg.traceWriter == nil {
+		return nil
+	}
+
+	err := dbg.traceWriter.End()
+	logger.Logf(logger.Allow, "debugger", "trace file ended: %s", dbg.traceWriter)
+	dbg.traceWriter = nil
+
+	return err
+}
+
+// resyncTraceFile should be called when the emulation state changes without
+// the instructions being seen by stepTraceFile(). for example, at the end of a
+// rewind
+func (dbg *Debugger) resyncTraceFile() {
+	if dbg.traceWriter == nil {
+		return
+	}
+	dbg.traceWriter.Resync()
+}
+
+// stepTraceFile should be called after every CPU instruction. it should not be
+// called during catch-up because the instructions in that situation have
+// already been executed once. instructions executed while the emulation is
+// rewinding are ignored for the same reason
+func (dbg *Debugger) stepTraceFile() {
+	if dbg.traceWriter == nil {
+		return
+	}
+
+	if dbg.State() == govern.Rewinding {
+		return
+	}
+
+	err := dbg.traceWriter.Step()
+	if err != nil {
+		logger.Log(logger.Allow, "debugger", err)
+	}
+
+	if dbg.traceWriter.Ended() {
+		logger.Logf(logger.Allow, "debugger", "trace file ended: %s", dbg.traceWriter)
+		dbg.traceWriter = nil
+	}
+}
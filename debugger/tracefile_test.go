@@ -0,0 +1,80 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package debugger_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func testTraceFile(t *testing.T, trm *mockTerm) {
+	trm.command("TRACE FILE")
+	test.ExpectEquality(t, trm.lastLine(), "no trace file")
+
+	pth := filepath.Join(t.TempDir(), "trace")
+	trm.command(fmt.Sprintf("TRACE FILE %s PRESET MAME STOP COUNT 2", pth))
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	trm.command("TRACE FILE")
+	test.ExpectSuccess(t, strings.HasSuffix(trm.lastLine(), "stop at count 2 (0 lines)"))
+
+	// the trace file will end after two instructions
+	for range 3 {
+		trm.command("STEP")
+		trm.response()
+	}
+
+	trm.command("TRACE FILE")
+	test.ExpectEquality(t, trm.lastLine(), "no trace file")
+
+	b, err := os.ReadFile(pth)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, strings.Count(string(b), "\n"), 2)
+}
+
+// the trace file should be resynchronised with the emulation after a rewind
+func testTraceFileRewind(t *testing.T, trm *mockTerm) {
+	pth := filepath.Join(t.TempDir(), "trace")
+	trm.command(fmt.Sprintf(`TRACE FILE %s FORMAT "{frame} {scanline} {clock} {pc} {a} {x} {y} {sp}"`, pth))
+	test.ExpectEquality(t, trm.lastLine(), "")
+
+	// step forward twice and then back once before stepping forward again. the
+	// instruction after the rewind is the same as the second instruction and
+	// should be traced identically
+	trm.command("STEP")
+	trm.response()
+	trm.command("STEP")
+	trm.response()
+	trm.command("STEP BACK")
+	trm.response()
+	trm.command("STEP")
+	trm.response()
+
+	trm.command("TRACE FILE END")
+	trm.response()
+
+	b, err := os.ReadFile(pth)
+	test.ExpectSuccess(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	test.ExpectEquality(t, len(lines), 3)
+	test.ExpectEquality(t, lines[2], lines[1])
+}
@@ -0,0 +1,422 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package disassembly
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/disassembly/symbols"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/execution"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/instructions"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// the limits placed on the cycle analysis. without these a large or
+// complicated program could cause the analysis to run for a very long time
+const (
+	maxCyclePaths       = 256
+	maxCyclePathLength  = 4096
+	maxCycleSteps       = 65536
+	maxCycleCallDepth   = 16
+	maxCycleAnalysisErr = 16
+)
+
+// CycleBranch records the decision made at a branch instruction for a CyclePath.
+type CycleBranch struct {
+	Address uint16
+	Taken   bool
+
+	// whether the branch crossed a page. only ever true if the branch is taken
+	PageCrossed bool
+}
+
+func (b CycleBranch) String() string {
+	if b.Taken {
+		if b.PageCrossed {
+			return fmt.Sprintf("$%04x taken (page crossed)", b.Address)
+		}
+		return fmt.Sprintf("$%04x taken", b.Address)
+	}
+	return fmt.Sprintf("$%04x not taken", b.Address)
+}
+
+// CyclePath is a single route through the program between the two addresses
+// of a CycleAnalysis.
+type CyclePath struct {
+	// the minimum and maximum number of cycles taken by the path. the maximum
+	// value will be greater than the minimum value if the path contains
+	// instructions that may incur a page-crossing penalty. for example,
+	// indexed addressing instructions
+	Min int
+	Max int
+
+	// the decisions made at each branch along the path
+	Branches []CycleBranch
+
+	// the number of instructions in the path
+	Length int
+}
+
+func (p CyclePath) String() string {
+	s := strings.Builder{}
+	if p.Min == p.Max {
+		fmt.Fprintf(&s, "%d cycles", p.Min)
+	} else {
+		fmt.Fprintf(&s, "%d to %d cycles", p.Min, p.Max)
+	}
+	fmt.Fprintf(&s, " (%d instructions)", p.Length)
+	if len(p.Branches) > 0 {
+		s.WriteString(": ")
+		for i, b := range p.Branches {
+			if i > 0 {
+				s.WriteString(", ")
+			}
+			s.WriteString(b.String())
+		}
+	}
+	return s.String()
+}
+
+// CycleAnalysis is the result of a static analysis of every path between two
+// addresses in the disassembly.
+type CycleAnalysis struct {
+	From uint16
+	To   uint16
+	Bank int
+
+	// every path found between the From and To address
+	Paths []CyclePath
+
+	// paths that could not be followed to the To address. for example, a path
+	// that contains a loop that cannot be bounded or a path that leaves the
+	// bank
+	Warnings []string
+
+	// there were more warnings than could be recorded in the Warnings field.
+	// the analysis itself is still complete
+	WarningsTruncated bool
+
+	// the analysis was stopped early because it reached one of the limits
+	// placed on it
+	Truncated bool
+}
+
+// Min returns the minimum number of cycles for all paths in the analysis.
+func (ca *CycleAnalysis) Min() int {
+	if len(ca.Paths) == 0 {
+		return 0
+	}
+	m := ca.Paths[0].Min
+	for _, p := range ca.Paths[1:] {
+		m = min(m, p.Min)
+	}
+	return m
+}
+
+// Max returns the maximum number of cycles for all paths in the analysis.
+func (ca *CycleAnalysis) Max() int {
+	m := 0
+	for _, p := range ca.Paths {
+		m = max(m, p.Max)
+	}
+	return m
+}
+
+// Write the result of the analysis to io.Writer.
+func (ca *CycleAnalysis) Write(output io.Writer) {
+	for i, p := range ca.Paths {
+		fmt.Fprintf(output, "path %d: %s\n", i+1, p.String())
+	}
+
+	if len(ca.Paths) == 0 {
+		fmt.Fprintf(output, "no path from $%04x to $%04x\n", ca.From, ca.To)
+	} else {
+		fmt.Fprintf(output, "%d paths from $%04x to $%04x: minimum %d cycles, maximum %d cycles\n",
+			len(ca.Paths), ca.From, ca.To, ca.Min(), ca.Max())
+	}
+
+	for _, w := range ca.Warnings {
+		fmt.Fprintf(output, "warning: %s\n", w)
+	}
+
+	if ca.WarningsTruncated {
+		fmt.Fprintf(output, "warning: further warnings have not been shown\n")
+	}
+
+	if ca.Truncated {
+		fmt.Fprintf(output, "warning: analysis is incomplete because the program is too complex\n")
+	}
+}
+
+// Cycles performs a static analysis of every path between the two addresses
+// and counts the number of cycles taken by each path. The from and to
+// arguments can be labels or numeric addresses. Both addresses must resolve to
+// the same cartridge bank.
+//
+// The cycles for the instruction at the to address are not included in the
+// count. If the from and to addresses are the same then the analysis will
+// count the cycles for every path that leads back to the starting address.
+//
+// Branches are followed in both the taken and not-taken direction and the
+// additional cost of a taken branch, including any page-crossing penalty, is
+// included in the count. Subroutines are followed from the JSR instruction to
+// the matching RTS instruction.
+//
+// Paths that cannot be followed to the to address, including paths with loops
+// that do not pass through the to address, are noted in the Warnings field of
+// the returned CycleAnalysis.
+func (dsm *Disassembly) Cycles(from string, to string) (*CycleAnalysis, error) {
+	fromBank, fromAddr, err := dsm.resolveCycleAddress(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toBank, toAddr, err := dsm.resolveCycleAddress(to)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromBank != toBank {
+		return nil, fmt.Errorf("cycles: %s and %s are in different banks", from, to)
+	}
+
+	dsm.crit.Lock()
+	defer dsm.crit.Unlock()
+
+	if fromBank >= len(dsm.disasmEntries.Entries) {
+		return nil, fmt.Errorf("cycles: no bank %d in disassembly", fromBank)
+	}
+
+	bits := dsm.vcs.Mem.Cart.CartridgeBits()
+	entries := dsm.disasmEntries.Entries[fromBank]
+
+	lookup := func(addr uint16) *execution.Result {
+		e := entries[addr&bits]
+		if e == nil || e.Result.Defn == nil || e.Level < EntryLevelDecoded {
+			return nil
+		}
+		return &e.Result
+	}
+
+	ca := cycleAnalysis(lookup, bits, fromAddr, toAddr)
+	ca.Bank = fromBank
+	return ca, nil
+}
+
+// resolve the string to a bank and address. the string can be a label or a
+// numeric address. numeric addresses are assumed to be in the current bank
+func (dsm *Disassembly) resolveCycleAddress(s string) (int, uint16, error) {
+	if v, err := strconv.ParseUint(s, 0, 16); err == nil {
+		addr := uint16(v)
+		if _, area := memorymap.MapAddress(addr, true); area != memorymap.Cartridge {
+			return 0, 0, fmt.Errorf("cycles: %s is not a cartridge address", s)
+		}
+		return dsm.vcs.Mem.Cart.GetBank(addr).Number, addr, nil
+	}
+
+	res := dsm.Sym.SearchBySymbol(s, symbols.SearchLabel)
+	if res == nil {
+		return 0, 0, fmt.Errorf("cycles: %s is not a label or an address", s)
+	}
+
+	return res.Bank, res.Address, nil
+}
+
+// cycleStep is one point in the walk through the program.
+type cycleStep struct {
+	addr     uint16
+	min      int
+	max      int
+	length   int
+	branches []CycleBranch
+
+	// return addresses for subroutines entered on this path
+	calls []uint16
+
+	// the addresses visited on this path. indexed by the address and the
+	// depth of the call stack. the same address at different call depths
+	// does not indicate a loop
+	visited map[cycleVisit]bool
+}
+
+type cycleVisit struct {
+	addr  uint16
+	depth int
+}
+
+// cycleAnalysis walks through every path from the from address to the to
+// address. the lookup function returns the execution result for the address,
+// or nil if there is no instruction at the address. the bits argument is used
+// to normalise addresses before comparison
+func cycleAnalysis(lookup func(uint16) *execution.Result, bits uint16, from uint16, to uint16) *CycleAnalysis {
+	ca := &CycleAnalysis{
+		From: from,
+		To:   to,
+	}
+
+	warn := func(msg string, args ...any) {
+		// don't repeat warnings
+		w := fmt.Sprintf(msg, args...)
+		for _, v := range ca.Warnings {
+			if v == w {
+				return
+			}
+		}
+
+		if len(ca.Warnings) >= maxCycleAnalysisErr {
+			ca.WarningsTruncated = true
+			return
+		}
+
+		ca.Warnings = append(ca.Warnings, w)
+	}
+
+	// the number of instructions visited over all paths, including paths that
+	// never reach the to address. limiting the number of paths that reach the
+	// to address is not enough because a program with many branches that
+	// don't lead to the to address would otherwise be explored exhaustively
+	var steps int
+
+	var walk func(step cycleStep, first bool)
+
+	walk = func(step cycleStep, first bool) {
+		for {
+			if ca.Truncated {
+				return
+			}
+
+			steps++
+			if steps > maxCycleSteps || len(ca.Paths) >= maxCyclePaths || step.length >= maxCyclePathLength {
+				ca.Truncated = true
+				return
+			}
+
+			if !first && step.addr&bits == to&bits {
+				ca.Paths = append(ca.Paths, CyclePath{
+					Min:      step.min,
+					Max:      step.max,
+					Branches: step.branches,
+					Length:   step.length,
+				})
+				return
+			}
+
+			v := cycleVisit{addr: step.addr & bits, depth: len(step.calls)}
+			if step.visited[v] {
+				warn("loop at $%04x cannot be bounded", step.addr)
+				return
+			}
+			step.visited[v] = true
+			first = false
+
+			res := lookup(step.addr)
+			if res == nil {
+				warn("no instruction at $%04x", step.addr)
+				return
+			}
+
+			defn := res.Defn
+			step.length++
+			next := step.addr + uint16(defn.Bytes)
+
+			// instructions that are page sensitive may take an additional cycle.
+			// branch instructions are dealt with separately below
+			if !defn.IsBranch() {
+				step.min += defn.Cycles
+				step.max += defn.Cycles
+				if defn.PageSensitive {
+					step.max++
+				}
+			}
+
+			switch defn.Operator {
+			case instructions.BRK, instructions.RTI, instructions.JAM:
+				warn("path ends with %s at $%04x", defn.Operator, step.addr)
+				return
+
+			case instructions.RTS:
+				if len(step.calls) == 0 {
+					warn("path returns from subroutine at $%04x", step.addr)
+					return
+				}
+				next = step.calls[len(step.calls)-1]
+				step.calls = step.calls[:len(step.calls)-1]
+
+			case instructions.JSR:
+				if len(step.calls) >= maxCycleCallDepth {
+					warn("subroutine calls are too deep at $%04x", step.addr)
+					return
+				}
+				step.calls = append(step.calls[:len(step.calls):len(step.calls)], next)
+				next = res.InstructionData
+
+			case instructions.JMP:
+				if defn.AddressingMode == instructions.Indirect {
+					warn("indirect jump at $%04x cannot be followed", step.addr)
+					return
+				}
+				next = res.InstructionData
+			}
+
+			if defn.IsBranch() {
+				taken := absoluteBranchDestination(res.Address, res.InstructionData)
+				crossed := taken&0xff00 != next&0xff00
+
+				// follow the taken branch as a separate path
+				tk := step
+				tk.min += defn.Cycles + 1
+				tk.max += defn.Cycles + 1
+				if crossed {
+					tk.min++
+					tk.max++
+				}
+				tk.addr = taken
+				tk.branches = append(step.branches[:len(step.branches):len(step.branches)], CycleBranch{
+					Address:     step.addr,
+					Taken:       true,
+					PageCrossed: crossed,
+				})
+				tk.visited = maps.Clone(step.visited)
+				walk(tk, false)
+
+				// continue with the not taken branch
+				step.min += defn.Cycles
+				step.max += defn.Cycles
+				step.branches = append(step.branches[:len(step.branches):len(step.branches)], CycleBranch{
+					Address: step.addr,
+				})
+			}
+
+			if _, area := memorymap.MapAddress(next, true); area != memorymap.Cartridge {
+				warn("path leaves cartridge space at $%04x", step.addr)
+				return
+			}
+
+			step.addr = next
+		}
+	}
+
+	walk(cycleStep{
+		addr:    from,
+		visited: make(map[cycleVisit]bool),
+	}, true)
+
+	return ca
+}
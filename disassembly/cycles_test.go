@@ -0,0 +1,151 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package disassembly
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware/cpu/execution"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// returns a lookup function suitable for the cycleAnalysis() function for the
+// program data, which is assumed to start at address $f000
+func cyclesLookup(t *testing.T, data []uint8) func(uint16) *execution.Result {
+	t.Helper()
+
+	const origin = 0xf000
+
+	dec := newQuickDecode()
+	results := make(map[uint16]*execution.Result)
+
+	// the CPU will read the byte after a single byte instruction so we pad the
+	// data to make sure the read is possible
+	padded := append(data, 0x00)
+
+	return func(addr uint16) *execution.Result {
+		if r, ok := results[addr]; ok {
+			return r
+		}
+		if addr < origin || int(addr-origin) >= len(data) {
+			return nil
+		}
+		res, err := dec.decode(addr, padded, origin)
+		test.ExpectSuccess(t, err)
+		results[addr] = &res
+		return &res
+	}
+}
+
+func TestCyclesStraight(t *testing.T) {
+	data := []uint8{
+		0xa9, 0x00, // $f000 lda #$00	(2 cycles)
+		0x85, 0x80, // $f002 sta $80	(3 cycles)
+		0xbd, 0x00, 0xf1, // $f004 lda $f100,x (4 cycles + 1 if page crossed)
+		0xea, // $f007 nop
+	}
+
+	ca := cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf000, 0xf007)
+	test.ExpectEquality(t, len(ca.Paths), 1)
+	test.ExpectEquality(t, len(ca.Warnings), 0)
+	test.ExpectEquality(t, ca.Min(), 9)
+	test.ExpectEquality(t, ca.Max(), 10)
+}
+
+func TestCyclesBranch(t *testing.T) {
+	data := []uint8{
+		0xd0, 0x02, // $f000 bne $f004
+		0xea, // $f002 nop
+		0xea, // $f003 nop
+		0xea, // $f004 nop
+	}
+
+	ca := cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf000, 0xf004)
+	test.ExpectEquality(t, len(ca.Paths), 2)
+	test.ExpectEquality(t, len(ca.Warnings), 0)
+
+	// branch taken (3 cycles) or not taken (2 cycles) followed by two NOPs
+	test.ExpectEquality(t, ca.Min(), 3)
+	test.ExpectEquality(t, ca.Max(), 6)
+}
+
+func TestCyclesSubroutine(t *testing.T) {
+	data := []uint8{
+		0x20, 0x04, 0xf0, // $f000 jsr $f004
+		0xea, // $f003 nop
+		0xea, // $f004 nop
+		0x60, // $f005 rts
+	}
+
+	ca := cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf000, 0xf003)
+	test.ExpectEquality(t, len(ca.Paths), 1)
+	test.ExpectEquality(t, ca.Min(), 14)
+	test.ExpectEquality(t, ca.Max(), 14)
+}
+
+func TestCyclesLoop(t *testing.T) {
+	data := []uint8{
+		0xca,       // $f000 dex
+		0xd0, 0xfd, // $f001 bne $f000
+		0xea, // $f003 nop
+	}
+
+	// the loop passes through the from address so every path is bounded
+	ca := cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf000, 0xf000)
+	test.ExpectEquality(t, len(ca.Paths), 1)
+	test.ExpectEquality(t, ca.Min(), 5)
+
+	// the loop does not pass through the from address so it can't be bounded
+	ca = cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf001, 0xf003)
+	test.ExpectEquality(t, len(ca.Paths), 1)
+	test.ExpectEquality(t, ca.Min(), 2)
+	test.ExpectEquality(t, len(ca.Warnings), 1)
+}
+
+func TestCyclesBranchLadder(t *testing.T) {
+	// a long sequence of branches that lead to the next instruction whether
+	// the branch is taken or not. the path ends with a BRK instruction and
+	// never reaches the to address. without a limit on the amount of work
+	// done, the number of paths to explore would be 2^N
+	var data []uint8
+	for range 40 {
+		data = append(data, 0xd0, 0x00) // bne *+2
+	}
+	data = append(data, 0x00) // brk
+
+	ca := cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf000, 0xf100)
+	test.ExpectEquality(t, len(ca.Paths), 0)
+	test.ExpectSuccess(t, ca.Truncated)
+}
+
+func TestCyclesManyWarnings(t *testing.T) {
+	// a sequence of branches where the not-taken direction always ends with a
+	// BRK instruction. every BRK produces a distinct warning but the path to
+	// the to address is still found
+	var data []uint8
+	for range 20 {
+		data = append(data, 0xf0, 0x01) // beq *+3
+		data = append(data, 0x00)       // brk
+	}
+	data = append(data, 0xea) // nop
+
+	ca := cycleAnalysis(cyclesLookup(t, data), 0x0fff, 0xf000, 0xf000+uint16(len(data)-1))
+	test.ExpectEquality(t, len(ca.Paths), 1)
+	test.ExpectEquality(t, ca.Min(), 60)
+	test.ExpectEquality(t, len(ca.Warnings), maxCycleAnalysisErr)
+	test.ExpectSuccess(t, ca.WarningsTruncated)
+	test.ExpectFailure(t, ca.Truncated)
+}
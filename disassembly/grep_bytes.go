@@ -0,0 +1,306 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package disassembly
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// BytePattern is a sequence of bytes to search for with GrepBytes(). Each
+// byte in the pattern has an associated mask. Only the bits set in the mask
+// are compared.
+type BytePattern struct {
+	Value []uint8
+	Mask  []uint8
+}
+
+// ParseBytePattern creates a BytePattern from a list of fields. Each field is
+// a single byte expressed in hexadecimal, with an optional 0x or $ prefix.
+//
+// A question mark can be used in place of either of the two hex digits to
+// indicate that the nibble can have any value. A field of ?? (or a single ?)
+// will match any byte.
+//
+// An explicit mask can be given after a forward slash. For example, 80/f0
+// will match any byte in the range 0x80 to 0x8f.
+func ParseBytePattern(fields []string) (BytePattern, error) {
+	var p BytePattern
+
+	for _, f := range fields {
+		for s := range strings.FieldsSeq(f) {
+			v, m, err := parsePatternByte(s)
+			if err != nil {
+				return BytePattern{}, err
+			}
+			p.Value = append(p.Value, v)
+			p.Mask = append(p.Mask, m)
+		}
+	}
+
+	if len(p.Value) == 0 {
+		return BytePattern{}, fmt.Errorf("grep bytes: empty pattern")
+	}
+
+	// a pattern made up entirely of wildcards would match everything
+	if !slices.ContainsFunc(p.Mask, func(m uint8) bool { return m != 0x00 }) {
+		return BytePattern{}, fmt.Errorf("grep bytes: pattern contains only wildcards")
+	}
+
+	return p, nil
+}
+
+// parse a single field of a byte pattern. returns the value and the mask
+func parsePatternByte(s string) (uint8, uint8, error) {
+	s = strings.ToLower(s)
+	s = strings.TrimPrefix(s, "0x")
+	s = strings.TrimPrefix(s, "$")
+
+	// explicit mask
+	if v, m, ok := strings.Cut(s, "/"); ok {
+		vv, err := strconv.ParseUint(v, 16, 8)
+		if err != nil {
+			return 0, 0, fmt.Errorf("grep bytes: invalid value in pattern (%s)", s)
+		}
+		m = strings.TrimPrefix(m, "0x")
+		m = strings.TrimPrefix(m, "$")
+		mm, err := strconv.ParseUint(m, 16, 8)
+		if err != nil {
+			return 0, 0, fmt.Errorf("grep bytes: invalid mask in pattern (%s)", s)
+		}
+		return uint8(vv) & uint8(mm), uint8(mm), nil
+	}
+
+	if s == "?" || s == "??" {
+		return 0x00, 0x00, nil
+	}
+
+	if len(s) == 1 {
+		s = "0" + s
+	}
+	if len(s) != 2 {
+		return 0, 0, fmt.Errorf("grep bytes: invalid byte in pattern (%s)", s)
+	}
+
+	var v, m uint8
+	for i := range 2 {
+		v <<= 4
+		m <<= 4
+		if s[i] == '?' {
+			continue // for loop
+		}
+		n, err := strconv.ParseUint(s[i:i+1], 16, 8)
+		if err != nil {
+			return 0, 0, fmt.Errorf("grep bytes: invalid byte in pattern (%s)", s)
+		}
+		v |= uint8(n)
+		m |= 0x0f
+	}
+
+	return v, m, nil
+}
+
+// String returns the pattern in the format accepted by ParseBytePattern().
+func (p BytePattern) String() string {
+	s := strings.Builder{}
+	for i := range p.Value {
+		if i > 0 {
+			s.WriteRune(' ')
+		}
+		switch p.Mask[i] {
+		case 0xff:
+			fmt.Fprintf(&s, "%02x", p.Value[i])
+		case 0x00:
+			s.WriteString("??")
+		case 0xf0:
+			fmt.Fprintf(&s, "%x?", p.Value[i]>>4)
+		case 0x0f:
+			fmt.Fprintf(&s, "?%x", p.Value[i]&0x0f)
+		default:
+			fmt.Fprintf(&s, "%02x/%02x", p.Value[i], p.Mask[i])
+		}
+	}
+	return s.String()
+}
+
+// match returns the index of every occurrence of the pattern in the data
+func (p BytePattern) match(data []uint8) []int {
+	var idx []int
+	for i := 0; i+len(p.Value) <= len(data); i++ {
+		ok := true
+		for j := range p.Value {
+			if data[i+j]&p.Mask[j] != p.Value[j] {
+				ok = false
+				break // for loop
+			}
+		}
+		if ok {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// ByteArea indicates the type of memory in which a ByteMatch was found.
+type ByteArea int
+
+// List of valid ByteArea values.
+const (
+	ByteAreaROM ByteArea = iota
+	ByteAreaRAM
+	ByteAreaStatic
+)
+
+func (a ByteArea) String() string {
+	switch a {
+	case ByteAreaROM:
+		return "ROM"
+	case ByteAreaRAM:
+		return "RAM"
+	case ByteAreaStatic:
+		return "static"
+	}
+	return "unknown"
+}
+
+// ByteMatch is a single result of a GrepBytes() search.
+type ByteMatch struct {
+	Area ByteArea
+
+	// the name of the cartridge RAM or static segment the match was found in.
+	// empty for ByteAreaROM
+	Segment string
+
+	// the bank number of the ROM or the index of the cartridge RAM in which
+	// the match was found. not used for ByteAreaStatic
+	Bank int
+
+	// offset of the match from the start of the bank or segment
+	Offset int
+
+	// the address the match is mapped to. for ByteAreaROM this is an address
+	// in the primary cartridge mirror. for ByteAreaStatic this is an address
+	// in the coprocessor's address space
+	Address uint32
+
+	// whether the Address field is valid. some ROM banks can not be mapped
+	// into the cartridge address space
+	Mapped bool
+}
+
+func (m ByteMatch) String() string {
+	var s strings.Builder
+	switch m.Area {
+	case ByteAreaROM:
+		fmt.Fprintf(&s, "bank %d", m.Bank)
+	case ByteAreaRAM:
+		fmt.Fprintf(&s, "RAM %s", m.Segment)
+	case ByteAreaStatic:
+		fmt.Fprintf(&s, "static %s", m.Segment)
+	}
+	fmt.Fprintf(&s, " offset %#04x", m.Offset)
+	if m.Mapped {
+		if m.Area == ByteAreaStatic {
+			fmt.Fprintf(&s, " address %#08x", m.Address)
+		} else {
+			fmt.Fprintf(&s, " address $%04x", m.Address)
+		}
+	}
+	return s.String()
+}
+
+// GrepBytes searches every cartridge bank, the cartridge RAM and the
+// cartridge static area for the byte pattern. Returns every match in the
+// order of ROM banks, RAM and then static areas.
+func (dsm *Disassembly) GrepBytes(pattern BytePattern) ([]ByteMatch, error) {
+	if len(pattern.Value) == 0 || len(pattern.Value) != len(pattern.Mask) {
+		return nil, fmt.Errorf("grep bytes: invalid pattern")
+	}
+
+	var matches []ByteMatch
+
+	banks, err := dsm.vcs.Mem.Cart.CopyBanks()
+	if err != nil {
+		return nil, fmt.Errorf("grep bytes: %w", err)
+	}
+
+	for _, b := range banks {
+		for _, i := range pattern.match(b.Data) {
+			m := ByteMatch{
+				Area:   ByteAreaROM,
+				Bank:   b.Number,
+				Offset: i,
+			}
+			if len(b.Origins) > 0 {
+				m.Address = uint32(b.Origins[0]) + uint32(i)
+				m.Mapped = m.Address <= uint32(memorymap.MemtopCart)
+			}
+			matches = append(matches, m)
+		}
+	}
+
+	if bus := dsm.vcs.Mem.Cart.GetRAMbus(); bus != nil {
+		for b, r := range bus.GetRAM() {
+			for _, i := range pattern.match(r.Data) {
+				matches = append(matches, ByteMatch{
+					Area:    ByteAreaRAM,
+					Segment: r.Label,
+					Bank:    b,
+					Offset:  i,
+					Address: uint32(r.Origin) + uint32(i),
+					Mapped:  r.Mapped,
+				})
+			}
+		}
+	}
+
+	if bus := dsm.vcs.Mem.Cart.GetStaticBus(); bus != nil {
+		static := bus.GetStatic()
+		if static != nil {
+			for _, seg := range static.Segments() {
+				data, ok := static.Reference(seg.Name)
+				if !ok {
+					continue // for loop
+				}
+				for _, i := range pattern.match(data) {
+					matches = append(matches, ByteMatch{
+						Area:    ByteAreaStatic,
+						Segment: seg.Name,
+						Offset:  i,
+						Address: seg.Origin + uint32(i),
+						Mapped:  true,
+					})
+				}
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// WriteByteMatches writes the list of matches to the io.Writer, one match per
+// line.
+func WriteByteMatches(output io.Writer, matches []ByteMatch) {
+	for _, m := range matches {
+		output.Write([]byte(m.String()))
+		output.Write([]byte("\n"))
+	}
+}
@@ -0,0 +1,76 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package disassembly
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestBytePatternParse(t *testing.T) {
+	p, err := ParseBytePattern([]string{"a9", "??", "0x85", "$8?", "?f", "80/e0"})
+	test.DemandSuccess(t, err)
+	test.ExpectEquality(t, p.String(), "a9 ?? 85 8? ?f 80/e0")
+
+	// fields can contain more than one byte
+	p, err = ParseBytePattern([]string{"a9 00", "85"})
+	test.DemandSuccess(t, err)
+	test.ExpectEquality(t, p.String(), "a9 00 85")
+
+	// single digit values are allowed
+	p, err = ParseBytePattern([]string{"a"})
+	test.DemandSuccess(t, err)
+	test.ExpectEquality(t, p.String(), "0a")
+
+	_, err = ParseBytePattern([]string{})
+	test.ExpectFailure(t, err)
+	_, err = ParseBytePattern([]string{"??", "?"})
+	test.ExpectFailure(t, err)
+	_, err = ParseBytePattern([]string{"a9", "100"})
+	test.ExpectFailure(t, err)
+	_, err = ParseBytePattern([]string{"zz"})
+	test.ExpectFailure(t, err)
+	_, err = ParseBytePattern([]string{"80/"})
+	test.ExpectFailure(t, err)
+}
+
+func TestBytePatternMatch(t *testing.T) {
+	data := []uint8{0xa9, 0x00, 0x85, 0x80, 0xa9, 0xff, 0x85, 0x81, 0xea}
+
+	p, err := ParseBytePattern([]string{"a9", "??", "85"})
+	test.DemandSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(p.match(data), []int{0, 4}))
+
+	p, err = ParseBytePattern([]string{"85", "8?"})
+	test.DemandSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(p.match(data), []int{2, 6}))
+
+	p, err = ParseBytePattern([]string{"85", "81/ff", "ea"})
+	test.DemandSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(p.match(data), []int{6}))
+
+	// pattern at the very end of the data
+	p, err = ParseBytePattern([]string{"ea"})
+	test.DemandSuccess(t, err)
+	test.ExpectSuccess(t, slices.Equal(p.match(data), []int{8}))
+
+	// pattern longer than data
+	p, err = ParseBytePattern([]string{"ea", "ea"})
+	test.DemandSuccess(t, err)
+	test.ExpectEquality(t, len(p.match(data)), 0)
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
 	"github.com/jetsetilly/gopher2600/hardware/memory/cpubus"
 	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
-	"github.com/jetsetilly/gopher2600/logger"
 )
 
 // put canonical symbols into table. prefer flag should be true if canonical
@@ -63,9 +62,12 @@ func (sym *Symbols) canonise(cart *cartridge.Cartridge) {
 	hb := cart.GetCartHotspotsBus()
 	if hb != nil {
 		for k, v := range hb.Hotspots() {
+			// hotspots outside of the cartridge address space are not mapped
+			// because doing so would replace the system symbol for the mapped
+			// address
 			ma, area := memorymap.MapAddress(k, true)
 			if area != memorymap.Cartridge {
-				logger.Logf(logger.Allow, "symbols", "%s reporting hotspot (%s) outside of cartridge address space", cart.ID(), v.Symbol)
+				ma = k
 			}
 
 			switch v.Action {
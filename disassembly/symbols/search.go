@@ -40,6 +40,10 @@ type SearchResults struct {
 
 	// the normalised address the symbol refers to
 	Address uint16
+
+	// the bank the symbol was found in. only meaningful for the SearchLabel
+	// table
+	Bank int
 }
 
 // SearchBySymbol return the address of the supplied search string. Matching is
@@ -50,12 +54,13 @@ func (sym *Symbols) SearchBySymbol(symbol string, table SearchTable) *SearchResu
 
 	switch table {
 	case SearchLabel:
-		for _, l := range sym.label {
+		for b, l := range sym.label {
 			if e, addr, ok := l.search(symbol); ok {
 				return &SearchResults{
 					Table:   SearchLabel,
 					Entry:   e,
 					Address: addr,
+					Bank:    b,
 				}
 			}
 		}
@@ -88,12 +93,13 @@ func (sym *Symbols) SearchByAddress(addr uint16, table SearchTable) *SearchResul
 		// label symbol table contains normalised/mapped addresses
 		addr, _ = memorymap.MapAddress(addr, true)
 
-		for _, l := range sym.label {
+		for b, l := range sym.label {
 			if s, ok := l.symbols[addr]; ok {
 				return &SearchResults{
 					Table:   SearchLabel,
 					Entry:   s,
 					Address: addr,
+					Bank:    b,
 				}
 			}
 		}
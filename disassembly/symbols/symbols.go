@@ -151,6 +151,12 @@ func (sym *Symbols) GetReadSymbol(val uint16, allowSystemSymbols bool) (Entry, b
 	sym.crit.Lock()
 	defer sym.crit.Unlock()
 
+	// cartridge symbols for addresses outside of the cartridge address space
+	// are not mapped and take priority over any system symbol
+	if e, ok := sym.read.get(val); ok && e.Source == SourceCartridge {
+		return e, true
+	}
+
 	// we allow address mapping of system symbols
 	ma, _ := memorymap.MapAddress(val, true)
 	e, ok := sym.read.get(ma)
@@ -169,6 +175,12 @@ func (sym *Symbols) GetWriteSymbol(val uint16) (Entry, bool) {
 	sym.crit.Lock()
 	defer sym.crit.Unlock()
 
+	// cartridge symbols for addresses outside of the cartridge address space
+	// are not mapped and take priority over any system symbol
+	if e, ok := sym.write.get(val); ok && e.Source == SourceCartridge {
+		return e, true
+	}
+
 	// we allow address mapping of system symbols
 	ma, _ := memorymap.MapAddress(val, false)
 	e, ok := sym.write.get(ma)
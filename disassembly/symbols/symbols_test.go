@@ -21,7 +21,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
 	"github.com/jetsetilly/gopher2600/disassembly/symbols"
+	"github.com/jetsetilly/gopher2600/environment"
 	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge"
 	"github.com/jetsetilly/gopher2600/test"
 )
@@ -72,3 +74,48 @@ func TestFlappySymbols(t *testing.T) {
 		t.Errorf("flappy symbols list is wrong")
 	}
 }
+
+func TestHotspotSymbols(t *testing.T) {
+	var sym symbols.Symbols
+
+	// without a cartridge the TIA mirror has the system symbol
+	cart := cartridge.NewCartridge(nil)
+	test.ExpectSuccess(t, sym.InitialiseFromCartridge(cart))
+
+	e, ok := sym.GetWriteSymbol(0x0800)
+	test.ExpectEquality(t, ok, true)
+	test.ExpectEquality(t, e.Symbol, "VSYNC")
+
+	// the 0840 mapper has hotspots in a mirror of the TIA
+	ld, err := cartridgeloader.NewLoaderFromData("symbols_test", make([]byte, 8192), "0840", "", nil, nil)
+	test.ExpectSuccess(t, err)
+	cart = cartridge.NewCartridge(&environment.Environment{Loader: ld})
+	test.ExpectSuccess(t, cart.Attach(ld))
+	test.ExpectSuccess(t, sym.InitialiseFromCartridge(cart))
+
+	// the cartridge symbol takes priority over the system symbol for the
+	// hotspot address
+	e, ok = sym.GetWriteSymbol(0x0800)
+	test.ExpectEquality(t, ok, true)
+	test.ExpectEquality(t, e.Symbol, "BANK0")
+	test.ExpectEquality(t, e.Source, symbols.SourceCartridge)
+
+	e, ok = sym.GetReadSymbol(0x0840, false)
+	test.ExpectEquality(t, ok, true)
+	test.ExpectEquality(t, e.Symbol, "BANK1")
+
+	// the hotspots have not replaced the system symbols for the mapped
+	// addresses
+	e, ok = sym.GetWriteSymbol(0x0000)
+	test.ExpectEquality(t, ok, true)
+	test.ExpectEquality(t, e.Symbol, "VSYNC")
+
+	e, ok = sym.GetReadSymbol(0x0000, true)
+	test.ExpectEquality(t, ok, true)
+	test.ExpectEquality(t, e.Symbol, "CXM0P")
+
+	// other mirrors of the TIA still have the system symbol
+	e, ok = sym.GetWriteSymbol(0x0040)
+	test.ExpectEquality(t, ok, true)
+	test.ExpectEquality(t, e.Symbol, "VSYNC")
+}
@@ -37,6 +37,7 @@ type Television interface {
 	GetFrameInfo() frameinfo.Current
 	SetRotation(specification.Rotation)
 	GetCoords() coords.TelevisionCoords
+	IsRewinding() bool
 }
 
 // Environment is used to provide context for an emulation. Particularly useful
@@ -110,6 +111,13 @@ func (env *Environment) IsEmulation(label Label) bool {
 	return env.Label == label
 }
 
+// IsRewinding returns true if the emulation is being run by the rewind system.
+// Side effects outside of the emulation, such as writing files, should not
+// happen while rewinding
+func (env *Environment) IsRewinding() bool {
+	return env.TV != nil && env.TV.IsRewinding()
+}
+
 // AllowLogging returns true if environment is permitted to create new log entries
 func (env *Environment) AllowLogging() bool {
 	return env.IsEmulation(MainEmulation)
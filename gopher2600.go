@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/coprocessor/developer/budget"
 	"github.com/jetsetilly/gopher2600/debugger"
 	"github.com/jetsetilly/gopher2600/debugger/govern"
 	"github.com/jetsetilly/gopher2600/debugger/terminal"
@@ -292,10 +293,14 @@ func headless(mode string, sync *mainSync, args []string) error {
 
 	flgs := flag.NewFlagSet(mode, flag.ContinueOnError)
 	flgs.StringVar(&opts.DWARF, "dwarf", "", "path to DWARF file. only valid for some coproc supporting ROMs")
+	flgs.StringVar(&opts.Trace, "trace", "", "write CPU instructions to file. takes the same arguments as the TRACE FILE debugger command")
+	flgs.StringVar(&opts.CoProcProfile, "coprocProfile", "", "export coprocessor profile to file. takes the same arguments as the COPROC PROFILE debugger command")
+	flgs.StringVar(&opts.CoProcBudget, "budget", "", "coprocessor cycle budget file. defaults to a .budget file next to the ROM")
 	err := flgs.Parse(args)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			fmt.Fprintf(os.Stderr, "  pipe debugger commands to stdin\n")
+			fmt.Fprintf(os.Stderr, "  the emulation fails with a report if the coprocessor cycle budget is exceeded\n")
 			return nil
 		}
 		return err
@@ -313,7 +318,26 @@ func headless(mode string, sync *mainSync, args []string) error {
 		romFile = args[0]
 	}
 
-	return dbg.StartInDebugMode(romFile)
+	err = dbg.StartInDebugMode(romFile)
+	if err != nil && !errors.Is(err, ports.PowerOff) {
+		return err
+	}
+
+	// fail if the coprocessor cycle budget has been exceeded. also fail if a
+	// budget was specified but was not loaded. the reason for the failure to
+	// load will have been logged
+	dbg.CoProcDev.BorrowBudget(func(b *budget.Budget) {
+		if b == nil && opts.CoProcBudget != "" {
+			err = fmt.Errorf("coprocessor cycle budget could not be loaded from %s", opts.CoProcBudget)
+			return
+		}
+		if b != nil && b.Exceeded() {
+			b.Report(os.Stdout)
+			err = fmt.Errorf("coprocessor cycle budget exceeded")
+		}
+	})
+
+	return err
 }
 
 // emulate is the main emulation launch function, shared by play and debug
@@ -350,11 +374,18 @@ func emulate(mode string, sync *mainSync, args []string) error {
 	flgs.StringVar(&opts.Keyportari, "keyportari", "NONE", "protocol to use for keyportari: NONE, ASCII, 24CHAR")
 	flgs.StringVar(&opts.Profile, "profile", "none", "run performance check with profiling: CPU, MEM, TRACE, ALL (comma sep)")
 	flgs.StringVar(&opts.DWARF, "dwarf", "", "path to DWARF file. only valid for some coproc supporting ROMs")
+	flgs.StringVar(&opts.Trace, "trace", "", "write CPU instructions to file. takes the same arguments as the TRACE FILE debugger command")
+	flgs.StringVar(&opts.CoProcProfile, "coprocProfile", "", "export coprocessor profile to file. takes the same arguments as the COPROC PROFILE debugger command")
+	flgs.StringVar(&opts.CoProcBudget, "budget", "", "coprocessor cycle budget file. defaults to a .budget file next to the ROM")
 
 	// playmode specific arguments
 	if emulationMode == govern.ModePlay {
 		flgs.StringVar(&opts.ComparisonROM, "comparisonROM", "", "ROM to run in parallel for comparison")
 		flgs.StringVar(&opts.ComparisonPrefs, "comparisonPrefs", "", "preferences for comparison emulation")
+		flgs.StringVar(&opts.ComparisonReference, "comparisonReference", "", "trace or digests file to compare emulation against")
+		flgs.StringVar(&opts.ComparisonTraceFormat, "comparisonTraceFormat", "GOPHER", "format of comparisonReference if it is a trace. preset name or format string")
+		flgs.StringVar(&opts.ComparisonDigests, "comparisonDigests", "", "write video and audio digests of every frame to file for use with comparisonReference")
+		flgs.StringVar(&opts.ComparisonLockstep, "comparisonLockstep", "NONE", "compare emulation state and halt on divergence: NONE, SCANLINE, INSTRUCTION")
 		flgs.BoolVar(&opts.Record, "record", false, "record user input to new file for future playback")
 		flgs.StringVar(&opts.RecordFilename, "recordFilename", "", "set output name for recording")
 		flgs.BoolVar(&opts.PlaybackCheckROM, "playbackCheckROM", true, "check ROM hash on playback")
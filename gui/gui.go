@@ -55,7 +55,7 @@ const (
 	ReqPeripheralPlugged FeatureReq = "ReqPeripheralPlugging" // plugging.PortID, plugging.PeripheralID
 
 	// request for a comparison window to be opened.
-	ReqComparison FeatureReq = "ReqComparison" // chan *image.RGBA, chan *image.RGBA, chan string
+	ReqComparison FeatureReq = "ReqComparison" // chan *image.RGBA, chan *image.RGBA, chan comparison.FrameDiff
 
 	// request for bot features to be enabled. a nil argument will cause the
 	// bot features to be removed.
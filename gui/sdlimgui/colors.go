@@ -221,6 +221,7 @@ type imguiColors struct {
 	TermStyleInstrument        imgui.Vec4
 	TermStyleError             imgui.Vec4
 	TermStyleLog               imgui.Vec4
+	TermStyleCoProcConsole     imgui.Vec4
 
 	// helpers
 	ToolTipBG imgui.Vec4
@@ -451,6 +452,7 @@ func newColors() *imguiColors {
 		TermStyleInstrument:        imgui.Vec4{X: 0.1, Y: 0.95, Z: 0.9, W: 1.0},
 		TermStyleError:             imgui.Vec4{X: 0.8, Y: 0.3, Z: 0.3, W: 1.0},
 		TermStyleLog:               imgui.Vec4{X: 0.8, Y: 0.7, Z: 0.3, W: 1.0},
+		TermStyleCoProcConsole:     imgui.Vec4{X: 0.4, Y: 0.9, Z: 0.4, W: 1.0},
 
 		// helpers
 		ToolTipBG: imgui.Vec4{X: 0.2, Y: 0.1, Z: 0.2, W: 0.8},
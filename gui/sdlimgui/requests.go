@@ -20,6 +20,7 @@ import (
 	"image"
 
 	"github.com/jetsetilly/gopher2600/bots"
+	"github.com/jetsetilly/gopher2600/comparison"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
 	"github.com/jetsetilly/gopher2600/debugger/govern"
 	"github.com/jetsetilly/gopher2600/gui"
@@ -119,7 +120,7 @@ func (img *SdlImgui) serviceSetFeature(request featureRequest) {
 				open = true
 			}
 			if request.args[2] != nil {
-				img.wm.playmodeWindows[winComparisonID].(*winComparison).audioDiff = request.args[2].(chan bool)
+				img.wm.playmodeWindows[winComparisonID].(*winComparison).diff = request.args[2].(chan comparison.FrameDiff)
 				open = true
 			}
 			img.wm.playmodeWindows[winComparisonID].(*winComparison).playmodeSetOpen(open)
@@ -78,7 +78,7 @@ func (win *winCDFRegisters) draw(regs cdf.Registers) {
 	imgui.BeginGroup()
 	imgui.Text("Pointers")
 	imgui.Spacing()
-	for i := range len(regs.Datastream) {
+	for i := range regs.NumDatastreams {
 		if i%2 != 0 {
 			imgui.SameLine()
 		}
@@ -100,7 +100,7 @@ func (win *winCDFRegisters) draw(regs cdf.Registers) {
 	imgui.BeginGroup()
 	imgui.Text("Increments")
 	imgui.Spacing()
-	for i := range len(regs.Datastream) {
+	for i := range regs.NumDatastreams {
 		if i%2 != 0 {
 			imgui.SameLine()
 		}
@@ -45,14 +45,14 @@ type winCDFStreams struct {
 
 	img *SdlImgui
 
-	streamPixels   [cdf.NumDatastreams]*image.RGBA
-	streamTextures [cdf.NumDatastreams]texture
+	streamPixels   [cdf.MaxDatastreams]*image.RGBA
+	streamTextures [cdf.MaxDatastreams]texture
 	pixelsSize     image.Point
 
 	detailTexture texture
 
 	colouriser   datastreamDragAndDrop
-	colourSource [cdf.NumDatastreams]int
+	colourSource [cdf.MaxDatastreams]int
 
 	trackScreen bool
 	scanlines   int32
@@ -114,7 +114,7 @@ func (win *winCDFStreams) updateStreams(regs cdf.Registers, static mapper.CartSt
 	spec := win.img.cache.TV.GetFrameInfo().Spec
 
 	// draw pixels
-	for i := range regs.Datastream {
+	for i := range regs.NumDatastreams {
 		for y := 0; y < win.pixelsSize.Y; y++ {
 			// pixel data
 			v := regs.Datastream[i].Peek(y, static)
@@ -198,7 +198,7 @@ func (win *winCDFStreams) draw(regs cdf.Registers, static mapper.CartStatic) {
 		// disable preview color. it will be turned on if drag and drop is being used this frame.
 		win.colouriser.active = false
 
-		for i := range len(win.streamTextures) {
+		for i := range regs.NumDatastreams {
 			imgui.BeginGroup()
 
 			// styling for datastream buttons )including the image button)
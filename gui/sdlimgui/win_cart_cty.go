@@ -0,0 +1,144 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package sdlimgui
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge"
+	"github.com/jetsetilly/imgui-go/v5"
+)
+
+const winCTYregistersID = "CTY Registers"
+
+type winCTYregisters struct {
+	debuggerWin
+
+	img *SdlImgui
+}
+
+func newWinCTYregisters(img *SdlImgui) (window, error) {
+	win := &winCTYregisters{
+		img: img,
+	}
+
+	return win, nil
+}
+
+func (win *winCTYregisters) init() {
+}
+
+func (win *winCTYregisters) id() string {
+	return winCTYregistersID
+}
+
+func (win *winCTYregisters) debuggerDraw() bool {
+	if !win.debuggerOpen {
+		return false
+	}
+
+	// do not open window if there is no cartridge registers bus available
+	bus := win.img.cache.VCS.Mem.Cart.GetRegistersBus()
+	if bus == nil {
+		return false
+	}
+	regs, ok := bus.GetRegisters().(cartridge.CTYregisters)
+	if !ok {
+		return false
+	}
+
+	imgui.SetNextWindowPosV(imgui.Vec2{X: 255, Y: 153}, imgui.ConditionFirstUseEver, imgui.Vec2{X: 0, Y: 0})
+	if imgui.BeginV(win.debuggerID(win.id()), &win.debuggerOpen, imgui.WindowFlagsAlwaysAutoResize) {
+		win.draw(regs)
+	}
+
+	win.debuggerGeom.update()
+	imgui.End()
+
+	return true
+}
+
+func (win *winCTYregisters) draw(regs cartridge.CTYregisters) {
+	// operation register
+	op := fmt.Sprintf("%02x", regs.Operation)
+	imguiLabel("Operation")
+	if imguiHexInput("##operation", 2, &op) {
+		win.img.dbg.PushFunction(func() {
+			b := win.img.dbg.VCS().Mem.Cart.GetRegistersBus()
+			b.PutRegister("operation", op)
+		})
+	}
+	if regs.Busy {
+		imgui.SameLine()
+		imgui.Text("busy")
+	}
+
+	// random number generator value
+	rng := fmt.Sprintf("%08x", regs.RNG)
+	imguiLabel("Random Number Generator")
+	if imguiHexInput("##rng", 8, &rng) {
+		win.img.dbg.PushFunction(func() {
+			b := win.img.dbg.VCS().Mem.Cart.GetRegistersBus()
+			b.PutRegister("rng", rng)
+		})
+	}
+
+	imguiSeparator()
+
+	// tune and position in tune
+	imguiLabel(fmt.Sprintf("Tune %d", regs.Tune))
+	imgui.SameLine()
+	pos := fmt.Sprintf("%04x", regs.TunePosition)
+	imguiLabel("Position")
+	if imguiHexInput("##tuneposition", 4, &pos) {
+		win.img.dbg.PushFunction(func() {
+			b := win.img.dbg.VCS().Mem.Cart.GetRegistersBus()
+			b.PutRegister("tune::position", pos)
+		})
+	}
+
+	imgui.Spacing()
+
+	// loop over music fetchers
+	imgui.Text("Music Fetchers")
+	imgui.Spacing()
+	for i := range len(regs.MusicFetcher) {
+		f := i
+
+		imguiLabel(fmt.Sprintf("%d.", f))
+
+		label := fmt.Sprintf("##m%dfreq", i)
+		freq := fmt.Sprintf("%08x", regs.MusicFetcher[i].Freq)
+		imguiLabel("Freq")
+		if imguiHexInput(label, 8, &freq) {
+			win.img.dbg.PushFunction(func() {
+				b := win.img.dbg.VCS().Mem.Cart.GetRegistersBus()
+				b.PutRegister(fmt.Sprintf("musicfetcher::%d::freq", f), freq)
+			})
+		}
+
+		imgui.SameLine()
+		label = fmt.Sprintf("##m%dcount", i)
+		count := fmt.Sprintf("%08x", regs.MusicFetcher[i].Count)
+		imguiLabel("Count")
+		if imguiHexInput(label, 8, &count) {
+			win.img.dbg.PushFunction(func() {
+				b := win.img.dbg.VCS().Mem.Cart.GetRegistersBus()
+				b.PutRegister(fmt.Sprintf("musicfetcher::%d::count", f), count)
+			})
+		}
+	}
+}
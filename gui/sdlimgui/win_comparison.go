@@ -16,8 +16,10 @@
 package sdlimgui
 
 import (
+	"fmt"
 	"image"
 
+	"github.com/jetsetilly/gopher2600/comparison"
 	"github.com/jetsetilly/gopher2600/hardware/television/specification"
 	"github.com/jetsetilly/imgui-go/v5"
 )
@@ -29,14 +31,16 @@ type winComparison struct {
 
 	img *SdlImgui
 
-	cmpTexture       texture
-	diffTexture      texture
-	audioIsDifferent bool
+	cmpTexture  texture
+	diffTexture texture
+
+	// the most recent summary of differences between the two emulations
+	frameDiff comparison.FrameDiff
 
 	// render channels are given to use by the main emulation through a GUI request
 	render     chan *image.RGBA
 	diffRender chan *image.RGBA
-	audioDiff  chan bool
+	diff       chan comparison.FrameDiff
 }
 
 func newWinComparison(img *SdlImgui) (window, error) {
@@ -62,7 +66,7 @@ func (win *winComparison) playmodeSetOpen(open bool) {
 }
 
 func (win *winComparison) playmodeDraw() bool {
-	if win.render == nil || win.diffRender == nil || win.audioDiff == nil {
+	if win.render == nil || win.diffRender == nil || win.diff == nil {
 		win.playmodeWin.playmodeSetOpen(false)
 		return false
 	}
@@ -87,9 +91,9 @@ func (win *winComparison) playmodeDraw() bool {
 	default:
 	}
 
-	// receive new thumbnail data and copy to texture
+	// receive summary of differences
 	select {
-	case win.audioIsDifferent = <-win.audioDiff:
+	case win.frameDiff = <-win.diff:
 	default:
 	}
 
@@ -113,9 +117,34 @@ func (win *winComparison) draw() {
 	sz := imgui.Vec2{X: specification.WidthTV, Y: specification.HeightTV}.Times(2.5)
 	imgui.Image(imgui.TextureID(win.cmpTexture.getID()), sz)
 	imgui.Image(imgui.TextureID(win.diffTexture.getID()), sz)
-	if win.audioIsDifferent {
+
+	if win.frameDiff.AudioDiffers {
 		imguiColorLabel("Audio is different", win.img.cols.False)
 	} else {
 		imguiColorLabel("Audio is the same", win.img.cols.True)
 	}
+
+	// the difference signal is the sum of two channels for each emulation so
+	// the range of the signal is -30 to 30
+	imgui.PushStyleColor(imgui.StyleColorFrameBg, win.img.cols.AudioOscBg)
+	imgui.PushStyleColor(imgui.StyleColorPlotLines, win.img.cols.AudioOscLine)
+	imgui.PlotLinesV("##audiodiff", win.frameDiff.Audio, 0, "", -30, 30, imgui.Vec2{X: sz.X, Y: imgui.FrameHeight() * 2})
+	imgui.PopStyleColorV(2)
+
+	if len(win.frameDiff.RAM) == 0 {
+		imguiColorLabel("RAM is the same", win.img.cols.True)
+		return
+	}
+
+	imguiColorLabel(fmt.Sprintf("RAM is different at %d addresses", len(win.frameDiff.RAM)), win.img.cols.False)
+
+	const columns = 8
+	if imgui.BeginTableV("##ramdiff", columns, imgui.TableFlagsBordersInnerV, imgui.Vec2{}, 0) {
+		for _, a := range win.frameDiff.RAM {
+			imgui.TableNextColumn()
+			i := a & 0x7f
+			imgui.Text(fmt.Sprintf("$%02x %02x/%02x", a, win.frameDiff.DriverRAM[i], win.frameDiff.ComparisonRAM[i]))
+		}
+		imgui.EndTable()
+	}
 }
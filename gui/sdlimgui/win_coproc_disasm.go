@@ -18,6 +18,7 @@ package sdlimgui
 import (
 	"fmt"
 
+	"github.com/jetsetilly/gopher2600/coprocessor"
 	"github.com/jetsetilly/gopher2600/coprocessor/developer/dwarf"
 	"github.com/jetsetilly/gopher2600/coprocessor/disassembly"
 	"github.com/jetsetilly/gopher2600/debugger/govern"
@@ -37,6 +38,7 @@ type winCoProcDisasm struct {
 
 	optionsHeight        float32
 	optionsLastExecution bool
+	optionsTrace         bool
 
 	// scroll window if last item is visible
 	lastItemVisible bool
@@ -75,7 +77,7 @@ func (win *winCoProcDisasm) debuggerDraw() bool {
 	if imgui.BeginV(win.debuggerID(title), &win.debuggerOpen, imgui.WindowFlagsNone) {
 		// only support specific ARM architectures
 		arch := architecture.ARMArchitecture(coproc.ProcessorID())
-		if arch == architecture.ARM7TDMI || arch == architecture.ARMv7_M {
+		if arch == architecture.ARM7TDMI || arch == architecture.ARMv7_M || arch == architecture.ARMv6_M {
 			win.draw()
 		} else {
 			imgui.Text(fmt.Sprintf("%s is an unsupported architecture", arch))
@@ -92,12 +94,22 @@ func (win *winCoProcDisasm) draw() {
 	height := imguiRemainingWinHeight() - win.optionsHeight
 	isEnabled := win.img.dbg.CoProcDisasm.IsEnabled()
 
+	// the coprocessor is not part of the cached VCS state so the tracer
+	// returned here is the live coprocessor. access to the trace is guarded by
+	// the BorrowTrace() function
+	tracer, hasTracer := win.img.cache.VCS.Mem.Cart.GetCoProc().(coprocessor.CartCoProcTracer)
+	var isTracing bool
+	if hasTracer {
+		isTracing, _ = tracer.Tracing()
+	}
+
 	win.img.dbg.CoProcDisasm.BorrowDisassembly(func(dsm *disassembly.DisasmEntries) {
 		if imgui.BeginChildV("##coprocDisasmMain", imgui.Vec2{X: 0, Y: height}, false, imgui.ChildFlagsNone) {
 			if isEnabled {
 				imgui.BeginTabBar("##coprocDisasmTabBar")
 				if imgui.BeginTabItem("Disassembly") {
 					win.optionsLastExecution = false
+					win.optionsTrace = false
 					if len(dsm.Entries) > 0 {
 						win.drawDisasm(dsm, false)
 					} else {
@@ -108,6 +120,7 @@ func (win *winCoProcDisasm) draw() {
 				}
 				if imgui.BeginTabItem("Last Execution") {
 					win.optionsLastExecution = true
+					win.optionsTrace = false
 					if len(dsm.LastExecution) > 0 {
 						win.drawDisasm(dsm, true)
 					} else {
@@ -116,6 +129,17 @@ func (win *winCoProcDisasm) draw() {
 					}
 					imgui.EndTabItem()
 				}
+				if hasTracer && imgui.BeginTabItem("Trace") {
+					win.optionsLastExecution = false
+					win.optionsTrace = true
+					if isTracing {
+						win.drawTrace(dsm, tracer)
+					} else {
+						imgui.Spacing()
+						imgui.Text("Tracing is disabled")
+					}
+					imgui.EndTabItem()
+				}
 				imgui.EndTabBar()
 			} else {
 				imgui.Text("Coprocessor disassembly is disabled")
@@ -141,6 +165,22 @@ func (win *winCoProcDisasm) draw() {
 				})
 			}
 
+			if hasTracer && win.optionsTrace {
+				imgui.SameLineV(0, 20)
+				if imgui.Checkbox("Tracing", &isTracing) {
+					win.img.dbg.PushFunction(func() {
+						_ = win.img.dbg.CoProcDev.SetTracing(isTracing, 0)
+					})
+				}
+
+				imgui.SameLineV(0, 20)
+				drawDisabled(!isTracing || win.img.dbg.State() != govern.Paused, func() {
+					if imgui.Button("Step Back") {
+						win.img.term.pushCommand("COPROC STEP BACK")
+					}
+				})
+			}
+
 			// total cycles including tooltip
 			if isEnabled && win.optionsLastExecution {
 				if summary, ok := dsm.LastExecutionSummary.(arm.DisasmSummary); ok {
@@ -237,6 +277,75 @@ func (win *winCoProcDisasm) drawDisasm(dsm *disassembly.DisasmEntries, lastExecu
 	}
 }
 
+func (win *winCoProcDisasm) drawTrace(dsm *disassembly.DisasmEntries, tracer coprocessor.CartCoProcTracer) {
+	height := imguiRemainingWinHeight()
+	imgui.BeginChildV("trace", imgui.Vec2{X: 0, Y: height}, false, 0)
+	defer imgui.EndChild()
+
+	const numColumns = 4
+
+	flgs := imgui.TableFlagsNone
+	flgs |= imgui.TableFlagsSizingFixedFit
+	flgs |= imgui.TableFlagsRowBg
+	if imgui.BeginTableV("traceTable", numColumns, flgs, imgui.Vec2{}, 0) {
+		width := imgui.ContentRegionAvail().X
+		imgui.TableSetupColumnV("Address", imgui.TableColumnFlagsNone, width*0.15, 0)
+		imgui.TableSetupColumnV("Operator", imgui.TableColumnFlagsNone, width*0.10, 1)
+		imgui.TableSetupColumnV("Operands", imgui.TableColumnFlagsNone, width*0.30, 2)
+		imgui.TableSetupColumnV("Writes", imgui.TableColumnFlagsNone, width*0.45, 3)
+
+		imgui.PushStyleColor(imgui.StyleColorTableRowBg, win.img.cols.WindowBg)
+		imgui.PushStyleColor(imgui.StyleColorTableRowBgAlt, win.img.cols.WindowBg)
+		defer imgui.PopStyleColorV(2)
+
+		tracer.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+			imgui.ListClipperAll(len(trace), func(i int) {
+				if i >= len(trace) {
+					imgui.Text("")
+					return
+				}
+				e := trace[i]
+
+				imgui.TableNextRow()
+
+				imgui.TableNextColumn()
+				imgui.PushStyleColor(imgui.StyleColorText, win.img.cols.DisasmAddress)
+				imgui.Text(fmt.Sprintf("%08x", e.Addr))
+				imgui.PopStyleColor()
+
+				// the disassembly will not contain an entry for the address if
+				// disassembly was not enabled when the instruction was executed
+				imgui.TableNextColumn()
+				d, ok := dsm.Entries[fmt.Sprintf("%08x", e.Addr)].(arm.DisasmEntry)
+				if ok {
+					imgui.PushStyleColor(imgui.StyleColorText, win.img.cols.DisasmOperator)
+					imgui.Text(d.Operator)
+					imgui.PopStyleColor()
+				}
+
+				imgui.TableNextColumn()
+				if ok {
+					imgui.PushStyleColor(imgui.StyleColorText, win.img.cols.DisasmOperand)
+					imgui.Text(d.Operand)
+					imgui.PopStyleColor()
+				}
+
+				imgui.TableNextColumn()
+				for _, w := range e.Writes {
+					imgui.Text(fmt.Sprintf("%08x: %0*x -> %0*x", w.Addr, w.Size*2, w.Prev, w.Size*2, w.Value))
+				}
+			})
+
+			// keep the most recent instruction visible
+			if win.img.dbg.State() == govern.Running {
+				imgui.SetScrollY(imgui.ScrollMaxY())
+			}
+		})
+
+		imgui.EndTable()
+	}
+}
+
 func (win *winCoProcDisasm) drawEntry(src *dwarf.Source, e arm.DisasmEntry) {
 	var ln *dwarf.SourceLine
 	if src != nil {
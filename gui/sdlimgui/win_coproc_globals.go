@@ -445,8 +445,8 @@ func drawVariableTooltip(varb *dwarf.SourceVariable, value uint32, cols *imguiCo
 			imgui.Text("Converted Value: ")
 			imgui.SameLine()
 			imgui.PushStyleColor(imgui.StyleColorText, cols.CoProcVariablesNotes)
-			c, _ := varb.Type.Conversion(varb.Value())
-			imgui.Text(c)
+			f, v := varb.Type.Conversion(varb.Value())
+			imgui.Text(fmt.Sprintf(f, v))
 			imgui.PopStyleColor()
 		}
 
@@ -301,8 +301,8 @@ func (win *winCoProcLocals) drawVariable(varb *dwarf.SourceVariable, indentLevel
 		if varb.Error != nil {
 			imgui.Text(string(fonts.CoProcBug))
 		} else if varb.Type.Conversion != nil {
-			c, _ := varb.Type.Conversion(varb.Value())
-			imgui.Text(c)
+			f, v := varb.Type.Conversion(varb.Value())
+			imgui.Text(fmt.Sprintf(f, v))
 		} else {
 			imgui.Text(fmt.Sprintf(varb.Type.Hex(), varb.Value()))
 		}
@@ -243,6 +243,22 @@ func (win *winCoProcSource) draw() {
 				win.focusYieldLineManual = true
 			}
 			imgui.SameLineV(0, 20)
+
+			// source level stepping is only possible when the emulation is paused
+			drawDisabled(win.img.dbg.State() != govern.Paused, func() {
+				if imgui.Button("Step Over") {
+					win.img.term.pushCommand("COPROC STEP OVER")
+				}
+				imgui.SameLine()
+				if imgui.Button("Step Into") {
+					win.img.term.pushCommand("COPROC STEP INTO")
+				}
+				imgui.SameLine()
+				if imgui.Button("Step Out") {
+					win.img.term.pushCommand("COPROC STEP OUT")
+				}
+			})
+			imgui.SameLineV(0, 20)
 			imgui.Checkbox("Highlight Comments & String Literals", &win.syntaxHighlighting)
 		})
 
@@ -132,6 +132,14 @@ time each instruction in the ARM program takes`)
 		win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.AbortOnMemoryFault.Set(abortOnMemoryFault)
 	}
 
+	abortOnUninitialisedRead := win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.AbortOnUninitialisedRead.Get().(bool)
+	if imgui.Checkbox("Abort on Uninitialised Read", &abortOnUninitialisedRead) {
+		win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.AbortOnUninitialisedRead.Set(abortOnUninitialisedRead)
+	}
+	win.img.imguiTooltipSimple(`Reads of SRAM that has never been written to by the ARM program
+are always recorded as memory faults. This option causes the emulation to halt
+when such a read occurs. Only available when DWARF information has been found`)
+
 	undefinedSymbolWarning := win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.UndefinedSymbolWarning.Get().(bool)
 	if imgui.Checkbox("Undefined Symbols Warning", &undefinedSymbolWarning) {
 		win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.UndefinedSymbolWarning.Set(undefinedSymbolWarning)
@@ -139,6 +147,13 @@ time each instruction in the ARM program takes`)
 	win.img.imguiTooltipSimple(`It is possible to compile an ELF binary with undefined symbols.
 This option presents causes a warning to appear when such a binary is loaded`)
 
+	debugOutput := win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.DebugOutput.Get().(bool)
+	if imgui.Checkbox("Debug Output Register", &debugOutput) {
+		win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.DebugOutput.Set(debugOutput)
+	}
+	win.img.imguiTooltipSimple(`Bytes written to the debug output register are sent to the
+coprocessor console. Only available for memory models that define the register`)
+
 	imgui.Spacing()
 	if win.setDefaultButton("Set ARM Defaults") {
 		win.img.dbg.PushFunction(win.img.dbg.VCS().Env.Prefs.Cartridge.ARM.SetDefaults)
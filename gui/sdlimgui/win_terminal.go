@@ -379,6 +379,9 @@ func (l terminalOutput) draw(wrap bool) {
 
 	case terminal.StyleLog:
 		imgui.PushStyleColor(imgui.StyleColorText, l.cols.TermStyleLog)
+
+	case terminal.StyleCoProcConsole:
+		imgui.PushStyleColor(imgui.StyleColorText, l.cols.TermStyleCoProcConsole)
 	}
 
 	// text wrap for window
@@ -259,7 +259,7 @@ func (cart *Ace) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMint
 }
 
 // BusStuff implements the mapper.CartBusStuff interface.
-func (cart *Ace) BusStuff() (uint8, bool) {
+func (cart *Ace) BusStuff(_ uint16, _ bool) (uint8, bool) {
 	if cart.mem.isDataModeOut() {
 		cart.mem.gpio[DATA_MODER-cart.mem.gpioOrigin] = 0x00
 		cart.mem.gpio[DATA_MODER-cart.mem.gpioOrigin+1] = 0x00
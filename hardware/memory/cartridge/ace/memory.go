@@ -364,6 +364,18 @@ func (mem *aceMemory) IsExecutable(addr uint32) bool {
 	return true
 }
 
+// UninitialisedMemory implements the arm.SharedMemoryUninitialised interface.
+// Only the stack is included because the main SRAM block contains values
+// written by the ACE loader
+func (mem *aceMemory) UninitialisedMemory() []arm.UninitialisedArea {
+	return []arm.UninitialisedArea{
+		{
+			Origin: mem.sramStackOrigin,
+			Memtop: mem.sramStackMemtop,
+		},
+	}
+}
+
 // returns a list of memory areas in the cartridge's static memory
 func (a *aceMemory) Segments() []mapper.CartStaticSegment {
 	return []mapper.CartStaticSegment{
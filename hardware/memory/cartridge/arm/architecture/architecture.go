@@ -33,6 +33,7 @@ type ARMArchitecture string
 const (
 	ARM7TDMI ARMArchitecture = "ARM7TDMI"
 	ARMv7_M  ARMArchitecture = "ARMv7-M"
+	ARMv6_M  ARMArchitecture = "ARMv6-M"
 )
 
 // MAMCR defines the state of the MAM.
@@ -108,6 +109,13 @@ type Map struct {
 
 	APBDIV uint32
 
+	// the debug output register is not a real peripheral. a byte written to the
+	// address is sent to the coprocessor console. the address has been chosen
+	// so that it does not clash with any real peripheral in the architecture.
+	// the register is only active when enabled in the ARM preferences
+	HasDebugOutput bool
+	DebugOutput    uint32
+
 	// the address below which a null access is considered to have happened
 	NullAccessBoundary uint32
 
@@ -175,6 +183,10 @@ func NewMap(cart CartArchitecture) Map {
 
 		mmap.APBDIV = 0xe01fc100
 
+		// first address after the APB peripherals of the LPC2103
+		mmap.HasDebugOutput = true
+		mmap.DebugOutput = 0xe0200000
+
 		// boundary value is arbitrary and was suggested by John Champeau (09/04/2022)
 		mmap.NullAccessBoundary = 0x00000751
 		mmap.IllegalAccessValue = 0x00000000
@@ -250,6 +262,10 @@ func NewMap(cart CartArchitecture) Map {
 
 		mmap.APBDIV = 0x40021004
 
+		// first address after the AHB2 peripherals of the STM32F4
+		mmap.HasDebugOutput = true
+		mmap.DebugOutput = 0x50070000
+
 		// boundary value is arbitrary and was suggested by John Champeau (09/04/2022)
 		mmap.NullAccessBoundary = 0x00000751
 		mmap.IllegalAccessValue = 0xffffffff
@@ -181,6 +181,11 @@ type ARMState struct {
 
 	// the first 16bits of the most recent 32bit instruction
 	instruction32bitOpcodeHi uint16
+
+	// the written state of the uninitialised memory shadows at the time of
+	// the snapshot. nil if uninitialised memory was not being tracked. the
+	// slices are never modified once the snapshot has been taken
+	uninitialised [][]uint64
 }
 
 // Snapshot implements the mapper.CartMapper interface.
@@ -228,7 +233,12 @@ type ARM struct {
 	state *ARMState
 
 	// updated on every call to run()
-	abortOnMemoryFault bool
+	abortOnMemoryFault       bool
+	abortOnUninitialisedRead bool
+
+	// whether the debug output register in the memory map is active. updated
+	// on every call to run()
+	debugOutput bool
 
 	// the speed at which the arm is running at and the required stretching for
 	// access to flash memory. speed is in MHz. Access latency of Flash memory is
@@ -285,6 +295,11 @@ type ARM struct {
 	// interface to an option development package
 	dev coprocessor.CartCoProcDeveloper
 
+	// tracking of memory that has not been written to by the ARM program. will
+	// be nil if there is no developer or if the shared memory has no
+	// uninitialised areas
+	uninitialised *uninitialised
+
 	// immediateMode controls whether cycle count or not. value updated from
 	// updatePrefs()
 	//
@@ -314,6 +329,12 @@ type ARM struct {
 
 	// profiler for executed instructions. measures cycles counts
 	profiler *coprocessor.CartCoProcProfiler
+
+	// output from semihosting and the debug output register
+	console console
+
+	// record of executed instructions. only used if tracing has been enabled
+	trace trace
 }
 
 // NewARM is the preferred method of initialisation for the ARM type.
@@ -329,6 +350,14 @@ func NewARM(env *environment.Environment, mmap architecture.Map, mem SharedMemor
 		state:          &ARMState{},
 	}
 
+	// the ARMv6-M core can be selected through the preferences regardless of
+	// the cartridge architecture. the memory map of the cartridge is unchanged
+	// but misaligned accesses are never allowed by the architecture
+	if arm.env.Prefs.Cartridge.ARM.Model.Get().(string) == "ARMv6_M" {
+		arm.mmap.ARMArchitecture = architecture.ARMv6_M
+		arm.mmap.MisalignedAccesses = false
+	}
+
 	// disassembly printed to stderr
 	if disassembleToStderr {
 		arm.disasm = &coprocessor.CartCoProcDisassemblerStderr{}
@@ -339,6 +368,8 @@ func NewARM(env *environment.Environment, mmap architecture.Map, mem SharedMemor
 		arm.stepFunction = arm.stepARM7TDMI
 	case architecture.ARMv7_M:
 		arm.stepFunction = arm.stepARM7_M
+	case architecture.ARMv6_M:
+		arm.stepFunction = arm.stepARMv6_M
 	default:
 		panic(fmt.Sprintf("unhandled ARM architecture: cannot set %s", arm.mmap.ARMArchitecture))
 	}
@@ -367,6 +398,9 @@ func (arm *ARM) Reset() {
 	arm.resetPeripherals()
 	arm.resetRegisters()
 	arm.resetYield()
+	if arm.uninitialised != nil {
+		arm.uninitialised.reset()
+	}
 }
 
 // Sets the immediate mode cycle flag. This is required to be set for ARM drivers that yield
@@ -401,11 +435,20 @@ func (arm *ARM) SetDisassembler(disasm coprocessor.CartCoProcDisassembler) {
 // SetDeveloper implements the coprocessor.CartCoProc interface.
 func (arm *ARM) SetDeveloper(dev coprocessor.CartCoProcDeveloper) {
 	arm.dev = dev
+	if arm.dev != nil {
+		arm.uninitialised = newUninitialised(arm.mem)
+	} else {
+		arm.uninitialised = nil
+	}
 }
 
 // Snapshot implements the mapper.CartMapper interface.
 func (arm *ARM) Snapshot() *ARMState {
-	return arm.state.Snapshot()
+	s := arm.state.Snapshot()
+	if arm.uninitialised != nil {
+		s.uninitialised = arm.uninitialised.snapshot()
+	}
+	return s
 }
 
 // Plumb should be used to update the shared memory reference.
@@ -424,6 +467,12 @@ func (arm *ARM) Plumb(env *environment.Environment, state *ARMState, mem SharedM
 	if state != nil {
 		arm.state = state
 		arm.state.Plumb(env)
+		if arm.uninitialised != nil {
+			arm.uninitialised.restore(state.uninitialised)
+		}
+
+		// the trace belongs to the timeline of the previous state
+		arm.traceReset()
 	}
 
 	// any more plumbing work is superfluous unless we're dealing with the main
@@ -522,12 +571,18 @@ func (arm *ARM) updatePrefs() {
 			arm.Icycle = arm.iCycle_ARMv7_M
 			arm.Scycle = arm.sCycle_ARMv7_M
 			arm.Ncycle = arm.nCycle_ARMv7_M
+		case architecture.ARMv6_M:
+			arm.Icycle = arm.iCycle_ARMv6_M
+			arm.Scycle = arm.sCycle_ARMv6_M
+			arm.Ncycle = arm.nCycle_ARMv6_M
 		default:
 			panic(fmt.Sprintf("unhandled ARM architecture: cannot set %s", arm.mmap.ARMArchitecture))
 		}
 	}
 
 	arm.abortOnMemoryFault = arm.env.Prefs.Cartridge.ARM.AbortOnMemoryFault.Get().(bool)
+	arm.abortOnUninitialisedRead = arm.env.Prefs.Cartridge.ARM.AbortOnUninitialisedRead.Get().(bool)
+	arm.debugOutput = arm.mmap.HasDebugOutput && arm.env.Prefs.Cartridge.ARM.DebugOutput.Get().(bool)
 }
 
 func (arm *ARM) String() string {
@@ -579,6 +634,9 @@ func (arm *ARM) clock(cycles float32) {
 func (arm *ARM) resetYield() {
 	arm.state.yield.Type = coprocessor.YieldRunning
 	arm.state.yield.Error = nil
+	if arm.uninitialised != nil {
+		arm.uninitialised.halt = false
+	}
 }
 
 func (arm *ARM) logYield() {
@@ -702,6 +760,13 @@ func (arm *ARM) Run() (coprocessor.CoProcYield, float32) {
 	// of the program execution
 	if arm.state.yield.Type == coprocessor.YieldProgramEnded {
 		arm.resetRegisters()
+		if arm.trace.enabled {
+			arm.traceReset()
+		}
+	} else if arm.state.yield.Type == coprocessor.YieldSyncWithVCS {
+		if arm.trace.enabled {
+			arm.traceResume()
+		}
 	}
 
 	// reset cycles count
@@ -899,6 +964,11 @@ func (arm *ARM) run() (coprocessor.CoProcYield, float32) {
 		// opcode for executed instruction
 		opcode := arm.byteOrder.Uint16((*arm.state.programMemory)[memIdx:])
 
+		// record instruction before it is executed
+		if arm.trace.enabled && !arm.state.instruction32bitDecoding {
+			arm.traceInstruction()
+		}
+
 		// bump PC counter for prefetch. actual prefetch is done after execution
 		arm.state.registers[rPC] += 2
 
@@ -1020,9 +1090,10 @@ func (arm *ARM) run() (coprocessor.CoProcYield, float32) {
 		}
 
 		// handle memory access yields. we don't these want these to bleed out
-		// of the ARM unless the abort preference is set
+		// of the ARM unless the abort preference is set. uninitialised reads
+		// have their own preference
 		if arm.state.yield.Type == coprocessor.YieldMemoryFault {
-			if !arm.abortOnMemoryFault {
+			if !arm.abortOnMemoryFault && (arm.uninitialised == nil || !arm.uninitialised.halt) {
 				arm.resetYield()
 			}
 		}
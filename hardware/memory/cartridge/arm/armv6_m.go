@@ -0,0 +1,295 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+// The "ARMv6-M Architecture Reference Manual" referenced in this document
+// ("ARMv6-M" for brevity) can be found at:
+//
+// https://developer.arm.com/documentation/ddi0419/latest/
+//
+// The "Cortex-M0+ Technical Reference Manual" can be found at:
+//
+// https://developer.arm.com/documentation/ddi0484/latest/
+
+package arm
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+)
+
+// the ARMv6-M architecture executes a subset of the Thumb-2 instruction set.
+// the 16bit instructions are mostly the same as the ARMv7-M architecture but
+// there is no IT or CB(N)Z instruction. there are only six 32bit instructions:
+// BL, MSR, MRS, DMB, DSB and ISB
+//
+// "A5.1 Thumb instruction set encoding" of "ARMv6-M"
+func (arm *ARM) stepARMv6_M(opcode uint16, memIdx int) {
+	var df decodeFunction
+
+	if arm.state.instruction32bitDecoding {
+		arm.state.instruction32bitDecoding = false
+		arm.state.instruction32bitResolving = true
+		df = arm.state.currentExecutionCache[memIdx]
+		if df == nil {
+			df = arm.decode32bitARMv6_M(arm.state.instruction32bitOpcodeHi, opcode)
+			arm.state.currentExecutionCache[memIdx] = df
+		}
+	} else {
+		arm.state.instruction32bitResolving = false
+		arm.state.instruction32bitOpcodeHi = 0x0
+
+		arm.state.instructionPC = arm.state.executingPC
+		if is32BitThumb2(opcode) {
+			arm.state.instruction32bitDecoding = true
+			arm.state.instruction32bitOpcodeHi = opcode
+		} else {
+			df = arm.state.currentExecutionCache[memIdx]
+			if df == nil {
+				df = arm.decodeARMv6_M(opcode)
+				arm.state.currentExecutionCache[memIdx] = df
+			}
+		}
+	}
+
+	if df != nil {
+		df()
+	}
+}
+
+// decode 16bit instructions for the ARMv6-M architecture. instructions that are
+// not part of the architecture cause a HardFault
+func (arm *ARM) decodeARMv6_M(opcode uint16) decodeFunction {
+	if opcode&0xff00 == 0xde00 {
+		// permanently undefined
+		return arm.undefinedARMv6_M(opcode, 0)
+	} else if opcode&0xff00 == 0xbf00 && opcode&0x000f != 0x0000 {
+		// IT instruction
+		return arm.undefinedARMv6_M(opcode, 0)
+	} else if opcode&0xf500 == 0xb100 {
+		// compare and branch on (non-)zero
+		return arm.undefinedARMv6_M(opcode, 0)
+	} else if opcode&0xffe0 == 0xb640 || opcode&0xffe0 == 0xb660 {
+		// SETEND and the unpredictable encodings around CPS are not part of
+		// the architecture. CPS itself only has the I bit in the ARMv6-M
+		// architecture
+		if opcode&0xffef != 0xb662 {
+			return arm.undefinedARMv6_M(opcode, 0)
+		}
+	}
+
+	return arm.decodeThumb2(opcode)
+}
+
+// decode 32bit instructions for the ARMv6-M architecture
+//
+// "A5.3 32-bit Thumb instruction encoding" of "ARMv6-M"
+func (arm *ARM) decode32bitARMv6_M(opcodeHi uint16, opcode uint16) decodeFunction {
+	if opcodeHi&0xf800 == 0xf000 && opcode&0x8000 == 0x8000 {
+		if opcode&0xd000 == 0xd000 {
+			// BL is the only 32bit branch instruction
+			return arm.decode32bitThumb2Branches(opcode)
+		}
+
+		if opcode&0xd000 == 0x8000 {
+			if opcodeHi&0xffe0 == 0xf380 && opcode&0xff00 == 0x8800 {
+				return arm.decodeARMv6_MMoveToSpecialRegister(opcodeHi, opcode)
+			}
+			if opcodeHi == 0xf3ef && opcode&0xf000 == 0x8000 {
+				return arm.decodeARMv6_MMoveFromSpecialRegister(opcode)
+			}
+			if opcodeHi == 0xf3bf && opcode&0xff00 == 0x8f00 {
+				return arm.decodeARMv6_MBarrier(opcode)
+			}
+		}
+	}
+
+	return arm.undefinedARMv6_M(opcode, opcodeHi)
+}
+
+// the response to an undefined instruction in the ARMv6-M architecture is a
+// HardFault exception. we don't emulate the exception model so the fault
+// causes the ARM to yield
+//
+// "B1.5 ARMv6-M exception model" of "ARMv6-M"
+func (arm *ARM) undefinedARMv6_M(opcode uint16, opcodeHi uint16) decodeFunction {
+	is32bit := is32BitThumb2(opcodeHi)
+
+	var operand string
+	if is32bit {
+		operand = fmt.Sprintf("%04x %04x", opcodeHi, opcode)
+	} else {
+		operand = fmt.Sprintf("%04x", opcode)
+	}
+
+	return func() *DisasmEntry {
+		if arm.decodeOnly {
+			return &DisasmEntry{
+				Is32bit:  is32bit,
+				Operator: "UNDEFINED",
+				Operand:  operand,
+			}
+		}
+
+		arm.state.yield.Type = coprocessor.YieldUndefinedBehaviour
+		arm.state.yield.Error = fmt.Errorf("HardFault: instruction (%s) is undefined in the %s architecture",
+			operand, arm.mmap.ARMArchitecture)
+		return nil
+	}
+}
+
+// special registers that can be used with the MRS and MSR instructions
+//
+// "B4.2.2 MRS" of "ARMv6-M"
+const (
+	sysmAPSR    = 0
+	sysmIAPSR   = 1
+	sysmEAPSR   = 2
+	sysmXPSR    = 3
+	sysmIPSR    = 5
+	sysmEPSR    = 6
+	sysmIEPSR   = 7
+	sysmMSP     = 8
+	sysmPSP     = 9
+	sysmPRIMASK = 16
+	sysmCONTROL = 20
+)
+
+func sysmLabel(sysm uint16) string {
+	switch sysm {
+	case sysmAPSR:
+		return "APSR"
+	case sysmIAPSR:
+		return "IAPSR"
+	case sysmEAPSR:
+		return "EAPSR"
+	case sysmXPSR:
+		return "XPSR"
+	case sysmIPSR:
+		return "IPSR"
+	case sysmEPSR:
+		return "EPSR"
+	case sysmIEPSR:
+		return "IEPSR"
+	case sysmMSP:
+		return "MSP"
+	case sysmPSP:
+		return "PSP"
+	case sysmPRIMASK:
+		return "PRIMASK"
+	case sysmCONTROL:
+		return "CONTROL"
+	}
+	return fmt.Sprintf("SYSm%d", sysm)
+}
+
+func (arm *ARM) decodeARMv6_MMoveToSpecialRegister(opcodeHi uint16, opcode uint16) decodeFunction {
+	// "B4.2.3 MSR" of "ARMv6-M"
+	Rn := opcodeHi & 0x000f
+	sysm := opcode & 0x00ff
+
+	return func() *DisasmEntry {
+		if arm.decodeOnly {
+			return &DisasmEntry{
+				Is32bit:  true,
+				Operator: "MSR",
+				Operand:  fmt.Sprintf("%s, R%d", sysmLabel(sysm), Rn),
+			}
+		}
+
+		switch sysm {
+		case sysmAPSR, sysmIAPSR, sysmEAPSR, sysmXPSR:
+			// only the NZCV flags are writeable
+			arm.state.status.setAPSR(arm.state.registers[Rn] & 0xf0000000)
+		case sysmMSP, sysmPSP:
+			// there is only one stack pointer in the emulation
+			arm.state.registers[rSP] = arm.state.registers[Rn] & 0xfffffffc
+		default:
+			// writes to the exception registers and to PRIMASK and CONTROL
+			// have no effect in the emulation
+		}
+
+		// the instruction takes three cycles on the Cortex-M0+. two of those
+		// cycles are accounted for by the fetching of both halves of the
+		// instruction
+		arm.Icycle()
+
+		return nil
+	}
+}
+
+func (arm *ARM) decodeARMv6_MMoveFromSpecialRegister(opcode uint16) decodeFunction {
+	// "B4.2.2 MRS" of "ARMv6-M"
+	Rd := (opcode & 0x0f00) >> 8
+	sysm := opcode & 0x00ff
+
+	return func() *DisasmEntry {
+		if arm.decodeOnly {
+			return &DisasmEntry{
+				Is32bit:  true,
+				Operator: "MRS",
+				Operand:  fmt.Sprintf("R%d, %s", Rd, sysmLabel(sysm)),
+			}
+		}
+
+		switch sysm {
+		case sysmAPSR, sysmIAPSR, sysmEAPSR, sysmXPSR:
+			// the emulation is always in thread mode so the IPSR part of the
+			// register is always zero. the T bit of the EPSR reads as zero
+			arm.state.registers[Rd] = arm.state.status.apsr() & 0xf0000000
+		case sysmMSP, sysmPSP:
+			arm.state.registers[Rd] = arm.state.registers[rSP]
+		default:
+			arm.state.registers[Rd] = 0
+		}
+
+		// three cycles on the Cortex-M0+. see comment for MSR
+		arm.Icycle()
+
+		return nil
+	}
+}
+
+func (arm *ARM) decodeARMv6_MBarrier(opcode uint16) decodeFunction {
+	// DMB, DSB and ISB instructions in "B4.2 ARMv6-M system instructions" of "ARMv6-M"
+	var operator string
+	switch opcode & 0x00f0 {
+	case 0x0040:
+		operator = "DSB"
+	case 0x0050:
+		operator = "DMB"
+	case 0x0060:
+		operator = "ISB"
+	default:
+		return arm.undefinedARMv6_M(opcode, 0xf3bf)
+	}
+
+	return func() *DisasmEntry {
+		if arm.decodeOnly {
+			return &DisasmEntry{
+				Is32bit:  true,
+				Operator: operator,
+				Operand:  "SY",
+			}
+		}
+
+		// memory accesses are never reordered in the emulation so barriers have
+		// no effect other than to consume cycles
+		// three cycles on the Cortex-M0+. see comment for MSR
+		arm.Icycle()
+
+		return nil
+	}
+}
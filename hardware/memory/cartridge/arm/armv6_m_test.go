@@ -0,0 +1,234 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm/architecture"
+	"github.com/jetsetilly/gopher2600/hardware/preferences"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+const (
+	flashOrigin = 0x08020000
+	sramOrigin  = 0x20000000
+
+	// the entry point cannot be the very start of flash memory
+	entryPoint = flashOrigin + 0x10
+)
+
+// minimal implementation of the SharedMemory and CartridgeHook interfaces
+type testMemory struct {
+	flash []byte
+	sram  []byte
+}
+
+func newTestMemory(program ...uint16) *testMemory {
+	mem := &testMemory{
+		flash: make([]byte, 0x100),
+		sram:  make([]byte, 0x100),
+	}
+	for i, op := range program {
+		binary.LittleEndian.PutUint16(mem.flash[entryPoint-flashOrigin+i*2:], op)
+	}
+	return mem
+}
+
+func (mem *testMemory) MapAddress(addr uint32, write bool, executing bool) (*[]byte, uint32) {
+	if addr >= flashOrigin && addr < flashOrigin+uint32(len(mem.flash)) {
+		return &mem.flash, flashOrigin
+	}
+	if addr >= sramOrigin && addr < sramOrigin+uint32(len(mem.sram)) {
+		return &mem.sram, sramOrigin
+	}
+	return nil, 0
+}
+
+func (mem *testMemory) ResetVectors() (uint32, uint32, uint32) {
+	return sramOrigin + uint32(len(mem.sram)), entryPoint, entryPoint
+}
+
+func (mem *testMemory) IsExecutable(addr uint32) bool {
+	return addr >= flashOrigin && addr < flashOrigin+uint32(len(mem.flash))
+}
+
+func (mem *testMemory) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMinterruptReturn, error) {
+	return arm.ARMinterruptReturn{}, nil
+}
+
+// create an ARM with the model preference set to the specified value. the
+// memory map is always the PlusCart map
+func newTestARM(t *testing.T, model string, mem *testMemory) *arm.ARM {
+	t.Helper()
+	return newTestARMWithMemory(t, model, mem, mem)
+}
+
+// create an ARM in the same way as newTestARM() but with a different
+// implementation of the SharedMemory interface
+func newTestARMWithMemory(t *testing.T, model string, mem arm.SharedMemory, hook arm.CartridgeHook) *arm.ARM {
+	t.Helper()
+	return arm.NewARM(newTestEnvironment(model), architecture.NewMap(architecture.PlusCart), mem, hook)
+}
+
+// create an environment with the ARM model set in the preferences
+func newTestEnvironment(model string) *environment.Environment {
+	prefs := &preferences.Preferences{
+		Cartridge: &preferences.Cartridge{
+			ARM: &preferences.ARMPreferences{},
+		},
+	}
+	prefs.Cartridge.ARM.SetDefaults()
+	prefs.Cartridge.ARM.Model.Set(model)
+
+	// memory faults should cause the ARM to yield so that they can be tested
+	prefs.Cartridge.ARM.AbortOnMemoryFault.Set(true)
+
+	return &environment.Environment{
+		Label: "test",
+		Prefs: prefs,
+	}
+}
+
+func register(t *testing.T, cpu *arm.ARM, reg int) uint32 {
+	t.Helper()
+	v, ok := cpu.Register(reg)
+	test.ExpectSuccess(t, ok)
+	return v
+}
+
+func TestARMv6_M_model(t *testing.T) {
+	cpu := newTestARM(t, "ARMv6_M", newTestMemory(0xbe00))
+	test.ExpectEquality(t, cpu.ProcessorID(), string(architecture.ARMv6_M))
+
+	cpu = newTestARM(t, "AUTO", newTestMemory(0xbe00))
+	test.ExpectEquality(t, cpu.ProcessorID(), string(architecture.ARMv7_M))
+}
+
+func TestARMv6_M_dataProcessing(t *testing.T) {
+	cpu := newTestARM(t, "ARMv6_M", newTestMemory(
+		0x2005, // MOVS R0, #5
+		0x2107, // MOVS R1, #7
+		0x1842, // ADDS R2, R0, R1
+		0x4348, // MULS R0, R1
+		0xbad3, // REVSH R3, R2
+		0xbe00, // BKPT
+	))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(35))
+	test.ExpectEquality(t, register(t, cpu, 2), uint32(12))
+	test.ExpectEquality(t, register(t, cpu, 3), uint32(0x0c00))
+}
+
+func TestARMv6_M_branchWithLink(t *testing.T) {
+	cpu := newTestARM(t, "ARMv6_M", newTestMemory(
+		0xf000, 0xf801, // BL +2
+		0xbe00, // BKPT
+		0x2042, // MOVS R0, #0x42
+		0xbe00, // BKPT
+	))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(0x42))
+	test.ExpectEquality(t, register(t, cpu, 14), uint32(entryPoint+4)|0x01)
+}
+
+func TestARMv6_M_specialRegisters(t *testing.T) {
+	cpu := newTestARM(t, "ARMv6_M", newTestMemory(
+		0x2000,         // MOVS R0, #0
+		0xf3ef, 0x8100, // MRS R1, APSR
+		0x2201,         // MOVS R2, #1
+		0xf381, 0x8800, // MSR APSR, R1
+		0xf3bf, 0x8f5f, // DMB SY
+		0xf3ef, 0x8300, // MRS R3, APSR
+		0xf3ef, 0x8408, // MRS R4, MSP
+		0xbe00, // BKPT
+	))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, register(t, cpu, 1), uint32(0x40000000))
+	test.ExpectEquality(t, register(t, cpu, 3), uint32(0x40000000))
+	test.ExpectEquality(t, register(t, cpu, 4), register(t, cpu, 13))
+}
+
+func TestARMv6_M_undefinedInstructions(t *testing.T) {
+	undefined := [][]uint16{
+		{0xb100},         // CBZ R0, +4
+		{0xbf08},         // IT EQ
+		{0xde00},         // UDF #0
+		{0xf8d0, 0x1000}, // LDR.W R1, [R0]
+		{0xfb00, 0xf001}, // MUL.W R0, R0, R1
+	}
+
+	for _, program := range undefined {
+		cpu := newTestARM(t, "ARMv6_M", newTestMemory(append(program, 0xbe00)...))
+		yld, _ := cpu.Run()
+		test.ExpectEquality(t, yld.Type, coprocessor.YieldUndefinedBehaviour)
+		test.ExpectFailure(t, yld.Error)
+	}
+
+	// the same CBZ instruction is valid in the ARMv7-M architecture
+	cpu := newTestARM(t, "AUTO", newTestMemory(0xb100, 0xbe00, 0xbe00, 0xbe00))
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+}
+
+func TestARMv6_M_misalignedAccess(t *testing.T) {
+	cpu := newTestARM(t, "ARMv6_M", newTestMemory(
+		0x4802,         // LDR R0, [PC, #8]
+		0x6801,         // LDR R1, [R0]
+		0xbe00,         // BKPT
+		0xbe00,         // BKPT
+		0xbe00,         // BKPT
+		0x0000,         // padding
+		0x0001, 0x2000, // literal: 0x20000001
+	))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldMemoryFault)
+}
+
+func TestARMv6_M_cycles(t *testing.T) {
+	program := []uint16{
+		0x2003, // MOVS R0, #3
+		0x3801, // loop: SUBS R0, #1
+		0xd1fd, // BNE loop
+		0xbe00, // BKPT
+	}
+
+	cpu := newTestARM(t, "ARMv6_M", newTestMemory(program...))
+	yld, v6Cycles := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(0))
+
+	// one cycle for each of the MOVS, SUBS, untaken BNE and BKPT instructions.
+	// two cycles for each taken branch
+	test.ExpectEquality(t, v6Cycles, float32(1+3+2*2+1+1))
+
+	// the three stage pipeline of the ARMv7-M architecture requires more
+	// cycles for the same program
+	cpu = newTestARM(t, "AUTO", newTestMemory(program...))
+	_, v7Cycles := cpu.Run()
+	test.ExpectSuccess(t, v7Cycles > v6Cycles)
+}
@@ -17,6 +17,8 @@ package arm
 
 import (
 	"strings"
+
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm/architecture"
 )
 
 type cycleOrder struct {
@@ -125,6 +127,13 @@ func (arm *ARM) isLatched(cycle cycleType, bus busAccess, addr uint32) bool {
 // called whenever PC changes unexpectedly (by a branch instruction for example).
 func (arm *ARM) fillPipelineAfterBranch() {
 	arm.Ncycle(branch, arm.state.registers[rPC])
+
+	// the two stage pipeline of the ARMv6-M architecture means that only one
+	// additional fetch is required
+	if arm.mmap.ARMArchitecture == architecture.ARMv6_M {
+		return
+	}
+
 	arm.Scycle(prefetch, arm.state.registers[rPC]+2)
 }
 
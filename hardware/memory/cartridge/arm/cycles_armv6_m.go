@@ -0,0 +1,48 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm
+
+// the Cortex-M0+ has a two stage pipeline and a single AHB-Lite bus. there is
+// no distinction between sequential and nonsequential accesses and there is
+// no merging of internal and sequential cycles
+
+func (arm *ARM) iCycle_ARMv6_M() {
+	if arm.disasm != nil {
+		arm.state.cycleOrder.add(I)
+	}
+	arm.state.stretchedCycles++
+	arm.state.lastCycle = I
+}
+
+func (arm *ARM) sCycle_ARMv6_M(_ busAccess, addr uint32) {
+	if arm.disasm != nil {
+		arm.state.cycleOrder.add(S)
+	}
+	arm.state.lastCycle = S
+
+	id := arm.mmap.RegionID(addr)
+	arm.state.stretchedCycles += arm.clkLen[id].length
+}
+
+func (arm *ARM) nCycle_ARMv6_M(_ busAccess, addr uint32) {
+	if arm.disasm != nil {
+		arm.state.cycleOrder.add(N)
+	}
+	arm.state.lastCycle = N
+
+	id := arm.mmap.RegionID(addr)
+	arm.state.stretchedCycles += arm.clkLen[id].length
+}
@@ -43,6 +43,17 @@ var arm7tdmiRegisterSpec = coprocessor.ExtendedRegisterSpec{
 	},
 }
 
+var armv6mRegisterSpec = coprocessor.ExtendedRegisterSpec{
+	{
+		Name:  coprocessor.ExtendedRegisterCoreGroup,
+		Start: 0,
+		End:   15,
+		Label: func(r int) string {
+			return fmt.Sprintf("R%02d", r)
+		},
+	},
+}
+
 var armv7mRegisterSpec = coprocessor.ExtendedRegisterSpec{
 	{
 		Name:  coprocessor.ExtendedRegisterCoreGroup,
@@ -84,6 +95,8 @@ func (arm *ARM) RegisterSpec() coprocessor.ExtendedRegisterSpec {
 		return arm7tdmiRegisterSpec
 	case architecture.ARMv7_M:
 		return armv7mRegisterSpec
+	case architecture.ARMv6_M:
+		return armv6mRegisterSpec
 	}
 	panic("register spec: unrecognised arm architecture")
 }
@@ -73,3 +73,21 @@ type ARMinterruptReturn struct {
 	NumMemAccess        int
 	NumAdditionalCycles int
 }
+
+// SharedMemoryUninitialised is an optional extension to the SharedMemory
+// interface. It lists the areas of memory that the parent cartridge-mapper
+// does not initialise and which the ARM program should therefore write to
+// before reading.
+//
+// Memory that is written to by the 6507 side of the cartridge (eg. the
+// DPC+ and CDF data areas) should not be listed.
+type SharedMemoryUninitialised interface {
+	UninitialisedMemory() []UninitialisedArea
+}
+
+// UninitialisedArea is a single area of memory returned by the
+// SharedMemoryUninitialised interface. The memtop value is inclusive.
+type UninitialisedArea struct {
+	Origin uint32
+	Memtop uint32
+}
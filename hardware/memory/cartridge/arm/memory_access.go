@@ -49,6 +49,9 @@ func (arm *ARM) read8bit(addr uint32) uint8 {
 		if addr == arm.mmap.APBDIV {
 			return uint8(0)
 		}
+		if arm.debugOutput && addr == arm.mmap.DebugOutput {
+			return uint8(0)
+		}
 
 		if ok, name := arm.mmap.IsUnimplemented(addr); ok {
 			arm.unimplemented(fmt.Sprintf("Read 8bit: %s", name), addr)
@@ -61,6 +64,12 @@ func (arm *ARM) read8bit(addr uint32) uint8 {
 
 	// adjust address so that it can be used as an index
 	idx := addr - origin
+	if arm.dev != nil {
+		arm.watch(addr, 1, false)
+		if arm.uninitialised != nil {
+			arm.checkUninitialised("Read 8bit", addr, 1)
+		}
+	}
 	return (*mem)[idx]
 }
 
@@ -94,6 +103,10 @@ func (arm *ARM) write8bit(addr uint32, val uint8) {
 		if addr == arm.mmap.APBDIV {
 			return
 		}
+		if arm.debugOutput && addr == arm.mmap.DebugOutput {
+			arm.consoleWrite(uint8(val))
+			return
+		}
 
 		if ok, name := arm.mmap.IsUnimplemented(addr); ok {
 			arm.unimplemented(fmt.Sprintf("Write 8bit: %s", name), addr)
@@ -106,7 +119,16 @@ func (arm *ARM) write8bit(addr uint32, val uint8) {
 
 	// adjust address so that it can be used as an index
 	idx := addr - origin
+	if arm.trace.enabled {
+		arm.traceWrite(addr, *mem, idx, 1, uint32(val))
+	}
 	(*mem)[idx] = val
+	if arm.dev != nil {
+		if arm.uninitialised != nil {
+			arm.uninitialised.write(addr, 1)
+		}
+		arm.watch(addr, 1, true)
+	}
 }
 
 // for 16bit and 32bit access functions, there is a parameter called
@@ -157,6 +179,9 @@ func (arm *ARM) read16bit(addr uint32, requiresAlignment bool) uint16 {
 		if addr == arm.mmap.APBDIV {
 			return uint16(0)
 		}
+		if arm.debugOutput && addr == arm.mmap.DebugOutput {
+			return uint16(0)
+		}
 
 		if ok, name := arm.mmap.IsUnimplemented(addr); ok {
 			arm.unimplemented(fmt.Sprintf("Read 16bit: %s", name), addr)
@@ -176,6 +201,12 @@ func (arm *ARM) read16bit(addr uint32, requiresAlignment bool) uint16 {
 		return uint16(arm.mmap.IllegalAccessValue)
 	}
 
+	if arm.dev != nil {
+		arm.watch(addr, 2, false)
+		if arm.uninitialised != nil {
+			arm.checkUninitialised("Read 16bit", addr, 2)
+		}
+	}
 	return arm.byteOrder.Uint16((*mem)[idx:])
 }
 
@@ -217,6 +248,10 @@ func (arm *ARM) write16bit(addr uint32, val uint16, requiresAlignment bool) {
 		if addr == arm.mmap.APBDIV {
 			return
 		}
+		if arm.debugOutput && addr == arm.mmap.DebugOutput {
+			arm.consoleWrite(uint8(val))
+			return
+		}
 
 		if ok, name := arm.mmap.IsUnimplemented(addr); ok {
 			arm.unimplemented(fmt.Sprintf("Write 16bit: %s", name), addr)
@@ -236,7 +271,16 @@ func (arm *ARM) write16bit(addr uint32, val uint16, requiresAlignment bool) {
 		return
 	}
 
+	if arm.trace.enabled {
+		arm.traceWrite(addr, *mem, idx, 2, uint32(val))
+	}
 	arm.byteOrder.PutUint16((*mem)[idx:], val)
+	if arm.dev != nil {
+		if arm.uninitialised != nil {
+			arm.uninitialised.write(addr, 2)
+		}
+		arm.watch(addr, 2, true)
+	}
 }
 
 func (arm *ARM) read32bit(addr uint32, requiresAlignment bool) uint32 {
@@ -277,6 +321,9 @@ func (arm *ARM) read32bit(addr uint32, requiresAlignment bool) uint32 {
 		if addr == arm.mmap.APBDIV {
 			return uint32(0)
 		}
+		if arm.debugOutput && addr == arm.mmap.DebugOutput {
+			return uint32(0)
+		}
 
 		if ok, name := arm.mmap.IsUnimplemented(addr); ok {
 			arm.unimplemented(fmt.Sprintf("Read 32bit: %s", name), addr)
@@ -296,6 +343,12 @@ func (arm *ARM) read32bit(addr uint32, requiresAlignment bool) uint32 {
 		return arm.mmap.IllegalAccessValue
 	}
 
+	if arm.dev != nil {
+		arm.watch(addr, 4, false)
+		if arm.uninitialised != nil {
+			arm.checkUninitialised("Read 32bit", addr, 4)
+		}
+	}
 	return arm.byteOrder.Uint32((*mem)[idx:])
 }
 
@@ -337,6 +390,10 @@ func (arm *ARM) write32bit(addr uint32, val uint32, requiresAlignment bool) {
 		if addr == arm.mmap.APBDIV {
 			return
 		}
+		if arm.debugOutput && addr == arm.mmap.DebugOutput {
+			arm.consoleWrite(uint8(val))
+			return
+		}
 
 		if ok, name := arm.mmap.IsUnimplemented(addr); ok {
 			arm.unimplemented(fmt.Sprintf("Write 32bit: %s", name), addr)
@@ -356,7 +413,16 @@ func (arm *ARM) write32bit(addr uint32, val uint32, requiresAlignment bool) {
 		return
 	}
 
+	if arm.trace.enabled {
+		arm.traceWrite(addr, *mem, idx, 4, val)
+	}
 	arm.byteOrder.PutUint32((*mem)[idx:], val)
+	if arm.dev != nil {
+		if arm.uninitialised != nil {
+			arm.uninitialised.write(addr, 4)
+		}
+		arm.watch(addr, 4, true)
+	}
 }
 
 // Peek implements the coprocessor.CoProc interface
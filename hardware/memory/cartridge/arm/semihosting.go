@@ -0,0 +1,144 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/notifications"
+)
+
+// the immediate value of the BKPT (or SWI) instruction that indicates a
+// semihosting request
+const semihostingImmediate = 0xab
+
+// the semihosting operations that are supported. the operation number is
+// placed in R0 and the parameter in R1
+//
+// from "Semihosting for AArch32 and AArch64" (ARM IHI 0096)
+const (
+	semihostingSYS_WRITEC = 0x03
+	semihostingSYS_WRITE0 = 0x04
+	semihostingSYS_WRITE  = 0x05
+	semihostingSYS_EXIT   = 0x18
+)
+
+// the reason code given to SYS_EXIT by a program that has finished normally
+const semihostingADP_Stopped_ApplicationExit = 0x20026
+
+// the maximum number of bytes that will be read for a single SYS_WRITE0 or
+// SYS_WRITE operation. protects against runaway strings that have not been
+// terminated correctly
+const semihostingMaxWrite = 4096
+
+// the console collects output from semihosting and the debug output register.
+// output is sent to the environment's notifications one line at a time
+//
+// the console is not part of the ARMState because the output has already been
+// sent by the time a snapshot is restored
+type console struct {
+	line []byte
+}
+
+// write a single byte to the console. the line is sent when a newline is seen.
+// output while the emulation is rewinding will have been seen already and is
+// ignored
+func (arm *ARM) consoleWrite(b uint8) {
+	if arm.env.IsRewinding() {
+		return
+	}
+	if b == '\n' {
+		arm.consoleFlush()
+		return
+	}
+	if b == '\r' {
+		return
+	}
+	arm.console.line = append(arm.console.line, b)
+}
+
+// send any pending output as a complete line
+func (arm *ARM) consoleFlush() {
+	if len(arm.console.line) == 0 {
+		return
+	}
+	_ = arm.env.Notifications.Notify(notifications.NotifyCoProcConsole, string(arm.console.line))
+	arm.console.line = arm.console.line[:0]
+}
+
+// decodeSemihosting returns the decodeFunction for a BKPT or SWI instruction
+// with the semihosting immediate value
+func (arm *ARM) decodeSemihosting(operator string) decodeFunction {
+	return func() *DisasmEntry {
+		if arm.decodeOnly {
+			return &DisasmEntry{
+				Operator: operator,
+				Operand:  fmt.Sprintf("#$%02x", semihostingImmediate),
+			}
+		}
+		arm.semihosting()
+		return nil
+	}
+}
+
+// semihosting performs the operation requested by the program
+func (arm *ARM) semihosting() {
+	op := arm.state.registers[0]
+	param := arm.state.registers[1]
+
+	switch op {
+	case semihostingSYS_WRITEC:
+		arm.consoleWrite(arm.read8bit(param))
+
+	case semihostingSYS_WRITE0:
+		for i := 0; i < semihostingMaxWrite; i++ {
+			b := arm.read8bit(param)
+			if b == 0x00 {
+				break // for loop
+			}
+			arm.consoleWrite(b)
+			param++
+		}
+
+	case semihostingSYS_WRITE:
+		// the parameter block is three words: file handle, pointer to the data
+		// and the length of the data. the file handle is ignored and all output
+		// is sent to the console
+		ptr := arm.read32bit(param+4, true)
+		n := arm.read32bit(param+8, true)
+		for i := uint32(0); i < n && i < semihostingMaxWrite; i++ {
+			arm.consoleWrite(arm.read8bit(ptr + i))
+		}
+
+		// the return value is the number of bytes that were not written
+		arm.state.registers[0] = 0
+
+	case semihostingSYS_EXIT:
+		arm.consoleFlush()
+		if param == semihostingADP_Stopped_ApplicationExit {
+			arm.state.yield.Type = coprocessor.YieldProgramEnded
+			arm.state.yield.Error = nil
+		} else {
+			arm.state.yield.Type = coprocessor.YieldExecutionError
+			arm.state.yield.Error = fmt.Errorf("semihosting exit with reason code %#x", param)
+		}
+
+	default:
+		arm.state.yield.Type = coprocessor.YieldUnimplementedFeature
+		arm.state.yield.Error = fmt.Errorf("unsupported semihosting operation (%#02x)", op)
+	}
+}
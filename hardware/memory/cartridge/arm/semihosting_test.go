@@ -0,0 +1,261 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm/architecture"
+	"github.com/jetsetilly/gopher2600/notifications"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// the address of the debug output register in the PlusCart memory map
+const debugOutput = 0x50070000
+
+// records the lines sent to the coprocessor console
+type consoleNotify struct {
+	lines []string
+}
+
+func (n *consoleNotify) Notify(notice notifications.Notice, data ...string) error {
+	if notice == notifications.NotifyCoProcConsole {
+		n.lines = append(n.lines, data...)
+	}
+	return nil
+}
+
+func (n *consoleNotify) PushNotify(notice notifications.Notice, data ...string) error {
+	return n.Notify(notice, data...)
+}
+
+// television stub that reports whether the emulation is rewinding
+type consoleTV struct {
+	environment.Television
+	rewinding bool
+}
+
+func (tv *consoleTV) IsRewinding() bool {
+	return tv.rewinding
+}
+
+// create an ARM for the model with console output sent to the returned
+// consoleNotify instance. the data is copied to the start of SRAM
+func newConsoleARM(t *testing.T, model string, data string, program ...uint16) (*arm.ARM, *testMemory, *consoleNotify, *consoleTV) {
+	t.Helper()
+
+	mem := newTestMemory(program...)
+	copy(mem.sram, data)
+
+	notify := &consoleNotify{}
+	tv := &consoleTV{}
+	env := newTestEnvironment(model)
+	env.Notifications = notify
+	env.TV = tv
+
+	cpu := arm.NewARM(env, architecture.NewMap(architecture.PlusCart), mem, mem)
+	return cpu, mem, notify, tv
+}
+
+func TestSemihosting_write0(t *testing.T) {
+	for _, model := range []string{"AUTO", "ARMv6_M"} {
+		cpu, _, notify, _ := newConsoleARM(t, model, "hello\nworld\x00",
+			0xbeab, // BKPT #0xab
+			0xbe00, // BKPT
+		)
+		test.ExpectSuccess(t, cpu.SetInitialRegisters(0x04, sramOrigin))
+
+		yld, _ := cpu.Run()
+		test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+
+		// the second line is not sent until it is terminated
+		test.ExpectEquality(t, len(notify.lines), 1)
+		test.ExpectEquality(t, notify.lines[0], "hello")
+	}
+}
+
+func TestSemihosting_writec(t *testing.T) {
+	cpu, mem, notify, _ := newConsoleARM(t, "AUTO", "a",
+		0xbeab, // BKPT #0xab
+		0x2403, // MOVS R4, #3
+		0x700c, // STRB R4, [R1]
+		0x2003, // MOVS R0, #3
+		0xbeab, // BKPT #0xab
+		0x240a, // MOVS R4, #10
+		0x700c, // STRB R4, [R1]
+		0x2003, // MOVS R0, #3
+		0xbeab, // BKPT #0xab
+		0xbe00, // BKPT
+	)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x03, sramOrigin))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+
+	// the bytes written are 'a', 3 and a newline. carriage returns are
+	// ignored but other control characters are kept
+	test.ExpectEquality(t, mem.sram[0], uint8(10))
+	test.ExpectEquality(t, len(notify.lines), 1)
+	test.ExpectEquality(t, notify.lines[0], "a\x03")
+}
+
+func TestSemihosting_write(t *testing.T) {
+	// the parameter block is at the start of SRAM and the data follows it
+	cpu, mem, notify, _ := newConsoleARM(t, "AUTO", "",
+		0xbeab, // BKPT #0xab
+		0xbe00, // BKPT
+	)
+	copy(mem.sram, []byte{
+		0x01, 0x00, 0x00, 0x00, // file handle
+		0x0c, 0x00, 0x00, 0x20, // pointer to data
+		0x07, 0x00, 0x00, 0x00, // length of data
+	})
+	copy(mem.sram[12:], "ab\r\ncd\nef")
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x05, sramOrigin))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+
+	// only the number of bytes in the parameter block are written
+	test.ExpectEquality(t, len(notify.lines), 2)
+	test.ExpectEquality(t, notify.lines[0], "ab")
+	test.ExpectEquality(t, notify.lines[1], "cd")
+
+	// the return value is the number of bytes not written
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(0))
+}
+
+func TestSemihosting_exit(t *testing.T) {
+	// a normal exit ends the program and flushes pending output
+	cpu, _, notify, _ := newConsoleARM(t, "AUTO", "bye\x00",
+		0xbeab, // BKPT #0xab
+		0x2018, // MOVS R0, #0x18
+		0x4902, // LDR R1, [PC, #8]
+		0xbeab, // BKPT #0xab
+		0xbe00, // BKPT
+		0x0000, // padding
+		0x0000, // padding
+		0x0000, // padding
+		0x0026, // ADP_Stopped_ApplicationExit (low)
+		0x0002, // ADP_Stopped_ApplicationExit (high)
+	)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x04, sramOrigin))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldProgramEnded)
+	test.ExpectEquality(t, len(notify.lines), 1)
+	test.ExpectEquality(t, notify.lines[0], "bye")
+
+	// any other reason is an execution error
+	cpu, _, _, _ = newConsoleARM(t, "AUTO", "",
+		0xbeab, // BKPT #0xab
+		0xbe00, // BKPT
+	)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x18, 0x20023))
+
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldExecutionError)
+	test.ExpectFailure(t, yld.Error)
+
+	// unsupported operations are reported as such
+	cpu, _, _, _ = newConsoleARM(t, "AUTO", "",
+		0xbeab, // BKPT #0xab
+		0xbe00, // BKPT
+	)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x01, 0))
+
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldUnimplementedFeature)
+}
+
+func TestSemihosting_breakpoint(t *testing.T) {
+	// a BKPT instruction with an immediate value other than 0xab is a normal
+	// breakpoint and does not produce any output
+	cpu, _, notify, _ := newConsoleARM(t, "AUTO", "hello\n\x00",
+		0xbeaa, // BKPT #0xaa
+	)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x04, sramOrigin))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, len(notify.lines), 0)
+}
+
+func TestSemihosting_debugOutput(t *testing.T) {
+	program := []uint16{
+		0x2068, // MOVS R0, #'h'
+		0x7008, // STRB R0, [R1]
+		0x2069, // MOVS R0, #'i'
+		0x7008, // STRB R0, [R1]
+		0x200a, // MOVS R0, #'\n'
+		0x7008, // STRB R0, [R1]
+		0x7808, // LDRB R0, [R1]
+		0xbe00, // BKPT
+	}
+
+	for _, model := range []string{"AUTO", "ARMv6_M"} {
+		for _, enabled := range []bool{false, true} {
+			mem := newTestMemory(program...)
+			notify := &consoleNotify{}
+			env := newTestEnvironment(model)
+			env.Notifications = notify
+			env.TV = &consoleTV{}
+			env.Prefs.Cartridge.ARM.DebugOutput.Set(enabled)
+
+			cpu := arm.NewARM(env, architecture.NewMap(architecture.PlusCart), mem, mem)
+			test.ExpectSuccess(t, cpu.SetInitialRegisters(0, debugOutput))
+			yld, _ := cpu.Run()
+
+			if !enabled {
+				// the register is not mapped and the first write is a memory fault
+				test.ExpectEquality(t, yld.Type, coprocessor.YieldMemoryFault)
+				test.ExpectEquality(t, len(notify.lines), 0)
+				continue
+			}
+
+			test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+			test.ExpectEquality(t, len(notify.lines), 1)
+			test.ExpectEquality(t, notify.lines[0], "hi")
+
+			// reading the debug output register returns zero
+			test.ExpectEquality(t, register(t, cpu, 0), uint32(0))
+		}
+	}
+}
+
+func TestSemihosting_rewinding(t *testing.T) {
+	cpu, _, notify, tv := newConsoleARM(t, "AUTO", "hello\n\x00",
+		0xbeab, // BKPT #0xab
+		0xbe00, // BKPT
+	)
+
+	// output while rewinding has already been seen and is not sent again
+	tv.rewinding = true
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x04, sramOrigin))
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, len(notify.lines), 0)
+
+	tv.rewinding = false
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0x04, sramOrigin))
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, len(notify.lines), 1)
+	test.ExpectEquality(t, notify.lines[0], "hello")
+}
@@ -120,6 +120,53 @@ func (sr *status) setSaturation(a bool) {
 	sr.saturation = a
 }
 
+// the flags of the status register packed into the layout of the APSR register
+func (sr status) apsr() uint32 {
+	var v uint32
+	if sr.negative {
+		v |= 0x80000000
+	}
+	if sr.zero {
+		v |= 0x40000000
+	}
+	if sr.carry {
+		v |= 0x20000000
+	}
+	if sr.overflow {
+		v |= 0x10000000
+	}
+	if sr.saturation {
+		v |= 0x08000000
+	}
+	return v
+}
+
+// set the flags of the status register from a value in the layout of the APSR
+// register
+func (sr *status) setAPSR(v uint32) {
+	sr.negative = v&0x80000000 == 0x80000000
+	sr.zero = v&0x40000000 == 0x40000000
+	sr.carry = v&0x20000000 == 0x20000000
+	sr.overflow = v&0x10000000 == 0x10000000
+	sr.saturation = v&0x08000000 == 0x08000000
+}
+
+// the flags and the IT state of the status register packed into the layout of
+// the xPSR register
+func (sr status) xpsr() uint32 {
+	it := uint32(sr.itCond<<4 | sr.itMask)
+	return sr.apsr() | (it&0x03)<<25 | (it&0xfc)<<8
+}
+
+// set the flags and the IT state of the status register from a value in the
+// layout of the xPSR register
+func (sr *status) setXPSR(v uint32) {
+	sr.setAPSR(v)
+	it := uint8((v>>25)&0x03 | (v>>8)&0xfc)
+	sr.itCond = it >> 4
+	sr.itMask = it & 0x0f
+}
+
 // conditional execution information from "A7.3 Conditional execution" in "ARMv7-M"
 func (sr *status) condition(cond uint8) (bool, string) {
 	var mnemonic string
@@ -37,6 +37,10 @@ func (arm *ARM) decodeThumb(opcode uint16) decodeFunction {
 	} else if opcode&0xff00 == 0xdf00 {
 		// format 17 - Software interrupt"
 		return arm.decodeThumbSoftwareInterrupt(opcode)
+	} else if opcode == 0xbe00|semihostingImmediate {
+		// breakpoint is not an ARMv4T instruction but it is the conventional
+		// way of making a semihosting request so we accept it
+		return arm.decodeSemihosting("BKPT")
 	} else if opcode&0xf000 == 0xd000 {
 		// format 16 - Conditional branch
 		return arm.decodeThumbConditionalBranch(opcode)
@@ -766,7 +770,9 @@ func (arm *ARM) decodeThumbALUoperations(opcode uint16) decodeFunction {
 			logger.Log(arm.env, "ARM7", "shift and store in PC is not possible in thumb mode")
 		}
 
-		if mul {
+		// the Cortex-M0+ is normally implemented with the single cycle
+		// multiplier so no additional cycles are required for ARMv6-M
+		if mul && arm.mmap.ARMArchitecture != architecture.ARMv6_M {
 			// "7.7 Data Operations" in "ARM7TDMI-S Technical Reference Manual r4p3"
 			//  and
 			// "7.2 Instruction Cycle Count Summary"  in "ARM7TDMI-S Technical
@@ -936,7 +942,7 @@ func (arm *ARM) decodeThumbHiRegisterOps(opcode uint16) decodeFunction {
 			thumbMode = arm.state.registers[srcReg]&0x01 == 0x01
 
 			switch arm.mmap.ARMArchitecture {
-			case architecture.ARMv7_M:
+			case architecture.ARMv7_M, architecture.ARMv6_M:
 				if opcode&0x0080 == 0x0080 {
 					// "A7.7.19 BLX (register)" in "ARMv7-M"
 					nextPC := arm.state.registers[rPC] - 2
@@ -1838,6 +1844,12 @@ func (arm *ARM) decodeThumbConditionalBranch(opcode uint16) decodeFunction {
 
 func (arm *ARM) decodeThumbSoftwareInterrupt(opcode uint16) decodeFunction {
 	// format 17 - Software interrupt"
+
+	// software interrupts are only supported for the purposes of semihosting
+	if opcode&0x00ff == semihostingImmediate {
+		return arm.decodeSemihosting("SWI")
+	}
+
 	panic(fmt.Sprintf("unimplemented (software interrupt) thumb instruction (%04x)", opcode))
 }
 
@@ -146,6 +146,10 @@ func (arm *ARM) decodeThumb2Miscellaneous(opcode uint16) decodeFunction {
 		}
 	} else {
 		if opcode&0xff00 == 0xbe00 {
+			if opcode&0x00ff == semihostingImmediate {
+				return arm.decodeSemihosting("BKPT")
+			}
+
 			// software breakpoint
 			return func() *DisasmEntry {
 				arm.state.yield.Type = coprocessor.YieldSyncWithVCS
@@ -218,6 +222,24 @@ func (arm *ARM) decodeThumb2ReverseBytes(opcode uint16) decodeFunction {
 			arm.state.registers[Rd] = r
 			return nil
 		}
+	case 0b11:
+		return func() *DisasmEntry {
+			// "4.6.113 REVSH" of "Thumb-2 Supplement"
+			if arm.decodeOnly {
+				return &DisasmEntry{
+					Operator: "REVSH",
+					Operand:  fmt.Sprintf("R%d, R%d", Rd, Rm),
+				}
+			}
+
+			v := arm.state.registers[Rm]
+			r := ((v & 0x000000ff) << 8) | ((v & 0x0000ff00) >> 8)
+			if r&0x8000 == 0x8000 {
+				r |= 0xffff0000
+			}
+			arm.state.registers[Rd] = r
+			return nil
+		}
 	default:
 		panic(fmt.Sprintf("unimplemented thumb2 reverse byte instruction (%02b)", opc))
 	}
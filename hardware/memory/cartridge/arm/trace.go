@@ -0,0 +1,228 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+)
+
+// the number of instructions kept in the trace if no limit is specified
+const defaultTraceLimit = 50000
+
+// trace records the instructions executed by the ARM so that execution can be
+// stepped backwards. tracing is opt-in because of the cost of recording every
+// instruction
+//
+// only writes to memory that is returned by the SharedMemory interface are
+// recorded. writes to peripherals cannot be undone
+type trace struct {
+	crit sync.Mutex
+
+	// enabled is only changed by the emulation goroutine and so can be checked
+	// without acquiring the critical section
+	enabled bool
+	limit   int
+
+	// entries are appended to the list. when the list reaches twice the limit
+	// the oldest entries are discarded. the most recent entries are therefore
+	// always in a contiguous list without the need for a ring buffer
+	entries []coprocessor.CartCoProcTraceEntry
+
+	// entries before the boundary index were executed before the ARM last
+	// yielded to the VCS. the 6507 has run since then so undoing the ARM
+	// writes alone would leave the emulation in an inconsistent state
+	boundary int
+}
+
+// SetTracing implements the coprocessor.CartCoProcTracer interface.
+func (arm *ARM) SetTracing(enable bool, limit int) {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+
+	if limit <= 0 {
+		limit = defaultTraceLimit
+	}
+
+	arm.trace.enabled = enable
+	arm.trace.limit = limit
+
+	if enable {
+		if arm.trace.entries == nil {
+			arm.trace.entries = make([]coprocessor.CartCoProcTraceEntry, 0, limit)
+		}
+	} else {
+		arm.trace.entries = nil
+	}
+}
+
+// Tracing implements the coprocessor.CartCoProcTracer interface.
+func (arm *ARM) Tracing() (bool, int) {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+	return arm.trace.enabled, arm.trace.limit
+}
+
+// BorrowTrace implements the coprocessor.CartCoProcTracer interface.
+func (arm *ARM) BorrowTrace(f func([]coprocessor.CartCoProcTraceEntry)) {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+
+	if len(arm.trace.entries) > arm.trace.limit {
+		f(arm.trace.entries[len(arm.trace.entries)-arm.trace.limit:])
+		return
+	}
+	f(arm.trace.entries)
+}
+
+// StepBack implements the coprocessor.CartCoProcTracer interface.
+func (arm *ARM) StepBack(n int) (int, error) {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+
+	if !arm.trace.enabled {
+		return 0, fmt.Errorf("tracing is not enabled")
+	}
+
+	// a program that has ended will be restarted from the beginning the next
+	// time the ARM is run so there is nothing to step back into
+	if arm.state.yield.Type == coprocessor.YieldProgramEnded {
+		return 0, fmt.Errorf("program has ended")
+	}
+
+	// the 6507 may have run since the ARM yielded to the VCS
+	if arm.state.yield.Type == coprocessor.YieldSyncWithVCS {
+		return 0, fmt.Errorf("cannot step back past a yield to the VCS")
+	}
+
+	oldest := max(0, len(arm.trace.entries)-arm.trace.limit, arm.trace.boundary)
+	n = min(n, len(arm.trace.entries)-oldest)
+	if n <= 0 {
+		if arm.trace.boundary > 0 {
+			return 0, fmt.Errorf("cannot step back past a yield to the VCS")
+		}
+		return 0, fmt.Errorf("no instructions in trace")
+	}
+
+	for range n {
+		e := arm.trace.entries[len(arm.trace.entries)-1]
+		arm.trace.entries = arm.trace.entries[:len(arm.trace.entries)-1]
+
+		// undo writes in the reverse order to which they were made
+		for i := len(e.Writes) - 1; i >= 0; i-- {
+			w := e.Writes[i]
+			mem, origin := arm.mem.MapAddress(w.Addr, true, false)
+			if mem == nil {
+				continue
+			}
+			idx := w.Addr - origin
+			switch w.Size {
+			case 1:
+				(*mem)[idx] = uint8(w.Prev)
+			case 2:
+				arm.byteOrder.PutUint16((*mem)[idx:], uint16(w.Prev))
+			case 4:
+				arm.byteOrder.PutUint32((*mem)[idx:], w.Prev)
+			}
+		}
+
+		copy(arm.state.registers[:], e.Registers[:])
+		arm.state.status.setXPSR(e.Status)
+		arm.state.instructionPC = e.Addr
+	}
+
+	// the ARM will continue from the restored program counter the next time
+	// it is run. the restored PC is treated as a branch so that the program
+	// memory is checked
+	arm.state.executingPC = arm.state.registers[rPC] - 2
+	arm.state.branchedExecution = true
+	arm.state.instruction32bitDecoding = false
+	arm.state.instruction32bitResolving = false
+	arm.state.instruction32bitOpcodeHi = 0x0
+
+	return n, nil
+}
+
+// record the state of the ARM before the instruction at the executing PC is
+// executed. should not be called for the second half of a 32bit instruction
+func (arm *ARM) traceInstruction() {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+
+	if len(arm.trace.entries) >= arm.trace.limit*2 {
+		discard := len(arm.trace.entries) - arm.trace.limit
+		n := copy(arm.trace.entries, arm.trace.entries[discard:])
+		clear(arm.trace.entries[n:])
+		arm.trace.entries = arm.trace.entries[:n]
+		arm.trace.boundary = max(0, arm.trace.boundary-discard)
+	}
+
+	arm.trace.entries = append(arm.trace.entries, coprocessor.CartCoProcTraceEntry{
+		Addr:      arm.state.executingPC,
+		Registers: arm.state.registers,
+		Status:    arm.state.status.xpsr(),
+	})
+}
+
+// record a write to memory. the write is associated with the most recent
+// instruction in the trace. the previous value is taken from memory so the
+// function must be called before memory is written to
+func (arm *ARM) traceWrite(addr uint32, mem []byte, idx uint32, size int, val uint32) {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+
+	if len(arm.trace.entries) == 0 {
+		return
+	}
+
+	w := coprocessor.CartCoProcTraceWrite{
+		Addr:  addr,
+		Size:  size,
+		Value: val,
+	}
+
+	switch size {
+	case 1:
+		w.Prev = uint32(mem[idx])
+	case 2:
+		w.Prev = uint32(arm.byteOrder.Uint16(mem[idx:]))
+	case 4:
+		w.Prev = arm.byteOrder.Uint32(mem[idx:])
+	}
+
+	e := &arm.trace.entries[len(arm.trace.entries)-1]
+	e.Writes = append(e.Writes, w)
+}
+
+// the trace is cleared at the start of each new program execution and when a
+// new state is plumbed in
+func (arm *ARM) traceReset() {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+	clear(arm.trace.entries)
+	arm.trace.entries = arm.trace.entries[:0]
+	arm.trace.boundary = 0
+}
+
+// the ARM is resuming after a yield to the VCS. instructions executed before
+// this point can no longer be stepped back
+func (arm *ARM) traceResume() {
+	arm.trace.crit.Lock()
+	defer arm.trace.crit.Unlock()
+	arm.trace.boundary = len(arm.trace.entries)
+}
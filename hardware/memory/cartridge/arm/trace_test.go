@@ -0,0 +1,186 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/coprocessor/faults"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm/architecture"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// minimal implementation of the CartCoProcDeveloper interface with a single
+// breakpoint
+type traceDeveloper struct {
+	breakpoint uint32
+}
+
+func (dev *traceDeveloper) MemoryFault(_ string, _ faults.Category, _ uint32, _ uint32) {}
+func (dev *traceDeveloper) HighAddress() uint32                                         { return 0 }
+func (dev *traceDeveloper) CheckBreakpoint(addr uint32) bool                            { return addr == dev.breakpoint }
+func (dev *traceDeveloper) UpdateStrobe(_ uint32)                                       {}
+func (dev *traceDeveloper) StartProfiling()                                             {}
+func (dev *traceDeveloper) ProcessProfiling()                                           {}
+func (dev *traceDeveloper) OnYield(_ uint32, _ coprocessor.CoProcYield)                 {}
+
+func (dev *traceDeveloper) Profiling() *coprocessor.CartCoProcProfiler {
+	return &coprocessor.CartCoProcProfiler{}
+}
+
+func (dev *traceDeveloper) CheckWatchpoint(_ uint32, _ uint32, _ int, _ bool) (bool, string) {
+	return false, ""
+}
+
+func (dev *traceDeveloper) UninitialisedRead(_ string, _ uint32, _ uint32, _ int) string {
+	return ""
+}
+
+func TestTrace_stepBack(t *testing.T) {
+	mem := newTestMemory(
+		0x2001, // MOVS R0, #1
+		0x6008, // STR R0, [R1]
+		0x2002, // MOVS R0, #2
+		0x6008, // STR R0, [R1]
+		0xbe00, // BKPT
+		0x2003, // MOVS R0, #3
+		0x6008, // STR R0, [R1]
+		0xbe00, // BKPT
+	)
+
+	cpu := newTestARM(t, "AUTO", mem)
+	dev := &traceDeveloper{breakpoint: entryPoint + 8}
+	cpu.SetDeveloper(dev)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0, sramOrigin))
+	cpu.SetTracing(true, 0)
+
+	// the breakpoint is on the first BKPT instruction
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldBreakpoint)
+	test.ExpectEquality(t, mem.sram[0], uint8(2))
+
+	cpu.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+		test.ExpectEquality(t, len(trace), 4)
+		test.ExpectEquality(t, trace[3].Addr, uint32(entryPoint+6))
+		test.ExpectEquality(t, len(trace[3].Writes), 1)
+		test.ExpectEquality(t, trace[3].Writes[0].Prev, uint32(1))
+		test.ExpectEquality(t, trace[3].Writes[0].Value, uint32(2))
+	})
+
+	// undo the second STR
+	n, err := cpu.StepBack(1)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, n, 1)
+	test.ExpectEquality(t, mem.sram[0], uint8(1))
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(2))
+
+	// undo the second MOVS
+	n, err = cpu.StepBack(1)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, n, 1)
+	test.ExpectEquality(t, mem.sram[0], uint8(1))
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(1))
+
+	// the program continues from the second MOVS and stops at the
+	// breakpoint again
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldBreakpoint)
+	test.ExpectEquality(t, mem.sram[0], uint8(2))
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(2))
+
+	// continue to the first BKPT instruction, which yields to the VCS
+	dev.breakpoint = 0
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+
+	// the 6507 may have run since the yield to the VCS
+	_, err = cpu.StepBack(1)
+	test.ExpectFailure(t, err)
+	test.ExpectEquality(t, mem.sram[0], uint8(2))
+
+	// resume and stop at the breakpoint on the second BKPT
+	dev.breakpoint = entryPoint + 14
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldBreakpoint)
+	test.ExpectEquality(t, mem.sram[0], uint8(3))
+
+	// stepping back is limited to the instructions executed since the yield
+	// to the VCS
+	n, err = cpu.StepBack(100)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, n, 2)
+	test.ExpectEquality(t, mem.sram[0], uint8(2))
+	test.ExpectEquality(t, register(t, cpu, 0), uint32(2))
+
+	_, err = cpu.StepBack(1)
+	test.ExpectFailure(t, err)
+	test.ExpectEquality(t, mem.sram[0], uint8(2))
+}
+
+func TestTrace_plumb(t *testing.T) {
+	mem := newTestMemory(
+		0x2001, // MOVS R0, #1
+		0x6008, // STR R0, [R1]
+		0x2002, // MOVS R0, #2
+		0x6008, // STR R0, [R1]
+		0xbe00, // BKPT
+	)
+
+	env := newTestEnvironment("AUTO")
+	cpu := arm.NewARM(env, architecture.NewMap(architecture.PlusCart), mem, mem)
+	dev := &traceDeveloper{breakpoint: entryPoint + 6}
+	cpu.SetDeveloper(dev)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0, sramOrigin))
+	cpu.SetTracing(true, 0)
+
+	before := cpu.Snapshot()
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldBreakpoint)
+	test.ExpectEquality(t, mem.sram[0], uint8(1))
+
+	// plumbing in a new state, as happens when the emulation is rewound,
+	// clears the trace. the instructions in the trace belong to a timeline
+	// that has been abandoned
+	cpu.Plumb(env, before, mem, mem)
+	cpu.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+		test.ExpectEquality(t, len(trace), 0)
+	})
+
+	_, err := cpu.StepBack(1)
+	test.ExpectFailure(t, err)
+	test.ExpectEquality(t, mem.sram[0], uint8(1))
+}
+
+func TestTrace_limit(t *testing.T) {
+	cpu := newTestARM(t, "AUTO", newTestMemory(
+		0x2003, // MOVS R0, #3
+		0x3801, // loop: SUBS R0, #1
+		0xd1fd, // BNE loop
+		0xbe00, // BKPT
+	))
+	cpu.SetTracing(true, 4)
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+
+	cpu.BorrowTrace(func(trace []coprocessor.CartCoProcTraceEntry) {
+		test.ExpectEquality(t, len(trace), 4)
+		test.ExpectEquality(t, trace[3].Addr, uint32(entryPoint+6))
+	})
+}
@@ -0,0 +1,192 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/coprocessor/faults"
+)
+
+// shadow of a single area of uninitialised memory. each byte in the area has
+// a corresponding bit in the written bitmap
+type uninitialisedShadow struct {
+	origin  uint32
+	memtop  uint32
+	written []uint64
+}
+
+// whether the byte at index idx of the area has been written
+func (s *uninitialisedShadow) isWritten(idx uint32) bool {
+	return s.written[idx>>6]&(1<<(idx&63)) != 0
+}
+
+// mark the byte at index idx of the area as written
+func (s *uninitialisedShadow) setWritten(idx uint32) {
+	s.written[idx>>6] |= 1 << (idx & 63)
+}
+
+// uninitialised tracks which bytes of uninitialised memory have been written
+// to by the ARM program. the tracking is only active when a developer instance
+// is attached to the ARM because of the additional cost to every memory access
+//
+// the written state of the shadows is part of the ARMState snapshot so that
+// it is correct after the emulation has been rewound
+type uninitialised struct {
+	shadows []uninitialisedShadow
+
+	// the most recent fault should halt the emulation. this is so that the run
+	// loop doesn't reset the memory fault yield as it would normally do when
+	// the abortOnMemoryFault preference is not set
+	halt bool
+}
+
+// create shadows for the memory areas returned by the SharedMemoryUninitialised
+// interface. returns nil if the shared memory does not implement the interface
+func newUninitialised(mem SharedMemory) *uninitialised {
+	m, ok := mem.(SharedMemoryUninitialised)
+	if !ok {
+		return nil
+	}
+
+	u := &uninitialised{}
+	for _, a := range m.UninitialisedMemory() {
+		if a.Memtop < a.Origin {
+			continue // for loop
+		}
+		u.shadows = append(u.shadows, uninitialisedShadow{
+			origin:  a.Origin,
+			memtop:  a.Memtop,
+			written: make([]uint64, (a.Memtop-a.Origin)/64+1),
+		})
+	}
+
+	if len(u.shadows) == 0 {
+		return nil
+	}
+
+	return u
+}
+
+// reset all shadows to the unwritten state
+func (u *uninitialised) reset() {
+	for _, s := range u.shadows {
+		clear(s.written)
+	}
+	u.halt = false
+}
+
+// snapshot returns a copy of the written state of every shadow
+func (u *uninitialised) snapshot() [][]uint64 {
+	n := make([][]uint64, len(u.shadows))
+	for i, s := range u.shadows {
+		n[i] = make([]uint64, len(s.written))
+		copy(n[i], s.written)
+	}
+	return n
+}
+
+// restore the written state of every shadow from a value returned by
+// snapshot(). if the snapshot is nil, because it was taken when the shadows
+// did not exist, then all memory is marked as written. this prevents false
+// reports of uninitialised reads at the cost of missing real ones until the
+// next reset
+func (u *uninitialised) restore(snapshot [][]uint64) {
+	u.halt = false
+
+	if len(snapshot) != len(u.shadows) {
+		for _, s := range u.shadows {
+			for i := range s.written {
+				s.written[i] = ^uint64(0)
+			}
+		}
+		return
+	}
+
+	for i, s := range u.shadows {
+		copy(s.written, snapshot[i])
+	}
+}
+
+// returns the shadow for the address or nil if the address is not in an
+// uninitialised area
+func (u *uninitialised) shadow(addr uint32) *uninitialisedShadow {
+	for i := range u.shadows {
+		if addr >= u.shadows[i].origin && addr <= u.shadows[i].memtop {
+			return &u.shadows[i]
+		}
+	}
+	return nil
+}
+
+// mark memory as having been written
+func (u *uninitialised) write(addr uint32, size int) {
+	s := u.shadow(addr)
+	if s == nil {
+		return
+	}
+	idx := addr - s.origin
+	for i := uint32(0); i < uint32(size) && idx+i <= s.memtop-s.origin; i++ {
+		s.setWritten(idx + i)
+	}
+}
+
+// returns true if any of the bytes in the memory range have not been written
+// to. the bytes are marked as written so that the same memory is reported only
+// once
+func (u *uninitialised) read(addr uint32, size int) bool {
+	s := u.shadow(addr)
+	if s == nil {
+		return false
+	}
+	var unwritten bool
+	idx := addr - s.origin
+	for i := uint32(0); i < uint32(size) && idx+i <= s.memtop-s.origin; i++ {
+		if !s.isWritten(idx + i) {
+			s.setWritten(idx + i)
+			unwritten = true
+		}
+	}
+	return unwritten
+}
+
+// MemoryInitialised should be called when memory shared with the ARM has been
+// written to by something other than the ARM program. For example, by a
+// function that has been emulated by the parent cartridge-mapper.
+func (arm *ARM) MemoryInitialised(addr uint32, size uint32) {
+	if arm.uninitialised == nil {
+		return
+	}
+	arm.uninitialised.write(addr, int(size))
+}
+
+// checkUninitialised reports a read of memory that has not been written to by
+// the ARM program. the emulation will halt if the abortOnUninitialisedRead
+// preference is set
+func (arm *ARM) checkUninitialised(event string, addr uint32, size int) {
+	if !arm.uninitialised.read(addr, size) {
+		return
+	}
+
+	detail := arm.dev.UninitialisedRead(event, arm.state.instructionPC, addr, size)
+
+	if arm.abortOnUninitialisedRead && arm.state.yield.Type == coprocessor.YieldRunning {
+		arm.state.yield.Type = coprocessor.YieldMemoryFault
+		arm.state.yield.Error = fmt.Errorf("%s: %s", faults.UninitialisedRead, detail)
+		arm.uninitialised.halt = true
+	}
+}
@@ -0,0 +1,128 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+	"github.com/jetsetilly/gopher2600/coprocessor/faults"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/arm/architecture"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// test memory with the SRAM marked as uninitialised
+type uninitialisedMemory struct {
+	*testMemory
+}
+
+func (mem uninitialisedMemory) UninitialisedMemory() []arm.UninitialisedArea {
+	return []arm.UninitialisedArea{
+		{
+			Origin: sramOrigin,
+			Memtop: sramOrigin + uint32(len(mem.sram)) - 1,
+		},
+	}
+}
+
+// minimal implementation of the CartCoProcDeveloper interface that records
+// uninitialised reads
+type uninitialisedDeveloper struct {
+	reads []uint32
+}
+
+func (dev *uninitialisedDeveloper) MemoryFault(_ string, _ faults.Category, _ uint32, _ uint32) {}
+func (dev *uninitialisedDeveloper) HighAddress() uint32                                         { return 0 }
+func (dev *uninitialisedDeveloper) CheckBreakpoint(_ uint32) bool                               { return false }
+func (dev *uninitialisedDeveloper) UpdateStrobe(_ uint32)                                       {}
+func (dev *uninitialisedDeveloper) StartProfiling()                                             {}
+func (dev *uninitialisedDeveloper) ProcessProfiling()                                           {}
+func (dev *uninitialisedDeveloper) OnYield(_ uint32, _ coprocessor.CoProcYield)                 {}
+
+func (dev *uninitialisedDeveloper) Profiling() *coprocessor.CartCoProcProfiler {
+	return &coprocessor.CartCoProcProfiler{}
+}
+
+func (dev *uninitialisedDeveloper) CheckWatchpoint(_ uint32, _ uint32, _ int, _ bool) (bool, string) {
+	return false, ""
+}
+
+func (dev *uninitialisedDeveloper) UninitialisedRead(_ string, _ uint32, accessAddr uint32, _ int) string {
+	dev.reads = append(dev.reads, accessAddr)
+	return ""
+}
+
+func TestUninitialisedRead(t *testing.T) {
+	mem := newTestMemory(
+		0x6808, // LDR R0, [R1]
+		0x6048, // STR R0, [R1, #4]
+		0x6848, // LDR R0, [R1, #4]
+		0x6808, // LDR R0, [R1]
+		0xbe00, // BKPT
+	)
+
+	cpu := newTestARMWithMemory(t, "AUTO", uninitialisedMemory{mem}, mem)
+	dev := &uninitialisedDeveloper{}
+	cpu.SetDeveloper(dev)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0, sramOrigin))
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+
+	// the first read is of uninitialised memory. the second read is of
+	// memory that has been written to. the third read is of the same
+	// uninitialised memory as the first read and is not reported again
+	test.ExpectEquality(t, len(dev.reads), 1)
+	test.ExpectEquality(t, dev.reads[0], uint32(sramOrigin))
+}
+
+func TestUninitialisedRewind(t *testing.T) {
+	mem := newTestMemory(
+		0x6848, // LDR R0, [R1, #4]
+		0x6048, // STR R0, [R1, #4]
+		0xbe00, // BKPT
+	)
+
+	env := newTestEnvironment("AUTO")
+	cpu := arm.NewARM(env, architecture.NewMap(architecture.PlusCart), uninitialisedMemory{mem}, mem)
+	dev := &uninitialisedDeveloper{}
+	cpu.SetDeveloper(dev)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0, sramOrigin))
+
+	// snapshot before the memory has been written
+	before := cpu.Snapshot()
+
+	yld, _ := cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, len(dev.reads), 1)
+
+	// snapshot after the memory has been written
+	after := cpu.Snapshot()
+
+	// rewinding to before the write means that the read is reported again
+	cpu.Plumb(env, before, uninitialisedMemory{mem}, mem)
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, len(dev.reads), 2)
+
+	// rewinding to after the write means that the read is not reported
+	cpu.Plumb(env, after, uninitialisedMemory{mem}, mem)
+	test.ExpectSuccess(t, cpu.SetInitialRegisters(0, sramOrigin))
+	yld, _ = cpu.Run()
+	test.ExpectEquality(t, yld.Type, coprocessor.YieldSyncWithVCS)
+	test.ExpectEquality(t, len(dev.reads), 2)
+}
@@ -0,0 +1,35 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package arm
+
+import (
+	"errors"
+
+	"github.com/jetsetilly/gopher2600/coprocessor"
+)
+
+// watch checks whether the memory access triggers a watchpoint. the ARM will
+// yield once the current instruction has completed
+//
+// the check is always made, even if the ARM is already yielding, because the
+// developer may need to record the new value of the watched memory
+func (arm *ARM) watch(addr uint32, size int, write bool) {
+	ok, detail := arm.dev.CheckWatchpoint(arm.state.instructionPC, addr, size, write)
+	if ok && arm.state.yield.Type == coprocessor.YieldRunning {
+		arm.state.yield.Type = coprocessor.YieldWatchpoint
+		arm.state.yield.Error = errors.New(detail)
+	}
+}
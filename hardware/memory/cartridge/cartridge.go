@@ -330,6 +330,14 @@ func (cart *Cartridge) Attach(loader cartridgeloader.Loader) error {
 		cart.mapper, err = newUA(cart.env, false)
 	case "UASW":
 		cart.mapper, err = newUA(cart.env, true)
+	case "0840":
+		cart.mapper, err = newEconobanking(cart.env)
+	case "X07":
+		cart.mapper, err = newX07(cart.env)
+	case "4A50":
+		cart.mapper, err = newFourA50(cart.env)
+	case "CTY":
+		cart.mapper, err = newChetiry(cart.env)
 	case "AR":
 		cart.mapper, err = supercharger.NewSupercharger(cart.env)
 	case "DF":
@@ -367,6 +375,8 @@ func (cart *Cartridge) Attach(loader cartridgeloader.Loader) error {
 		cart.mapper, err = cdf.NewCDF(cart.env, "CDFJ")
 	case "CDFJ+":
 		cart.mapper, err = cdf.NewCDF(cart.env, "CDFJ+")
+	case "BUS":
+		cart.mapper, err = cdf.NewBUS(cart.env)
 
 	case "MVC":
 		cart.mapper, err = moviecart.NewMoviecart(cart.env)
@@ -624,9 +634,9 @@ func (cart *Cartridge) CoProcExecutionState() coprocessor.CoProcExecutionState {
 }
 
 // BusStuff implements the mapper.CartBusStuff interface
-func (cart *Cartridge) BusStuff() (uint8, bool) {
+func (cart *Cartridge) BusStuff(addr uint16, write bool) (uint8, bool) {
 	if cart.hasBusStuff {
-		return cart.busStuff.BusStuff()
+		return cart.busStuff.BusStuff(addr, write)
 	}
 	return 0, false
 }
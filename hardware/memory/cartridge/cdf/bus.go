@@ -0,0 +1,289 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cdf
+
+import (
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+)
+
+// bus implements the mapper.CartMapper interface for the BUS format. BUS is
+// the predecessor of CDF and the two formats share the same memory layout, ARM
+// driver interface and datastream handling. the differences are in how the
+// 6507 accesses the datastreams.
+//
+// the CDF format uses the fast fetch mode and "LDA #immediate" instructions. BUS
+// uses dedicated hotspots and "bus-stuffing". in bus-stuffing the cartridge
+// drives the data bus during a "STY zeropage" instruction. the value that is
+// written to the TIA register is therefore taken from a datastream rather than
+// the Y register
+//
+// the mode that enables bus-stuffing also enables the fast jump. to make the
+// most of the CDF register handling, this mode is indicated by the FastFetch
+// register.
+type bus struct {
+	*cdf
+
+	// the address of the next fast jump operand byte
+	jmpOperand uint16
+
+	// a "STY zeropage" instruction has been read and styOperand is the address
+	// of the operand
+	sty        bool
+	styOperand uint16
+
+	// the TIA register that will receive the next bus-stuffed value. the
+	// value is only stuffed if the access immediately after the STY operand
+	// is a write to the zero page address given by the operand. any other
+	// access means that the 0x84 byte was not a STY instruction. for example,
+	// it was the operand of an "LDA #immediate" instruction
+	busStuff        bool
+	busStuffAddress uint8
+
+	// BusStuff() is called on every bus access. busStuffDelay makes sure that
+	// the read of the STY operand is not treated as the access that follows it
+	busStuffDelay bool
+}
+
+// the datastreams used by the DSREAD, DSWRITE and DSPTR hotspots and by fast
+// jumps. these differ from the equivalent CDF datastreams
+const (
+	busDSCOMM = 16
+	busDSJMP  = 17
+)
+
+// the number of TIA registers that have an address map. the address maps sit
+// between the increments and the music fetchers in the driver RAM and cover
+// the registers from VSYNC to HMM0
+const busAddressMaps = 35
+
+const styZeroPage = 0x84
+
+// NewBUS is the preferred method of initialisation for the BUS type.
+func NewBUS(env *environment.Environment) (mapper.CartMapper, error) {
+	c, err := newCDF(env, "BUS", "BUS")
+	if err != nil {
+		return nil, err
+	}
+	return &bus{cdf: c}, nil
+}
+
+// Snapshot implements the mapper.CartMapper interface.
+func (cart *bus) Snapshot() mapper.CartMapper {
+	n := *cart
+	n.cdf = cart.cdf.Snapshot().(*cdf)
+	return &n
+}
+
+// Reset implements the mapper.CartMapper interface.
+func (cart *bus) Reset() error {
+	cart.jmpOperand = 0
+	cart.sty = false
+	cart.busStuff = false
+	cart.busStuffDelay = false
+	return cart.cdf.Reset()
+}
+
+// Access implements the mapper.CartMapper interface.
+func (cart *bus) Access(addr uint16, peek bool) (uint8, uint8, error) {
+	if b, ok := cart.state.callfn.Check(addr); ok {
+		return b, mapper.CartDrivenPins, nil
+	}
+
+	if peek {
+		return cart.banks[cart.state.bank][addr], mapper.CartDrivenPins, nil
+	}
+
+	if cart.bankswitch(addr) {
+		return 0, mapper.CartDrivenPins, nil
+	}
+
+	data := cart.banks[cart.state.bank][addr]
+
+	// the two operand bytes of a fast jump are taken from the jump datastream
+	if cart.state.fastJMP > 0 {
+		if addr == cart.jmpOperand {
+			cart.state.fastJMP--
+			cart.jmpOperand++
+
+			jmp := cart.readDatastreamPointer(busDSJMP)
+			idx := int(jmp >> cart.version.fetcherShift)
+			if idx >= len(cart.state.static.dataRAM.data) {
+				return 0, mapper.CartDrivenPins, nil
+			}
+			data = cart.state.static.dataRAM.data[idx]
+
+			// the jump datastream always increments by one
+			jmp += 1 << cart.version.fetcherShift
+			cart.updateDatastreamPointer(busDSJMP, jmp)
+
+			return data, mapper.CartDrivenPins, nil
+		}
+
+		// the instruction was not a fast jump after all. for example, it was
+		// a phantom read (see the commentary in the CDF Access() function)
+		cart.state.fastJMP = 0
+	}
+
+	if cart.state.registers.FastFetch {
+		// only "jmp absolute" instructions with an address operand of $0000
+		// are treated as fast jumps
+		if data == jmpAbsolute &&
+			cart.banks[cart.state.bank][(addr+1)&0x0fff] == 0x00 &&
+			cart.banks[cart.state.bank][(addr+2)&0x0fff] == 0x00 {
+			cart.state.fastJMP = 2
+			cart.jmpOperand = addr + 1
+			return data, mapper.CartDrivenPins, nil
+		}
+
+		// the operand of the STY instruction is the TIA register that will be
+		// bus-stuffed
+		if cart.sty && addr == cart.styOperand {
+			cart.busStuff = true
+			cart.busStuffAddress = data
+			cart.busStuffDelay = true
+		}
+	}
+	cart.sty = false
+
+	switch addr {
+	case 0x0fee:
+		// AMPLITUDE
+		data = cart.amplitude()
+	case 0x0fef:
+		// DSREAD
+		data = cart.streamData(busDSCOMM)
+	}
+
+	if cart.state.registers.FastFetch && data == styZeroPage {
+		cart.sty = true
+		cart.styOperand = addr + 1
+	}
+
+	return data, mapper.CartDrivenPins, nil
+}
+
+// AccessVolatile implements the mapper.CartMapper interface.
+func (cart *bus) AccessVolatile(addr uint16, data uint8, poke bool) error {
+	// bank switches can not take place if coprocessor is active
+	if cart.state.callfn.IsActive() {
+		return nil
+	}
+
+	if !poke {
+		if cart.bankswitch(addr) {
+			return nil
+		}
+	}
+
+	switch addr {
+	case 0x0ff0:
+		// DSWRITE
+		cart.writeDatastream(busDSCOMM, data)
+
+	case 0x0ff1:
+		// DSPTR
+		cart.shiftDatastreamPointer(busDSCOMM, data)
+
+	case 0x0ff2:
+		// SETMODE
+		cart.state.registers.FastFetch = data&0x0f == 0x00
+		cart.state.registers.SampleMode = data&0xf0 == 0x00
+
+		if !cart.state.registers.FastFetch {
+			cart.state.fastJMP = 0
+			cart.sty = false
+			cart.busStuff = false
+		}
+
+	case 0x0ff3:
+		// CALLFN
+		cart.callFunction(data)
+
+	default:
+		if poke {
+			cart.banks[cart.state.bank][addr] = data
+		}
+	}
+
+	return nil
+}
+
+// bankswitch on hotspot access.
+func (cart *bus) bankswitch(addr uint16) bool {
+	if addr >= 0x0ff5 && addr <= 0x0ffb {
+		cart.state.bank = int(addr - 0x0ff5)
+		return true
+	}
+	return false
+}
+
+// BusStuff implements the mapper.CartBusStuff interface.
+func (cart *bus) BusStuff(addr uint16, write bool) (uint8, bool) {
+	if !cart.busStuff {
+		return 0, false
+	}
+
+	if cart.busStuffDelay {
+		cart.busStuffDelay = false
+		return 0, false
+	}
+
+	cart.busStuff = false
+
+	if !write || addr != uint16(cart.busStuffAddress) {
+		return 0, false
+	}
+
+	reg := int(cart.busStuffAddress)
+	if reg >= busAddressMaps {
+		return 0, false
+	}
+
+	// the lowest nibble of the address map is the datastream to use for the
+	// TIA register
+	m := cart.readAddressMap(reg)
+	ds := int(m & 0x0f)
+	data := cart.streamData(ds)
+
+	// rotate the address map so that the next write to the TIA register uses
+	// the next datastream in the map
+	cart.updateAddressMap(reg, (m>>4)|(uint32(ds)<<28))
+
+	// on the real hardware the cartridge can only pull data lines low. the
+	// program is expected to set the Y register to $ff before bus-stuffing so
+	// we can return the datastream value as is
+	return data, true
+}
+
+// Hotspots implements the mapper.CartHotspotsBus interface.
+func (cart *bus) Hotspots() map[uint16]mapper.CartHotspotInfo {
+	return map[uint16]mapper.CartHotspotInfo{
+		0x1ff5: {Symbol: "BANK0", Action: mapper.HotspotBankSwitch},
+		0x1ff6: {Symbol: "BANK1", Action: mapper.HotspotBankSwitch},
+		0x1ff7: {Symbol: "BANK2", Action: mapper.HotspotBankSwitch},
+		0x1ff8: {Symbol: "BANK3", Action: mapper.HotspotBankSwitch},
+		0x1ff9: {Symbol: "BANK4", Action: mapper.HotspotBankSwitch},
+		0x1ffa: {Symbol: "BANK5", Action: mapper.HotspotBankSwitch},
+		0x1ffb: {Symbol: "BANK6", Action: mapper.HotspotBankSwitch},
+		0x1fee: {Symbol: "AMPLITUDE", Action: mapper.HotspotReadRegister},
+		0x1fef: {Symbol: "DSREAD", Action: mapper.HotspotReadRegister},
+		0x1ff0: {Symbol: "DSWRITE", Action: mapper.HotspotWriteRegister},
+		0x1ff1: {Symbol: "DSPTR", Action: mapper.HotspotWriteRegister},
+		0x1ff2: {Symbol: "SETMODE", Action: mapper.HotspotWriteRegister},
+		0x1ff3: {Symbol: "CALLFN", Action: mapper.HotspotFunction},
+	}
+}
@@ -0,0 +1,218 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cdf
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+	"github.com/jetsetilly/gopher2600/hardware/preferences"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// create a BUS cartridge where every byte in a 6507 bank is the number of the
+// bank. the ARM driver and custom code are empty
+func newTestBUS(t *testing.T) *bus {
+	t.Helper()
+
+	data := make([]byte, 32768)
+	for i := driverSize + customSize; i < len(data); i++ {
+		data[i] = byte((i - driverSize - customSize) / 4096)
+	}
+
+	ld, err := cartridgeloader.NewLoaderFromData("bus_test", data, "BUS", "", nil, nil)
+	test.ExpectSuccess(t, err)
+
+	prefs := &preferences.Preferences{
+		Cartridge: &preferences.Cartridge{
+			ARM: &preferences.ARMPreferences{},
+		},
+	}
+	prefs.Cartridge.ARM.SetDefaults()
+
+	cart, err := NewBUS(&environment.Environment{Label: "test", Prefs: prefs, Loader: ld})
+	test.ExpectSuccess(t, err)
+
+	c := cart.(*bus)
+	test.ExpectSuccess(t, c.Reset())
+
+	return c
+}
+
+// read from the cartridge in the same way as the 6507
+func testRead(t *testing.T, cart mapper.CartMapper, addr uint16) uint8 {
+	t.Helper()
+	v, _, err := cart.Access(addr, false)
+	test.ExpectSuccess(t, err)
+	return v
+}
+
+// read from the cartridge in the same way as the 6507 and the memory
+// package. the cartridge is given the opportunity to drive the data bus after
+// every read
+func testBusRead(t *testing.T, cart *bus, addr uint16) uint8 {
+	t.Helper()
+	v := testRead(t, cart, addr)
+	if stuff, ok := cart.BusStuff(addr, false); ok {
+		return stuff
+	}
+	return v
+}
+
+// point the datastream at the index in data RAM with an increment of one
+func testDatastream(cart *bus, reg int, idx uint32) {
+	cart.updateDatastreamPointer(reg, idx<<cart.version.fetcherShift)
+	cart.updateDatastreamIncrement(reg, 1<<8)
+}
+
+func TestBUS_bankswitching(t *testing.T) {
+	cart := newTestBUS(t)
+
+	// the last bank is selected on reset
+	test.ExpectEquality(t, testRead(t, cart, 0x0100), 6)
+
+	for b := 0; b < cart.NumBanks(); b++ {
+		testRead(t, cart, 0x0ff5+uint16(b))
+		test.ExpectEquality(t, int(testRead(t, cart, 0x0100)), b)
+	}
+}
+
+func TestBUS_datastreams(t *testing.T) {
+	cart := newTestBUS(t)
+
+	// set the pointer of the comm stream with DSPTR
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0ff1, 0x00, false))
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0ff1, 0x10, false))
+	test.ExpectEquality(t, cart.readDatastreamPointer(busDSCOMM)>>cart.version.fetcherShift, 0x10)
+
+	// DSWRITE writes to the data RAM and advances the pointer
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0ff0, 0xaa, false))
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0ff0, 0xbb, false))
+	test.ExpectEquality(t, cart.state.static.dataRAM.data[0x10], 0xaa)
+	test.ExpectEquality(t, cart.state.static.dataRAM.data[0x11], 0xbb)
+
+	// DSREAD reads the comm stream using the datastream increment
+	testDatastream(cart, busDSCOMM, 0x10)
+	test.ExpectEquality(t, testRead(t, cart, 0x0fef), 0xaa)
+	test.ExpectEquality(t, testRead(t, cart, 0x0fef), 0xbb)
+}
+
+func TestBUS_busStuffing(t *testing.T) {
+	cart := newTestBUS(t)
+
+	// STY GRP0 and JMP $0000 in the current bank
+	copy(cart.banks[cart.state.bank][0x0100:], []byte{styZeroPage, 0x1b})
+	copy(cart.banks[cart.state.bank][0x0200:], []byte{jmpAbsolute, 0x00, 0x00})
+
+	// GRP0 is mapped to datastream 3
+	cart.updateAddressMap(0x1b, 0x00000003)
+	testDatastream(cart, 3, 0x20)
+	cart.state.static.dataRAM.data[0x20] = 0x55
+
+	// bus-stuffing is not enabled
+	testBusRead(t, cart, 0x0100)
+	testBusRead(t, cart, 0x0101)
+	_, ok := cart.BusStuff(0x001b, true)
+	test.ExpectFailure(t, ok)
+
+	// enable bus-stuffing
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0ff2, 0xf0, false))
+	test.ExpectSuccess(t, cart.GetRegisters().(Registers).FastFetch)
+
+	// the data bus is not driven during the read of the operand, only during
+	// the write to the TIA
+	testBusRead(t, cart, 0x0100)
+	test.ExpectEquality(t, testBusRead(t, cart, 0x0101), 0x1b)
+	v, ok := cart.BusStuff(0x001b, true)
+	test.ExpectSuccess(t, ok)
+	test.ExpectEquality(t, v, 0x55)
+	_, ok = cart.BusStuff(0x001b, true)
+	test.ExpectFailure(t, ok)
+
+	// the address map has been rotated
+	test.ExpectEquality(t, cart.readAddressMap(0x1b), 0x30000000)
+
+	// the operand of a fast jump is taken from the jump datastream
+	testDatastream(cart, busDSJMP, 0x30)
+	copy(cart.state.static.dataRAM.data[0x30:], []byte{0x34, 0x12})
+	test.ExpectEquality(t, testRead(t, cart, 0x0200), jmpAbsolute)
+	test.ExpectEquality(t, testRead(t, cart, 0x0201), 0x34)
+	test.ExpectEquality(t, testRead(t, cart, 0x0202), 0x12)
+}
+
+func TestBUS_busStuffingFalsePositive(t *testing.T) {
+	cart := newTestBUS(t)
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0ff2, 0xf0, false))
+
+	// LDA #$84 followed by JSR $f080. the operand of the LDA instruction looks
+	// like a STY opcode and the JSR opcode looks like the STY operand
+	copy(cart.banks[cart.state.bank][0x0100:], []byte{0xa9, styZeroPage, 0x20, 0x80, 0xf0})
+
+	// the JSR opcode is $20, which is a valid TIA register
+	cart.updateAddressMap(0x20, 0x00000003)
+	testDatastream(cart, 3, 0x20)
+	cart.state.static.dataRAM.data[0x20] = 0x55
+
+	// the operand fetch of the JSR is not affected
+	test.ExpectEquality(t, testBusRead(t, cart, 0x0100), 0xa9)
+	test.ExpectEquality(t, testBusRead(t, cart, 0x0101), styZeroPage)
+	test.ExpectEquality(t, testBusRead(t, cart, 0x0102), 0x20)
+	test.ExpectEquality(t, testBusRead(t, cart, 0x0103), 0x80)
+
+	// the armed bus-stuff was discarded by the read and does not affect a
+	// later write to the register
+	_, ok := cart.BusStuff(0x0020, true)
+	test.ExpectFailure(t, ok)
+
+	// the datastream and address map have not been used
+	test.ExpectEquality(t, cart.readAddressMap(0x20), 0x00000003)
+	test.ExpectEquality(t, cart.readDatastreamPointer(3)>>cart.version.fetcherShift, 0x20)
+
+	// a STY instruction that writes to a different address to the operand is
+	// not bus-stuffed
+	copy(cart.banks[cart.state.bank][0x0200:], []byte{styZeroPage, 0x20})
+	testBusRead(t, cart, 0x0200)
+	testBusRead(t, cart, 0x0201)
+	_, ok = cart.BusStuff(0x0021, true)
+	test.ExpectFailure(t, ok)
+	_, ok = cart.BusStuff(0x0020, true)
+	test.ExpectFailure(t, ok)
+}
+
+func TestBUS_registers(t *testing.T) {
+	cart := newTestBUS(t)
+
+	// BUS has fewer datastreams than CDF
+	regs := cart.GetRegisters().(Registers)
+	test.ExpectEquality(t, regs.NumDatastreams, 18)
+
+	// the datastream registers are copies of the driver RAM
+	cart.PutRegister("datastream::17::pointer", "12345678")
+	cart.PutRegister("datastream::17::increment", "00000100")
+	regs = cart.GetRegisters().(Registers)
+	test.ExpectEquality(t, regs.Datastream[17].Pointer, 0x12345678)
+	test.ExpectEquality(t, regs.Datastream[17].Increment, 0x00000100)
+	test.ExpectEquality(t, cart.readDatastreamPointer(17), 0x12345678)
+	test.ExpectEquality(t, cart.readDatastreamIncrement(17), 0x00000100)
+
+	// datastreams beyond the number used by BUS are not registers
+	defer func() {
+		test.ExpectInequality(t, recover(), nil)
+	}()
+	cart.PutRegister("datastream::18::pointer", "12345678")
+}
@@ -77,14 +77,22 @@ const (
 
 // NewCDF is the preferred method of initialisation for the CDF type.
 func NewCDF(env *environment.Environment, version string) (mapper.CartMapper, error) {
+	cart, err := newCDF(env, "CDF", version)
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func newCDF(env *environment.Environment, mappingID string, version string) (*cdf, error) {
 	data, err := io.ReadAll(env.Loader)
 	if err != nil {
-		return nil, fmt.Errorf("CDF: %w", err)
+		return nil, fmt.Errorf("%s: %w", mappingID, err)
 	}
 
 	cart := &cdf{
 		env:       env,
-		mappingID: "CDF",
+		mappingID: mappingID,
 		bankSize:  4096,
 		state:     newCDFstate(),
 		yieldHook: coprocessor.StubCartYieldHook{},
@@ -92,12 +100,12 @@ func NewCDF(env *environment.Environment, version string) (mapper.CartMapper, er
 
 	// size check
 	if cart.NumBanks()*cart.bankSize > env.Loader.Size() {
-		return nil, fmt.Errorf("CDF: not enough bytes in cartridge data")
+		return nil, fmt.Errorf("%s: not enough bytes in cartridge data", mappingID)
 	}
 
 	cart.version, err = newVersion(env.Prefs.Cartridge.ARM.Model.Get().(string), version, data)
 	if err != nil {
-		return nil, fmt.Errorf("CDF: %w", err)
+		return nil, fmt.Errorf("%s: %w", mappingID, err)
 	}
 
 	// allocate enough banks
@@ -117,12 +125,13 @@ func NewCDF(env *environment.Environment, version string) (mapper.CartMapper, er
 	// initialise static memory
 	cart.state.static, err = cart.newCDFstatic(env, cart.version, data)
 	if err != nil {
-		return nil, fmt.Errorf("CDF: %w", err)
+		return nil, fmt.Errorf("%s: %w", mappingID, err)
 	}
 
 	// datastream registers need to reference the incrementShift and
 	// fetcherShift values in the version type. we make a copy of these values
 	// on ROM initialisation
+	cart.state.registers.NumDatastreams = cart.version.numDatastreams
 	for i := range cart.state.registers.Datastream {
 		cart.state.registers.Datastream[i].incrementShift = cart.version.incrementShift
 		cart.state.registers.Datastream[i].fetcherShift = cart.version.fetcherShift
@@ -265,31 +274,7 @@ func (cart *cdf) Access(addr uint16, peek bool) (uint8, uint8, error) {
 
 		// music fetchers
 		if data == byte(cart.version.amplitudeRegister)+cart.version.datastreamOffset {
-			if cart.state.registers.SampleMode {
-				addr := cart.readMusicFetcher(0)
-				addr += cart.state.registers.MusicFetcher[0].Count >> (cart.version.musicFetcherShift + 1)
-
-				// get sample from memory
-				data, _ = cart.state.static.Read8bit(addr)
-
-				// prevent excessive volume
-				if cart.state.registers.MusicFetcher[0].Count&(1<<cart.version.musicFetcherShift) == 0 {
-					data >>= 4
-				}
-
-				return data, mapper.CartDrivenPins, nil
-			}
-
-			// data retrieval for non-SampleMode uses all three music fetchers
-			data = 0
-			for i := range cart.state.registers.MusicFetcher {
-				m := cart.readMusicFetcher(i)
-				m += (cart.state.registers.MusicFetcher[i].Count >> cart.state.registers.MusicFetcher[i].Waveform)
-				v, _ := cart.state.static.Read8bit(m)
-				data += v
-			}
-
-			return data, mapper.CartDrivenPins, nil
+			return cart.amplitude(), mapper.CartDrivenPins, nil
 		}
 
 		// if data is higher than AMPLITUDE then the 0xa9 we detected in the
@@ -340,33 +325,11 @@ func (cart *cdf) AccessVolatile(addr uint16, data uint8, poke bool) error {
 	switch addr {
 	case 0x0ff0:
 		// DSWRITE
-
-		// top 12 bits are significant
-		v := cart.readDatastreamPointer(DSCOMM)
-
-		// write data to ARM RAM
-		idx := int(v >> cart.version.fetcherShift)
-		if idx >= len(cart.state.static.dataRAM.data) {
-			return nil
-		}
-		cart.state.static.dataRAM.data[idx] = data
-
-		// advance address value
-		v += 1 << cart.version.fetcherShift
-
-		// write adjusted address (making sure to put the bits in the top 12 bits)
-		cart.updateDatastreamPointer(DSCOMM, v)
+		cart.writeDatastream(DSCOMM, data)
 
 	case 0x0ff1:
 		// DSPTR
-		v := cart.readDatastreamPointer(DSCOMM) << 8
-		v &= cart.version.fetcherMask
-
-		// add new data to lower byte of dsptr value
-		v |= (uint32(data) << cart.version.fetcherShift)
-
-		// write dsptr to dscomm register
-		cart.updateDatastreamPointer(DSCOMM, v)
+		cart.shiftDatastreamPointer(DSCOMM, data)
 
 	case 0x0ff2:
 		// SETMODE
@@ -383,34 +346,7 @@ func (cart *cdf) AccessVolatile(addr uint16, data uint8, poke bool) error {
 
 	case 0x0ff4:
 		// CALLFN
-		switch data {
-		case 0xfe:
-			// generate interrupt to update AUDV0 while running ARM code
-			fallthrough
-		case 0xff:
-			runArm := func() {
-				cart.arm.StartProfiling()
-				defer cart.arm.ProcessProfiling()
-				cart.state.yield = cart.runArm()
-			}
-
-			// keep calling runArm() for as long as program has not ended
-			runArm()
-			for cart.state.yield.Type != coprocessor.YieldProgramEnded {
-				// the ARM should never return YieldSyncWithVCS when executing code
-				// from the CDFJ type. if it does then it is an error and we should yield
-				// with YieldExecutionError
-				if cart.state.yield.Type == coprocessor.YieldSyncWithVCS {
-					cart.state.yield.Type = coprocessor.YieldExecutionError
-					cart.state.yield.Error = fmt.Errorf("%s does not support SyncWithVCS yield type", cart.mappingID)
-				}
-
-				if cart.yieldHook.CartYield(cart.state.yield) == coprocessor.YieldHookEnd {
-					break
-				}
-				runArm()
-			}
-		}
+		cart.callFunction(data)
 
 	default:
 		if poke {
@@ -421,6 +357,69 @@ func (cart *cdf) AccessVolatile(addr uint16, data uint8, poke bool) error {
 	return nil
 }
 
+// amplitude returns the current value of the music fetchers. the value is
+// either a sample or the sum of the three music fetchers depending on
+// SampleMode.
+func (cart *cdf) amplitude() uint8 {
+	if cart.state.registers.SampleMode {
+		addr := cart.readMusicFetcher(0)
+		addr += cart.state.registers.MusicFetcher[0].Count >> (cart.version.musicFetcherShift + 1)
+
+		// get sample from memory
+		data, _ := cart.state.static.Read8bit(addr)
+
+		// prevent excessive volume
+		if cart.state.registers.MusicFetcher[0].Count&(1<<cart.version.musicFetcherShift) == 0 {
+			data >>= 4
+		}
+
+		return data
+	}
+
+	// data retrieval for non-SampleMode uses all three music fetchers
+	var data uint8
+	for i := range cart.state.registers.MusicFetcher {
+		m := cart.readMusicFetcher(i)
+		m += (cart.state.registers.MusicFetcher[i].Count >> cart.state.registers.MusicFetcher[i].Waveform)
+		v, _ := cart.state.static.Read8bit(m)
+		data += v
+	}
+
+	return data
+}
+
+// callFunction handles a write to the CALLFN hotspot.
+func (cart *cdf) callFunction(data uint8) {
+	switch data {
+	case 0xfe:
+		// generate interrupt to update AUDV0 while running ARM code
+		fallthrough
+	case 0xff:
+		runArm := func() {
+			cart.arm.StartProfiling()
+			defer cart.arm.ProcessProfiling()
+			cart.state.yield = cart.runArm()
+		}
+
+		// keep calling runArm() for as long as program has not ended
+		runArm()
+		for cart.state.yield.Type != coprocessor.YieldProgramEnded {
+			// the ARM should never return YieldSyncWithVCS when executing code
+			// from the CDFJ type. if it does then it is an error and we should yield
+			// with YieldExecutionError
+			if cart.state.yield.Type == coprocessor.YieldSyncWithVCS {
+				cart.state.yield.Type = coprocessor.YieldExecutionError
+				cart.state.yield.Error = fmt.Errorf("%s does not support SyncWithVCS yield type", cart.mappingID)
+			}
+
+			if cart.yieldHook.CartYield(cart.state.yield) == coprocessor.YieldHookEnd {
+				break
+			}
+			runArm()
+		}
+	}
+}
+
 // bankswitch on hotspot access.
 func (cart *cdf) bankswitch(addr uint16) bool {
 	if addr >= 0x0ff4 && addr <= 0x0ffb {
@@ -602,53 +601,11 @@ func (cart *cdf) Hotspots() map[uint16]mapper.CartHotspotInfo {
 func (cart *cdf) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMinterruptReturn, error) {
 	var r arm.ARMinterruptReturn
 
-	if cart.version.submapping == "CDF0" {
-		switch addr {
-		case cart.version.mmap.Regions["Flash"].Origin | 0x000006e2:
-			r.InterruptEvent = "Set music note"
-			if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
-				return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
-			}
-			cart.state.registers.MusicFetcher[val1].Freq = val2
-			r.NumMemAccess = 2
-			r.NumAdditionalCycles = 11
-		case cart.version.mmap.Regions["Flash"].Origin | 0x000006e6:
-			// reset wave
-			r.InterruptEvent = "Reset wave"
-			if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
-				return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
-			}
-			cart.state.registers.MusicFetcher[val1].Count = 0
-			r.NumMemAccess = 3
-			r.NumAdditionalCycles = 13
-		case cart.version.mmap.Regions["Flash"].Origin | 0x000006ea:
-			r.InterruptEvent = "Get wave pointer"
-			if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
-				return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
-			}
-			r.SaveValue = cart.state.registers.MusicFetcher[val1].Count
-			r.SaveRegister = 2
-			r.SaveResult = true
-			r.NumMemAccess = 3
-			r.NumAdditionalCycles = 13
-		case cart.version.mmap.Regions["Flash"].Origin | 0x000006ee:
-			r.InterruptEvent = "Set wave size"
-			if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
-				return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
-			}
-			cart.state.registers.MusicFetcher[val1].Waveform = uint8(val2)
-			r.NumMemAccess = 3
-			r.NumAdditionalCycles = 28
-		default:
-			return r, nil
-		}
-
-		r.InterruptServiced = true
-		return r, nil
-	}
+	// the location of the interrupts in the driver differs by version
+	interrupt := cart.version.mmap.Regions["Flash"].Origin | cart.version.musicInterrupt
 
 	switch addr {
-	case cart.version.mmap.Regions["Flash"].Origin | 0x00000752:
+	case interrupt:
 		r.InterruptEvent = "Set music note"
 		if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
 			return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
@@ -656,7 +613,7 @@ func (cart *cdf) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMint
 		cart.state.registers.MusicFetcher[val1].Freq = val2
 		r.NumMemAccess = 2
 		r.NumAdditionalCycles = 11
-	case cart.version.mmap.Regions["Flash"].Origin | 0x00000756:
+	case interrupt + 4:
 		r.InterruptEvent = "Reset wave"
 		if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
 			return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
@@ -664,7 +621,7 @@ func (cart *cdf) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMint
 		cart.state.registers.MusicFetcher[val1].Count = 0
 		r.NumMemAccess = 3
 		r.NumAdditionalCycles = 13
-	case cart.version.mmap.Regions["Flash"].Origin | 0x0000075a:
+	case interrupt + 8:
 		r.InterruptEvent = "Get wave pointer"
 		if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
 			return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
@@ -674,7 +631,7 @@ func (cart *cdf) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMint
 		r.SaveResult = true
 		r.NumMemAccess = 3
 		r.NumAdditionalCycles = 13
-	case cart.version.mmap.Regions["Flash"].Origin | 0x0000075e:
+	case interrupt + 12:
 		r.InterruptEvent = "Set wave size"
 		if val1 >= uint32(len(cart.state.registers.MusicFetcher)) {
 			return r, fmt.Errorf("music fetcher index (%d) too high ", val1)
@@ -722,8 +679,9 @@ func (cart *cdf) runArm() coprocessor.CoProcYield {
 	}
 
 	// update the Register types after each return from arm.Run() regardless of
-	// yield reason
-	for i := range cart.state.registers.Datastream {
+	// yield reason. datastreams beyond the number used by the version are
+	// not read because the driver RAM at those indexes has another purpose
+	for i := range cart.state.registers.NumDatastreams {
 		cart.state.registers.Datastream[i].Pointer = cart.readDatastreamPointer(i)
 		cart.state.registers.Datastream[i].Increment = cart.readDatastreamIncrement(i)
 		cart.state.registers.Datastream[i].AfterCALLFN = cart.readDatastreamPointer(i)
@@ -23,5 +23,10 @@
 // is the Stella source code. Therefore, I have resorted to the study of the
 // CartCDF.cxx file as found in Stella 6.4.
 //
+// The package also implements the BUS format, which predates CDF. BUS shares
+// the memory layout and the datastream handling of CDF but uses bus-stuffing to
+// get data from the datastreams onto the data bus. Again, the CartBUS.cxx file
+// in the Stella source code has been useful.
+//
 // Note that all CDF formats rely on the arm7 package.
 package cdf
@@ -24,7 +24,7 @@ import (
 )
 
 const (
-	NumDatastreams       = 32
+	MaxDatastreams       = 32
 	NumMusicDataFetchers = 3
 )
 
@@ -36,7 +36,11 @@ type Registers struct {
 	// the MusicFetcher and Datastream feilds are copies of the data as it
 	// exists in ARM memory
 	MusicFetcher [NumMusicDataFetchers]musicDataFetcher
-	Datastream   [NumDatastreams]datastream
+	Datastream   [MaxDatastreams]datastream
+
+	// the number of entries in the Datastream array that are used by the
+	// cartridge. depends on the CDF version
+	NumDatastreams int
 }
 
 func (r *Registers) initialise() {
@@ -80,7 +84,7 @@ func (cart *cdf) PutRegister(register string, data string) {
 	switch r[0] {
 	case "datastream":
 		f, err := strconv.Atoi(r[1])
-		if err != nil || f > len(cart.state.registers.Datastream) {
+		if err != nil || f < 0 || f >= cart.state.registers.NumDatastreams {
 			panic(fmt.Sprintf("cdf: unrecognised register [%s]", register))
 		}
 		switch r[2] {
@@ -91,7 +95,7 @@ func (cart *cdf) PutRegister(register string, data string) {
 		}
 	case "music":
 		f, err := strconv.Atoi(r[1])
-		if err != nil || f > len(cart.state.registers.Datastream) {
+		if err != nil || f < 0 || f >= len(cart.state.registers.MusicFetcher) {
 			panic(fmt.Sprintf("cdf: unrecognised register [%s]", register))
 		}
 		switch r[2] {
@@ -69,7 +69,7 @@ func (cart *cdf) readDatastreamIncrement(reg int) uint32 {
 }
 
 func (cart *cdf) updateDatastreamPointer(reg int, data uint32) {
-	if reg < len(cart.state.registers.Datastream) {
+	if reg < cart.state.registers.NumDatastreams {
 		cart.state.registers.Datastream[reg].Pointer = data
 	}
 
@@ -80,7 +80,7 @@ func (cart *cdf) updateDatastreamPointer(reg int, data uint32) {
 // updateDatastreamIncrement is not used by the CDF mapper itself except as a
 // call from PutRegister(), which is a debugging facility.
 func (cart *cdf) updateDatastreamIncrement(reg int, data uint32) {
-	if reg < len(cart.state.registers.Datastream) {
+	if reg < cart.state.registers.NumDatastreams {
 		cart.state.registers.Datastream[reg].Increment = data
 	}
 
@@ -110,3 +110,48 @@ func (cart *cdf) streamData(reg int) uint8 {
 
 	return output
 }
+
+// writeDatastream writes data to the location indicated by the datastream
+// register and advances the pointer by one. used to implement the DSWRITE
+// hotspot.
+func (cart *cdf) writeDatastream(reg int, data uint8) {
+	// top 12 bits are significant
+	v := cart.readDatastreamPointer(reg)
+
+	// write data to ARM RAM
+	idx := int(v >> cart.version.fetcherShift)
+	if idx >= len(cart.state.static.dataRAM.data) {
+		return
+	}
+	cart.state.static.dataRAM.data[idx] = data
+
+	// advance address value
+	v += 1 << cart.version.fetcherShift
+
+	// write adjusted address (making sure to put the bits in the top 12 bits)
+	cart.updateDatastreamPointer(reg, v)
+}
+
+// shiftDatastreamPointer shifts data into the pointer of the datastream
+// register. used to implement the DSPTR hotspot.
+func (cart *cdf) shiftDatastreamPointer(reg int, data uint8) {
+	v := cart.readDatastreamPointer(reg) << 8
+	v &= cart.version.fetcherMask
+
+	// add new data to lower byte of dsptr value
+	v |= (uint32(data) << cart.version.fetcherShift)
+
+	// write dsptr to dscomm register
+	cart.updateDatastreamPointer(reg, v)
+}
+
+// the address map for a TIA register is only used by the BUS version.
+func (cart *cdf) readAddressMap(reg int) uint32 {
+	idx := cart.version.mapBase + (uint32(reg) * 4)
+	return binary.LittleEndian.Uint32(cart.state.static.driverRAM.data[idx:])
+}
+
+func (cart *cdf) updateAddressMap(reg int, data uint32) {
+	idx := cart.version.mapBase + (uint32(reg) * 4)
+	binary.LittleEndian.PutUint32(cart.state.static.driverRAM.data[idx:], data)
+}
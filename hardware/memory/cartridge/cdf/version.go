@@ -35,9 +35,22 @@ type version struct {
 	incrementBase uint32
 	musicBase     uint32
 
+	// the base index for the datastream maps used by bus-stuffing. only used
+	// by the BUS version
+	mapBase uint32
+
+	// the address of the first music interrupt in the ARM driver. the other
+	// interrupts follow at four byte intervals
+	musicInterrupt uint32
+
 	// which data fetcher is the amplitude fetcher differs by CFD version
 	amplitudeRegister int
 
+	// the number of datastreams. the BUS version has fewer datastreams than
+	// the CDF versions because the address maps follow the increments in the
+	// driver RAM
+	numDatastreams int
+
 	// the significant bits of the most-significant byte of a fastjmp operand
 	// must be masked appropriately. in the case of CDFJ a fast jump can be
 	// triggered with either "4c 00 00" or "4c 01 00"
@@ -106,6 +119,11 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		// old value used to indicate ARM7TDMI architecture. easiest to support
 		// it here in this manner
 		mmap = architecture.NewMap(architecture.PlusCart)
+
+	case "ARMv6_M":
+		// the ARMv6-M core is emulated with the PlusCart memory map. the
+		// core itself is selected by NewARM() from the same preference value
+		mmap = architecture.NewMap(architecture.PlusCart)
 	}
 
 	ver := version{
@@ -126,6 +144,9 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		// that instance
 		variablesRAMOrigin: mmap.Regions["SRAM"].Origin | 0x00001800,
 		variablesRAMMemtop: mmap.Regions["SRAM"].Origin | 0x00001fff,
+
+		// the number of datastreams is different for BUS
+		numDatastreams: MaxDatastreams,
 	}
 
 	// entry point into ARM program (different for CDFJ+)
@@ -147,6 +168,7 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		ver.fetcherBase = 0x0098
 		ver.incrementBase = 0x0124
 		ver.musicBase = 0x01b0
+		ver.musicInterrupt = 0x0752
 		ver.fastJMPmask = 0xfe
 		ver.amplitudeRegister = 35
 		ver.fetcherShift = 16
@@ -193,6 +215,7 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		ver.fetcherBase = 0x0098
 		ver.incrementBase = 0x0124
 		ver.musicBase = 0x01b0
+		ver.musicInterrupt = 0x0752
 		ver.fastJMPmask = 0xfe
 		ver.amplitudeRegister = 35
 		ver.fetcherShift = 20
@@ -205,6 +228,7 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		ver.fetcherBase = 0x06e0
 		ver.incrementBase = 0x0768
 		ver.musicBase = 0x07f0
+		ver.musicInterrupt = 0x06e2
 		ver.fastJMPmask = 0xff
 		ver.amplitudeRegister = 34
 		ver.fetcherShift = 20
@@ -217,6 +241,7 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		ver.fetcherBase = 0x00a0
 		ver.incrementBase = 0x0128
 		ver.musicBase = 0x01b0
+		ver.musicInterrupt = 0x0752
 		ver.fastJMPmask = 0xff
 		ver.amplitudeRegister = 34
 		ver.fetcherShift = 20
@@ -224,6 +249,20 @@ func newVersion(memModel string, v string, data []uint8) (version, error) {
 		ver.musicFetcherShift = 20
 		ver.fetcherMask = 0xf0000000
 
+	case "BUS":
+		ver.submapping = "BUS"
+		ver.fetcherBase = 0x06d8
+		ver.incrementBase = 0x0720
+		ver.mapBase = 0x0768
+		ver.numDatastreams = 18
+		ver.musicBase = 0x07f4
+		ver.musicInterrupt = 0x06da
+		ver.fastJMPmask = 0xff
+		ver.fetcherShift = 20
+		ver.incrementShift = 12
+		ver.musicFetcherShift = 20
+		ver.fetcherMask = 0xf0000000
+
 	default:
 		return version{}, fmt.Errorf("unknown version: %s", v)
 	}
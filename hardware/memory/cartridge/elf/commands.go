@@ -18,13 +18,14 @@ package elf
 import (
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
 )
 
 func newCommands() (*commandline.Commands, error) {
 	var template = []string{
-		"STREAM (DRAIN|NEXT)",
+		"STREAM (DRAIN|NEXT|RECORD (%<start frame>N %<end frame>N|OFF)|EXPORT %<file>F|ANALYSE)",
 	}
 
 	commands, err := commandline.ParseCommandTemplate(template)
@@ -52,11 +53,27 @@ func (elf *Elf) ParseCommand(w io.Writer, command string) error {
 
 	switch arg {
 	case "STREAM":
+		// recording commands are available whether or not the stream is active
+		arg, ok := tokens.Get()
+		if ok {
+			switch arg {
+			case "RECORD":
+				return elf.streamRecord(w, tokens)
+			case "EXPORT":
+				return elf.streamExport(w, tokens)
+			case "ANALYSE":
+				if !elf.recorder.started {
+					return fmt.Errorf("ELF stream has not been recorded")
+				}
+				elf.recorder.analyse(w)
+				return nil
+			}
+		}
+
 		if !elf.mem.stream.active {
 			return fmt.Errorf("ELF streaming is not active")
 		}
 
-		arg, ok := tokens.Get()
 		if ok {
 			switch arg {
 			case "DRAIN":
@@ -86,3 +103,88 @@ func (elf *Elf) ParseCommand(w io.Writer, command string) error {
 
 	return nil
 }
+
+func (elf *Elf) streamRecord(w io.Writer, tokens *commandline.Tokens) error {
+	arg, ok := tokens.Get()
+	if !ok {
+		r := elf.recorder
+		if !r.started {
+			w.Write([]byte("ELF stream is not being recorded"))
+			return nil
+		}
+		status := "recording"
+		if r.finished(elf.env.TV.GetCoords().Frame) {
+			status = "recorded"
+		}
+		w.Write(fmt.Appendf(nil, "ELF stream %s for frames %d to %d: %d records",
+			status, r.startFrame, r.endFrame, len(r.records)))
+		return nil
+	}
+
+	if arg == "OFF" {
+		if !elf.recorder.started {
+			w.Write([]byte("ELF stream is not being recorded"))
+			return nil
+		}
+
+		// the end of the recording is moved to the current frame so that the
+		// recording is still available for exporting and analysis
+		frame := elf.env.TV.GetCoords().Frame
+		if elf.recorder.endFrame >= frame {
+			elf.recorder.endFrame = frame - 1
+		}
+		w.Write(fmt.Appendf(nil, "ELF stream recording stopped: %d records",
+			len(elf.recorder.records)))
+		return nil
+	}
+
+	var start, end int
+	_, err := fmt.Sscan(arg, &start)
+	if err != nil {
+		return fmt.Errorf("ELF stream recording: %w", err)
+	}
+	arg, ok = tokens.Get()
+	if !ok {
+		return fmt.Errorf("ELF stream recording: end frame required")
+	}
+	_, err = fmt.Sscan(arg, &end)
+	if err != nil {
+		return fmt.Errorf("ELF stream recording: %w", err)
+	}
+	if end < start {
+		return fmt.Errorf("ELF stream recording: end frame is before start frame")
+	}
+
+	elf.recorder.start(start, end)
+
+	if elf.mem.stream.disabled {
+		w.Write(fmt.Appendf(nil, "ELF stream recording for frames %d to %d (streaming is currently disabled)", start, end))
+	} else {
+		w.Write(fmt.Appendf(nil, "ELF stream recording for frames %d to %d", start, end))
+	}
+
+	return nil
+}
+
+func (elf *Elf) streamExport(w io.Writer, tokens *commandline.Tokens) error {
+	if !elf.recorder.started {
+		return fmt.Errorf("ELF stream has not been recorded")
+	}
+
+	filename, _ := tokens.Get()
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("ELF stream export: %w", err)
+	}
+	defer f.Close()
+
+	err = elf.recorder.export(f)
+	if err != nil {
+		return fmt.Errorf("ELF stream export: %w", err)
+	}
+
+	w.Write(fmt.Appendf(nil, "ELF stream exported to %s: %d records",
+		filename, len(elf.recorder.records)))
+
+	return nil
+}
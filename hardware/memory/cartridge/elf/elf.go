@@ -52,6 +52,12 @@ type Elf struct {
 	// commandline extensions
 	commands *commandline.Commands
 
+	// recording of the stream as it is delivered to the 6507. the recorder is
+	// not part of the rewindable state of the cartridge. the pointer is
+	// shared by every snapshot of the cartridge so the recording is kept when
+	// the emulation is rewound
+	recorder *streamRecorder
+
 	// the initial state created immediately after creation [at the end of NewELF()]
 	// we use these to return the mapper to the initial state when Reset() is called
 	resetStateARM *arm.ARMState
@@ -149,6 +155,8 @@ func NewElf(env *environment.Environment, inACE bool) (mapper.CartMapper, error)
 		yieldHook: coprocessor.StubCartYieldHook{},
 	}
 
+	cart.recorder = &streamRecorder{}
+
 	cart.commands, err = newCommands()
 	if err != nil {
 		return nil, fmt.Errorf("ELF: %w", err)
@@ -253,6 +261,9 @@ func (cart *Elf) PlumbFromDifferentEmulation(env *environment.Environment) {
 	cart.arm.Plumb(cart.env, cart.armState, cart.mem, cart)
 	cart.armState = nil
 	cart.yieldHook = &coprocessor.StubCartYieldHook{}
+
+	// the recording belongs to the emulation that made it
+	cart.recorder = &streamRecorder{}
 }
 
 // Plumb implements the mapper.CartMapper interface.
@@ -280,6 +291,7 @@ func (cart *Elf) reset() {
 
 	// initialise ROM for the VCS
 	if cart.mem.stream.active {
+		cart.mem.stream.origin = "reset"
 		cart.mem.stream.push(streamEntry{
 			addr: 0x1ffc,
 			data: 0x00,
@@ -320,12 +332,21 @@ func (cart *Elf) reset() {
 // Access implements the mapper.CartMapper interface.
 func (cart *Elf) Access(addr uint16, _ bool) (uint8, uint8, error) {
 	if cart.mem.stream.active {
+		// the ARM is only run if the stream is not draining. the stream has
+		// been refilled if the stream is draining after the ARM has been run
+		var refill bool
 		if !cart.mem.stream.drain {
 			_ = cart.runARM(addr)
+			refill = cart.mem.stream.drain
 		}
-		if addr == cart.mem.stream.peek().addr&memorymap.CartridgeBits {
-			e := cart.mem.stream.pull()
+		if !cart.mem.stream.drain {
+			cart.recordStream(streamEventUnderrun, streamEntry{}, refill)
+		} else if e := cart.mem.stream.peek(); addr == e.addr&memorymap.CartridgeBits {
+			e = cart.mem.stream.pull()
 			cart.mem.gpio.data[DATA_ODR] = e.data
+			cart.recordStream(streamEventPull, e, refill)
+		} else {
+			cart.recordStream(streamEventMismatch, e, refill)
 		}
 	}
 
@@ -488,7 +509,7 @@ func (cart *Elf) ARMinterrupt(addr uint32, val1 uint32, val2 uint32) (arm.ARMint
 }
 
 // BusStuff implements the mapper.CartBusStuff interface.
-func (cart *Elf) BusStuff() (uint8, bool) {
+func (cart *Elf) BusStuff(_ uint16, _ bool) (uint8, bool) {
 	if !cart.mem.usesBusStuffing {
 		return 0, false
 	}
@@ -501,6 +522,7 @@ func (cart *Elf) BusStuff() (uint8, bool) {
 	if cart.mem.stream.active {
 		if cart.mem.stream.peek().busstuff {
 			e := cart.mem.stream.pull()
+			cart.recordStream(streamEventBusStuff, e, false)
 			return e.data, true
 		}
 		return 0, false
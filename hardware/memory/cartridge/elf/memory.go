@@ -19,7 +19,9 @@ import (
 	"debug/elf"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jetsetilly/gopher2600/coprocessor"
@@ -35,6 +37,7 @@ import (
 type elfMemoryARM interface {
 	Interrupt()
 	MemoryFault(event string, fault faults.Category)
+	MemoryInitialised(addr uint32, size uint32)
 	CoreRegisters() [arm.NumCoreRegisters]uint32
 	RegisterSet(int, uint32) bool
 }
@@ -67,6 +70,7 @@ func (sec elfSection) executable() bool {
 
 func (sec elfSection) inMemory() bool {
 	return (sec.typ == elf.SHT_INIT_ARRAY ||
+		sec.typ == elf.SHT_PREINIT_ARRAY ||
 		sec.typ == elf.SHT_NOBITS ||
 		sec.typ == elf.SHT_PROGBITS) &&
 		sec.debugging == false
@@ -398,18 +402,53 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 			offset := ef.ByteOrder.Uint32(relData[i:])
 			info := ef.ByteOrder.Uint32(relData[i+4:])
 
-			// symbol is encoded in the info value
+			// symbol is encoded in the info value. a symbol index of zero
+			// indicates that there is no symbol for the relocation
+			var sym elf.Symbol
 			symbolIdx := info >> 8
-			sym := mem.symbols[symbolIdx-1]
+			if symbolIdx > 0 {
+				if int(symbolIdx) > len(mem.symbols) {
+					return fmt.Errorf("invalid symbol index (%d) in %s", symbolIdx, rel.Name)
+				}
+				sym = mem.symbols[symbolIdx-1]
+			}
 
 			// reltype is encoded in the info value
 			relType := info & 0xff
 
+			// structured error for the relocation entry
+			relocationError := func(err error) error {
+				return &RelocationError{
+					Type:    elf.R_ARM(relType),
+					Symbol:  sym.Name,
+					Section: secBeingRelocated.name,
+					Offset:  offset,
+					Err:     err,
+				}
+			}
+
+			// the address of the symbol in memory
+			symbolAddress := func() (uint32, error) {
+				if int(sym.Section) >= len(ef.Sections) {
+					return 0, relocationError(fmt.Errorf("invalid section (%s)", sym.Section))
+				}
+				n := ef.Sections[sym.Section].Name
+				sec, ok := mem.sectionsByName[n]
+				if !ok {
+					return 0, relocationError(fmt.Errorf("cannot find section (%s)", n))
+				}
+				return sec.origin + uint32(sym.Value), nil
+			}
+
 			switch elf.R_ARM(relType) {
+			case elf.R_ARM_NONE, elf.R_ARM_V4BX:
+				// nothing to do. R_ARM_V4BX marks BX instructions for the benefit
+				// of ARMv4 targets, which we don't need to worry about
+
 			case elf.R_ARM_TARGET1, elf.R_ARM_ABS32:
 				ok, tgt, err := getStrongArmDefinition(mem, sym.Name)
 				if err != nil {
-					return fmt.Errorf("%s: %w", sym.Name, err)
+					return relocationError(err)
 				}
 				if !ok {
 					switch sym.Name {
@@ -441,16 +480,8 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 							logger.Logf(mem.env, "ELF", "absolute value for %s", sym.Name)
 						} else if sym.Section >= elf.SHN_LORESERVE {
 							logger.Logf(mem.env, "ELF", "unsupported reserved section (%d) for %s", sym.Section, sym.Name)
-						} else if int(sym.Section) >= len(ef.Sections) {
-							return fmt.Errorf("invalid section (%s) while relocating %s", sym.Section, sym.Name)
 						} else {
-							n := ef.Sections[sym.Section].Name
-							if sec, ok := mem.sectionsByName[n]; !ok {
-								return fmt.Errorf("can not find section (%s) while relocating %s", n, sym.Name)
-							} else {
-								tgt = sec.origin
-								tgt += uint32(sym.Value)
-							}
+							tgt, err = symbolAddress()
 						}
 					}
 				}
@@ -461,7 +492,7 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 
 				// add placeholder value to relocation address
 				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+					return relocationError(RelocationOutOfBounds)
 				}
 				addend := ef.ByteOrder.Uint32(secBeingRelocated.data[offset:])
 				tgt += addend
@@ -473,12 +504,12 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 
 				// commit write
 				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+					return relocationError(RelocationOutOfBounds)
 				}
 				ef.ByteOrder.PutUint32(secBeingRelocated.data[offset:], tgt)
 				if d, err := ef.Section(secBeingRelocated.name).Data(); err == nil {
 					if offset >= uint32(len(d)) {
-						return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+						return relocationError(RelocationOutOfBounds)
 					}
 					ef.ByteOrder.PutUint32(d[offset:], tgt)
 				}
@@ -512,7 +543,7 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 
 				ok, tgt, err := getStrongArmDefinition(mem, sym.Name)
 				if err != nil {
-					return fmt.Errorf("%s: %w", sym.Name, err)
+					return relocationError(err)
 				}
 				if !ok {
 					if sym.Section == elf.SHN_UNDEF {
@@ -525,14 +556,10 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 						continue
 					}
 
-					n := ef.Sections[sym.Section].Name
-					sec, ok := mem.sectionsByName[n]
-					if !ok {
-						return fmt.Errorf("can not find section (%s)", n)
+					tgt, err = symbolAddress()
+					if err != nil {
+						return err
 					}
-
-					tgt = sec.origin
-					tgt += uint32(sym.Value)
 				}
 
 				tgt &= 0xfffffffe
@@ -563,10 +590,10 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 
 				// commit write
 				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+					return relocationError(RelocationOutOfBounds)
 				}
 				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+					return relocationError(RelocationOutOfBounds)
 				}
 				ef.ByteOrder.PutUint32(secBeingRelocated.data[offset:], opcode)
 
@@ -588,15 +615,11 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 					continue
 				}
 
-				n := ef.Sections[sym.Section].Name
-				sec, ok := mem.sectionsByName[n]
-				if !ok {
-					return fmt.Errorf("can not find section (%s) while relocating %s", n, sym.Name)
+				tgt, err := symbolAddress()
+				if err != nil {
+					return err
 				}
 
-				tgt := sec.origin
-				tgt += uint32(sym.Value)
-
 				// check address is recognised
 				if mappedData, _ := mem.mapAddress(tgt, false); mappedData == nil {
 					continue
@@ -604,12 +627,12 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 
 				// commit write
 				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+					return relocationError(RelocationOutOfBounds)
 				}
 				ef.ByteOrder.PutUint32(secBeingRelocated.data[offset:], tgt)
 				if d, err := ef.Section(secBeingRelocated.name).Data(); err == nil {
 					if offset >= uint32(len(d)) {
-						return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+						return relocationError(RelocationOutOfBounds)
 					}
 					ef.ByteOrder.PutUint32(d[offset:], tgt)
 				}
@@ -621,79 +644,98 @@ func (mem *elfMemory) decode(ef *elf.File) error {
 					logger.Logf(mem.env, "ELF", "PREL31 section is undefined")
 					continue
 				}
-				return fmt.Errorf("PREL31 not fully supported")
 
-			case elf.R_ARM_THM_MOVW_ABS_NC, elf.R_ARM_THM_MOVT_ABS:
+				tgt, err := symbolAddress()
+				if err != nil {
+					return err
+				}
+
+				if offset+4 > uint32(len(secBeingRelocated.data)) {
+					return relocationError(RelocationOutOfBounds)
+				}
+				v := ef.ByteOrder.Uint32(secBeingRelocated.data[offset:])
+				v, err = relocatePREL31(v, tgt, secBeingRelocated.origin+offset)
+				if err != nil {
+					return relocationError(err)
+				}
+				ef.ByteOrder.PutUint32(secBeingRelocated.data[offset:], v)
+
+				logger.Logf(mem.env, "ELF", "PREL31 %s (%08x) => %08x", sym.Name, secBeingRelocated.origin+offset, v)
+
+			case elf.R_ARM_THM_JUMP11, elf.R_ARM_THM_JUMP8:
+				// 16bit thumb branches. JUMP11 is an unconditional branch and
+				// JUMP8 is a conditional branch
 				if sym.Section == elf.SHN_UNDEF {
-					logger.Logf(logger.Allow, "ELF", "THM_MOVW/MOVT symbol is undefined")
+					logger.Logf(logger.Allow, "ELF", "%v symbol is undefined", elf.R_ARM(relType))
 					continue
 				}
 
-				n := ef.Sections[sym.Section].Name
-				sec, ok := mem.sectionsByName[n]
-				if !ok {
-					return fmt.Errorf("cannot find section (%s) while relocating %s", n, sym.Name)
+				tgt, err := symbolAddress()
+				if err != nil {
+					return err
 				}
 
-				tgt := sec.origin
-				tgt += uint32(sym.Value)
-				tgt &= 0xfffffffe
+				if offset+2 > uint32(len(secBeingRelocated.data)) {
+					return relocationError(RelocationOutOfBounds)
+				}
+				op := ef.ByteOrder.Uint16(secBeingRelocated.data[offset:])
+				op, err = relocateThumbJump(elf.R_ARM(relType), op, tgt, secBeingRelocated.origin+offset)
+				if err != nil {
+					return relocationError(err)
+				}
+				ef.ByteOrder.PutUint16(secBeingRelocated.data[offset:], op)
 
-				// "4.6.1.6 Static Thumb32 relocations"
-				// of "ELF for the ARM Architecture, 24th November 2015"
-				switch elf.R_ARM(relType) {
-				case elf.R_ARM_THM_MOVW_ABS_NC:
-					tgt &= 0x0000ffff
-				case elf.R_ARM_THM_MOVT_ABS:
-					tgt >>= 16
+				logger.Logf(logger.Allow, "ELF", "%v %s (%08x) => opcode %04x", elf.R_ARM(relType), sym.Name, secBeingRelocated.origin+offset, op)
+
+			case elf.R_ARM_THM_JUMP19:
+				// 32bit conditional thumb-2 branch
+				if sym.Section == elf.SHN_UNDEF {
+					logger.Logf(logger.Allow, "ELF", "THM_JUMP19 symbol is undefined")
+					continue
 				}
 
-				// extract fields. opposite of this (from thumb2_32bit.go)
-				// 		imm16 := uint16((imm4 << 12) | (i << 11) | (imm3 << 8) | imm8)
-				imm4 := (tgt >> 12) & 0x000f
-				i := (tgt >> 11) & 0x0001
-				imm3 := (tgt >> 8) & 0x0007
-				imm8 := tgt & 0x00ff
+				tgt, err := symbolAddress()
+				if err != nil {
+					return err
+				}
 
-				// opcode to be transformed
-				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+				if offset+4 > uint32(len(secBeingRelocated.data)) {
+					return relocationError(RelocationOutOfBounds)
 				}
-				op := ef.ByteOrder.Uint32(secBeingRelocated.data[offset:])
-				op = (op << 16) | (op >> 16)
+				op := mem.thumb2Instruction(secBeingRelocated.data[offset:])
+				op, err = relocateThumbJump19(op, tgt, secBeingRelocated.origin+offset)
+				if err != nil {
+					return relocationError(err)
+				}
+				mem.putThumb2Instruction(secBeingRelocated.data[offset:], op)
 
-				// clear bits
-				op &= 0b11111011111100001000111100000000
+				logger.Logf(logger.Allow, "ELF", "THM_JUMP19 %s (%08x) => opcode %08x", sym.Name, secBeingRelocated.origin+offset, op)
 
-				// set bits
-				switch elf.R_ARM(relType) {
-				case elf.R_ARM_THM_MOVT_ABS:
-					op |= (imm4 << 16)
-					op |= (i << 26)
-					op |= (imm3 << 12)
-					op |= imm8
-				case elf.R_ARM_THM_MOVW_ABS_NC:
-					op |= (imm4 << 16)
-					op |= (i << 26)
-					op |= (imm3 << 12)
-					op |= imm8
+			case elf.R_ARM_THM_MOVW_ABS_NC, elf.R_ARM_THM_MOVT_ABS, elf.R_ARM_THM_MOVW_PREL_NC, elf.R_ARM_THM_MOVT_PREL:
+				if sym.Section == elf.SHN_UNDEF {
+					logger.Logf(logger.Allow, "ELF", "THM_MOVW/MOVT symbol is undefined")
+					continue
 				}
 
-				// commit write
-				if offset >= uint32(len(secBeingRelocated.data)) {
-					return fmt.Errorf("relocation out-of-bounds (%s)", sym.Name)
+				tgt, err := symbolAddress()
+				if err != nil {
+					return err
 				}
-				ef.ByteOrder.PutUint32(secBeingRelocated.data[offset:], (op<<16)|(op>>16))
 
-				switch elf.R_ARM(relType) {
-				case elf.R_ARM_THM_MOVW_ABS_NC:
-					logger.Logf(logger.Allow, "ELF", "THM_MOVW_ABS_NC %s (%08x) => %08x", sym.Name, secBeingRelocated.origin+offset, tgt)
-				case elf.R_ARM_THM_MOVT_ABS:
-					logger.Logf(logger.Allow, "ELF", "THM_MOVT_ABS %s (%08x) => %08x", sym.Name, secBeingRelocated.origin+offset, tgt)
+				if offset+4 > uint32(len(secBeingRelocated.data)) {
+					return relocationError(RelocationOutOfBounds)
 				}
+				op := mem.thumb2Instruction(secBeingRelocated.data[offset:])
+				op, err = relocateThumbMov(elf.R_ARM(relType), op, tgt, secBeingRelocated.origin+offset)
+				if err != nil {
+					return relocationError(err)
+				}
+				mem.putThumb2Instruction(secBeingRelocated.data[offset:], op)
+
+				logger.Logf(logger.Allow, "ELF", "%v %s (%08x) => opcode %08x", elf.R_ARM(relType), sym.Name, secBeingRelocated.origin+offset, op)
 
 			default:
-				return fmt.Errorf("unhandled ARM relocation type (%v)", relType)
+				return relocationError(UnsupportedRelocation)
 			}
 		}
 	}
@@ -734,21 +776,42 @@ func (mem *elfMemory) runInitialisation(arm *arm.ARM) error {
 	mem.resetSP = mem.model.Regions["SRAM"].Origin | 0x0000ffdc
 	mem.resetLR = mem.model.Regions["CCM"].Origin
 
-	for _, typ := range []elf.SectionType{elf.SHT_PREINIT_ARRAY, elf.SHT_INIT_ARRAY} {
-		for _, sec := range mem.sections {
-			if sec.typ == typ {
-				ptr := 0
-				for ptr < len(sec.data) {
-					mem.resetPC = mem.byteOrder.Uint32(sec.data[ptr:])
-					ptr += 4
-					if mem.resetPC == 0x00000000 {
-						break // for loop
-					}
-					mem.resetPC &= 0xfffffffe
+	// preinit arrays are run before init arrays. init arrays with a priority
+	// suffix (eg. ".init_array.00101") are run in order of priority, before
+	// any init array without a priority
+	var preinit []*elfSection
+	var initArrays []*elfSection
+	for _, sec := range mem.sections {
+		switch sec.typ {
+		case elf.SHT_PREINIT_ARRAY:
+			preinit = append(preinit, sec)
+		case elf.SHT_INIT_ARRAY:
+			initArrays = append(initArrays, sec)
+		}
+	}
+	sortInitArrays(initArrays)
+
+	for _, sec := range append(preinit, initArrays...) {
+		for ptr := 0; ptr+4 <= len(sec.data); ptr += 4 {
+			mem.resetPC = mem.byteOrder.Uint32(sec.data[ptr:])
+
+			// a null entry in the array is skipped rather than treated as the
+			// end of the array
+			if mem.resetPC == 0x00000000 {
+				continue // for loop
+			}
+			mem.resetPC &= 0xfffffffe
 
-					logger.Logf(mem.env, "ELF", "running %s at %08x", sec.name, mem.resetPC)
-					_, _ = arm.Run()
+			logger.Logf(mem.env, "ELF", "running %s at %08x", sec.name, mem.resetPC)
+			yld, _ := arm.Run()
+			if yld.Type.Bug() {
+				if yld.Error != nil {
+					return fmt.Errorf("%s at %08x: %w", sec.name, mem.resetPC, yld.Error)
 				}
+				return fmt.Errorf("%s at %08x: %s", sec.name, mem.resetPC, yld.Type)
+			}
+			if yld.Type != coprocessor.YieldProgramEnded {
+				logger.Logf(mem.env, "ELF", "%s at %08x did not end normally: %s", sec.name, mem.resetPC, yld.Type)
 			}
 		}
 	}
@@ -771,6 +834,28 @@ func (mem *elfMemory) runInitialisation(arm *arm.ARM) error {
 	return nil
 }
 
+// the priority of an init array section is taken from the numeric suffix of
+// the section name. sections without a suffix have the lowest priority
+func initArrayPriority(name string) int {
+	_, suffix, ok := strings.Cut(name, ".init_array.")
+	if !ok {
+		return math.MaxInt
+	}
+	p, err := strconv.Atoi(suffix)
+	if err != nil {
+		return math.MaxInt
+	}
+	return p
+}
+
+// sort init array sections by priority. sections of the same priority remain
+// in the order they were found
+func sortInitArrays(initArrays []*elfSection) {
+	sort.SliceStable(initArrays, func(i, j int) bool {
+		return initArrayPriority(initArrays[i].name) < initArrayPriority(initArrays[j].name)
+	})
+}
+
 func (mem *elfMemory) relocateStrongArmTable(table strongarmTable) uint32 {
 	// address of table in memory
 	addr := mem.strongArmMemtop
@@ -856,6 +941,7 @@ func (mem *elfMemory) MapAddress(addr uint32, write bool, executing bool) (*[]by
 					mem.runStrongArmFunction(f.function)
 				} else {
 					if mem.stream.active {
+						mem.stream.origin = f.name
 						mem.setStrongArmFunction(f.function)
 						for mem.strongarm.running.function != nil {
 							mem.strongarm.running.function(mem)
@@ -978,6 +1064,16 @@ func (mem *elfMemory) IsExecutable(addr uint32) bool {
 	return mem.lastMappedExecutable
 }
 
+// UninitialisedMemory implements the arm.SharedMemoryUninitialised interface
+func (mem *elfMemory) UninitialisedMemory() []arm.UninitialisedArea {
+	return []arm.UninitialisedArea{
+		{
+			Origin: mem.sramOrigin,
+			Memtop: mem.sramMemtop,
+		},
+	}
+}
+
 // Segments implements the mapper.CartStatic interface
 func (mem *elfMemory) Segments() []mapper.CartStaticSegment {
 	segments := []mapper.CartStaticSegment{
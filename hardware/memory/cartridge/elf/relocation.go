@@ -0,0 +1,206 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package elf
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors wrapped by RelocationError.
+var (
+	UnsupportedRelocation = errors.New("unsupported relocation type")
+	RelocationOutOfBounds = errors.New("relocation out-of-bounds")
+	RelocationOutOfRange  = errors.New("relocated value out of range")
+)
+
+// RelocationError is returned when the ELF file cannot be loaded because a
+// relocation entry cannot be applied. The underlying reason can be tested for
+// with errors.Is()
+type RelocationError struct {
+	// the relocation type
+	Type elf.R_ARM
+
+	// the symbol being relocated. may be empty if the symbol is anonymous
+	Symbol string
+
+	// the section being relocated and the offset of the relocation in that
+	// section
+	Section string
+	Offset  uint32
+
+	Err error
+}
+
+func (e *RelocationError) Error() string {
+	sym := e.Symbol
+	if sym == "" {
+		sym = "anonymous"
+	}
+	return fmt.Sprintf("%v: %s in %s at offset %08x: %v", e.Type, sym, e.Section, e.Offset, e.Err)
+}
+
+func (e *RelocationError) Unwrap() error {
+	return e.Err
+}
+
+// sign extend the value v which is n bits wide
+func signExtend(v uint32, n int) uint32 {
+	shift := 32 - n
+	return uint32(int32(v<<shift) >> shift)
+}
+
+// check that the value v can be represented in n bits as a signed value
+func fitsSigned(v uint32, n int) bool {
+	return signExtend(v, n) == v
+}
+
+// the 16bit immediate value of a thumb-2 MOVW or MOVT instruction. the
+// instruction is in the form returned by thumb2Instruction()
+func thumb2MovImmediate(op uint32) uint32 {
+	imm4 := (op >> 16) & 0x000f
+	i := (op >> 26) & 0x0001
+	imm3 := (op >> 12) & 0x0007
+	imm8 := op & 0x00ff
+	return (imm4 << 12) | (i << 11) | (imm3 << 8) | imm8
+}
+
+// read a 32bit thumb-2 instruction from data. the first halfword of the
+// instruction is placed in the upper 16bits of the returned value
+func (mem *elfMemory) thumb2Instruction(data []byte) uint32 {
+	return uint32(mem.byteOrder.Uint16(data))<<16 | uint32(mem.byteOrder.Uint16(data[2:]))
+}
+
+// write a 32bit thumb-2 instruction to data. the instruction is in the form
+// returned by thumb2Instruction()
+func (mem *elfMemory) putThumb2Instruction(data []byte, op uint32) {
+	mem.byteOrder.PutUint16(data, uint16(op>>16))
+	mem.byteOrder.PutUint16(data[2:], uint16(op))
+}
+
+// relocate the 31bit place value v for a R_ARM_PREL31 relocation. the tgt
+// value is the address of the symbol and the place is the address being
+// relocated. the top bit of the place value is preserved
+//
+// "R_ARM_PREL31 ((S + A) | T) – P"
+// of "ELF for the ARM Architecture, 24th November 2015"
+func relocatePREL31(v uint32, tgt uint32, place uint32) (uint32, error) {
+	tgt += signExtend(v&0x7fffffff, 31)
+	tgt -= place
+	if !fitsSigned(tgt, 31) {
+		return 0, RelocationOutOfRange
+	}
+	return (v & 0x80000000) | (tgt & 0x7fffffff), nil
+}
+
+// relocate the 16bit thumb branch instruction for a R_ARM_THM_JUMP11 or
+// R_ARM_THM_JUMP8 relocation. JUMP11 is an unconditional branch and JUMP8 is a
+// conditional branch
+//
+// "S + A – P" where the addend is encoded in the instruction
+func relocateThumbJump(relType elf.R_ARM, op uint16, tgt uint32, place uint32) (uint16, error) {
+	var bits int
+	var mask uint16
+	switch relType {
+	case elf.R_ARM_THM_JUMP11:
+		bits = 12
+		mask = 0x07ff
+	case elf.R_ARM_THM_JUMP8:
+		bits = 9
+		mask = 0x00ff
+	default:
+		return 0, UnsupportedRelocation
+	}
+
+	tgt &= 0xfffffffe
+	tgt += signExtend(uint32(op&mask)<<1, bits)
+	tgt -= place
+	if !fitsSigned(tgt, bits) {
+		return 0, RelocationOutOfRange
+	}
+
+	return (op &^ mask) | (uint16(tgt>>1) & mask), nil
+}
+
+// relocate the 32bit conditional thumb-2 branch instruction for a
+// R_ARM_THM_JUMP19 relocation. the instruction is in the form returned by
+// thumb2Instruction()
+//
+// "A7.7.12 B" of "ARMv7-M"
+//
+// imm32 = SignExtend(S:J2:J1:imm6:imm11:'0', 32)
+func relocateThumbJump19(op uint32, tgt uint32, place uint32) (uint32, error) {
+	s := (op >> 26) & 0x01
+	imm6 := (op >> 16) & 0x3f
+	j1 := (op >> 13) & 0x01
+	j2 := (op >> 11) & 0x01
+	imm11 := op & 0x7ff
+
+	tgt &= 0xfffffffe
+	tgt += signExtend((s<<20)|(j2<<19)|(j1<<18)|(imm6<<12)|(imm11<<1), 21)
+	tgt -= place
+	if !fitsSigned(tgt, 21) {
+		return 0, RelocationOutOfRange
+	}
+
+	s = (tgt >> 20) & 0x01
+	j2 = (tgt >> 19) & 0x01
+	j1 = (tgt >> 18) & 0x01
+	imm6 = (tgt >> 12) & 0x3f
+	imm11 = (tgt >> 1) & 0x7ff
+
+	op &= 0b11111011110000001101000000000000
+	op |= (s << 26) | (imm6 << 16) | (j1 << 13) | (j2 << 11) | imm11
+	return op, nil
+}
+
+// relocate the thumb-2 MOVW or MOVT instruction for the R_ARM_THM_MOVW_ABS_NC,
+// R_ARM_THM_MOVT_ABS, R_ARM_THM_MOVW_PREL_NC and R_ARM_THM_MOVT_PREL
+// relocations. the instruction is in the form returned by thumb2Instruction()
+//
+// "4.6.1.6 Static Thumb32 relocations"
+// of "ELF for the ARM Architecture, 24th November 2015"
+func relocateThumbMov(relType elf.R_ARM, op uint32, tgt uint32, place uint32) (uint32, error) {
+	// the PC relative forms of the relocation are "S + A - P" where the addend
+	// is encoded in the instruction
+	switch relType {
+	case elf.R_ARM_THM_MOVW_PREL_NC, elf.R_ARM_THM_MOVT_PREL:
+		tgt += signExtend(thumb2MovImmediate(op), 16)
+		tgt -= place
+	case elf.R_ARM_THM_MOVW_ABS_NC, elf.R_ARM_THM_MOVT_ABS:
+		tgt &= 0xfffffffe
+	default:
+		return 0, UnsupportedRelocation
+	}
+
+	switch relType {
+	case elf.R_ARM_THM_MOVW_ABS_NC, elf.R_ARM_THM_MOVW_PREL_NC:
+		tgt &= 0x0000ffff
+	case elf.R_ARM_THM_MOVT_ABS, elf.R_ARM_THM_MOVT_PREL:
+		tgt >>= 16
+	}
+
+	// extract fields. opposite of thumb2MovImmediate()
+	imm4 := (tgt >> 12) & 0x000f
+	i := (tgt >> 11) & 0x0001
+	imm3 := (tgt >> 8) & 0x0007
+	imm8 := tgt & 0x00ff
+
+	op &= 0b11111011111100001000111100000000
+	op |= (imm4 << 16) | (i << 26) | (imm3 << 12) | imm8
+	return op, nil
+}
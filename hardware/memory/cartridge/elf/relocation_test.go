@@ -0,0 +1,273 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package elf
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestSignExtend(t *testing.T) {
+	for _, c := range []struct {
+		v        uint32
+		n        int
+		expected uint32
+	}{
+		{v: 0x7f, n: 8, expected: 0x0000007f},
+		{v: 0xff, n: 8, expected: 0xffffffff},
+		{v: 0x80, n: 8, expected: 0xffffff80},
+		{v: 0x800, n: 12, expected: 0xfffff800},
+		{v: 0x7ff, n: 12, expected: 0x000007ff},
+		{v: 0x1fffff, n: 21, expected: 0xffffffff},
+		{v: 0x40000000, n: 31, expected: 0xc0000000},
+		{v: 0xffffff01, n: 8, expected: 0x00000001},
+	} {
+		test.ExpectEquality(t, signExtend(c.v, c.n), c.expected)
+	}
+}
+
+func TestFitsSigned(t *testing.T) {
+	for _, c := range []struct {
+		v        uint32
+		n        int
+		expected bool
+	}{
+		{v: 0x0000007f, n: 8, expected: true},
+		{v: 0x00000080, n: 8, expected: false},
+		{v: 0xffffff80, n: 8, expected: true},
+		{v: 0xffffff7f, n: 8, expected: false},
+		{v: 0x000ffffe, n: 21, expected: true},
+		{v: 0x00100000, n: 21, expected: false},
+		{v: 0xfff00000, n: 21, expected: true},
+		{v: 0x3fffffff, n: 31, expected: true},
+		{v: 0x40000000, n: 31, expected: false},
+	} {
+		test.ExpectEquality(t, fitsSigned(c.v, c.n), c.expected)
+	}
+}
+
+func TestThumb2MovImmediate(t *testing.T) {
+	test.ExpectEquality(t, thumb2MovImmediate(0xf2400000), 0x0000)
+	test.ExpectEquality(t, thumb2MovImmediate(0xf2456078), 0x5678)
+	test.ExpectEquality(t, thumb2MovImmediate(0xf6400000), 0x0800)
+	test.ExpectEquality(t, thumb2MovImmediate(0xf64f70ff), 0xffff)
+}
+
+func TestRelocatePREL31(t *testing.T) {
+	for _, c := range []struct {
+		v        uint32
+		tgt      uint32
+		place    uint32
+		expected uint32
+	}{
+		// forward and backward references
+		{v: 0x00000000, tgt: 0x2000, place: 0x1000, expected: 0x00001000},
+		{v: 0x00000000, tgt: 0x1000, place: 0x2000, expected: 0x7ffff000},
+
+		// the top bit of the place is preserved
+		{v: 0x80000000, tgt: 0x1000, place: 0x2000, expected: 0xfffff000},
+
+		// the addend in the place
+		{v: 0x00000010, tgt: 0x2000, place: 0x1000, expected: 0x00001010},
+		{v: 0x7ffffff0, tgt: 0x2000, place: 0x1000, expected: 0x00000ff0},
+	} {
+		v, err := relocatePREL31(c.v, c.tgt, c.place)
+		test.ExpectSuccess(t, err)
+		test.ExpectEquality(t, v, c.expected)
+	}
+
+	_, err := relocatePREL31(0, 0x40000000, 0)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+}
+
+func TestRelocateThumbJump(t *testing.T) {
+	for _, c := range []struct {
+		relType  elf.R_ARM
+		op       uint16
+		tgt      uint32
+		place    uint32
+		expected uint16
+	}{
+		// B with an addend of -4. the thumb bit of the target is ignored
+		{relType: elf.R_ARM_THM_JUMP11, op: 0xe7fe, tgt: 0x1000, place: 0x0ff0, expected: 0xe006},
+		{relType: elf.R_ARM_THM_JUMP11, op: 0xe7fe, tgt: 0x1001, place: 0x0ff0, expected: 0xe006},
+		{relType: elf.R_ARM_THM_JUMP11, op: 0xe7fe, tgt: 0x0000, place: 0x0100, expected: 0xe77e},
+		{relType: elf.R_ARM_THM_JUMP11, op: 0xe7fe, tgt: 0x0802, place: 0x0000, expected: 0xe3ff},
+
+		// BEQ and BNE with an addend of -4. the condition is preserved
+		{relType: elf.R_ARM_THM_JUMP8, op: 0xd0fe, tgt: 0x0100, place: 0x00f0, expected: 0xd006},
+		{relType: elf.R_ARM_THM_JUMP8, op: 0xd1fe, tgt: 0x0000, place: 0x0080, expected: 0xd1be},
+		{relType: elf.R_ARM_THM_JUMP8, op: 0xd0fe, tgt: 0x0000, place: 0x00fc, expected: 0xd080},
+	} {
+		op, err := relocateThumbJump(c.relType, c.op, c.tgt, c.place)
+		test.ExpectSuccess(t, err)
+		test.ExpectEquality(t, op, c.expected)
+	}
+
+	// out of range branches
+	_, err := relocateThumbJump(elf.R_ARM_THM_JUMP11, 0xe7fe, 0x0804, 0x0000)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+	_, err = relocateThumbJump(elf.R_ARM_THM_JUMP11, 0xe7fe, 0x0000, 0x0800)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+	_, err = relocateThumbJump(elf.R_ARM_THM_JUMP8, 0xd0fe, 0x0104, 0x0000)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+	_, err = relocateThumbJump(elf.R_ARM_THM_JUMP8, 0xd0fe, 0x0000, 0x0100)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+
+	// not a 16bit branch relocation
+	_, err = relocateThumbJump(elf.R_ARM_ABS32, 0xe7fe, 0x0000, 0x0000)
+	test.ExpectEquality(t, err, UnsupportedRelocation)
+}
+
+func TestRelocateThumbJump19(t *testing.T) {
+	// BEQ.W with an addend of -4
+	const beq = 0xf43faffe
+
+	for _, c := range []struct {
+		tgt      uint32
+		place    uint32
+		expected uint32
+	}{
+		{tgt: 0x1000, place: 0x0ff0, expected: 0xf0008006},
+		{tgt: 0x1001, place: 0x0ff0, expected: 0xf0008006},
+		{tgt: 0x1000, place: 0x1000, expected: 0xf43faffe},
+		{tgt: 0x0000, place: 0x0ffc, expected: 0xf43fa800},
+
+		// all immediate bits set for the largest forward branch
+		{tgt: 0x100002, place: 0x0000, expected: 0xf03fafff},
+
+		// the sign bit only for the largest backward branch
+		{tgt: 0x000000, place: 0x0ffffc, expected: 0xf4008000},
+	} {
+		op, err := relocateThumbJump19(beq, c.tgt, c.place)
+		test.ExpectSuccess(t, err)
+		test.ExpectEquality(t, op, c.expected)
+	}
+
+	// the condition is preserved. BNE.W with an addend of zero
+	op, err := relocateThumbJump19(0xf0408000, 0x1000, 0x0ff0)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, op, 0xf0408008)
+
+	// out of range branches
+	_, err = relocateThumbJump19(beq, 0x100004, 0x0000)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+	_, err = relocateThumbJump19(beq, 0x000000, 0x100000)
+	test.ExpectEquality(t, err, RelocationOutOfRange)
+}
+
+func TestRelocateThumbMov(t *testing.T) {
+	// MOVW R0 and MOVT R0 with zero immediate values
+	const movw = 0xf2400000
+	const movt = 0xf2c00000
+
+	for _, c := range []struct {
+		relType  elf.R_ARM
+		op       uint32
+		tgt      uint32
+		place    uint32
+		expected uint32
+	}{
+		// the absolute forms ignore the place and the thumb bit of the target
+		{relType: elf.R_ARM_THM_MOVW_ABS_NC, op: movw, tgt: 0x12345679, place: 0x1000, expected: 0xf2456078},
+		{relType: elf.R_ARM_THM_MOVT_ABS, op: movt, tgt: 0x12345678, place: 0x1000, expected: 0xf2c12034},
+		{relType: elf.R_ARM_THM_MOVW_ABS_NC, op: movw, tgt: 0x0000ffff, place: 0x0000, expected: 0xf64f70fe},
+
+		// the destination register is preserved
+		{relType: elf.R_ARM_THM_MOVW_ABS_NC, op: movw | 0x0300, tgt: 0x00000800, place: 0x0000, expected: 0xf6400300},
+
+		// the PC relative forms
+		{relType: elf.R_ARM_THM_MOVW_PREL_NC, op: movw, tgt: 0x1000, place: 0x0800, expected: 0xf6400000},
+		{relType: elf.R_ARM_THM_MOVT_PREL, op: movt, tgt: 0x20001000, place: 0x1000, expected: 0xf2c20000},
+
+		// the PC relative forms with an addend in the instruction
+		{relType: elf.R_ARM_THM_MOVW_PREL_NC, op: movw | 0x0004, tgt: 0x1000, place: 0x0800, expected: 0xf6400004},
+
+		// a negative PC relative value
+		{relType: elf.R_ARM_THM_MOVW_PREL_NC, op: movw, tgt: 0x0800, place: 0x1000, expected: 0xf64f0000},
+	} {
+		op, err := relocateThumbMov(c.relType, c.op, c.tgt, c.place)
+		test.ExpectSuccess(t, err)
+		test.ExpectEquality(t, op, c.expected)
+	}
+
+	// the immediate value can be recovered from the relocated instruction
+	op, err := relocateThumbMov(elf.R_ARM_THM_MOVW_ABS_NC, movw, 0xdeadbeef, 0)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, thumb2MovImmediate(op), 0xbeee)
+	op, err = relocateThumbMov(elf.R_ARM_THM_MOVT_ABS, movt, 0xdeadbeef, 0)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, thumb2MovImmediate(op), 0xdead)
+
+	// not a MOVW or MOVT relocation
+	_, err = relocateThumbMov(elf.R_ARM_ABS32, movw, 0x0000, 0x0000)
+	test.ExpectEquality(t, err, UnsupportedRelocation)
+}
+
+func TestInitArrayPriority(t *testing.T) {
+	test.ExpectEquality(t, initArrayPriority(".init_array"), math.MaxInt)
+	test.ExpectEquality(t, initArrayPriority(".init_array.00100"), 100)
+	test.ExpectEquality(t, initArrayPriority(".init_array.0"), 0)
+	test.ExpectEquality(t, initArrayPriority(".init_array.high"), math.MaxInt)
+	test.ExpectEquality(t, initArrayPriority(".text"), math.MaxInt)
+
+	secs := []*elfSection{
+		{name: ".init_array"},
+		{name: ".init_array.00200"},
+		{name: ".init_array.a"},
+		{name: ".init_array.00100"},
+		{name: ".init_array.b"},
+	}
+	sortInitArrays(secs)
+
+	// sections of the same priority remain in the order they were found
+	for i, n := range []string{".init_array.00100", ".init_array.00200", ".init_array", ".init_array.a", ".init_array.b"} {
+		test.ExpectEquality(t, secs[i].name, n)
+	}
+}
+
+func TestRelocationError(t *testing.T) {
+	var err error = &RelocationError{
+		Type:    elf.R_ARM_THM_JUMP8,
+		Symbol:  "loop",
+		Section: ".text",
+		Offset:  0x10,
+		Err:     RelocationOutOfRange,
+	}
+	test.ExpectEquality(t, err.Error(), "R_ARM_THM_JUMP8: loop in .text at offset 00000010: relocated value out of range")
+	test.ExpectSuccess(t, errors.Is(err, RelocationOutOfRange))
+	test.ExpectFailure(t, errors.Is(err, RelocationOutOfBounds))
+
+	// anonymous symbol
+	err = &RelocationError{
+		Type:    elf.R_ARM_ABS32,
+		Section: ".data",
+		Err:     UnsupportedRelocation,
+	}
+	test.ExpectEquality(t, err.Error(), "R_ARM_ABS32: anonymous in .data at offset 00000000: unsupported relocation type")
+
+	// the relocation error can be found in a wrapped error
+	err = fmt.Errorf("ELF: %w", err)
+	var rerr *RelocationError
+	test.ExpectSuccess(t, errors.As(err, &rerr))
+	test.ExpectEquality(t, rerr.Type, elf.R_ARM_ABS32)
+	test.ExpectSuccess(t, errors.Is(err, UnsupportedRelocation))
+}
@@ -26,6 +26,9 @@ type streamEntry struct {
 	addr     uint16
 	data     uint8
 	busstuff bool
+
+	// the name of the strongarm function that pushed the entry
+	origin string
 }
 
 func (s streamEntry) String() string {
@@ -64,6 +67,9 @@ type stream struct {
 
 	// indicates that a data bus snooping needs resolving
 	snoopDataBus bool
+
+	// the name of the strongarm function currently pushing to the stream
+	origin string
 }
 
 func (s *stream) startDrain() {
@@ -73,6 +79,7 @@ func (s *stream) startDrain() {
 }
 
 func (s *stream) push(e streamEntry) {
+	e.origin = s.origin
 	s.stream[s.ptr] = e
 	s.ptr++
 
@@ -0,0 +1,260 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package elf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
+)
+
+// the type of event recorded by the stream recorder
+type streamEvent int
+
+const (
+	// a stream entry has been delivered to the 6507 address bus
+	streamEventPull streamEvent = iota
+
+	// a stream entry has been delivered by bus stuffing
+	streamEventBusStuff
+
+	// the 6507 accessed a cartridge address but the stream was empty
+	streamEventUnderrun
+
+	// the 6507 accessed a cartridge address that was not the address of the
+	// next entry in the stream. the entry is not delivered
+	streamEventMismatch
+)
+
+func (e streamEvent) String() string {
+	switch e {
+	case streamEventPull:
+		return "pull"
+	case streamEventBusStuff:
+		return "busstuff"
+	case streamEventUnderrun:
+		return "underrun"
+	case streamEventMismatch:
+		return "mismatch"
+	}
+	return "unknown"
+}
+
+// a single record in the stream recording
+type streamRecord struct {
+	coords coords.TelevisionCoords
+	event  streamEvent
+
+	// the entry delivered to the 6507. for a mismatch event this is the entry
+	// that was expected to be delivered. empty for an underrun event
+	entry streamEntry
+
+	// the stream was not draining at the time of the 6507 access. the ARM
+	// was run and it filled the stream, which then started to drain
+	refill bool
+
+	// state of the GPIO pins at the time of the event
+	gpioAddr    uint16
+	gpioDataIn  uint8
+	gpioDataOut uint8
+}
+
+// streamRecorder captures the delivery of stream entries to the 6507 over a
+// range of frames
+type streamRecorder struct {
+	// a recording has been started with start()
+	started bool
+
+	startFrame int
+	endFrame   int
+
+	records []streamRecord
+}
+
+// start a new recording for the range of frames. any previous recording is
+// discarded
+func (r *streamRecorder) start(startFrame int, endFrame int) {
+	r.started = true
+	r.startFrame = startFrame
+	r.endFrame = endFrame
+	r.records = r.records[:0]
+}
+
+// whether the recorder should record an event on the specified frame
+func (r *streamRecorder) inRange(frame int) bool {
+	return r.started && frame >= r.startFrame && frame <= r.endFrame
+}
+
+// whether the recorder has finished with the frame range
+func (r *streamRecorder) finished(frame int) bool {
+	return frame > r.endFrame
+}
+
+// add a record to the recording
+func (r *streamRecorder) add(rec streamRecord) {
+	if !r.inRange(rec.coords.Frame) {
+		return
+	}
+
+	// the record is earlier than the most recent record, meaning that the
+	// emulation has been rewound. the frames from the start of the frame of
+	// the new record will be recorded again so the old records are removed
+	if n := len(r.records); n > 0 && coords.GreaterThan(r.records[n-1].coords, rec.coords) {
+		i := sort.Search(n, func(i int) bool {
+			return r.records[i].coords.Frame >= rec.coords.Frame
+		})
+		r.records = r.records[:i]
+	}
+
+	r.records = append(r.records, rec)
+}
+
+// record a stream event with the current state of the GPIO pins
+func (cart *Elf) recordStream(event streamEvent, e streamEntry, refill bool) {
+	if !cart.recorder.started {
+		return
+	}
+
+	g := cart.mem.gpio
+	r := streamRecord{
+		coords:      cart.env.TV.GetCoords(),
+		event:       event,
+		entry:       e,
+		refill:      refill,
+		gpioDataIn:  g.data[DATA_IDR],
+		gpioDataOut: g.data[DATA_ODR],
+	}
+	r.gpioAddr = uint16(g.data[ADDR_IDR])
+	r.gpioAddr |= uint16(g.data[ADDR_IDR+1]) << 8
+
+	cart.recorder.add(r)
+}
+
+// export writes the recording to w in CSV format
+func (r *streamRecorder) export(w io.Writer) error {
+	_, err := io.WriteString(w, "frame,scanline,clock,event,refill,addr,data,gpio addr,gpio data in,gpio data out,origin\n")
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range r.records {
+		_, err = fmt.Fprintf(w, "%d,%d,%d,%s,%v,%04x,%02x,%04x,%02x,%02x,%s\n",
+			rec.coords.Frame, rec.coords.Scanline, rec.coords.Clock,
+			rec.event, rec.refill, rec.entry.addr, rec.entry.data,
+			rec.gpioAddr, rec.gpioDataIn, rec.gpioDataOut,
+			rec.entry.origin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the maximum number of underruns and mismatches listed individually by the
+// analysis
+const maxListedEvents = 10
+
+// analyse writes a summary of the recording to w
+func (r *streamRecorder) analyse(w io.Writer) {
+	if len(r.records) == 0 {
+		fmt.Fprintf(w, "no stream entries recorded for frames %d to %d", r.startFrame, r.endFrame)
+		return
+	}
+
+	var pulls int
+	var busStuff int
+	var underruns []streamRecord
+	var mismatches []streamRecord
+	var refills int
+
+	// number of entries produced by each vcsLib function
+	origins := make(map[string]int)
+
+	for _, rec := range r.records {
+		switch rec.event {
+		case streamEventPull:
+			pulls++
+		case streamEventBusStuff:
+			busStuff++
+		case streamEventUnderrun:
+			underruns = append(underruns, rec)
+		case streamEventMismatch:
+			mismatches = append(mismatches, rec)
+		}
+
+		if rec.refill {
+			refills++
+		}
+
+		if rec.event == streamEventPull || rec.event == streamEventBusStuff {
+			origin := rec.entry.origin
+			if origin == "" {
+				origin = "unknown"
+			}
+			origins[origin]++
+		}
+	}
+
+	first := r.records[0].coords.Frame
+	last := r.records[len(r.records)-1].coords.Frame
+	fmt.Fprintf(w, "frames %d to %d: %d entries (%d bus stuff)\n", first, last, pulls+busStuff, busStuff)
+	fmt.Fprintf(w, "stream refills: %d\n", refills)
+
+	// every underrun is a 6507 cycle in which the cartridge had nothing new to
+	// put on the data bus
+	fmt.Fprintf(w, "underruns: %d (6507 cycles waiting)\n", len(underruns))
+	for i, rec := range underruns {
+		if i >= maxListedEvents {
+			fmt.Fprintf(w, "  ... %d more\n", len(underruns)-maxListedEvents)
+			break // for loop
+		}
+		fmt.Fprintf(w, "  %s  addr=%04x\n", rec.coords, rec.gpioAddr)
+	}
+
+	// every mismatch is a 6507 cycle in which the 6507 was at a different
+	// address to the one expected by the next entry in the stream
+	fmt.Fprintf(w, "mismatches: %d (unexpected addresses)\n", len(mismatches))
+	for i, rec := range mismatches {
+		if i >= maxListedEvents {
+			fmt.Fprintf(w, "  ... %d more\n", len(mismatches)-maxListedEvents)
+			break // for loop
+		}
+		fmt.Fprintf(w, "  %s  addr=%04x expected=%04x\n", rec.coords, rec.gpioAddr, rec.entry.addr)
+	}
+
+	type originCount struct {
+		name  string
+		count int
+	}
+	var counts []originCount
+	for n, c := range origins {
+		counts = append(counts, originCount{name: n, count: c})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count == counts[j].count {
+			return counts[i].name < counts[j].name
+		}
+		return counts[i].count > counts[j].count
+	})
+
+	fmt.Fprintf(w, "entries by function:")
+	for _, c := range counts {
+		fmt.Fprintf(w, "\n  %-30s %d", c.name, c.count)
+	}
+}
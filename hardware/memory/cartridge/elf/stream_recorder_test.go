@@ -0,0 +1,202 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package elf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/television"
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func testStreamRecord(frame int, scanline int, clock int, event streamEvent, origin string) streamRecord {
+	return streamRecord{
+		coords: coords.TelevisionCoords{Frame: frame, Scanline: scanline, Clock: clock},
+		event:  event,
+		entry:  streamEntry{addr: 0x1000, data: 0xea, origin: origin},
+	}
+}
+
+func TestStreamRecorderRange(t *testing.T) {
+	r := &streamRecorder{}
+	r.start(2, 3)
+
+	for frame := 1; frame <= 4; frame++ {
+		r.add(testStreamRecord(frame, 0, 0, streamEventPull, ""))
+	}
+	test.ExpectEquality(t, len(r.records), 2)
+	test.ExpectEquality(t, r.records[0].coords.Frame, 2)
+	test.ExpectEquality(t, r.records[1].coords.Frame, 3)
+
+	test.ExpectFailure(t, r.finished(3))
+	test.ExpectSuccess(t, r.finished(4))
+}
+
+func TestStreamRecorderRewind(t *testing.T) {
+	r := &streamRecorder{}
+	r.start(0, 10)
+
+	for frame := 0; frame <= 5; frame++ {
+		r.add(testStreamRecord(frame, 10, 0, streamEventPull, ""))
+		r.add(testStreamRecord(frame, 20, 0, streamEventPull, ""))
+	}
+	test.ExpectEquality(t, len(r.records), 12)
+
+	// the emulation is rewound to part way through frame 3. the records for
+	// frames 3, 4 and 5 are removed
+	r.add(testStreamRecord(3, 15, 0, streamEventPull, ""))
+	test.ExpectEquality(t, len(r.records), 7)
+	test.ExpectEquality(t, r.records[5].coords.Frame, 2)
+	test.ExpectEquality(t, r.records[6].coords.Frame, 3)
+	test.ExpectEquality(t, r.records[6].coords.Scanline, 15)
+
+	// records in the same frame that are later than the most recent record
+	// are added as normal
+	r.add(testStreamRecord(3, 20, 0, streamEventPull, ""))
+	test.ExpectEquality(t, len(r.records), 8)
+
+	// rewinding to before the start of the recording removes everything
+	r.add(testStreamRecord(0, 0, 0, streamEventPull, ""))
+	test.ExpectEquality(t, len(r.records), 1)
+}
+
+func TestStreamRecorderExport(t *testing.T) {
+	r := &streamRecorder{}
+	r.start(0, 10)
+
+	rec := testStreamRecord(1, 2, 3, streamEventPull, "vcsWrite3")
+	rec.refill = true
+	rec.gpioAddr = 0x1234
+	rec.gpioDataIn = 0x56
+	rec.gpioDataOut = 0xea
+	r.add(rec)
+
+	r.add(streamRecord{
+		coords: coords.TelevisionCoords{Frame: 1, Scanline: 2, Clock: 6},
+		event:  streamEventUnderrun,
+	})
+
+	rec = testStreamRecord(1, 2, 9, streamEventMismatch, "vcsWrite3")
+	rec.gpioAddr = 0x1002
+	r.add(rec)
+
+	var b strings.Builder
+	test.ExpectSuccess(t, r.export(&b))
+	test.ExpectEquality(t, b.String(),
+		"frame,scanline,clock,event,refill,addr,data,gpio addr,gpio data in,gpio data out,origin\n"+
+			"1,2,3,pull,true,1000,ea,1234,56,ea,vcsWrite3\n"+
+			"1,2,6,underrun,false,0000,00,0000,00,00,\n"+
+			"1,2,9,mismatch,false,1000,ea,1002,00,00,vcsWrite3\n")
+}
+
+func TestStreamRecorderAnalyse(t *testing.T) {
+	r := &streamRecorder{}
+	r.start(0, 10)
+
+	var b strings.Builder
+	r.analyse(&b)
+	test.ExpectEquality(t, b.String(), "no stream entries recorded for frames 0 to 10")
+
+	rec := testStreamRecord(1, 0, 0, streamEventPull, "vcsWrite3")
+	rec.refill = true
+	r.add(rec)
+	r.add(testStreamRecord(1, 0, 3, streamEventPull, "vcsWrite3"))
+	r.add(testStreamRecord(1, 0, 6, streamEventBusStuff, "vcsSta3"))
+	r.add(testStreamRecord(1, 0, 9, streamEventPull, ""))
+	for i := 0; i < maxListedEvents+2; i++ {
+		r.add(testStreamRecord(2, i, 0, streamEventUnderrun, ""))
+	}
+	mismatch := testStreamRecord(3, 0, 0, streamEventMismatch, "vcsWrite3")
+	mismatch.gpioAddr = 0x1002
+	r.add(mismatch)
+
+	// the first few underruns are listed individually
+	expected := []string{
+		"frames 1 to 3: 4 entries (1 bus stuff)",
+		"stream refills: 1",
+		"underruns: 12 (6507 cycles waiting)",
+	}
+	for _, rec := range r.records[4 : 4+maxListedEvents] {
+		expected = append(expected, fmt.Sprintf("  %s  addr=0000", rec.coords))
+	}
+	expected = append(expected,
+		"  ... 2 more",
+		"mismatches: 1 (unexpected addresses)",
+		fmt.Sprintf("  %s  addr=1002 expected=1000", mismatch.coords),
+		"entries by function:",
+		"  vcsWrite3                      2",
+		"  unknown                        1",
+		"  vcsSta3                        1",
+	)
+
+	b.Reset()
+	r.analyse(&b)
+	test.ExpectEquality(t, b.String(), strings.Join(expected, "\n"))
+}
+
+func TestStreamRecorderAccess(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	test.ExpectSuccess(t, err)
+
+	cart := &Elf{
+		env:      &environment.Environment{TV: tv},
+		mem:      &elfMemory{gpio: newGPIO()},
+		recorder: &streamRecorder{},
+	}
+	cart.mem.stream.active = true
+	cart.mem.stream.origin = "vcsWrite3"
+	cart.mem.stream.push(streamEntry{addr: 0x1010, data: 0x01})
+	cart.mem.stream.push(streamEntry{addr: 0x1011, data: 0x02})
+	cart.mem.stream.startDrain()
+
+	// the 6507 address is on the GPIO pins
+	access := func(addr uint16) uint8 {
+		t.Helper()
+		cart.mem.gpio.data[ADDR_IDR] = uint8(addr)
+		cart.mem.gpio.data[ADDR_IDR+1] = uint8(addr >> 8)
+		data, _, err := cart.Access(addr&0x0fff, false)
+		test.ExpectSuccess(t, err)
+		return data
+	}
+
+	// nothing is recorded before the recording is started
+	test.ExpectEquality(t, access(0x1010), uint8(0x01))
+	test.ExpectEquality(t, len(cart.recorder.records), 0)
+
+	cart.recorder.start(0, 10)
+
+	// an access to an address other than the address of the next entry is a
+	// mismatch and not an underrun. the entry stays in the stream
+	access(0x1012)
+	test.ExpectEquality(t, len(cart.recorder.records), 1)
+	test.ExpectEquality(t, cart.recorder.records[0].event, streamEventMismatch)
+	test.ExpectEquality(t, cart.recorder.records[0].entry.addr, uint16(0x1011))
+	test.ExpectEquality(t, cart.recorder.records[0].gpioAddr, uint16(0x1012))
+
+	test.ExpectEquality(t, access(0x1011), uint8(0x02))
+	test.ExpectEquality(t, len(cart.recorder.records), 2)
+	test.ExpectEquality(t, cart.recorder.records[1].event, streamEventPull)
+	test.ExpectEquality(t, cart.recorder.records[1].entry.origin, "vcsWrite3")
+
+	// a copy of the cartridge, as made by Snapshot(), shares the recorder
+	snapshot := *cart
+	snapshot.recorder.start(0, 10)
+	test.ExpectEquality(t, len(cart.recorder.records), 0)
+}
@@ -37,6 +37,7 @@ func memset(mem *elfMemory) {
 		for i := range l {
 			(*set)[idx+i] = byte(v)
 		}
+		mem.arm.MemoryInitialised(addr, l)
 	}
 }
 
@@ -54,5 +55,6 @@ func memcpy(mem *elfMemory) {
 		for i := range l {
 			(*to)[toIdx+i] = (*from)[fromIdx+i]
 		}
+		mem.arm.MemoryInitialised(toAddr, l)
 	}
 }
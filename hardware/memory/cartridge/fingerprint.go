@@ -268,6 +268,61 @@ func fingerprintUA(loader cartridgeloader.Loader) bool {
 		})
 }
 
+func fingerprintEconobanking(loader cartridgeloader.Loader) bool {
+	// 0840 fingerprint taken from Stella. the hotspots must be accessed at
+	// least twice
+	fingerprint := [][]byte{
+		{0xad, 0x00, 0x08},       // LDA $0800
+		{0xad, 0x40, 0x08},       // LDA $0840
+		{0x2c, 0x00, 0x08},       // BIT $0800
+		{0x0c, 0x00, 0x08, 0x4c}, // NOP $0800; JMP ...
+		{0x0c, 0xff, 0x0f, 0x4c}, // NOP $0FFF; JMP ...
+	}
+	return slices.ContainsFunc(fingerprint,
+		func(b []byte) bool {
+			return loader.Count(b) > 1
+		})
+}
+
+func fingerprintX07(loader cartridgeloader.Loader) bool {
+	// X07 fingerprint taken from Stella. the sequences are accesses to the
+	// hotspots for the first three banks
+	fingerprint := [][]byte{
+		{0xad, 0x0d, 0x08}, // LDA $080D
+		{0xad, 0x1d, 0x08}, // LDA $081D
+		{0xad, 0x2d, 0x08}, // LDA $082D
+		{0x0c, 0x0d, 0x08}, // NOP $080D
+		{0x0c, 0x1d, 0x08}, // NOP $081D
+		{0x0c, 0x2d, 0x08}, // NOP $082D
+	}
+	return slices.ContainsFunc(fingerprint, loader.Contains)
+}
+
+func fingerprint4A50(loader cartridgeloader.Loader) bool {
+	// 4A50 fingerprint taken from Stella. 4A50 cartridges of 64k or more store
+	// the address $4A50 in the NMI vector, which is in the fixed page at the
+	// end of the cartridge data
+	b := make([]byte, 4)
+	loader.Seek(int64(loader.Size())-6, io.SeekStart)
+	if n, err := loader.Read(b); n != len(b) || err != nil {
+		return false
+	}
+	if loader.Size() >= 0x10000 && bytes.Equal(b[:2], []byte{0x50, 0x4a}) {
+		return true
+	}
+
+	// alternatively, the reset vector points to the fixed page at $1Fxx and
+	// the first instruction is NOP $6Exx or NOP $6Fxx
+	if b[3]&0x1f != 0x1f {
+		return false
+	}
+	loader.Seek(int64(loader.Size())-0x100+int64(b[2]), io.SeekStart)
+	if n, err := loader.Read(b[:3]); n != 3 || err != nil {
+		return false
+	}
+	return b[0] == 0x0c && b[2]&0xfe == 0x6e
+}
+
 func fingerprintDPCplus(loader cartridgeloader.Loader) bool {
 	b := make([]byte, 4)
 	loader.Seek(0x0020, io.SeekStart)
@@ -339,6 +394,22 @@ func fingerprintCDF(loader cartridgeloader.Loader) (bool, string) {
 	return false, ""
 }
 
+func fingerprintBUS(loader cartridgeloader.Loader) bool {
+	// BUS cartridges are always 32k
+	if loader.Size() != 32768 {
+		return false
+	}
+
+	// the BUS string appears at least twice in the ARM driver. fingerprinting
+	// beyond the driver can easily result in a false positive
+	b := make([]byte, 2048)
+	loader.Seek(0, io.SeekStart)
+	if n, err := loader.Read(b); n != len(b) || err != nil {
+		return false
+	}
+	return bytes.Count(b, []byte("BUS")) >= 2
+}
+
 func fingerprintSupercharger(cartload cartridgeloader.Loader) bool {
 	if cartload.IsSoundData {
 		return true
@@ -536,6 +607,10 @@ func fingerprint8k(loader cartridgeloader.Loader) string {
 		return "UA"
 	}
 
+	if fingerprintEconobanking(loader) {
+		return "0840"
+	}
+
 	return "F8"
 }
 
@@ -569,17 +644,29 @@ func fingerprint32k(loader cartridgeloader.Loader) string {
 	if fingerprintTigervision(loader) {
 		return "3F"
 	}
+	if fingerprint4A50(loader) {
+		return "4A50"
+	}
 	return "F4"
 }
 
 func fingerprint64k(loader cartridgeloader.Loader) string {
+	if fingerprint4A50(loader) {
+		return "4A50"
+	}
 	if fingerprintEF(loader) {
 		return "EF"
 	}
+	if fingerprintX07(loader) {
+		return "X07"
+	}
 	return unrecognisedMapper
 }
 
 func fingerprint128k(loader cartridgeloader.Loader) string {
+	if fingerprint4A50(loader) {
+		return "4A50"
+	}
 	if fingerprintDF(loader) {
 		return "DF"
 	}
@@ -656,6 +743,10 @@ func (cart *Cartridge) fingerprint(loader cartridgeloader.Loader) (string, error
 		return version, nil
 	}
 
+	if fingerprintBUS(loader) {
+		return "BUS", nil
+	}
+
 	if fingerprintDPCplus(loader) {
 		return "DPC+", nil
 	}
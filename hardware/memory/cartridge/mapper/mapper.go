@@ -323,6 +323,10 @@ type CartLabels map[uint16]string
 // The index to the returned maps, must be addresses in the cartridge address
 // range. For normality, this should be in the primary cartridge mirror (ie.
 // 0x1000 to 0x1fff).
+//
+// The exception is for hotspots that are outside of the cartridge address
+// range (eg. the 0840 hotspots which are in a TIA mirror). These should be
+// the exact address that the cartridge expects to see.
 type CartHotspotsBus interface {
 	Hotspots() map[uint16]CartHotspotInfo
 }
@@ -372,7 +376,10 @@ type CartROMDump interface {
 // CartBusStuff is implemented by cartridge mappers than can arbitrarily drive
 // the pins on the data bus during a write.
 type CartBusStuff interface {
-	BusStuff() (uint8, bool)
+	// BusStuff is called on every read and write of the data bus. the address
+	// and direction of the access is supplied so that the cartridge can limit
+	// which accesses it drives
+	BusStuff(addr uint16, write bool) (uint8, bool)
 }
 
 // CartPatchable is implemented by cartridge mappers than can have their binary
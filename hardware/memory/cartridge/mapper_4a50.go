@@ -0,0 +1,582 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper/banking"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// 4A50 is a 128k ROM and 32k RAM scheme designed by John Payson. the
+// cartridge space is divided into four segments:
+//
+//	$1000 to $17FF: 2k ROM or RAM (low segment)
+//	$1800 to $1DFF: 1.5k ROM or RAM (middle segment)
+//	$1E00 to $1EFF: 256 bytes ROM or RAM (high segment)
+//	$1F00 to $1FFF: 256 bytes fixed to the last page of ROM
+//
+// the low segment can select any 2k slice of the lower 64k of ROM or any 2k
+// slice of RAM. the middle segment can select any 2k slice of the upper 64k of
+// ROM or any 2k slice of RAM, of which only the first 1.5k is visible. the
+// high segment can select any 256 byte page of the upper 64k of ROM or any
+// page of RAM
+//
+// bank switching is triggered by accesses to addresses outside of the
+// cartridge space. the cartridge must therefore snoop the entire address bus.
+// in all cases a bank switch will only happen if the value previously on the
+// data bus had a pattern of 011xxxxx and if the previous address was either in
+// the cartridge space or in the TIA. ie. an absolute instruction with an
+// operand in the $6000 to $7FFF range. the hotspots are:
+//
+//	$6Cxx: select ROM page xx for the high segment
+//	$6Dxx: select RAM page xx for the high segment
+//	$6E00 to $6E1F: select ROM slice for the low segment
+//	$6E40 to $6E4F: select RAM slice for the low segment
+//	$6F00 to $6F1F: select ROM slice for the middle segment
+//	$6F40 to $6F4F: select RAM slice for the middle segment
+//	$6074: select ROM page for the high segment according to the data value
+//	$6075: select RAM page for the high segment according to the data value
+//	$6078: select a low or middle segment slice according to the data value
+//
+// finally, accesses to $1Fxx, when the previous data bus value follows the same
+// pattern as above, will select a new ROM page for the high segment according
+// to bits 3 to 6 of the address. the upper bits of the page are unchanged
+//
+// supported image sizes are 32k, 64k and 128k. the smaller sizes are
+// duplicated to fill the entire 128k ROM space
+//
+// cartridges:
+//	- Ruby Runner
+
+const (
+	fourA50ROMSize = 0x20000
+	fourA50RAMSize = 0x8000
+
+	fourA50LowSliceSize  = 0x0800
+	fourA50HighSliceSize = 0x0100
+
+	// the number of ROM slices available to each segment
+	fourA50NumLowSlices    = 0x10000 / fourA50LowSliceSize
+	fourA50NumMiddleSlices = 0x10000 / fourA50LowSliceSize
+	fourA50NumHighSlices   = 0x10000 / fourA50HighSliceSize
+
+	// the number of RAM slices available to each segment
+	fourA50NumLowRAMSlices  = fourA50RAMSize / fourA50LowSliceSize
+	fourA50NumHighRAMSlices = fourA50RAMSize / fourA50HighSliceSize
+
+	// disassembly banks for each segment are numbered consecutively
+	fourA50MiddleBankBase = fourA50NumLowSlices
+	fourA50HighBankBase   = fourA50MiddleBankBase + fourA50NumMiddleSlices
+
+	// the fixed page is the same as the last page selectable by the high segment
+	fourA50FixedBank = fourA50HighBankBase + fourA50NumHighSlices - 1
+
+	fourA50Segments = 3
+)
+
+type fourA50 struct {
+	env *environment.Environment
+
+	mappingID string
+
+	// the ROM image normalised to 128k
+	image []uint8
+
+	state *fourA50State
+}
+
+func newFourA50(env *environment.Environment) (mapper.CartMapper, error) {
+	data, err := io.ReadAll(env.Loader)
+	if err != nil {
+		return nil, fmt.Errorf("4A50: %w", err)
+	}
+
+	cart := &fourA50{
+		env:       env,
+		mappingID: "4A50",
+		image:     make([]uint8, fourA50ROMSize),
+		state:     newFourA50State(),
+	}
+
+	switch len(data) {
+	case 0x8000, 0x10000, fourA50ROMSize:
+	default:
+		return nil, fmt.Errorf("%s: wrong number of bytes in the cartridge data", cart.mappingID)
+	}
+
+	for i := 0; i < fourA50ROMSize; i += len(data) {
+		copy(cart.image[i:], data)
+	}
+
+	return cart, nil
+}
+
+// MappedBanks implements the mapper.CartMapper interface.
+func (cart *fourA50) MappedBanks() string {
+	segment := func(isROM bool, slice int) string {
+		if isROM {
+			return fmt.Sprintf("%d", slice)
+		}
+		return fmt.Sprintf("%dR", slice)
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Lo: %s", segment(cart.state.isROMLow, cart.state.sliceLow/fourA50LowSliceSize)))
+	s.WriteString(fmt.Sprintf(" Mid: %s", segment(cart.state.isROMMiddle, cart.state.sliceMiddle/fourA50LowSliceSize)))
+	s.WriteString(fmt.Sprintf(" Hi: %s", segment(cart.state.isROMHigh, cart.state.sliceHigh/fourA50HighSliceSize)))
+	return s.String()
+}
+
+// ID implements the mapper.CartMapper interface.
+func (cart *fourA50) ID() string {
+	return cart.mappingID
+}
+
+// Snapshot implements the mapper.CartMapper interface.
+func (cart *fourA50) Snapshot() mapper.CartMapper {
+	n := *cart
+	n.state = cart.state.Snapshot()
+	return &n
+}
+
+// Plumb implements the mapper.CartMapper interface.
+func (cart *fourA50) Plumb(env *environment.Environment) {
+	cart.env = env
+}
+
+// Reset implements the mapper.CartMapper interface.
+func (cart *fourA50) Reset() error {
+	for i := range cart.state.ram {
+		if cart.env.Prefs.RandomState.Get().(bool) {
+			cart.state.ram[i] = uint8(cart.env.Random.Intn(0xff))
+		} else {
+			cart.state.ram[i] = 0
+		}
+	}
+
+	cart.state.lastData = 0xff
+	cart.state.lastAddress = 0xffff
+	cart.state.pending = false
+
+	cart.SetBank("AUTO")
+
+	return nil
+}
+
+// Access implements the mapper.CartMapper interface.
+func (cart *fourA50) Access(addr uint16, peek bool) (uint8, uint8, error) {
+	var data uint8
+
+	switch {
+	case addr <= 0x07ff:
+		if cart.state.isROMLow {
+			data = cart.image[int(addr&0x07ff)+cart.state.sliceLow]
+		} else {
+			data = cart.state.ram[int(addr&0x07ff)+cart.state.sliceLow]
+		}
+	case addr <= 0x0dff:
+		if cart.state.isROMMiddle {
+			data = cart.image[int(addr&0x07ff)+cart.state.sliceMiddle+0x10000]
+		} else {
+			data = cart.state.ram[int(addr&0x07ff)+cart.state.sliceMiddle]
+		}
+	case addr <= 0x0eff:
+		if cart.state.isROMHigh {
+			data = cart.image[int(addr&0x00ff)+cart.state.sliceHigh+0x10000]
+		} else {
+			data = cart.state.ram[int(addr&0x00ff)+cart.state.sliceHigh]
+		}
+	default:
+		data = cart.image[int(addr&0x00ff)+fourA50ROMSize-fourA50HighSliceSize]
+		if !peek {
+			cart.fixedPage(addr)
+		}
+	}
+
+	if !peek {
+		cart.state.lastData = data
+		cart.state.lastAddress = addr | memorymap.OriginCart
+	}
+
+	return data, mapper.CartDrivenPins, nil
+}
+
+// AccessVolatile implements the mapper.CartMapper interface.
+func (cart *fourA50) AccessVolatile(addr uint16, data uint8, poke bool) error {
+	switch {
+	case addr <= 0x07ff:
+		if !cart.state.isROMLow {
+			cart.state.ram[int(addr&0x07ff)+cart.state.sliceLow] = data
+		} else if poke {
+			cart.image[int(addr&0x07ff)+cart.state.sliceLow] = data
+		}
+	case addr <= 0x0dff:
+		if !cart.state.isROMMiddle {
+			cart.state.ram[int(addr&0x07ff)+cart.state.sliceMiddle] = data
+		} else if poke {
+			cart.image[int(addr&0x07ff)+cart.state.sliceMiddle+0x10000] = data
+		}
+	case addr <= 0x0eff:
+		if !cart.state.isROMHigh {
+			cart.state.ram[int(addr&0x00ff)+cart.state.sliceHigh] = data
+		} else if poke {
+			cart.image[int(addr&0x00ff)+cart.state.sliceHigh+0x10000] = data
+		}
+	default:
+		if poke {
+			cart.image[int(addr&0x00ff)+fourA50ROMSize-fourA50HighSliceSize] = data
+		} else {
+			cart.fixedPage(addr)
+		}
+	}
+
+	if !poke {
+		cart.state.lastData = data
+		cart.state.lastAddress = addr | memorymap.OriginCart
+	}
+
+	return nil
+}
+
+// CartridgeBits implements the mapper.CartConnection interface.
+func (cart *fourA50) CartridgeBits() uint16 {
+	return memorymap.CartridgeBits
+}
+
+// ReadWriteLine implements the mapper.CartConnection interface. the 4A50
+// cartridge can see the R/W line and so RAM is not corrupted by reads.
+func (cart *fourA50) ReadWriteLine() bool {
+	return true
+}
+
+// returns true if the previous bus activity permits a bank switch.
+func (cart *fourA50) switchPermitted() bool {
+	return cart.state.lastData&0xe0 == 0x60 &&
+		(cart.state.lastAddress >= memorymap.OriginCart || cart.state.lastAddress < 0x0200)
+}
+
+// accesses to the fixed page can change the ROM page in the high segment.
+func (cart *fourA50) fixedPage(addr uint16) {
+	if cart.switchPermitted() {
+		cart.state.isROMHigh = true
+		cart.state.sliceHigh = (cart.state.sliceHigh & 0xf0ff) | int(addr&0x08)<<8 | int(addr&0x70)<<4
+	}
+}
+
+// bank switching for accesses outside of the cartridge space. the data value
+// is the value on the data bus at the end of the access.
+func (cart *fourA50) bankswitch(addr uint16, data uint8) {
+	if cart.switchPermitted() {
+		switch {
+		case addr&0x0f00 == 0x0c00:
+			cart.state.isROMHigh = true
+			cart.state.sliceHigh = int(addr&0xff) << 8
+		case addr&0x0f00 == 0x0d00:
+			cart.state.isROMHigh = false
+			cart.state.sliceHigh = int(addr&0x7f) << 8
+		case addr&0x0f40 == 0x0e00:
+			cart.state.isROMLow = true
+			cart.state.sliceLow = int(addr&0x1f) << 11
+		case addr&0x0f40 == 0x0e40:
+			cart.state.isROMLow = false
+			cart.state.sliceLow = int(addr&0x0f) << 11
+		case addr&0x0f40 == 0x0f00:
+			cart.state.isROMMiddle = true
+			cart.state.sliceMiddle = int(addr&0x1f) << 11
+		case addr&0x0f50 == 0x0f40:
+			cart.state.isROMMiddle = false
+			cart.state.sliceMiddle = int(addr&0x0f) << 11
+		case addr&0x0f75 == 0x0074:
+			cart.state.isROMHigh = true
+			cart.state.sliceHigh = int(data) << 8
+		case addr&0x0f75 == 0x0075:
+			cart.state.isROMHigh = false
+			cart.state.sliceHigh = int(data&0x7f) << 8
+		case addr&0x0f7c == 0x0078:
+			switch data & 0xf0 {
+			case 0x00:
+				cart.state.isROMLow = true
+				cart.state.sliceLow = int(data&0x0f) << 11
+			case 0x40:
+				cart.state.isROMLow = false
+				cart.state.sliceLow = int(data&0x0f) << 11
+			case 0x90:
+				cart.state.isROMMiddle = true
+				cart.state.sliceMiddle = (int(data&0x0f) | 0x10) << 11
+			case 0xc0:
+				cart.state.isROMMiddle = false
+				cart.state.sliceMiddle = int(data&0x0f) << 11
+			}
+		}
+	}
+
+	cart.state.lastData = data
+	cart.state.lastAddress = addr
+}
+
+// NumBanks implements the mapper.CartMapper interface. the number of banks is
+// the number of ROM slices that can be selected into each segment.
+func (cart *fourA50) NumBanks() int {
+	return fourA50NumLowSlices + fourA50NumMiddleSlices + fourA50NumHighSlices
+}
+
+// GetBank implements the mapper.CartMapper interface.
+func (cart *fourA50) GetBank(addr uint16) banking.Information {
+	switch {
+	case addr&memorymap.CartridgeBits <= 0x07ff:
+		if cart.state.isROMLow {
+			return banking.Information{Number: cart.state.sliceLow / fourA50LowSliceSize, IsSegmented: true, Segment: 0}
+		}
+		return banking.Information{Number: cart.state.sliceLow / fourA50LowSliceSize, IsRAM: true, IsSegmented: true, Segment: 0}
+	case addr&memorymap.CartridgeBits <= 0x0dff:
+		if cart.state.isROMMiddle {
+			return banking.Information{Number: fourA50MiddleBankBase + cart.state.sliceMiddle/fourA50LowSliceSize, IsSegmented: true, Segment: 1}
+		}
+		return banking.Information{Number: cart.state.sliceMiddle / fourA50LowSliceSize, IsRAM: true, IsSegmented: true, Segment: 1}
+	case addr&memorymap.CartridgeBits <= 0x0eff:
+		if cart.state.isROMHigh {
+			return banking.Information{Number: fourA50HighBankBase + cart.state.sliceHigh/fourA50HighSliceSize, IsSegmented: true, Segment: 2}
+		}
+		return banking.Information{Number: cart.state.sliceHigh / fourA50HighSliceSize, IsRAM: true, IsSegmented: true, Segment: 2}
+	}
+	return banking.Information{Number: fourA50FixedBank, IsSegmented: true, Segment: 3}
+}
+
+// SetBank implements the mapper.CartMapper interface. the bank numbers for
+// each segment are the slice numbers for that segment and not the bank numbers
+// used for disassembly. for example, "2:5R:255" will select ROM slice 2 into
+// the low segment, RAM slice 5 into the middle segment and ROM page 255 into
+// the high segment.
+func (cart *fourA50) SetBank(bank string) error {
+	if banking.IsAutoSelection(bank) {
+		cart.state.sliceLow = 0
+		cart.state.sliceMiddle = 0
+		cart.state.sliceHigh = 0
+		cart.state.isROMLow = true
+		cart.state.isROMMiddle = true
+		cart.state.isROMHigh = true
+		return nil
+	}
+
+	segs, err := banking.SegmentedSelection(bank)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cart.mappingID, err)
+	}
+
+	if len(segs) > fourA50Segments {
+		return fmt.Errorf("%s: too many segments specified (%d)", cart.mappingID, len(segs))
+	}
+
+	// check that the selections are valid before committing any of them
+	limits := [fourA50Segments][2]int{
+		{fourA50NumLowSlices, fourA50NumLowRAMSlices},
+		{fourA50NumMiddleSlices, fourA50NumLowRAMSlices},
+		{fourA50NumHighSlices, fourA50NumHighRAMSlices},
+	}
+	for i, b := range segs {
+		limit := limits[i][0]
+		if b.IsRAM {
+			limit = limits[i][1]
+		}
+		if b.Number >= limit {
+			if b.IsRAM {
+				return fmt.Errorf("%s: segment %d does not have RAM bank '%d'", cart.mappingID, i, b.Number)
+			}
+			return fmt.Errorf("%s: segment %d does not have bank '%d'", cart.mappingID, i, b.Number)
+		}
+	}
+
+	for i, b := range segs {
+		switch i {
+		case 0:
+			cart.state.isROMLow = !b.IsRAM
+			cart.state.sliceLow = b.Number * fourA50LowSliceSize
+		case 1:
+			cart.state.isROMMiddle = !b.IsRAM
+			cart.state.sliceMiddle = b.Number * fourA50LowSliceSize
+		case 2:
+			cart.state.isROMHigh = !b.IsRAM
+			cart.state.sliceHigh = b.Number * fourA50HighSliceSize
+		}
+	}
+
+	return nil
+}
+
+// AccessPassive implements the mapper.CartMapper interface.
+//
+// accesses outside of the cartridge space are not complete until the data bus
+// has settled. for reads, the data bus value given to this function is the
+// value from before the access. we therefore record the address and complete
+// the access on the next change to the address bus, at which point the data
+// value is the value that was on the data bus at the end of the access.
+func (cart *fourA50) AccessPassive(addr uint16, data uint8) error {
+	if cart.state.pending {
+		cart.state.pending = false
+		cart.bankswitch(cart.state.pendingAddress, data)
+	}
+
+	if addr&memorymap.OriginCart != memorymap.OriginCart {
+		cart.state.pending = true
+		cart.state.pendingAddress = addr
+	}
+
+	return nil
+}
+
+// Step implements the mapper.CartMapper interface.
+func (cart *fourA50) Step(_ float32) {
+}
+
+// Patch implements the mapper.CartPatchable interface.
+func (cart *fourA50) Patch(offset int, data uint8) error {
+	if offset >= len(cart.image) {
+		return fmt.Errorf("%s: patch offset too high (%d)", cart.mappingID, offset)
+	}
+	cart.image[offset] = data
+	return nil
+}
+
+// GetRAM implements the mapper.CartRAMBus interface.
+func (cart *fourA50) GetRAM() []mapper.CartRAM {
+	r := make([]mapper.CartRAM, 1)
+	r[0] = mapper.CartRAM{
+		Label:  "RAM",
+		Origin: 0x0000,
+		Data:   make([]uint8, len(cart.state.ram)),
+		Mapped: !cart.state.isROMLow || !cart.state.isROMMiddle || !cart.state.isROMHigh,
+	}
+	copy(r[0].Data, cart.state.ram)
+	return r
+}
+
+// PutRAM implements the mapper.CartRAMBus interface.
+func (cart *fourA50) PutRAM(_ int, idx int, data uint8) {
+	cart.state.ram[idx] = data
+}
+
+// CopyBanks implements the mapper.CartMapper interface.
+//
+// the ROM is presented as a series of slices, one for every slice that can be
+// selected by each segment. the middle and high segment slices overlap one
+// another in the ROM image.
+func (cart *fourA50) CopyBanks() []banking.Content {
+	c := make([]banking.Content, cart.NumBanks())
+
+	for b := range fourA50NumLowSlices {
+		o := b * fourA50LowSliceSize
+		c[b] = banking.Content{Number: b,
+			Data:    cart.image[o : o+fourA50LowSliceSize],
+			Origins: []uint16{memorymap.OriginCartFxxx},
+		}
+	}
+
+	// only the first 1.5k of the middle segment is visible
+	for b := range fourA50NumMiddleSlices {
+		o := 0x10000 + b*fourA50LowSliceSize
+		c[fourA50MiddleBankBase+b] = banking.Content{Number: fourA50MiddleBankBase + b,
+			Data:    cart.image[o : o+fourA50LowSliceSize-0x0200],
+			Origins: []uint16{memorymap.OriginCartFxxx + 0x0800},
+		}
+	}
+
+	for b := range fourA50NumHighSlices {
+		o := 0x10000 + b*fourA50HighSliceSize
+		c[fourA50HighBankBase+b] = banking.Content{Number: fourA50HighBankBase + b,
+			Data:    cart.image[o : o+fourA50HighSliceSize],
+			Origins: []uint16{memorymap.OriginCartFxxx + 0x0e00},
+		}
+	}
+
+	// the last page can be seen in the high segment and in the fixed page
+	c[fourA50FixedBank].Origins = append(c[fourA50FixedBank].Origins, memorymap.OriginCartFxxx+0x0f00)
+
+	return c
+}
+
+// Hotspots implements the mapper.CartHotspotsBus interface.
+func (cart *fourA50) Hotspots() map[uint16]mapper.CartHotspotInfo {
+	h := map[uint16]mapper.CartHotspotInfo{
+		0x6074: {Symbol: "ROMHI", Action: mapper.HotspotBankSwitch},
+		0x6075: {Symbol: "RAMHI", Action: mapper.HotspotBankSwitch},
+		0x6078: {Symbol: "LOMID", Action: mapper.HotspotBankSwitch},
+	}
+	for i := range fourA50NumHighSlices {
+		h[0x6c00|uint16(i)] = mapper.CartHotspotInfo{Symbol: fmt.Sprintf("ROMHI%d", i), Action: mapper.HotspotBankSwitch}
+	}
+	for i := range fourA50NumHighRAMSlices {
+		h[0x6d00|uint16(i)] = mapper.CartHotspotInfo{Symbol: fmt.Sprintf("RAMHI%d", i), Action: mapper.HotspotBankSwitch}
+	}
+	for i := range fourA50NumLowSlices {
+		h[0x6e00|uint16(i)] = mapper.CartHotspotInfo{Symbol: fmt.Sprintf("ROMLO%d", i), Action: mapper.HotspotBankSwitch}
+		h[0x6f00|uint16(i)] = mapper.CartHotspotInfo{Symbol: fmt.Sprintf("ROMMID%d", i), Action: mapper.HotspotBankSwitch}
+	}
+	for i := range fourA50NumLowRAMSlices {
+		h[0x6e40|uint16(i)] = mapper.CartHotspotInfo{Symbol: fmt.Sprintf("RAMLO%d", i), Action: mapper.HotspotBankSwitch}
+		h[0x6f40|uint16(i)] = mapper.CartHotspotInfo{Symbol: fmt.Sprintf("RAMMID%d", i), Action: mapper.HotspotBankSwitch}
+	}
+	return h
+}
+
+type fourA50State struct {
+	ram []uint8
+
+	// offsets into the ROM or RAM for each segment. for the middle and high
+	// segments, the ROM offset is relative to the upper 64k of ROM
+	sliceLow    int
+	sliceMiddle int
+	sliceHigh   int
+
+	// whether each segment is pointing to ROM or RAM
+	isROMLow    bool
+	isROMMiddle bool
+	isROMHigh   bool
+
+	// the most recent data and address seen on the bus. bank switching
+	// depends on the previous bus activity
+	lastData    uint8
+	lastAddress uint16
+
+	// an access outside of the cartridge space that is yet to be completed.
+	// see AccessPassive() for details
+	pending        bool
+	pendingAddress uint16
+}
+
+func newFourA50State() *fourA50State {
+	return &fourA50State{
+		ram:         make([]uint8, fourA50RAMSize),
+		isROMLow:    true,
+		isROMMiddle: true,
+		isROMHigh:   true,
+		lastData:    0xff,
+		lastAddress: 0xffff,
+	}
+}
+
+// Snapshot implements the mapper.CartMapper interface.
+func (s *fourA50State) Snapshot() *fourA50State {
+	n := *s
+	n.ram = make([]uint8, len(s.ram))
+	copy(n.ram, s.ram)
+	return &n
+}
@@ -0,0 +1,201 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// create a 4A50 cartridge where every byte in the lower 64k is the number of
+// the 2k slice and every byte in the upper 64k is the number of the 256 byte
+// page
+func newTestFourA50(t *testing.T) *fourA50 {
+	t.Helper()
+
+	data := append(bankNumberedData(0x10000, 0x0800), bankNumberedData(0x10000, 0x0100)...)
+	cart, err := newFourA50(&environment.Environment{Loader: newTestLoader(t, data, "4A50")})
+	test.ExpectSuccess(t, err)
+
+	return cart.(*fourA50)
+}
+
+// the address bus activity of the LDA ($80),Y instruction, with the Y register
+// set to zero, up to the point where the effective address is put on the
+// address bus. the pointer at $80 is in RIOT RAM so the last value on the data
+// bus is the high byte of the effective address
+func testPointer(t *testing.T, cart *fourA50, addr uint16) {
+	t.Helper()
+	test.ExpectSuccess(t, cart.AccessPassive(0x0080, 0x80))
+	test.ExpectSuccess(t, cart.AccessPassive(0x0081, uint8(addr)))
+	test.ExpectSuccess(t, cart.AccessPassive(addr&0x1fff, uint8(addr>>8)))
+}
+
+// access a hotspot outside of the cartridge space with the LDA ($80),Y
+// instruction. the data value is the value on the data bus at the end of the
+// access
+func testHotspot(t *testing.T, cart *fourA50, addr uint16, data uint8) {
+	t.Helper()
+	testPointer(t, cart, addr)
+	test.ExpectSuccess(t, cart.AccessPassive(0x1000, data))
+}
+
+func TestFourA50_ROM(t *testing.T) {
+	cart := newTestFourA50(t)
+
+	test.ExpectEquality(t, testRead(t, cart, 0x0000), 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0800), 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0f00), 0xff)
+
+	// address based hotspots
+	testHotspot(t, cart, 0x6e05, 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0000), 5)
+	testHotspot(t, cart, 0x6f03, 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0800), 3*8)
+	testHotspot(t, cart, 0x6c42, 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0x42)
+
+	// value based hotspots
+	testHotspot(t, cart, 0x6074, 0x20)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0x20)
+	testHotspot(t, cart, 0x6078, 0x93)
+	test.ExpectEquality(t, testRead(t, cart, 0x0800), 19*8)
+	testHotspot(t, cart, 0x6078, 0x07)
+	test.ExpectEquality(t, testRead(t, cart, 0x0000), 7)
+
+	// the hotspots have no effect if the previous data value is not of the
+	// correct pattern
+	testHotspot(t, cart, 0x0e01, 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0000), 7)
+	testHotspot(t, cart, 0x8e01, 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0000), 7)
+
+	// accesses to the fixed page change the high segment. the high byte of
+	// the pointer is a mirror of the cartridge space
+	testHotspot(t, cart, 0x6c00, 0)
+	testPointer(t, cart, 0x7f38)
+	_, _, err := cart.Access(0x0f38, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0x0b)
+
+	// the fixed page is not a hotspot when peeking
+	testPointer(t, cart, 0x7f08)
+	_, _, err = cart.Access(0x0f08, true)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0x0b)
+
+	// and the fixed page is not a hotspot if the previous data value is not
+	// of the correct pattern
+	testPointer(t, cart, 0x1f08)
+	_, _, err = cart.Access(0x0f08, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0x0b)
+}
+
+func TestFourA50_RAM(t *testing.T) {
+	cart := newTestFourA50(t)
+
+	// select RAM slice 1 into the low segment and write to it
+	testHotspot(t, cart, 0x6e41, 0)
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xaa, false))
+	test.ExpectEquality(t, testRead(t, cart, 0x0010), 0xaa)
+
+	// the same RAM is visible through page 8 in the high segment
+	testHotspot(t, cart, 0x6d08, 0)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e10), 0xaa)
+
+	// and through RAM slice 1 in the middle segment
+	testHotspot(t, cart, 0x6078, 0xc1)
+	test.ExpectEquality(t, testRead(t, cart, 0x0810), 0xaa)
+
+	r := cart.GetRAM()
+	test.ExpectEquality(t, len(r), 1)
+	test.ExpectEquality(t, r[0].Data[0x0810], 0xaa)
+
+	// writes to ROM segments have no effect
+	testHotspot(t, cart, 0x6e00, 0)
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xbb, false))
+	test.ExpectEquality(t, testRead(t, cart, 0x0010), 0)
+}
+
+func TestFourA50_disassembly(t *testing.T) {
+	cart := newTestFourA50(t)
+
+	banks := cart.CopyBanks()
+	test.ExpectEquality(t, len(banks), cart.NumBanks())
+
+	// the fixed page is the last bank
+	b := cart.GetBank(0x0ffc)
+	test.ExpectEquality(t, b.Number, len(banks)-1)
+	test.ExpectEquality(t, banks[b.Number].Data[0xfc], 0xff)
+	test.ExpectEquality(t, len(banks[b.Number].Origins), 2)
+
+	// banks reported by GetBank() match the data in CopyBanks()
+	testHotspot(t, cart, 0x6f03, 0)
+	b = cart.GetBank(0x0800)
+	test.ExpectEquality(t, b.Segment, 1)
+	test.ExpectEquality(t, banks[b.Number].Data[0], 3*8)
+
+	testHotspot(t, cart, 0x6c42, 0)
+	b = cart.GetBank(0x0e00)
+	test.ExpectEquality(t, b.Segment, 2)
+	test.ExpectEquality(t, banks[b.Number].Data[0], 0x42)
+
+	// bank selection by segment
+	test.ExpectSuccess(t, cart.SetBank("2:5R:255"))
+	test.ExpectEquality(t, testRead(t, cart, 0x0000), 2)
+	test.ExpectEquality(t, cart.GetBank(0x0800).IsRAM, true)
+	test.ExpectEquality(t, testRead(t, cart, 0x0e00), 0xff)
+	test.ExpectFailure(t, cart.SetBank("32"))
+	test.ExpectFailure(t, cart.SetBank("0:16R"))
+}
+
+func TestFourA50_fingerprint(t *testing.T) {
+	// the address $4A50 in the NMI vector
+	data := make([]byte, 0x10000)
+	copy(data[len(data)-6:], []byte{0x50, 0x4a})
+	test.ExpectEquality(t, fingerprint64k(newTestLoader(t, data, "AUTO")), "4A50")
+
+	test.ExpectInequality(t, fingerprint64k(newTestLoader(t, make([]byte, 0x10000), "AUTO")), "4A50")
+
+	// the NMI vector is not used for 32k cartridges
+	data = make([]byte, 0x8000)
+	copy(data[len(data)-6:], []byte{0x50, 0x4a})
+	test.ExpectInequality(t, fingerprint32k(newTestLoader(t, data, "AUTO")), "4A50")
+
+	// the reset vector points to the fixed page and the first instruction is
+	// NOP $6Exx or NOP $6Fxx
+	for _, op := range []byte{0x6e, 0x6f} {
+		data = make([]byte, 0x8000)
+		copy(data[len(data)-4:], []byte{0x20, 0xff})
+		copy(data[len(data)-0x100+0x20:], []byte{0x0c, 0x00, op})
+		test.ExpectEquality(t, fingerprint32k(newTestLoader(t, data, "AUTO")), "4A50")
+	}
+
+	// the first instruction is not NOP $6Exx or NOP $6Fxx
+	copy(data[len(data)-0x100+0x20:], []byte{0x0c, 0x00, 0x6c})
+	test.ExpectInequality(t, fingerprint32k(newTestLoader(t, data, "AUTO")), "4A50")
+	copy(data[len(data)-0x100+0x20:], []byte{0xea, 0x00, 0x6e})
+	test.ExpectInequality(t, fingerprint32k(newTestLoader(t, data, "AUTO")), "4A50")
+
+	// the reset vector does not point to the fixed page
+	copy(data[len(data)-0x100+0x20:], []byte{0x0c, 0x00, 0x6e})
+	copy(data[len(data)-4:], []byte{0x20, 0xf0})
+	test.ExpectInequality(t, fingerprint32k(newTestLoader(t, data, "AUTO")), "4A50")
+}
@@ -0,0 +1,758 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper/banking"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/logger"
+	"github.com/jetsetilly/gopher2600/resources"
+)
+
+// chetiry implements the CTY bank switching scheme used by the game Chetiry.
+// the scheme was designed for the Harmony cartridge and uses the ARM
+// processor in that cartridge to emulate extra hardware.
+//
+// the cartridge has eight banks of 4096 bytes. the first bank contains the
+// ARM driver and cannot be selected by the 6507. banks one to seven are
+// selected by accessing addresses $1FF5 to $1FFB
+//
+// there are 64 bytes of RAM. the write port is at $1000 to $103F and the read
+// port is at $1040 to $107F. the first four bytes of each port are registers
+// rather than RAM:
+//
+//	$1000 (write): the operation to perform when $1FF4 is next accessed
+//	$1001 (write): reset random number generator
+//	$1002 (write): reset tune to the beginning
+//	$1003 (write): advance tune to the next position
+//	$1040 (read): result of the most recent operation (zero is success)
+//	$1041 (read): next random number
+//	$1042 (read): tune position (low byte)
+//	$1043 (read): tune position (high byte)
+//
+// accessing $1FF4 starts the operation in the operation register. the value
+// of the register is in the form iiiioooo, where iiii is an index value and
+// oooo is the operation:
+//
+//	1: load tune (index is the tune number)
+//	2: load score table (index is the table number)
+//	3: save score table (index is the table number)
+//	4: wipe all score tables
+//
+// the value read from $1FF4 will have bit 6 set while the operation is in
+// progress. once the operation is complete bit 6 will be clear and the result
+// register will be zero
+//
+// score tables are stored in the EEPROM of the Harmony cartridge. in the
+// emulation the EEPROM is stored in the resources path and is unique to the
+// ROM
+//
+// score tables are only written to the EEPROM by the main emulation and never
+// while the emulation is rewinding. they are still read from the EEPROM so that the emulation is correct
+//
+// tune data is not accessible to the 6507 and is stored in the Harmony flash
+// memory. the tune data is not part of the published ROM and is not included
+// with the emulator. music is therefore not supported unless the tune data has
+// been appended to the 32k of cartridge data. without the tune data all tunes
+// are silent but otherwise the game is unaffected
+//
+// the music fetcher is read with an "LDA #$F2" instruction. the immediate
+// value is replaced with the current volume of the tune
+type chetiry struct {
+	env *environment.Environment
+
+	mappingID string
+
+	// chetiry cartridges have 8 banks of 4096 bytes
+	bankSize int
+	banks    [][]uint8
+
+	// tune data appended to the cartridge data. missing tune data is zero
+	tunes []uint8
+
+	// rewindable state
+	state *chetiryState
+}
+
+const (
+	chetiryNumBanks = 8
+	chetiryRAMsize  = 64
+
+	// tune data consists of seven tunes of 4096 bytes
+	chetiryTuneSize = 4096
+	chetiryNumTunes = 7
+
+	// score tables are 64 bytes. the first four bytes of each table are not
+	// used because they correspond to the register addresses in RAM
+	chetiryScoreTableSize = 64
+	chetiryNumScoreTables = 4
+	chetiryEEPROMsize     = chetiryScoreTableSize * chetiryNumScoreTables
+
+	// the time taken for the Harmony to complete an operation, in microseconds
+	chetiryReadTime  = 500000
+	chetiryWriteTime = 1000000
+)
+
+// the resource path to the eeprom files
+const cty_eeprom = "cty_eeprom"
+
+// frequency values for each note in the tune data. note zero is silence and
+// note one is C2. the values are the amount added to the music fetcher's
+// counter on every tick of the 20KHz clock
+var chetiryFrequencies [64]uint32
+
+func init() {
+	const c2 = 65.406
+	for n := 1; n < len(chetiryFrequencies); n++ {
+		hz := c2 * math.Pow(2, float64(n-1)/12)
+		chetiryFrequencies[n] = uint32(hz * (1 << 32) / 20000)
+	}
+}
+
+func newChetiry(env *environment.Environment) (mapper.CartMapper, error) {
+	data, err := io.ReadAll(env.Loader)
+	if err != nil {
+		return nil, fmt.Errorf("CTY: %w", err)
+	}
+
+	cart := &chetiry{
+		env:       env,
+		mappingID: "CTY",
+		bankSize:  4096,
+		state:     newChetiryState(),
+	}
+
+	romSize := cart.bankSize * chetiryNumBanks
+	if len(data) < romSize || len(data) > romSize+chetiryTuneSize*chetiryNumTunes {
+		return nil, fmt.Errorf("%s: wrong number of bytes in the cartridge data", cart.mappingID)
+	}
+
+	cart.banks = make([][]uint8, chetiryNumBanks)
+	for k := 0; k < cart.NumBanks(); k++ {
+		cart.banks[k] = make([]uint8, cart.bankSize)
+		offset := k * cart.bankSize
+		copy(cart.banks[k], data[offset:offset+cart.bankSize])
+	}
+
+	// the tune data is always the maximum size
+	cart.tunes = make([]uint8, chetiryTuneSize*chetiryNumTunes)
+	copy(cart.tunes, data[romSize:])
+	if len(data) == romSize {
+		logger.Log(env, "CTY", "no tune data in cartridge. music is not supported")
+	}
+
+	return cart, nil
+}
+
+// MappedBanks implements the mapper.CartMapper interface.
+func (cart *chetiry) MappedBanks() string {
+	return fmt.Sprintf("Bank: %d", cart.state.bank)
+}
+
+// ID implements the mapper.CartMapper interface.
+func (cart *chetiry) ID() string {
+	return cart.mappingID
+}
+
+// Snapshot implements the mapper.CartMapper interface.
+func (cart *chetiry) Snapshot() mapper.CartMapper {
+	n := *cart
+	n.state = cart.state.Snapshot()
+	return &n
+}
+
+// Plumb implements the mapper.CartMapper interface.
+func (cart *chetiry) Plumb(env *environment.Environment) {
+	cart.env = env
+}
+
+// Reset implements the mapper.CartMapper interface.
+func (cart *chetiry) Reset() error {
+	for i := range cart.state.ram {
+		if cart.env.Prefs.RandomState.Get().(bool) {
+			cart.state.ram[i] = uint8(cart.env.Random.Intn(0xff))
+		} else {
+			cart.state.ram[i] = 0
+		}
+	}
+
+	cart.state.registers.reset()
+	cart.state.lda = false
+	cart.state.beats = 0
+
+	cart.SetBank("AUTO")
+
+	return nil
+}
+
+// Access implements the mapper.CartMapper interface.
+func (cart *chetiry) Access(addr uint16, peek bool) (uint8, uint8, error) {
+	if addr <= 0x003f {
+		return cart.state.ram[addr], mapper.CartDrivenPins, nil
+	}
+
+	if addr <= 0x007f {
+		addr -= 0x0040
+		switch addr {
+		case 0x00:
+			return cart.state.ram[0], mapper.CartDrivenPins, nil
+		case 0x01:
+			if !peek {
+				cart.state.registers.nextRNG()
+			}
+			return uint8(cart.state.registers.RNG), mapper.CartDrivenPins, nil
+		case 0x02:
+			return uint8(cart.state.registers.TunePosition), mapper.CartDrivenPins, nil
+		case 0x03:
+			return uint8(cart.state.registers.TunePosition >> 8), mapper.CartDrivenPins, nil
+		}
+		return cart.state.ram[addr], mapper.CartDrivenPins, nil
+	}
+
+	data := cart.banks[cart.state.bank][addr]
+
+	if peek {
+		return data, mapper.CartDrivenPins, nil
+	}
+
+	// the operation hotspot is only handled by Access() and not by
+	// AccessVolatile(). the memory system calls both functions when a
+	// cartridge address is read and the operation must only be started once
+	if addr == 0x0ff4 {
+		cart.state.lda = false
+		return cart.operation(), mapper.CartDrivenPins, nil
+	}
+
+	if cart.bankswitch(addr) {
+		cart.state.lda = false
+		return data, mapper.CartDrivenPins, nil
+	}
+
+	// the preceding data value was 0xa9 (the opcode for LDA <immediate>) and
+	// the operand is 0xf2 so we return the current volume of the tune
+	if cart.state.lda && data == 0xf2 {
+		cart.state.lda = false
+		return cart.state.registers.volume(), mapper.CartDrivenPins, nil
+	}
+
+	cart.state.lda = data == 0xa9
+
+	return data, mapper.CartDrivenPins, nil
+}
+
+// AccessVolatile implements the mapper.CartMapper interface.
+func (cart *chetiry) AccessVolatile(addr uint16, data uint8, poke bool) error {
+	// poking the registers changes the underlying RAM rather than the register
+	if poke && addr <= 0x007f {
+		cart.state.ram[addr&0x003f] = data
+		return nil
+	}
+
+	if addr <= 0x003f {
+		switch addr {
+		case 0x00:
+			cart.state.registers.Operation = data
+		case 0x01:
+			cart.state.registers.RNG = chetiryRNGseed
+		case 0x02:
+			cart.state.registers.resetTune()
+		case 0x03:
+			cart.advanceTune()
+		default:
+			cart.state.ram[addr] = data
+		}
+		return nil
+	}
+
+	if poke {
+		cart.banks[cart.state.bank][addr] = data
+		return nil
+	}
+
+	cart.bankswitch(addr)
+
+	return nil
+}
+
+// bankswitch on hotspot access.
+func (cart *chetiry) bankswitch(addr uint16) bool {
+	if addr >= 0x0ff5 && addr <= 0x0ffb {
+		cart.state.bank = int(addr - 0x0ff4)
+		return true
+	}
+	return false
+}
+
+// operation is called when the operation hotspot is accessed. the return
+// value is the value to put on the data bus.
+func (cart *chetiry) operation() uint8 {
+	const busy = 0x40
+	data := cart.banks[cart.state.bank][0x0ff4]
+
+	if cart.state.registers.Busy {
+		if cart.state.busyTime > 0 {
+			return data | busy
+		}
+		cart.state.registers.Busy = false
+		cart.state.ram[0] = 0
+		return data &^ busy
+	}
+
+	index := cart.state.registers.Operation >> 4
+
+	switch cart.state.registers.Operation & 0x0f {
+	case 1:
+		if index < chetiryNumTunes {
+			cart.state.registers.Tune = int(index)
+			cart.state.registers.resetTune()
+			cart.startOperation(chetiryReadTime)
+		}
+	case 2:
+		if index < chetiryNumScoreTables {
+			cart.loadScoreTable(int(index))
+			cart.startOperation(chetiryReadTime)
+		}
+	case 3:
+		if index < chetiryNumScoreTables {
+			if cart.allowEEPROMWrite() {
+				cart.saveScoreTable(int(index))
+			}
+			cart.startOperation(chetiryWriteTime)
+		}
+	case 4:
+		if cart.allowEEPROMWrite() {
+			cart.writeEEPROM(make([]uint8, chetiryEEPROMsize))
+		}
+		cart.startOperation(chetiryWriteTime)
+	}
+
+	return data | busy
+}
+
+// the EEPROM file is shared with the user's other sessions so it should only be
+// changed by the main emulation and never while rewinding
+func (cart *chetiry) allowEEPROMWrite() bool {
+	return cart.env.IsEmulation(environment.MainEmulation) && !cart.env.IsRewinding()
+}
+
+func (cart *chetiry) startOperation(duration float32) {
+	cart.state.registers.Busy = true
+	cart.state.busyTime = duration
+}
+
+func (cart *chetiry) advanceTune() {
+	r := &cart.state.registers
+	r.TunePosition++
+	p := cart.state.registers.Tune*chetiryTuneSize + (int(r.TunePosition)-1)*len(r.MusicFetcher)
+	for i := range r.MusicFetcher {
+		if p+i < len(cart.tunes) {
+			r.MusicFetcher[i].Freq = chetiryFrequencies[cart.tunes[p+i]&0x3f]
+		} else {
+			r.MusicFetcher[i].Freq = 0
+		}
+	}
+}
+
+// readEEPROM always returns a slice of the correct size even if the EEPROM
+// file cannot be read.
+func (cart *chetiry) readEEPROM() []uint8 {
+	eeprom := make([]uint8, chetiryEEPROMsize)
+
+	p, err := resources.JoinLoadPath(cty_eeprom, cart.env.Loader.HashMD5)
+	if err != nil {
+		// it's not an error if the file doesn't exist yet
+		return eeprom
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+		return eeprom
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+		return eeprom
+	}
+
+	if st.Size() != chetiryEEPROMsize {
+		logger.Logf(cart.env, "CTY", "%s is not %d bytes in size", p, chetiryEEPROMsize)
+		return eeprom
+	}
+
+	_, err = io.ReadFull(f, eeprom)
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+		return make([]uint8, chetiryEEPROMsize)
+	}
+
+	return eeprom
+}
+
+func (cart *chetiry) writeEEPROM(eeprom []uint8) {
+	p, err := resources.JoinPath(cty_eeprom, cart.env.Loader.HashMD5)
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+		return
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+		return
+	}
+
+	n, err := f.Write(eeprom)
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+	}
+	if n != len(eeprom) {
+		logger.Logf(cart.env, "CTY", "failed to write %d bytes to eeprom file", len(eeprom))
+	}
+	err = f.Close()
+	if err != nil {
+		logger.Log(cart.env, "CTY", err)
+	}
+}
+
+// the first four bytes of a score table are not copied to or from RAM
+func (cart *chetiry) loadScoreTable(index int) {
+	eeprom := cart.readEEPROM()
+	offset := index * chetiryScoreTableSize
+	copy(cart.state.ram[4:], eeprom[offset+4:offset+chetiryScoreTableSize])
+}
+
+func (cart *chetiry) saveScoreTable(index int) {
+	eeprom := cart.readEEPROM()
+	offset := index * chetiryScoreTableSize
+	copy(eeprom[offset+4:offset+chetiryScoreTableSize], cart.state.ram[4:])
+	cart.writeEEPROM(eeprom)
+}
+
+// NumBanks implements the mapper.CartMapper interface.
+func (cart *chetiry) NumBanks() int {
+	return chetiryNumBanks
+}
+
+// GetBank implements the mapper.CartMapper interface.
+func (cart *chetiry) GetBank(addr uint16) banking.Information {
+	return banking.Information{Number: cart.state.bank, IsRAM: addr <= 0x007f}
+}
+
+// SetBank implements the mapper.CartMapper interface.
+func (cart *chetiry) SetBank(bank string) error {
+	if banking.IsAutoSelection(bank) {
+		cart.state.bank = 1
+		return nil
+	}
+
+	b, err := banking.SingleSelection(bank)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cart.mappingID, err)
+	}
+
+	if b.Number >= len(cart.banks) {
+		return fmt.Errorf("%s: cartridge does not have bank '%d'", cart.mappingID, b.Number)
+	}
+	if b.Number == 0 {
+		return fmt.Errorf("%s: bank '0' is not accessible to the 6507", cart.mappingID)
+	}
+	if b.IsRAM {
+		return fmt.Errorf("%s: cartridge does not have bankable RAM", cart.mappingID)
+	}
+
+	cart.state.bank = b.Number
+
+	return nil
+}
+
+// Patch implements the mapper.CartPatchable interface.
+func (cart *chetiry) Patch(offset int, data uint8) error {
+	if offset >= cart.bankSize*len(cart.banks) {
+		return fmt.Errorf("%s: patch offset too high (%d)", cart.mappingID, offset)
+	}
+
+	bank := offset / cart.bankSize
+	offset %= cart.bankSize
+	cart.banks[bank][offset] = data
+	return nil
+}
+
+// AccessPassive implements the mapper.CartMapper interface.
+func (cart *chetiry) AccessPassive(_ uint16, _ uint8) error {
+	return nil
+}
+
+// Step implements the mapper.CartMapper interface.
+func (cart *chetiry) Step(clock float32) {
+	// the time taken by the most recent operation
+	if cart.state.busyTime > 0 {
+		cart.state.busyTime -= 1 / clock
+	}
+
+	// the music fetchers are clocked at 20KHz. see the dpc mapper for
+	// commentary
+	divisor := int(clock * 50)
+
+	cart.state.beats++
+	if cart.state.beats%divisor == 0 {
+		cart.state.beats = 0
+		for i := range cart.state.registers.MusicFetcher {
+			cart.state.registers.MusicFetcher[i].Count += cart.state.registers.MusicFetcher[i].Freq
+		}
+	}
+}
+
+// GetRAM implements the mapper.CartRAMBus interface.
+func (cart *chetiry) GetRAM() []mapper.CartRAM {
+	r := make([]mapper.CartRAM, 1)
+	r[0] = mapper.CartRAM{
+		Label:  "CTY",
+		Origin: 0xf000,
+		Data:   make([]uint8, len(cart.state.ram)),
+		Mapped: true,
+	}
+	copy(r[0].Data, cart.state.ram)
+	return r
+}
+
+// PutRAM implements the mapper.CartRAMBus interface.
+func (cart *chetiry) PutRAM(_ int, idx int, data uint8) {
+	cart.state.ram[idx] = data
+}
+
+// GetRegisters implements the mapper.CartRegistersBus interface.
+func (cart *chetiry) GetRegisters() mapper.CartRegisters {
+	return cart.state.registers
+}
+
+// PutRegister implements the mapper.CartRegistersBus interface.
+//
+// Register specification is divided with the "::" string. The following table
+// describes what the valid register strings and, after the = sign, the type to
+// which the data argument will be converted.
+//
+//	operation = uint8
+//	rng = uint32
+//	tune = int
+//	tune::position = uint16
+//	musicfetcher::%int::freq = uint32
+//	musicfetcher::%int::count = uint32
+//
+// note that PutRegister() will panic() if the register or data string is invalid.
+func (cart *chetiry) PutRegister(register string, data string) {
+	d32, _ := strconv.ParseUint(data, 16, 32)
+
+	r := strings.Split(register, "::")
+	switch r[0] {
+	case "operation":
+		cart.state.registers.Operation = uint8(d32)
+	case "rng":
+		cart.state.registers.RNG = uint32(d32)
+	case "tune":
+		if len(r) > 1 {
+			switch r[1] {
+			case "position":
+				cart.state.registers.TunePosition = uint16(d32)
+			default:
+				panic(fmt.Sprintf("unrecognised variable [%s]", register))
+			}
+			break // switch
+		}
+		t, err := strconv.Atoi(data)
+		if err != nil || t < 0 || t >= chetiryNumTunes {
+			panic(fmt.Sprintf("unrecognised tune [%s]", data))
+		}
+		cart.state.registers.Tune = t
+	case "musicfetcher":
+		f, err := strconv.Atoi(r[1])
+		if err != nil || f >= len(cart.state.registers.MusicFetcher) {
+			panic(fmt.Sprintf("unrecognised register [%s]", register))
+		}
+
+		switch r[2] {
+		case "freq":
+			cart.state.registers.MusicFetcher[f].Freq = uint32(d32)
+		case "count":
+			cart.state.registers.MusicFetcher[f].Count = uint32(d32)
+		default:
+			panic(fmt.Sprintf("unrecognised variable [%s]", register))
+		}
+	default:
+		panic(fmt.Sprintf("unrecognised variable [%s]", register))
+	}
+}
+
+// CopyBanks implements the mapper.CartMapper interface.
+func (cart *chetiry) CopyBanks() []banking.Content {
+	c := make([]banking.Content, len(cart.banks))
+	for b := 0; b < len(cart.banks); b++ {
+		c[b] = banking.Content{Number: b,
+			Data:    cart.banks[b],
+			Origins: []uint16{memorymap.OriginCartFxxx},
+		}
+	}
+	return c
+}
+
+// Hotspots implements the mapper.CartHotspotsBus interface.
+func (cart *chetiry) Hotspots() map[uint16]mapper.CartHotspotInfo {
+	return map[uint16]mapper.CartHotspotInfo{
+		0x1000: {Symbol: "OPERATION", Action: mapper.HotspotWriteRegister},
+		0x1001: {Symbol: "RNGRESET", Action: mapper.HotspotFunction},
+		0x1002: {Symbol: "TUNERESET", Action: mapper.HotspotFunction},
+		0x1003: {Symbol: "TUNENEXT", Action: mapper.HotspotFunction},
+		0x1040: {Symbol: "RESULT", Action: mapper.HotspotReadRegister},
+		0x1041: {Symbol: "RNG", Action: mapper.HotspotReadRegister},
+		0x1042: {Symbol: "TUNEPOSLO", Action: mapper.HotspotReadRegister},
+		0x1043: {Symbol: "TUNEPOSHI", Action: mapper.HotspotReadRegister},
+		0x1ff4: {Symbol: "START", Action: mapper.HotspotFunction},
+		0x1ff5: {Symbol: "BANK1", Action: mapper.HotspotBankSwitch},
+		0x1ff6: {Symbol: "BANK2", Action: mapper.HotspotBankSwitch},
+		0x1ff7: {Symbol: "BANK3", Action: mapper.HotspotBankSwitch},
+		0x1ff8: {Symbol: "BANK4", Action: mapper.HotspotBankSwitch},
+		0x1ff9: {Symbol: "BANK5", Action: mapper.HotspotBankSwitch},
+		0x1ffa: {Symbol: "BANK6", Action: mapper.HotspotBankSwitch},
+		0x1ffb: {Symbol: "BANK7", Action: mapper.HotspotBankSwitch},
+	}
+}
+
+// the value the random number generator is set to on reset
+const chetiryRNGseed = 0x2b435044
+
+// CTYregisters implements the mapper.CartRegisters interface.
+type CTYregisters struct {
+	// the operation to perform on the next access of the operation hotspot
+	Operation uint8
+
+	// an operation is in progress
+	Busy bool
+
+	// the current random number value
+	RNG uint32
+
+	// the tune being played and the position in the tune
+	Tune         int
+	TunePosition uint16
+
+	MusicFetcher [3]CTYmusicFetcher
+}
+
+// CTYmusicFetcher represents a single CTY music fetcher.
+type CTYmusicFetcher struct {
+	Freq  uint32
+	Count uint32
+}
+
+func (r CTYregisters) String() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Operation: %#02x", r.Operation))
+	if r.Busy {
+		s.WriteString(" (busy)")
+	}
+	s.WriteString("\n")
+	s.WriteString(fmt.Sprintf("RNG: %#08x\n", r.RNG))
+	s.WriteString(fmt.Sprintf("Tune: %d Position: %d\n", r.Tune, r.TunePosition))
+	for f := range len(r.MusicFetcher) {
+		s.WriteString(fmt.Sprintf("M%d: f:%#08x c:%#08x", f,
+			r.MusicFetcher[f].Freq,
+			r.MusicFetcher[f].Count,
+		))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+func (r *CTYregisters) reset() {
+	r.Operation = 0
+	r.Busy = false
+	r.RNG = chetiryRNGseed
+	r.Tune = 0
+	r.resetTune()
+}
+
+func (r *CTYregisters) resetTune() {
+	r.TunePosition = 0
+	for i := range r.MusicFetcher {
+		r.MusicFetcher[i].Freq = 0
+		r.MusicFetcher[i].Count = 0
+	}
+}
+
+func (r *CTYregisters) nextRNG() {
+	if r.RNG&(1<<10) != 0 {
+		r.RNG = 0x10adab1e ^ ((r.RNG >> 11) | (r.RNG << 21))
+	} else {
+		r.RNG = (r.RNG >> 11) | (r.RNG << 21)
+	}
+}
+
+// the three music fetchers are square waves and share the 4bit volume range
+func (r *CTYregisters) volume() uint8 {
+	var v uint8
+	for i := range r.MusicFetcher {
+		v += uint8(r.MusicFetcher[i].Count>>31) * 5
+	}
+	return v
+}
+
+// rewindable state for the CTY cartridge.
+type chetiryState struct {
+	// currently selected bank
+	bank int
+
+	// RAM is directly accessible by the VCS except for the first four bytes,
+	// which are registers when written to or read from
+	ram []uint8
+
+	// registers are returned by the GetRegisters() function
+	registers CTYregisters
+
+	// the number of microseconds until the current operation is complete
+	busyTime float32
+
+	// the preceding data value was the opcode for LDA <immediate>
+	lda bool
+
+	// the music fetchers are clocked at a slower rate than the VCS. see the
+	// Step() function for details
+	beats int
+}
+
+func newChetiryState() *chetiryState {
+	return &chetiryState{
+		ram: make([]uint8, chetiryRAMsize),
+	}
+}
+
+// Snapshot implements the mapper.CartMapper interface.
+func (s *chetiryState) Snapshot() *chetiryState {
+	n := *s
+	n.ram = make([]uint8, len(s.ram))
+	copy(n.ram, s.ram)
+	return &n
+}
@@ -0,0 +1,232 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// create a CTY cartridge where every byte in a bank is the number of the bank.
+// the tune data is appended to the cartridge data
+func newTestChetiry(t *testing.T, tunes []uint8) *chetiry {
+	t.Helper()
+
+	ld := newTestLoader(t, append(bankNumberedData(32768, 4096), tunes...), "CTY")
+	cart, err := newChetiry(&environment.Environment{Label: environment.MainEmulation, Loader: ld})
+	test.ExpectSuccess(t, err)
+
+	c := cart.(*chetiry)
+	c.state.registers.reset()
+	test.ExpectSuccess(t, c.SetBank("AUTO"))
+
+	return c
+}
+
+// start an operation and wait for it to complete
+func testOperation(t *testing.T, cart *chetiry, op uint8, duration int) {
+	t.Helper()
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0000, op, false))
+	test.ExpectEquality(t, testRead(t, cart, 0x0ff4)&0x40, 0x40)
+	for range duration {
+		cart.Step(1.0)
+	}
+	test.ExpectEquality(t, testRead(t, cart, 0x0ff4)&0x40, 0x00)
+	test.ExpectEquality(t, testRead(t, cart, 0x0040), 0x00)
+}
+
+// television stub for the rewinding test
+type testChetiryTV struct {
+	environment.Television
+	rewinding bool
+}
+
+func (tv *testChetiryTV) IsRewinding() bool {
+	return tv.rewinding
+}
+
+func TestChetiry_bankswitching(t *testing.T) {
+	cart := newTestChetiry(t, nil)
+
+	// bank zero is not accessible
+	test.ExpectEquality(t, cart.GetBank(0x0100).Number, 1)
+	test.ExpectFailure(t, cart.SetBank("0"))
+
+	for b := 1; b < cart.NumBanks(); b++ {
+		testRead(t, cart, 0x0ff4+uint16(b))
+		test.ExpectEquality(t, int(testRead(t, cart, 0x0100)), b)
+	}
+}
+
+func TestChetiry_registers(t *testing.T) {
+	cart := newTestChetiry(t, nil)
+
+	// RAM is written through the write port and read through the read port
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xaa, false))
+	v, _, err := cart.Access(0x0050, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, v, 0xaa)
+
+	// the random number generator advances on every read and can be reset
+	a, _, err := cart.Access(0x0041, false)
+	test.ExpectSuccess(t, err)
+	b, _, err := cart.Access(0x0041, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0001, 0x00, false))
+	c, _, err := cart.Access(0x0041, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectInequality(t, a, b)
+	test.ExpectEquality(t, a, c)
+
+	// the operation register is separate from RAM
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0000, 0x21, false))
+	test.ExpectEquality(t, cart.GetRegisters().(CTYregisters).Operation, 0x21)
+}
+
+func TestChetiry_tune(t *testing.T) {
+	// the first position in tune one plays a single note
+	tunes := make([]uint8, chetiryTuneSize*2)
+	tunes[chetiryTuneSize] = 34
+
+	cart := newTestChetiry(t, tunes)
+
+	// load tune one
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0000, 0x11, false))
+	v, _, err := cart.Access(0x0ff4, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, v&0x40, 0x40)
+	test.ExpectEquality(t, cart.GetRegisters().(CTYregisters).Tune, 1)
+
+	// the operation remains busy until enough time has passed
+	v, _, err = cart.Access(0x0ff4, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, v&0x40, 0x40)
+	for range chetiryReadTime {
+		cart.Step(1.0)
+	}
+	v, _, err = cart.Access(0x0ff4, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, v&0x40, 0x00)
+
+	// advance to the first position in the tune
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0003, 0x00, false))
+	test.ExpectEquality(t, cart.GetRegisters().(CTYregisters).TunePosition, 1)
+	test.ExpectEquality(t, cart.GetRegisters().(CTYregisters).MusicFetcher[0].Freq, chetiryFrequencies[34])
+	test.ExpectEquality(t, cart.GetRegisters().(CTYregisters).MusicFetcher[1].Freq, 0)
+
+	// the volume is only returned by the LDA #$F2 instruction. setting the
+	// counter so that the square wave is high
+	cart.state.registers.MusicFetcher[0].Count = 0x80000000
+	cart.banks[1][0x0200] = 0xa9
+	cart.banks[1][0x0201] = 0xf2
+	_, _, err = cart.Access(0x0200, false)
+	test.ExpectSuccess(t, err)
+	v, _, err = cart.Access(0x0201, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, v, 5)
+	v, _, err = cart.Access(0x0201, false)
+	test.ExpectSuccess(t, err)
+	test.ExpectEquality(t, v, 0xf2)
+}
+
+func TestChetiry_EEPROM(t *testing.T) {
+	// the EEPROM file is created in the resources path, which is relative to
+	// the working directory
+	t.Chdir(t.TempDir())
+
+	cart := newTestChetiry(t, nil)
+
+	// loading a score table when there is no EEPROM file loads zeroes
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xaa, false))
+	testOperation(t, cart, 0x22, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0x00)
+
+	// save score tables two and three with different data
+	for i := 4; i < chetiryRAMsize; i++ {
+		test.ExpectSuccess(t, cart.AccessVolatile(uint16(i), uint8(i), false))
+	}
+	testOperation(t, cart, 0x23, chetiryWriteTime)
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xbb, false))
+	testOperation(t, cart, 0x33, chetiryWriteTime)
+
+	// the EEPROM file is shared by all cartridges with the same data
+	cart = newTestChetiry(t, nil)
+
+	testOperation(t, cart, 0x22, chetiryReadTime)
+	for i := 4; i < chetiryRAMsize; i++ {
+		test.ExpectEquality(t, testRead(t, cart, 0x0040|uint16(i)), uint8(i))
+	}
+	testOperation(t, cart, 0x32, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0xbb)
+
+	// the first four bytes of RAM are not part of the score table
+	test.ExpectEquality(t, testRead(t, cart, 0x0040), 0x00)
+
+	// wiping the EEPROM clears all score tables
+	testOperation(t, cart, 0x04, chetiryWriteTime)
+	testOperation(t, cart, 0x22, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0x00)
+	testOperation(t, cart, 0x32, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0x00)
+}
+
+func TestChetiry_rewinding(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cart := newTestChetiry(t, nil)
+	tv := &testChetiryTV{}
+	cart.env.TV = tv
+
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xaa, false))
+	testOperation(t, cart, 0x03, chetiryWriteTime)
+
+	// operations that write to the EEPROM still complete when rewinding but
+	// the EEPROM file is not changed
+	tv.rewinding = true
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xbb, false))
+	testOperation(t, cart, 0x03, chetiryWriteTime)
+	testOperation(t, cart, 0x04, chetiryWriteTime)
+
+	// score tables are read from the EEPROM when rewinding
+	testOperation(t, cart, 0x02, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0xaa)
+
+	tv.rewinding = false
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xbb, false))
+	testOperation(t, cart, 0x02, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0xaa)
+}
+
+func TestChetiry_notMainEmulation(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cart := newTestChetiry(t, nil)
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xaa, false))
+	testOperation(t, cart, 0x03, chetiryWriteTime)
+
+	// a preview or comparison emulation of the same cartridge can read the
+	// EEPROM file but not change it
+	cart = newTestChetiry(t, nil)
+	cart.env.Label = environment.Label("preview")
+	test.ExpectSuccess(t, cart.AccessVolatile(0x0010, 0xbb, false))
+	testOperation(t, cart, 0x03, chetiryWriteTime)
+	testOperation(t, cart, 0x04, chetiryWriteTime)
+
+	testOperation(t, cart, 0x02, chetiryReadTime)
+	test.ExpectEquality(t, testRead(t, cart, 0x0050), 0xaa)
+}
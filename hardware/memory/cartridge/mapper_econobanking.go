@@ -0,0 +1,195 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper/banking"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// econobanking implements the 0840 bank switching scheme. the scheme is
+// similar to UA in that the hotspots are outside of the cartridge address
+// space. in this case the hotspots are in a mirror of the TIA
+type econobanking struct {
+	env *environment.Environment
+
+	mappingID string
+
+	// 0840 cartridges are 8k in size and have two banks of 4096 bytes
+	bankSize int
+	banks    [][]uint8
+
+	// identifies the currently selected bank
+	bank int
+}
+
+func newEconobanking(env *environment.Environment) (mapper.CartMapper, error) {
+	data, err := io.ReadAll(env.Loader)
+	if err != nil {
+		return nil, fmt.Errorf("0840: %w", err)
+	}
+
+	cart := &econobanking{
+		env:       env,
+		mappingID: "0840",
+		bankSize:  4096,
+	}
+
+	if len(data) != cart.bankSize*cart.NumBanks() {
+		return nil, fmt.Errorf("%s: wrong number of bytes in the cartridge data", cart.mappingID)
+	}
+
+	cart.banks = make([][]uint8, cart.NumBanks())
+
+	for k := 0; k < cart.NumBanks(); k++ {
+		cart.banks[k] = make([]uint8, cart.bankSize)
+		offset := k * cart.bankSize
+		copy(cart.banks[k], data[offset:offset+cart.bankSize])
+	}
+
+	return cart, nil
+}
+
+// MappedBanks implements the mapper.CartMapper interface
+func (cart *econobanking) MappedBanks() string {
+	return fmt.Sprintf("Bank: %d", cart.bank)
+}
+
+// ID implements the mapper.CartMapper interface
+func (cart *econobanking) ID() string {
+	return cart.mappingID
+}
+
+// Snapshot implements the mapper.CartMapper interface
+func (cart *econobanking) Snapshot() mapper.CartMapper {
+	n := *cart
+	return &n
+}
+
+// Plumb implements the mapper.CartMapper interface
+func (cart *econobanking) Plumb(env *environment.Environment) {
+	cart.env = env
+}
+
+// Reset implements the mapper.CartMapper interface
+func (cart *econobanking) Reset() error {
+	cart.SetBank("AUTO")
+	return nil
+}
+
+// Access implements the mapper.CartMapper interface
+func (cart *econobanking) Access(addr uint16, _ bool) (uint8, uint8, error) {
+	return cart.banks[cart.bank][addr], mapper.CartDrivenPins, nil
+}
+
+// AccessVolatile implements the mapper.CartMapper interface
+func (cart *econobanking) AccessVolatile(addr uint16, data uint8, poke bool) error {
+	if poke {
+		cart.banks[cart.bank][addr] = data
+	}
+	return nil
+}
+
+// NumBanks implements the mapper.CartMapper interface
+func (cart *econobanking) NumBanks() int {
+	return 2
+}
+
+// GetBank implements the mapper.CartMapper interface
+func (cart *econobanking) GetBank(addr uint16) banking.Information {
+	// 0840 cartridges are like atari cartridges in that the entire address
+	// space points to the selected bank
+	return banking.Information{Number: cart.bank}
+}
+
+// SetBank implements the mapper.SelectableBank interface.
+func (cart *econobanking) SetBank(bank string) error {
+	if banking.IsAutoSelection(bank) {
+		cart.bank = len(cart.banks) - 1
+		return nil
+	}
+
+	b, err := banking.SingleSelection(bank)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cart.mappingID, err)
+	}
+
+	if b.Number >= len(cart.banks) {
+		return fmt.Errorf("%s: cartridge does not have bank '%d'", cart.mappingID, b.Number)
+	}
+	if b.IsRAM {
+		return fmt.Errorf("%s: cartridge does not have bankable RAM", cart.mappingID)
+	}
+
+	cart.bank = b.Number
+
+	return nil
+}
+
+// AccessPassive implements the mapper.CartMapper interface
+func (cart *econobanking) AccessPassive(addr uint16, data uint8) error {
+	// only address lines A12, A11 and A6 are used to decode the hotspots. this
+	// means that the hotspots are mirrored throughout the upper half of the
+	// TIA address space
+	switch addr & 0x1840 {
+	case 0x0800:
+		cart.bank = 0
+	case 0x0840:
+		cart.bank = 1
+	}
+	return nil
+}
+
+// Step implements the mapper.CartMapper interface
+func (cart *econobanking) Step(_ float32) {
+}
+
+// CopyBanks implements the mapper.CartMapper interface
+func (cart *econobanking) CopyBanks() []banking.Content {
+	c := make([]banking.Content, len(cart.banks))
+	for b := 0; b < len(cart.banks); b++ {
+		c[b] = banking.Content{Number: b,
+			Data:    cart.banks[b],
+			Origins: []uint16{memorymap.OriginCartFxxx},
+		}
+	}
+	return c
+}
+
+// Hotspots implements the mapper.CartHotspotsBus interface.
+func (cart *econobanking) Hotspots() map[uint16]mapper.CartHotspotInfo {
+	return map[uint16]mapper.CartHotspotInfo{
+		0x0800: {Symbol: "BANK0", Action: mapper.HotspotBankSwitch},
+		0x0840: {Symbol: "BANK1", Action: mapper.HotspotBankSwitch},
+	}
+}
+
+// Patch implements the mapper.CartPatchable interface
+func (cart *econobanking) Patch(offset int, data uint8) error {
+	if offset >= cart.bankSize*len(cart.banks) {
+		return fmt.Errorf("%s: patch offset too high (%d)", cart.mappingID, offset)
+	}
+
+	bank := offset / cart.bankSize
+	offset %= cart.bankSize
+	cart.banks[bank][offset] = data
+	return nil
+}
@@ -0,0 +1,98 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func newTestEconobanking(t *testing.T) *econobanking {
+	t.Helper()
+
+	ld := newTestLoader(t, bankNumberedData(8192, 4096), "0840")
+	cart, err := newEconobanking(&environment.Environment{Loader: ld})
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, cart.Reset())
+
+	return cart.(*econobanking)
+}
+
+func TestEconobanking_hotspots(t *testing.T) {
+	cart := newTestEconobanking(t)
+
+	// cartridge starts in the last bank
+	test.ExpectEquality(t, testBank(t, cart), 1)
+
+	test.ExpectSuccess(t, cart.AccessPassive(0x0800, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0840, 0))
+	test.ExpectEquality(t, testBank(t, cart), 1)
+
+	// the hotspots are mirrored throughout the upper half of the TIA
+	test.ExpectSuccess(t, cart.AccessPassive(0x0900, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0a40, 0))
+	test.ExpectEquality(t, testBank(t, cart), 1)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0fbf, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+
+	// addresses in cartridge space and in the lower half of the TIA are not
+	// hotspots
+	test.ExpectSuccess(t, cart.AccessPassive(0x1840, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0040, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0840, 0))
+	test.ExpectSuccess(t, cart.AccessPassive(0x1800, 0))
+	test.ExpectEquality(t, testBank(t, cart), 1)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0000, 0))
+	test.ExpectEquality(t, testBank(t, cart), 1)
+}
+
+func TestEconobanking_setBank(t *testing.T) {
+	cart := newTestEconobanking(t)
+
+	test.ExpectSuccess(t, cart.SetBank("0"))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+	test.ExpectEquality(t, cart.GetBank(0x0000).Number, 0)
+
+	// automatic selection is the last bank
+	test.ExpectSuccess(t, cart.SetBank("AUTO"))
+	test.ExpectEquality(t, testBank(t, cart), 1)
+	test.ExpectEquality(t, cart.GetBank(0x0000).Number, 1)
+
+	test.ExpectFailure(t, cart.SetBank("2"))
+	test.ExpectFailure(t, cart.SetBank("0R"))
+	test.ExpectEquality(t, testBank(t, cart), 1)
+}
+
+func TestEconobanking_fingerprint(t *testing.T) {
+	// a single access to a hotspot is not enough to identify the mapper
+	data := make([]byte, 8192)
+	copy(data[0x100:], []byte{0xad, 0x40, 0x08})
+	test.ExpectEquality(t, fingerprint8k(newTestLoader(t, data, "AUTO")), "F8")
+
+	copy(data[0x200:], []byte{0xad, 0x40, 0x08})
+	test.ExpectEquality(t, fingerprint8k(newTestLoader(t, data, "AUTO")), "0840")
+
+	data = make([]byte, 8192)
+	copy(data[0x100:], []byte{0x0c, 0x00, 0x08, 0x4c})
+	copy(data[0x1100:], []byte{0x0c, 0x00, 0x08, 0x4c})
+	test.ExpectEquality(t, fingerprint8k(newTestLoader(t, data, "AUTO")), "0840")
+}
@@ -0,0 +1,55 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// create cartridge data where every byte in a bank is the number of the bank
+func bankNumberedData(size int, bankSize int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i / bankSize)
+	}
+	return data
+}
+
+// create a loader for the cartridge data with the specified mapping
+func newTestLoader(t *testing.T, data []byte, mapping string) cartridgeloader.Loader {
+	t.Helper()
+	ld, err := cartridgeloader.NewLoaderFromData("test", data, mapping, "", nil, nil)
+	test.ExpectSuccess(t, err)
+	return ld
+}
+
+// read from the cartridge in the same way as the 6507
+func testRead(t *testing.T, cart mapper.CartMapper, addr uint16) uint8 {
+	t.Helper()
+	v, _, err := cart.Access(addr, false)
+	test.ExpectSuccess(t, err)
+	return v
+}
+
+// the bank as seen by the 6507 for cartridges created with bankNumberedData()
+func testBank(t *testing.T, cart mapper.CartMapper) int {
+	t.Helper()
+	return int(testRead(t, cart, 0x0000))
+}
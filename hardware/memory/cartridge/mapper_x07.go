@@ -0,0 +1,207 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper/banking"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// x07 implements the X07 bank switching scheme. it is a 64k scheme that
+// snoops the entire address bus for bank switching accesses. there are two
+// types of hotspot:
+//
+// accesses to addresses matching the pattern 0b0 1xxx bbbb 1101 select bank
+// bbbb (ie. addresses $080D to $0FFD, not including the many mirrors)
+//
+// when either bank 14 or 15 is selected, accesses to the TIA ($0000 to
+// $007F and mirrors) will select bank 14 or 15 according to address line A6
+type x07 struct {
+	env *environment.Environment
+
+	mappingID string
+
+	// x07 cartridges are 64k in size and have 16 banks of 4096 bytes
+	bankSize int
+	banks    [][]uint8
+
+	// identifies the currently selected bank
+	bank int
+}
+
+func newX07(env *environment.Environment) (mapper.CartMapper, error) {
+	data, err := io.ReadAll(env.Loader)
+	if err != nil {
+		return nil, fmt.Errorf("X07: %w", err)
+	}
+
+	cart := &x07{
+		env:       env,
+		mappingID: "X07",
+		bankSize:  4096,
+	}
+
+	if len(data) != cart.bankSize*cart.NumBanks() {
+		return nil, fmt.Errorf("%s: wrong number of bytes in the cartridge data", cart.mappingID)
+	}
+
+	cart.banks = make([][]uint8, cart.NumBanks())
+
+	for k := 0; k < cart.NumBanks(); k++ {
+		cart.banks[k] = make([]uint8, cart.bankSize)
+		offset := k * cart.bankSize
+		copy(cart.banks[k], data[offset:offset+cart.bankSize])
+	}
+
+	return cart, nil
+}
+
+// MappedBanks implements the mapper.CartMapper interface
+func (cart *x07) MappedBanks() string {
+	return fmt.Sprintf("Bank: %d", cart.bank)
+}
+
+// ID implements the mapper.CartMapper interface
+func (cart *x07) ID() string {
+	return cart.mappingID
+}
+
+// Snapshot implements the mapper.CartMapper interface
+func (cart *x07) Snapshot() mapper.CartMapper {
+	n := *cart
+	return &n
+}
+
+// Plumb implements the mapper.CartMapper interface
+func (cart *x07) Plumb(env *environment.Environment) {
+	cart.env = env
+}
+
+// Reset implements the mapper.CartMapper interface
+func (cart *x07) Reset() error {
+	cart.SetBank("AUTO")
+	return nil
+}
+
+// Access implements the mapper.CartMapper interface
+func (cart *x07) Access(addr uint16, _ bool) (uint8, uint8, error) {
+	return cart.banks[cart.bank][addr], mapper.CartDrivenPins, nil
+}
+
+// AccessVolatile implements the mapper.CartMapper interface
+func (cart *x07) AccessVolatile(addr uint16, data uint8, poke bool) error {
+	if poke {
+		cart.banks[cart.bank][addr] = data
+	}
+	return nil
+}
+
+// NumBanks implements the mapper.CartMapper interface
+func (cart *x07) NumBanks() int {
+	return 16
+}
+
+// GetBank implements the mapper.CartMapper interface
+func (cart *x07) GetBank(addr uint16) banking.Information {
+	// x07 cartridges are like atari cartridges in that the entire address
+	// space points to the selected bank
+	return banking.Information{Number: cart.bank}
+}
+
+// SetBank implements the mapper.SelectableBank interface.
+func (cart *x07) SetBank(bank string) error {
+	// the cartridge always starts in bank zero
+	if banking.IsAutoSelection(bank) {
+		cart.bank = 0
+		return nil
+	}
+
+	b, err := banking.SingleSelection(bank)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cart.mappingID, err)
+	}
+
+	if b.Number >= len(cart.banks) {
+		return fmt.Errorf("%s: cartridge does not have bank '%d'", cart.mappingID, b.Number)
+	}
+	if b.IsRAM {
+		return fmt.Errorf("%s: cartridge does not have bankable RAM", cart.mappingID)
+	}
+
+	cart.bank = b.Number
+
+	return nil
+}
+
+// AccessPassive implements the mapper.CartMapper interface
+func (cart *x07) AccessPassive(addr uint16, data uint8) error {
+	if addr&0x180f == 0x080d {
+		cart.bank = int((addr & 0x00f0) >> 4)
+	} else if addr&0x1880 == 0x0000 {
+		if cart.bank&0x0e == 0x0e {
+			cart.bank = int((addr&0x0040)>>6) | 0x0e
+		}
+	}
+	return nil
+}
+
+// Step implements the mapper.CartMapper interface
+func (cart *x07) Step(_ float32) {
+}
+
+// CopyBanks implements the mapper.CartMapper interface
+func (cart *x07) CopyBanks() []banking.Content {
+	c := make([]banking.Content, len(cart.banks))
+	for b := 0; b < len(cart.banks); b++ {
+		c[b] = banking.Content{Number: b,
+			Data:    cart.banks[b],
+			Origins: []uint16{memorymap.OriginCartFxxx},
+		}
+	}
+	return c
+}
+
+// Hotspots implements the mapper.CartHotspotsBus interface.
+//
+// The TIA hotspots that switch between banks 14 and 15 are not included
+// because they share addresses with the TIA registers
+func (cart *x07) Hotspots() map[uint16]mapper.CartHotspotInfo {
+	h := make(map[uint16]mapper.CartHotspotInfo)
+	for b := range cart.NumBanks() {
+		h[0x080d|uint16(b<<4)] = mapper.CartHotspotInfo{
+			Symbol: fmt.Sprintf("BANK%d", b),
+			Action: mapper.HotspotBankSwitch,
+		}
+	}
+	return h
+}
+
+// Patch implements the mapper.CartPatchable interface
+func (cart *x07) Patch(offset int, data uint8) error {
+	if offset >= cart.bankSize*len(cart.banks) {
+		return fmt.Errorf("%s: patch offset too high (%d)", cart.mappingID, offset)
+	}
+
+	bank := offset / cart.bankSize
+	offset %= cart.bankSize
+	cart.banks[bank][offset] = data
+	return nil
+}
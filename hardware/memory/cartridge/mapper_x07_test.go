@@ -0,0 +1,137 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package cartridge
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/environment"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func newTestX07(t *testing.T) *x07 {
+	t.Helper()
+
+	ld := newTestLoader(t, bankNumberedData(65536, 4096), "X07")
+	cart, err := newX07(&environment.Environment{Loader: ld})
+	test.ExpectSuccess(t, err)
+	test.ExpectSuccess(t, cart.Reset())
+
+	return cart.(*x07)
+}
+
+func TestX07_primaryHotspots(t *testing.T) {
+	cart := newTestX07(t)
+
+	// cartridge starts in bank zero
+	test.ExpectEquality(t, testBank(t, cart), 0)
+
+	// every bank can be selected with the primary hotspots
+	for b := range 16 {
+		test.ExpectSuccess(t, cart.AccessPassive(0x080d|uint16(b<<4), 0))
+		test.ExpectEquality(t, testBank(t, cart), b)
+	}
+
+	// address lines A8 to A10 are ignored
+	test.ExpectSuccess(t, cart.AccessPassive(0x0f2d, 0))
+	test.ExpectEquality(t, testBank(t, cart), 2)
+	test.ExpectSuccess(t, cart.AccessPassive(0x093d, 0))
+	test.ExpectEquality(t, testBank(t, cart), 3)
+
+	// address lines A13 to A15 are not connected to the cartridge
+	test.ExpectSuccess(t, cart.AccessPassive(0xe84d, 0))
+	test.ExpectEquality(t, testBank(t, cart), 4)
+
+	// the same address in cartridge space is not a hotspot
+	test.ExpectSuccess(t, cart.AccessPassive(0x185d, 0))
+	test.ExpectEquality(t, testBank(t, cart), 4)
+
+	// the low nibble must be 0xd
+	test.ExpectSuccess(t, cart.AccessPassive(0x085c, 0))
+	test.ExpectEquality(t, testBank(t, cart), 4)
+	test.ExpectSuccess(t, cart.AccessPassive(0x085e, 0))
+	test.ExpectEquality(t, testBank(t, cart), 4)
+}
+
+func TestX07_TIAHotspots(t *testing.T) {
+	cart := newTestX07(t)
+
+	// TIA accesses have no effect unless bank 14 or 15 is selected
+	test.ExpectSuccess(t, cart.AccessPassive(0x082d, 0))
+	test.ExpectEquality(t, testBank(t, cart), 2)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0040, 0))
+	test.ExpectEquality(t, testBank(t, cart), 2)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0000, 0))
+	test.ExpectEquality(t, testBank(t, cart), 2)
+
+	// select bank 14
+	test.ExpectSuccess(t, cart.AccessPassive(0x08ed, 0))
+	test.ExpectEquality(t, testBank(t, cart), 14)
+
+	// address line A6 selects between bank 14 and bank 15
+	test.ExpectSuccess(t, cart.AccessPassive(0x0040, 0))
+	test.ExpectEquality(t, testBank(t, cart), 15)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0002, 0))
+	test.ExpectEquality(t, testBank(t, cart), 14)
+	test.ExpectSuccess(t, cart.AccessPassive(0x007f, 0))
+	test.ExpectEquality(t, testBank(t, cart), 15)
+
+	// TIA mirrors are also hotspots
+	test.ExpectSuccess(t, cart.AccessPassive(0x0100, 0))
+	test.ExpectEquality(t, testBank(t, cart), 14)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0340, 0))
+	test.ExpectEquality(t, testBank(t, cart), 15)
+
+	// RAM and RIOT addresses (A7 set) are not hotspots
+	test.ExpectSuccess(t, cart.AccessPassive(0x0080, 0))
+	test.ExpectEquality(t, testBank(t, cart), 15)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0280, 0))
+	test.ExpectEquality(t, testBank(t, cart), 15)
+	test.ExpectSuccess(t, cart.AccessPassive(0x00c0, 0))
+	test.ExpectEquality(t, testBank(t, cart), 15)
+
+	// the primary hotspots still work
+	test.ExpectSuccess(t, cart.AccessPassive(0x080d, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+	test.ExpectSuccess(t, cart.AccessPassive(0x0040, 0))
+	test.ExpectEquality(t, testBank(t, cart), 0)
+}
+
+func TestX07_hotspotsBus(t *testing.T) {
+	cart := newTestX07(t)
+
+	h := cart.Hotspots()
+	test.ExpectEquality(t, len(h), 16)
+	test.ExpectEquality(t, h[0x080d].Symbol, "BANK0")
+	test.ExpectEquality(t, h[0x08fd].Symbol, "BANK15")
+
+	// every hotspot selects the bank named by the symbol
+	for addr, info := range h {
+		test.ExpectSuccess(t, cart.AccessPassive(addr, 0))
+		test.ExpectEquality(t, info.Symbol, fmt.Sprintf("BANK%d", testBank(t, cart)))
+	}
+}
+
+func TestX07_fingerprint(t *testing.T) {
+	data := make([]byte, 65536)
+	copy(data[0x1000:], []byte{0xad, 0x1d, 0x08}) // LDA $081D
+
+	test.ExpectEquality(t, fingerprint64k(newTestLoader(t, data, "AUTO")), "X07")
+
+	// without the hotspot access the data is not recognised
+	test.ExpectInequality(t, fingerprint64k(newTestLoader(t, make([]byte, 65536), "AUTO")), "X07")
+}
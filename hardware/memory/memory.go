@@ -192,7 +192,7 @@ func (mem *Memory) Read(address uint16) (uint8, error) {
 
 	// we also need to consider what happens when a cartridge is forcefully
 	// driving the data bus
-	if stuff, ok := mem.Cart.BusStuff(); ok {
+	if stuff, ok := mem.Cart.BusStuff(address, false); ok {
 		data = stuff
 	}
 
@@ -213,7 +213,7 @@ func (mem *Memory) Write(address uint16, data uint8) error {
 	area := mem.GetArea(ar)
 
 	// drive pins from cartridge
-	if stuff, ok := mem.Cart.BusStuff(); ok {
+	if stuff, ok := mem.Cart.BusStuff(address, true); ok {
 		data = stuff
 	}
 
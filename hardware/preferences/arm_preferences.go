@@ -30,6 +30,9 @@ type ARMPreferences struct {
 	// the specific model of ARM to use. this will affect things like memory
 	// addressing for cartridge formats that use the ARM.
 	//
+	// the ARMv6_M value selects the ARMv6-M core for every cartridge format
+	// that uses the ARM
+	//
 	// NOTE: this may be superceded in the future to allow for more flexibility
 	Model prefs.String
 
@@ -59,11 +62,20 @@ type ARMPreferences struct {
 	// abort conditions for memory faults
 	AbortOnMemoryFault prefs.Bool
 
+	// abort when the ARM program reads memory that it has never written to.
+	// reads of uninitialised memory are only detected when developer
+	// information is available
+	AbortOnUninitialisedRead prefs.Bool
+
 	// include disassembly and register details when logging memory faults
 	ExtendedMemoryFaultLogging prefs.Bool
 
 	// warn developer that the ELF contains an undefined symbol
 	UndefinedSymbolWarning prefs.Bool
+
+	// bytes written to the debug output register are sent to the coprocessor
+	// console. only for memory models that define a debug output register
+	DebugOutput prefs.Bool
 }
 
 func (p *ARMPreferences) String() string {
@@ -110,6 +122,10 @@ func newARMprefrences() (*ARMPreferences, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = p.dsk.Add("hardware.arm7.abortOnUninitialisedRead", &p.AbortOnUninitialisedRead)
+	if err != nil {
+		return nil, err
+	}
 	err = p.dsk.Add("hardware.arm7.extendedMemoryFaultLogging", &p.ExtendedMemoryFaultLogging)
 	if err != nil {
 		return nil, err
@@ -118,6 +134,10 @@ func newARMprefrences() (*ARMPreferences, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = p.dsk.Add("hardware.arm7.debugOutput", &p.DebugOutput)
+	if err != nil {
+		return nil, err
+	}
 	err = p.dsk.Load()
 	if err != nil {
 		return nil, err
@@ -136,8 +156,10 @@ func (p *ARMPreferences) SetDefaults() {
 	p.ImmediateCorrection.Set(false)
 	p.MAM.Set(-1)
 	p.AbortOnMemoryFault.Set(false)
+	p.AbortOnUninitialisedRead.Set(false)
 	p.ExtendedMemoryFaultLogging.Set(false)
 	p.UndefinedSymbolWarning.Set(false)
+	p.DebugOutput.Set(false)
 }
 
 // Load current arm preference from disk.
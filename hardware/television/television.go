@@ -453,6 +453,12 @@ func (tv *Television) SetRealTimeAudioMixer(m RealtimeAudioMixer) {
 	tv.realTimeMixer = m
 }
 
+// GetRealTimeAudioMixer returns the realtime audio mixer. Returns nil if no
+// realtime mixer has been set
+func (tv *Television) GetRealTimeAudioMixer() RealtimeAudioMixer {
+	return tv.realTimeMixer
+}
+
 // AddAudioMixer adds an implementation of AudioMixer.
 func (tv *Television) AddAudioMixer(m AudioMixer) {
 	if slices.Contains(tv.mixers, m) {
@@ -1000,6 +1006,12 @@ func (tv *Television) SetEmulationState(state govern.State) error {
 	return nil
 }
 
+// IsRewinding returns true if the emulation state most recently set by
+// SetEmulationState() is the rewinding state
+func (tv *Television) IsRewinding() bool {
+	return tv.emulationState == govern.Rewinding
+}
+
 // GetResetSpecID returns the specification that the television resets to. This
 // is useful for learning more about how the television was created
 func (tv *Television) GetResetSpecID() string {
@@ -51,6 +51,11 @@ const (
 
 	// atarivox notifications
 	NotifyAtariVoxSubtitle Notice = "NotifyAtariVoxSubtitle"
+
+	// a line of output from the coprocessor. the line is the first data
+	// argument. output is sent by semihosting requests or by writing to the
+	// coprocessor's debug output register
+	NotifyCoProcConsole Notice = "NotifyCoProcConsole"
 )
 
 // Notify is used for direct communication between a the hardware and the
@@ -0,0 +1,51 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tracewriter writes a log of every CPU instruction executed by the
+// emulation to a file. The intention is that the log can be compared with the
+// trace output of other emulators when investigating accuracy problems.
+//
+// Each line of the log is formatted according to a format string. A format
+// string is made up of literal text and fields. A field is the name of a
+// value enclosed in braces. For example:
+//
+//	{pc}: {instruction}  A={a} X={x} Y={y}
+//
+// The available fields are:
+//
+//	frame        television frame at the start of the instruction
+//	scanline     television scanline at the start of the instruction
+//	clock        television clock at the start of the instruction
+//	cycles       the number of CPU cycles taken by the instruction
+//	total        the number of CPU cycles since the start of the trace
+//	bank         the cartridge bank the instruction was executed from
+//	pc           address of the instruction
+//	bytes        the bytes that make up the instruction
+//	mnemonic     the instruction mnemonic
+//	operand      the instruction operand
+//	instruction  the mnemonic and operand together
+//	a, x, y, sp  value of the CPU registers at the start of the instruction
+//	p            value of the status register at the start of the instruction
+//	flags        the status register as a string of flags
+//
+// Hexadecimal values and mnemonics are written in lower case. If the field
+// name is written in upper case (eg. {PC}) then the value will be written in
+// upper case.
+//
+// The Presets map contains format strings that match (or closely match) the
+// trace output of other emulators.
+//
+// Tracing can be started and stopped automatically with a Condition.
+package tracewriter
@@ -0,0 +1,242 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracewriter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/hardware/cpu/execution"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/instructions"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/registers"
+	"github.com/jetsetilly/gopher2600/hardware/memory/cartridge/mapper/banking"
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
+)
+
+// List of preset names.
+const (
+	PresetGopher = "GOPHER"
+	PresetStella = "STELLA"
+	PresetMAME   = "MAME"
+)
+
+// Presets are format strings that can be used with NewTraceWriter(). The
+// STELLA and MAME presets follow the layout of the trace output of those
+// emulators as closely as possible so that the files can be compared with a
+// diff tool.
+var Presets = map[string]string{
+	PresetGopher: "{total} {frame} {scanline} {clock} {bank} {pc} {bytes} {instruction} A={a} X={x} Y={y} SP={sp} P={flags}",
+	PresetStella: "{PC}  {BYTES}  {instruction}  A={A} X={X} Y={Y} SP={SP} PS={flags} Scan={scanline} Frame={frame}",
+	PresetMAME:   "{PC}: {instruction}",
+}
+
+// PresetList is the list of preset names in a suitable order for presentation.
+var PresetList = []string{PresetGopher, PresetStella, PresetMAME}
+
+// the minimum width of some fields. the values are padded with spaces so that
+// the columns of the trace line up
+const (
+	bytesWidth       = 8
+	instructionWidth = 14
+)
+
+// state of the emulation at the start of an instruction
+type state struct {
+	coords coords.TelevisionCoords
+	bank   banking.Information
+	a      uint8
+	x      uint8
+	y      uint8
+	sp     uint8
+	status registers.Status
+
+	// the number of cycles since the start of the trace
+	total uint64
+
+	// whether the CPU will execute an instruction in the next step. if it
+	// doesn't then the step is a single cycle where the CPU does nothing
+	rdy bool
+}
+
+// a single field or piece of literal text in the format
+type formatField struct {
+	literal string
+	name    string
+	upper   bool
+}
+
+// list of valid field names
+var fieldNames = []string{
+	"frame", "scanline", "clock", "cycles", "total", "bank", "pc", "bytes",
+	"mnemonic", "operand", "instruction", "a", "x", "y", "sp", "p", "flags",
+}
+
+type format []formatField
+
+// parseFormat divides the format string into literal text and fields
+func parseFormat(f string) (format, error) {
+	var fm format
+
+	for len(f) > 0 {
+		i := strings.IndexRune(f, '{')
+		if i == -1 {
+			fm = append(fm, formatField{literal: f})
+			break // for loop
+		}
+		if i > 0 {
+			fm = append(fm, formatField{literal: f[:i]})
+		}
+
+		j := strings.IndexRune(f[i:], '}')
+		if j == -1 {
+			return nil, fmt.Errorf("tracewriter: unterminated field in format")
+		}
+
+		n := f[i+1 : i+j]
+		l := strings.ToLower(n)
+		if !slices.Contains(fieldNames, l) {
+			return nil, fmt.Errorf("tracewriter: unknown field in format (%s)", n)
+		}
+		fm = append(fm, formatField{name: l, upper: n == strings.ToUpper(n)})
+
+		f = f[i+j+1:]
+	}
+
+	if len(fm) == 0 {
+		return nil, fmt.Errorf("tracewriter: empty format")
+	}
+
+	return fm, nil
+}
+
+// write a single line of the trace for the executed instruction. the state
+// argument is the state of the emulation at the start of the instruction
+func (fm format) write(b *strings.Builder, st state, res execution.Result) {
+	for _, f := range fm {
+		if f.name == "" {
+			b.WriteString(f.literal)
+			continue // for loop
+		}
+
+		var s string
+
+		switch f.name {
+		case "frame":
+			s = fmt.Sprintf("%d", st.coords.Frame)
+		case "scanline":
+			s = fmt.Sprintf("%03d", st.coords.Scanline)
+		case "clock":
+			s = fmt.Sprintf("%03d", st.coords.Clock)
+		case "cycles":
+			s = fmt.Sprintf("%d", res.Cycles)
+		case "total":
+			s = fmt.Sprintf("%d", st.total)
+		case "bank":
+			s = st.bank.String()
+		case "pc":
+			s = fmt.Sprintf("%04x", res.Address)
+		case "bytes":
+			s = fmt.Sprintf("%-*s", bytesWidth, formatBytes(res))
+		case "mnemonic":
+			s = formatMnemonic(res)
+		case "operand":
+			s = formatOperand(res)
+		case "instruction":
+			s = strings.TrimSpace(fmt.Sprintf("%s %s", formatMnemonic(res), formatOperand(res)))
+			s = fmt.Sprintf("%-*s", instructionWidth, s)
+		case "a":
+			s = fmt.Sprintf("%02x", st.a)
+		case "x":
+			s = fmt.Sprintf("%02x", st.x)
+		case "y":
+			s = fmt.Sprintf("%02x", st.y)
+		case "sp":
+			s = fmt.Sprintf("%02x", st.sp)
+		case "p":
+			s = fmt.Sprintf("%02x", st.status.Value())
+		case "flags":
+			// the case of the flags is meaningful so we never change it
+			b.WriteString(st.status.String())
+			continue // for loop
+		}
+
+		if f.upper {
+			s = strings.ToUpper(s)
+		}
+		b.WriteString(s)
+	}
+}
+
+func formatBytes(res execution.Result) string {
+	if res.Defn == nil {
+		return ""
+	}
+	switch res.Defn.Bytes {
+	case 3:
+		return fmt.Sprintf("%02x %02x %02x", res.Defn.OpCode, res.InstructionData&0x00ff, res.InstructionData>>8)
+	case 2:
+		return fmt.Sprintf("%02x %02x", res.Defn.OpCode, res.InstructionData&0x00ff)
+	}
+	return fmt.Sprintf("%02x", res.Defn.OpCode)
+}
+
+func formatMnemonic(res execution.Result) string {
+	if res.Defn == nil {
+		return "???"
+	}
+	return res.Defn.Operator.String()
+}
+
+// operand is formatted without symbols so that it can be compared with the
+// output of other emulators
+func formatOperand(res execution.Result) string {
+	if res.Defn == nil {
+		return ""
+	}
+
+	var v string
+	if res.Defn.Bytes == 2 {
+		v = fmt.Sprintf("$%02x", res.InstructionData&0x00ff)
+	} else {
+		v = fmt.Sprintf("$%04x", res.InstructionData)
+	}
+
+	switch res.Defn.AddressingMode {
+	case instructions.Implied:
+		return ""
+	case instructions.Immediate:
+		return fmt.Sprintf("#%s", v)
+	case instructions.Relative:
+		// branch operand is shown as the address of the branch destination
+		dest := res.Address + 2 + uint16(int8(res.InstructionData))
+		return fmt.Sprintf("$%04x", dest)
+	case instructions.Absolute:
+		return v
+	case instructions.Indirect:
+		return fmt.Sprintf("(%s)", v)
+	case instructions.PreIndexed:
+		return fmt.Sprintf("(%s,x)", v)
+	case instructions.PostIndexed:
+		return fmt.Sprintf("(%s),y", v)
+	case instructions.AbsoluteX:
+		return fmt.Sprintf("%s,x", v)
+	case instructions.AbsoluteY:
+		return fmt.Sprintf("%s,y", v)
+	}
+
+	return v
+}
@@ -0,0 +1,114 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracewriter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware/cpu/execution"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/instructions"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/registers"
+	"github.com/jetsetilly/gopher2600/hardware/television/coords"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, p := range PresetList {
+		_, err := parseFormat(Presets[p])
+		test.ExpectSuccess(t, err)
+	}
+
+	_, err := parseFormat("")
+	test.ExpectFailure(t, err)
+	_, err = parseFormat("{pc")
+	test.ExpectFailure(t, err)
+	_, err = parseFormat("{foo}")
+	test.ExpectFailure(t, err)
+
+	fm, err := parseFormat("pc={pc} {PC}")
+	test.DemandSuccess(t, err)
+	test.ExpectEquality(t, len(fm), 4)
+	test.ExpectEquality(t, fm[0].literal, "pc=")
+	test.ExpectEquality(t, fm[1].upper, false)
+	test.ExpectEquality(t, fm[3].upper, true)
+}
+
+func TestFormatWrite(t *testing.T) {
+	st := state{
+		coords: coords.TelevisionCoords{Frame: 10, Scanline: 5, Clock: -30},
+		a:      0x0a,
+		x:      0xff,
+		y:      0x01,
+		sp:     0xfd,
+		status: registers.NewStatus(),
+	}
+
+	write := func(format string, res execution.Result) string {
+		t.Helper()
+		fm, err := parseFormat(format)
+		test.DemandSuccess(t, err)
+		s := strings.Builder{}
+		fm.write(&s, st, res)
+		return strings.TrimRight(s.String(), " ")
+	}
+
+	lda := execution.Result{
+		Defn: &instructions.Definition{
+			OpCode:         0xa9,
+			Operator:       instructions.LDA,
+			Bytes:          2,
+			AddressingMode: instructions.Immediate,
+		},
+		Address:         0xf000,
+		InstructionData: 0x00ab,
+		Cycles:          2,
+		Final:           true,
+	}
+
+	test.ExpectEquality(t, write(Presets[PresetMAME], lda), "F000: lda #$ab")
+	test.ExpectEquality(t, write("{bytes}|{BYTES}|{cycles}", lda), "a9 ab   |A9 AB   |2")
+	test.ExpectEquality(t, write("{frame} {scanline} {clock}", lda), "10 005 -30")
+	test.ExpectEquality(t, write("{a} {X} {y} {sp}", lda), "0a FF 01 fd")
+
+	sta := execution.Result{
+		Defn: &instructions.Definition{
+			OpCode:         0x9d,
+			Operator:       instructions.STA,
+			Bytes:          3,
+			AddressingMode: instructions.AbsoluteX,
+		},
+		Address:         0xf002,
+		InstructionData: 0x1080,
+		Final:           true,
+	}
+	test.ExpectEquality(t, write("{instruction}", sta), "sta $1080,x")
+	test.ExpectEquality(t, write("{bytes}", sta), "9d 80 10")
+
+	// branch operands are shown as the destination address
+	bne := execution.Result{
+		Defn: &instructions.Definition{
+			OpCode:         0xd0,
+			Operator:       instructions.BNE,
+			Bytes:          2,
+			AddressingMode: instructions.Relative,
+		},
+		Address:         0xf010,
+		InstructionData: 0x00fc,
+		Final:           true,
+	}
+	test.ExpectEquality(t, write("{mnemonic} {operand}", bne), "bne $f00e")
+}
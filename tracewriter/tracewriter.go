@@ -0,0 +1,309 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracewriter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+)
+
+// ConditionType indicates how the Value field of the Condition type should be
+// interpreted.
+type ConditionType int
+
+// List of valid ConditionType values.
+const (
+	// the condition is always met
+	ConditionNone ConditionType = iota
+
+	// the condition is met when the television frame is equal to or greater
+	// than the Value field
+	ConditionFrame
+
+	// the condition is met when the instruction at the address in the Value
+	// field is executed. cartridge mirrors are considered
+	ConditionPC
+
+	// the condition is met when the trace contains Value lines. only valid for
+	// stop conditions
+	ConditionCount
+)
+
+// Condition specifies when a trace should start or stop.
+type Condition struct {
+	Type  ConditionType
+	Value int
+}
+
+func (c Condition) String() string {
+	switch c.Type {
+	case ConditionFrame:
+		return fmt.Sprintf("frame %d", c.Value)
+	case ConditionPC:
+		return fmt.Sprintf("pc $%04x", c.Value)
+	case ConditionCount:
+		return fmt.Sprintf("count %d", c.Value)
+	}
+	return "none"
+}
+
+// the mask applied to addresses when checking for a ConditionPC. the 6507 has
+// 13 address lines so this means that every mirror will match
+const pcMask = 0x1fff
+
+// TraceWriter writes every CPU instruction to a file. The Step() function
+// should be called after every call to CPU.ExecuteInstruction(), either
+// directly or through the VCS.Step() and VCS.Run() functions.
+type TraceWriter struct {
+	vcs *hardware.VCS
+
+	filename string
+	w        io.Writer
+
+	// buf is only used when writing to a file. the closer will be nil if the
+	// trace is not being written to a file
+	buf    *bufio.Writer
+	closer io.Closer
+
+	format format
+
+	start Condition
+	stop  Condition
+
+	// whether the start condition has been met
+	started bool
+
+	// whether the trace has ended. once ended the trace can not restart
+	ended bool
+
+	// the number of lines written to the trace
+	lines int
+
+	// the number of cycles since the trace was created
+	total uint64
+
+	// state of the emulation at the start of the current instruction
+	pending state
+
+	// reusable string builder for each line of the trace
+	line strings.Builder
+}
+
+// NewTraceWriter is the preferred method of initialisation for the
+// TraceWriter type. The format argument can be the name of one of the
+// Presets or a format string.
+func NewTraceWriter(vcs *hardware.VCS, filename string, format string, start Condition, stop Condition) (*TraceWriter, error) {
+	tw, err := newTraceWriter(vcs, filename, format, start, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("tracewriter: %w", err)
+	}
+	tw.buf = bufio.NewWriter(f)
+	tw.w = tw.buf
+	tw.closer = f
+
+	return tw, nil
+}
+
+// NewTraceWriterFromWriter is the same as NewTraceWriter() except that the
+// trace is written to an io.Writer. Each line of the trace is written to the
+// io.Writer with a single call to Write(). The name argument is used to
+// describe the trace in the String() function.
+func NewTraceWriterFromWriter(vcs *hardware.VCS, w io.Writer, name string, format string, start Condition, stop Condition) (*TraceWriter, error) {
+	tw, err := newTraceWriter(vcs, name, format, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	tw.w = w
+	return tw, nil
+}
+
+func newTraceWriter(vcs *hardware.VCS, filename string, format string, start Condition, stop Condition) (*TraceWriter, error) {
+	if p, ok := Presets[strings.ToUpper(format)]; ok {
+		format = p
+	}
+
+	fm, err := parseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if start.Type == ConditionCount {
+		return nil, fmt.Errorf("tracewriter: count is not a valid start condition")
+	}
+
+	tw := &TraceWriter{
+		vcs:      vcs,
+		filename: filename,
+		format:   fm,
+		start:    start,
+		stop:     stop,
+		started:  start.Type == ConditionNone,
+	}
+
+	tw.pending = tw.capture()
+
+	return tw, nil
+}
+
+func (tw *TraceWriter) String() string {
+	s := strings.Builder{}
+	s.WriteString(tw.filename)
+	if tw.start.Type != ConditionNone {
+		fmt.Fprintf(&s, " start at %s", tw.start)
+	}
+	if tw.stop.Type != ConditionNone {
+		fmt.Fprintf(&s, " stop at %s", tw.stop)
+	}
+	if tw.ended {
+		fmt.Fprintf(&s, " (ended with %d lines)", tw.lines)
+	} else if tw.started {
+		fmt.Fprintf(&s, " (%d lines)", tw.lines)
+	} else {
+		s.WriteString(" (waiting)")
+	}
+	return s.String()
+}
+
+// capture state of the emulation
+func (tw *TraceWriter) capture() state {
+	return state{
+		coords: tw.vcs.TV.GetCoords(),
+		bank:   tw.vcs.Mem.Cart.GetBank(tw.vcs.CPU.PC.Address()),
+		a:      tw.vcs.CPU.A.Value(),
+		x:      tw.vcs.CPU.X.Value(),
+		y:      tw.vcs.CPU.Y.Value(),
+		sp:     tw.vcs.CPU.SP.Value(),
+		status: tw.vcs.CPU.Status,
+		total:  tw.total,
+		rdy:    tw.vcs.CPU.RdyFlg && !tw.vcs.CPU.Jammed,
+	}
+}
+
+// Step should be called after every call to CPU.ExecuteInstruction(). It
+// writes the most recently executed instruction to the trace if the start
+// condition has been met.
+//
+// Returns an error if the trace file could not be written to. The trace will
+// have ended in that case.
+func (tw *TraceWriter) Step() error {
+	if tw.ended {
+		return nil
+	}
+
+	st := tw.pending
+
+	// the CPU did not execute an instruction during the step, only a single
+	// cycle passed. this happens when the RDY flag is not set (eg. after a
+	// WSYNC) or when the CPU is jammed
+	if !st.rdy {
+		tw.total++
+		tw.pending = tw.capture()
+		return nil
+	}
+
+	res := tw.vcs.CPU.LastResult
+	tw.total += uint64(res.Cycles)
+	tw.pending = tw.capture()
+
+	if !res.Final {
+		return nil
+	}
+
+	if !tw.started {
+		switch tw.start.Type {
+		case ConditionFrame:
+			tw.started = st.coords.Frame >= tw.start.Value
+		case ConditionPC:
+			tw.started = res.Address&pcMask == uint16(tw.start.Value)&pcMask
+		}
+		if !tw.started {
+			return nil
+		}
+	}
+
+	if tw.stop.Type == ConditionFrame && st.coords.Frame >= tw.stop.Value {
+		return tw.End()
+	}
+
+	tw.line.Reset()
+	tw.format.write(&tw.line, st, res)
+	_, err := fmt.Fprintln(tw.w, strings.TrimRight(tw.line.String(), " "))
+	if err != nil {
+		_ = tw.End()
+		return fmt.Errorf("tracewriter: %w", err)
+	}
+	tw.lines++
+
+	switch tw.stop.Type {
+	case ConditionPC:
+		if res.Address&pcMask == uint16(tw.stop.Value)&pcMask {
+			return tw.End()
+		}
+	case ConditionCount:
+		if tw.lines >= tw.stop.Value {
+			return tw.End()
+		}
+	}
+
+	return nil
+}
+
+// Resync should be called when the state of the emulation has changed without
+// the change being seen by Step(). For example, after a rewind.
+func (tw *TraceWriter) Resync() {
+	tw.pending = tw.capture()
+}
+
+// Ended returns true if the trace has ended, either because the stop
+// condition has been met or because End() has been called.
+func (tw *TraceWriter) Ended() bool {
+	return tw.ended
+}
+
+// End the trace and close the file. It is safe to call End() more than once.
+func (tw *TraceWriter) End() error {
+	if tw.ended {
+		return nil
+	}
+	tw.ended = true
+
+	if tw.buf != nil {
+		err := tw.buf.Flush()
+		if err != nil {
+			_ = tw.closer.Close()
+			return fmt.Errorf("tracewriter: %w", err)
+		}
+	}
+
+	if tw.closer != nil {
+		err := tw.closer.Close()
+		if err != nil {
+			return fmt.Errorf("tracewriter: %w", err)
+		}
+	}
+
+	return nil
+}